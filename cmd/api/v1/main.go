@@ -2,6 +2,8 @@ package main
 
 import (
 	"cooperative-system/internal/config"
+	"cooperative-system/internal/jobs"
+	"cooperative-system/internal/repository"
 	"cooperative-system/internal/routers"
 	"os"
 
@@ -17,6 +19,9 @@ func init() {
 
 func main() {
 
+	stopMaturityJob := jobs.StartFixedDepositMaturityJob(repository.NewGormFixedDepositRepository(config.DB), config.FixedDepositJobInterval())
+	defer stopMaturityJob()
+
 	r := gin.Default()
 	routers.SetUpRoute(r)
 	r.Run(":" + os.Getenv("PORT"))
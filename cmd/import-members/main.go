@@ -0,0 +1,91 @@
+package main
+
+import (
+	"cooperative-system/internal/config"
+	"cooperative-system/internal/imports"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// import-members creates members from a CSV file, the same way POST /api/v1/admins/members/import does, and
+// prints the temporary passwords of the users it created, which the API never returns:
+//
+//	go run ./cmd/import-members -file members.csv -admin admin@example.com -dry-run
+func main() {
+	file := flag.String("file", "", "CSV file of members to import")
+	adminEmail := flag.String("admin", "", "email of the admin the import is recorded against")
+	dryRun := flag.Bool("dry-run", false, "validate the file and report what would happen without creating anything")
+	approve := flag.Bool("approve", false, "make the imported members active instead of leaving them as applications")
+	batchSize := flag.Int("batch-size", imports.DefaultBatchSize, "number of members created in each transaction")
+	flag.Parse()
+
+	if *file == "" || *adminEmail == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	config.LoadEnvVars()
+	config.ConnectDb()
+	config.SyncDB()
+
+	userRepo := repository.NewUserRepository(config.DB)
+	admin, msg, err := userRepo.FindUserByEmail(*adminEmail)
+	if err != nil {
+		log.Fatalf("%s: %v", msg, err)
+	}
+	// the admin's branch decides where members without a branch column go and which branches they can name
+	admin, msg, err = userRepo.FindUserByID(admin.ID)
+	if err != nil {
+		log.Fatalf("%s: %v", msg, err)
+	}
+	if !admin.Can(models.PermMembersImport) {
+		log.Fatalf("%s does not have the %s permission", *adminEmail, models.PermMembersImport)
+	}
+
+	input, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("failed to open %s: %v", *file, err)
+	}
+	defer input.Close()
+
+	report, msg, err := imports.ImportMembers(repository.NewMGormemberRepository(config.DB), input, imports.MemberImportOptions{
+		DryRun:      *dryRun,
+		Approve:     *approve,
+		BatchSize:   *batchSize,
+		ImportedBy:  admin.ID,
+		Branch:      admin.BranchID,
+		BranchScope: admin.BranchScope(),
+	})
+	if report != nil {
+		printReport(report)
+	}
+	if err != nil {
+		log.Fatalf("%s: %v", msg, err)
+	}
+	fmt.Println(msg)
+}
+
+func printReport(report *models.MemberImportReport) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "LINE\tEMAIL\tSTATUS\tMEMBER\tTEMPORARY PASSWORD\tERRORS")
+	for _, row := range report.Rows {
+		memberID := ""
+		if row.MemberID != 0 {
+			memberID = fmt.Sprint(row.MemberID)
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s\t%s\n", row.Line, row.Email, row.Status, memberID, row.TemporaryPassword, strings.Join(row.Errors, "; "))
+	}
+	writer.Flush()
+
+	created := "created"
+	if report.DryRun {
+		created = "would be created"
+	}
+	fmt.Printf("\n%d lines: %d %s, %d skipped, %d invalid\n", report.Total, report.Created, created, report.Skipped, report.Invalid)
+}
@@ -2,8 +2,13 @@ package config
 
 import (
 	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+	"cooperative-system/internal/storage"
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
@@ -38,10 +43,282 @@ func LoadEnvVars() {
 }
 
 func SyncDB() {
+	migrateMoneyColumns()
+	DB.AutoMigrate(&models.Branch{})
+	DB.AutoMigrate(&models.MemberGroup{})
 	DB.AutoMigrate(&models.User{})
+	DB.AutoMigrate(&models.RefreshToken{})
 	DB.AutoMigrate(&models.Member{})
+	DB.AutoMigrate(&models.MemberHistory{})
+	DB.AutoMigrate(&models.MemberSettlement{})
+	DB.AutoMigrate(&models.MemberSettlementLine{})
+	DB.AutoMigrate(&models.Nomination{})
+	DB.AutoMigrate(&models.Document{})
 	DB.AutoMigrate(&models.Savings{})
 	DB.AutoMigrate(&models.SavingTransaction{})
 	DB.AutoMigrate(&models.Loan{})
 	DB.AutoMigrate(&models.LoanHistory{})
+	DB.AutoMigrate(&models.LoanRepayment{})
+	DB.AutoMigrate(&models.LoanInstallment{})
+	DB.AutoMigrate(&models.MigrationBatch{})
+	DB.AutoMigrate(&models.MigrationTotal{})
+	DB.AutoMigrate(&models.FixedDeposit{})
+	DB.AutoMigrate(&models.ContributionMandate{})
+	DB.AutoMigrate(&models.ExchangeRate{})
+	DB.AutoMigrate(&models.SavingsTransfer{})
+	DB.AutoMigrate(&models.IdempotencyKey{})
+	DB.AutoMigrate(&models.ShareAccount{})
+	DB.AutoMigrate(&models.ShareCertificate{})
+	DB.AutoMigrate(&models.ShareTransaction{})
+	DB.AutoMigrate(&models.Distribution{})
+	DB.AutoMigrate(&models.DistributionLine{})
+	DB.AutoMigrate(&models.FeeDefinition{})
+	DB.AutoMigrate(&models.FeeCharge{})
+	DB.AutoMigrate(&models.NumberSequence{})
+	backfillTransactionTypes()
+
+	repository.SetNumbering(Numbering())
+	if err := repository.NumberExistingAccounts(DB); err != nil {
+		log.Printf("failed to number existing members and accounts: %v", err)
+	}
+}
+
+// backfillTransactionTypes types the savings transactions posted before transactions had a type. They all
+// default to deposits, so reversals and transfer legs are picked out by the links they already carry.
+// Only rows still typed as deposits are touched, so it is safe to call on every start.
+func backfillTransactionTypes() {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		untyped := tx.Model(&models.SavingTransaction{}).Where("type = ?", models.TransactionTypeDeposit).Session(&gorm.Session{})
+		updates := []struct {
+			transactionType string
+			condition       string
+		}{
+			{models.TransactionTypeReversal, "reversal_of_id IS NOT NULL"},
+			{models.TransactionTypeTransferOut, "transfer_id IS NOT NULL AND amount < 0"},
+			{models.TransactionTypeTransferIn, "transfer_id IS NOT NULL AND amount > 0"},
+		}
+		for _, update := range updates {
+			err := untyped.Where(update.condition).Updates(map[string]interface{}{
+				"type":    update.transactionType,
+				"channel": models.TransactionChannelInternal,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("failed to backfill transaction types: %v", err)
+	}
+}
+
+// FixedDepositJobInterval reads how often matured fixed deposits are processed, defaulting to hourly
+func FixedDepositJobInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("FIXED_DEPOSIT_JOB_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return time.Hour
+	}
+	return interval
+}
+
+// moneyColumns lists every column that used to hold a decimal amount and now holds minor units
+var moneyColumns = map[string][]string{
+	"loans":                 {"amount", "installment_amount", "total_repayable_amount"},
+	"savings":               {"balance", "amount_to_save"},
+	"saving_transactions":   {"amount"},
+	"fixed_deposits":        {"principal", "interest_earned", "penalty_amount", "payout_amount"},
+	"contribution_mandates": {"amount"},
+}
+
+// migrateMoneyColumns converts amounts stored as decimals by older versions into integer minor units.
+// It runs before AutoMigrate and only when loans.amount is not yet a bigint, so it is safe to call on every start.
+func migrateMoneyColumns() {
+	if !DB.Migrator().HasTable("loans") {
+		return
+	}
+	columnTypes, err := DB.Migrator().ColumnTypes("loans")
+	if err != nil {
+		log.Printf("failed to inspect loans table: %v", err)
+		return
+	}
+	legacy := false
+	for _, column := range columnTypes {
+		if column.Name() == "amount" && column.DatabaseTypeName() != "int8" {
+			legacy = true
+		}
+	}
+	if !legacy {
+		return
+	}
+
+	log.Println("converting money columns to minor units")
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		for table, columns := range moneyColumns {
+			if !tx.Migrator().HasTable(table) {
+				continue
+			}
+			for _, column := range columns {
+				if !tx.Migrator().HasColumn(table, column) {
+					continue
+				}
+				sql := fmt.Sprintf("ALTER TABLE %q ALTER COLUMN %q TYPE bigint USING round(%q * %d)", table, column, column, models.MinorUnitsPerMajor)
+				if err := tx.Exec(sql).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("failed to convert money columns: %v", err)
+	}
+}
+
+// defaultTransferDailyLimit applies when TRANSFER_DAILY_LIMIT is not set
+const defaultTransferDailyLimit = models.Money(50000000) // 500,000.00
+
+// TransferDailyLimit reads how much a member can transfer per day in a currency. TRANSFER_DAILY_LIMIT_<CODE>,
+// e.g. TRANSFER_DAILY_LIMIT_USD, overrides TRANSFER_DAILY_LIMIT for that currency. A limit of 0 turns it off.
+func TransferDailyLimit(currency string) models.Money {
+	for _, key := range []string{"TRANSFER_DAILY_LIMIT_" + currency, "TRANSFER_DAILY_LIMIT"} {
+		value := os.Getenv(key)
+		if value == "" {
+			continue
+		}
+		limit, err := models.ParseMoney(value)
+		if err != nil || limit < 0 {
+			log.Printf("ignoring invalid %s %q: %v", key, value, err)
+			continue
+		}
+		return limit
+	}
+	return defaultTransferDailyLimit
+}
+
+// Defaults for share capital when SHARE_PRICE or SHARE_MINIMUM_HOLDING is not set
+const (
+	defaultSharePrice          = models.Money(100000) // 1,000.00
+	defaultShareMinimumHolding = 10
+)
+
+// ShareSettings reads the share price (SHARE_PRICE, in the base currency) and the minimum number of shares
+// a member must hold (SHARE_MINIMUM_HOLDING) set by the bylaws
+func ShareSettings() models.ShareSettings {
+	settings := models.ShareSettings{
+		Price:          defaultSharePrice,
+		MinimumHolding: defaultShareMinimumHolding,
+	}
+
+	if value := os.Getenv("SHARE_PRICE"); value != "" {
+		price, err := models.ParseMoney(value)
+		if err != nil || price <= 0 {
+			log.Printf("ignoring invalid SHARE_PRICE %q: %v", value, err)
+		} else {
+			settings.Price = price
+		}
+	}
+
+	if value := os.Getenv("SHARE_MINIMUM_HOLDING"); value != "" {
+		minimum, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || minimum < 0 {
+			log.Printf("ignoring invalid SHARE_MINIMUM_HOLDING %q: %v", value, err)
+		} else {
+			settings.MinimumHolding = minimum
+		}
+	}
+	return settings
+}
+
+// TokenSettings reads how long access tokens (ACCESS_TOKEN_TTL, default 15m) and refresh tokens
+// (REFRESH_TOKEN_TTL, default 720h) last, as Go durations
+func TokenSettings() models.TokenSettings {
+	settings := models.DefaultTokenSettings()
+
+	ttls := []struct {
+		key string
+		ttl *time.Duration
+	}{
+		{"ACCESS_TOKEN_TTL", &settings.AccessTTL},
+		{"REFRESH_TOKEN_TTL", &settings.RefreshTTL},
+	}
+	for _, setting := range ttls {
+		value := os.Getenv(setting.key)
+		if value == "" {
+			continue
+		}
+		ttl, err := time.ParseDuration(value)
+		if err != nil || ttl <= 0 {
+			log.Printf("ignoring invalid %s %q: %v", setting.key, value, err)
+			continue
+		}
+		*setting.ttl = ttl
+	}
+	return settings
+}
+
+// Defaults for document uploads when DOCUMENT_STORAGE_DIR or DOCUMENT_MAX_SIZE_MB is not set
+const (
+	defaultDocumentStorageDir = "uploads"
+	defaultDocumentMaxSizeMB  = 5
+)
+
+// DocumentStorage is where uploaded member documents are kept, a directory on the local filesystem
+// (DOCUMENT_STORAGE_DIR) until an object store driver is added
+func DocumentStorage() storage.Storage {
+	dir := os.Getenv("DOCUMENT_STORAGE_DIR")
+	if dir == "" {
+		dir = defaultDocumentStorageDir
+	}
+	return storage.NewLocalStorage(dir)
+}
+
+// DocumentMaxSize reads the largest document that can be uploaded in bytes, set in megabytes with DOCUMENT_MAX_SIZE_MB
+func DocumentMaxSize() int64 {
+	if value := os.Getenv("DOCUMENT_MAX_SIZE_MB"); value != "" {
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err == nil && size > 0 {
+			return size << 20
+		}
+		log.Printf("ignoring invalid DOCUMENT_MAX_SIZE_MB %q: %v", value, err)
+	}
+	return defaultDocumentMaxSizeMB << 20
+}
+
+// Numbering reads how member, savings and loan numbers are written: the branch code they are issued under
+// (NUMBER_BRANCH_CODE) and a pattern for each kind (MEMBER_NUMBER_FORMAT, SAVINGS_NUMBER_FORMAT and
+// LOAN_NUMBER_FORMAT), e.g. {branch}{yy}{seq:5}{check}
+func Numbering() models.NumberingSettings {
+	settings := models.DefaultNumbering()
+
+	if value := os.Getenv("NUMBER_BRANCH_CODE"); value != "" {
+		code := models.NormalizeNumber(value)
+		if err := models.ValidateBranchCode(code); err != nil {
+			log.Printf("ignoring invalid NUMBER_BRANCH_CODE %q: %v", value, err)
+		} else {
+			settings.Branch = code
+		}
+	}
+
+	formats := []struct {
+		key    string
+		format *models.NumberFormat
+	}{
+		{"MEMBER_NUMBER_FORMAT", &settings.Member},
+		{"SAVINGS_NUMBER_FORMAT", &settings.Savings},
+		{"LOAN_NUMBER_FORMAT", &settings.Loan},
+	}
+	for _, setting := range formats {
+		value := os.Getenv(setting.key)
+		if value == "" {
+			continue
+		}
+		format, err := models.ParseNumberFormat(value)
+		if err != nil {
+			log.Printf("ignoring invalid %s %q: %v", setting.key, value, err)
+			continue
+		}
+		*setting.format = format
+	}
+	return settings
 }
@@ -7,25 +7,31 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type AdminHandler struct {
-	userRepo    repository.UserRepository
-	memberRepo  repository.MemberRepository
-	savingsRepo repository.SavingsRepository
-	loanRepo    repository.LoanRepository
+	userRepo         repository.UserRepository
+	memberRepo       repository.MemberRepository
+	savingsRepo      repository.SavingsRepository
+	loanRepo         repository.LoanRepository
+	contributionRepo repository.ContributionRepository
+	feeRepo          repository.FeeRepository
 }
 
-func NewAdminHandler(userRepo repository.UserRepository, memberRepo repository.MemberRepository, savingRepo repository.SavingsRepository, loanRepo repository.LoanRepository) *AdminHandler {
+func NewAdminHandler(userRepo repository.UserRepository, memberRepo repository.MemberRepository, savingRepo repository.SavingsRepository, loanRepo repository.LoanRepository, contributionRepo repository.ContributionRepository, feeRepo repository.FeeRepository) *AdminHandler {
 	return &AdminHandler{
-		userRepo:    userRepo,
-		memberRepo:  memberRepo,
-		savingsRepo: savingRepo,
-		loanRepo:    loanRepo,
+		userRepo:         userRepo,
+		memberRepo:       memberRepo,
+		savingsRepo:      savingRepo,
+		loanRepo:         loanRepo,
+		contributionRepo: contributionRepo,
+		feeRepo:          feeRepo,
 	}
 }
 
@@ -33,9 +39,14 @@ type AdminService interface {
 	CreateAdmin(c *gin.Context)
 	DeleteMember(c *gin.Context)
 	ApproveLoan(c *gin.Context)
+	DisburseLoan(c *gin.Context)
+	GetRoles(c *gin.Context)
+	AssignRole(c *gin.Context)
 }
 
-func getMemberByIDAndAuthorize(c *gin.Context, repo repository.MemberRepository, authUser *models.User) (*models.Member, error) {
+// getMemberByIDAndAuthorize fetches the member in the URL for their own user, or for staff whose role grants
+// the permission and who can see the member's branch
+func getMemberByIDAndAuthorize(c *gin.Context, repo repository.MemberRepository, authUser *models.User, permission string) (*models.Member, error) {
 	// Get the member ID from the URL parameter
 	memberID := c.Param("id")
 
@@ -49,16 +60,71 @@ func getMemberByIDAndAuthorize(c *gin.Context, repo repository.MemberRepository,
 		}
 		return nil, fmt.Errorf("failed to fetch member: %w", err)
 	}
+	if fetchedMember == nil {
+		utils.RespondWithError(c, http.StatusNotFound, message, nil)
+		return nil, fmt.Errorf("failed to fetch member: %s", message)
+	}
 
-	if authUser.Role != "admin" && fetchedMember.UserID != authUser.ID {
+	if !authUser.Can(permission) && fetchedMember.UserID != authUser.ID {
 		utils.RespondWithError(c, http.StatusUnauthorized, message, err)
 		return nil, fmt.Errorf("unauthorized access")
 	}
+	if !authUser.CanSeeBranch(fetchedMember.BranchID) {
+		utils.RespondWithError(c, http.StatusForbidden, "member belongs to another branch", nil)
+		return nil, fmt.Errorf("member outside branch")
+	}
 
 	// Return the member if everything is fine
 	return fetchedMember, nil
 }
 
+// memberIDParam reads the member in the URL, given as the member ID or the member number, responding when
+// no member has that number or the member is outside the admin's branch
+func memberIDParam(c *gin.Context, repo repository.MemberRepository, authUser *models.User) (uint, bool) {
+	param := c.Param("id")
+	if memberID, err := strconv.ParseUint(param, 10, 64); err == nil && authUser.BranchScope() == nil {
+		return uint(memberID), true
+	}
+
+	member, msg, err := repo.FetchByID(param)
+	if err != nil || member == nil {
+		status := http.StatusInternalServerError
+		if member == nil {
+			status = http.StatusNotFound
+		}
+		utils.RespondWithError(c, status, msg, err)
+		return 0, false
+	}
+	if !authUser.CanSeeBranch(member.BranchID) {
+		utils.RespondWithError(c, http.StatusForbidden, "member belongs to another branch", nil)
+		return 0, false
+	}
+	return member.ID, true
+}
+
+// requireMemberInBranch checks the member a loan or transaction belongs to is in the staff member's branch,
+// responding when it is not. Staff who see every branch are never refused.
+func requireMemberInBranch(c *gin.Context, repo repository.MemberRepository, authUser *models.User, memberID uint) bool {
+	if authUser.BranchScope() == nil {
+		return true
+	}
+
+	member, msg, err := repo.FetchByID(fmt.Sprint(memberID))
+	if err != nil || member == nil {
+		status := http.StatusInternalServerError
+		if member == nil && err == nil {
+			status = http.StatusNotFound
+		}
+		utils.RespondWithError(c, status, msg, err)
+		return false
+	}
+	if !authUser.CanSeeBranch(member.BranchID) {
+		utils.RespondWithError(c, http.StatusForbidden, "member belongs to another branch", nil)
+		return false
+	}
+	return true
+}
+
 func (h *AdminHandler) CreateAdmin(c *gin.Context) {
 	user, exist := c.Get("user")
 	if !exist {
@@ -67,7 +133,7 @@ func (h *AdminHandler) CreateAdmin(c *gin.Context) {
 	}
 
 	authUser, ok := user.(models.User)
-	if !ok || authUser.Role != "admin" {
+	if !ok || !authUser.Can(models.PermUsersManage) {
 		utils.RespondWithError(c, http.StatusForbidden, "only admins can create other admins", nil)
 		return
 	}
@@ -91,7 +157,7 @@ func (h *AdminHandler) CreateAdmin(c *gin.Context) {
 		return
 	}
 
-	promotedUser, msg, err := h.userRepo.UpdateUser(userToPromote, "admin")
+	promotedUser, msg, err := h.userRepo.UpdateUser(userToPromote, models.RoleAdmin)
 	if err != nil {
 		if promotedUser == nil {
 			utils.RespondWithError(c, http.StatusNotFound, msg, err)
@@ -112,7 +178,7 @@ func (h *AdminHandler) DeleteMember(c *gin.Context) {
 	}
 
 	authUser, ok := userCtx.(models.User)
-	if !ok || authUser.Role != "admin" {
+	if !ok || !authUser.Can(models.PermMembersManage) {
 		utils.RespondWithError(c, http.StatusForbidden, "only admins can delete members", nil) // Clarified error message
 		return
 	}
@@ -147,6 +213,9 @@ func (h *AdminHandler) DeleteMember(c *gin.Context) {
 		}
 		return
 	}
+	if !requireDeletableMember(c, memberToDelete) {
+		return
+	}
 
 	deletedMember, msg, err := h.memberRepo.Delete(memberToDelete)
 	if err != nil {
@@ -166,7 +235,7 @@ func (h *AdminHandler) ApproveLoan(c *gin.Context) {
 	}
 
 	authUser, ok := userCtx.(models.User)
-	if !ok || authUser.Role != "admin" {
+	if !ok || !authUser.Can(models.PermLoansApprove) {
 		utils.RespondWithError(c, http.StatusForbidden, "only admins can approve loans", nil) // Clarified error message
 		return
 	}
@@ -182,13 +251,10 @@ func (h *AdminHandler) ApproveLoan(c *gin.Context) {
 
 	// 1. Start a database transaction
 	tx := h.loanRepo.BeginTransaction()
-	var errLoop error
+	// roll back on every way out but a successful commit, the loan row stays locked until then
+	committed := false
 	defer func() {
-		if rcv := recover(); rcv != nil {
-			// h.loanRepo.RollbackTransaction(tx)
-			tx.Rollback()
-			panic(rcv)
-		} else if errLoop != nil {
+		if !committed {
 			tx.Rollback()
 		}
 	}()
@@ -215,39 +281,45 @@ func (h *AdminHandler) ApproveLoan(c *gin.Context) {
 		return
 	}
 
-	member, msg, errLoop := h.memberRepo.FetchMemberByID(tx, fmt.Sprint(fetchedLoan.MemberID))
-	if errLoop != nil {
-		utils.RespondWithError(c, http.StatusInternalServerError, "failed to fetch member for eligibility: "+msg, errLoop)
+	member, msg, err := h.memberRepo.FetchMemberByID(tx, fmt.Sprint(fetchedLoan.MemberID))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "failed to fetch member for eligibility: "+msg, err)
 		return
 	}
 
 	if member == nil {
-		errLoop = errors.New("member not found for eligibility check")
-		utils.RespondWithError(c, http.StatusNotFound, errLoop.Error(), nil)
+		utils.RespondWithError(c, http.StatusNotFound, "member not found for eligibility check", nil)
 		return
 	}
-
-	savings, msg, errLoop := h.savingsRepo.GetSavingsByMemberIDTx(tx, member.ID)
-	if errLoop != nil {
-		utils.RespondWithError(c, http.StatusInternalServerError, "failed to fetch savings for eligibility: "+msg, errLoop)
+	if !authUser.CanSeeBranch(member.BranchID) {
+		utils.RespondWithError(c, http.StatusForbidden, "member belongs to another branch", nil)
 		return
 	}
-	if savings == nil {
-		errLoop = errors.New("member savings record not found for eligibility check")
-		utils.RespondWithError(c, http.StatusNotFound, errLoop.Error(), nil)
+
+	// the loan is secured by savings in its own currency, a member without one is rejected by the eligibility check
+	savings, msg, err := h.savingsRepo.GetSavingsByMemberIDTx(tx, member.ID, fetchedLoan.Currency)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		savings, err = nil, nil
+	}
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "failed to fetch savings for eligibility: "+msg, err)
 		return
 	}
 
 	existingLoans, fetchLoansMsg, fetchLoansErr := h.loanRepo.GetAllLoansByMemberID(tx, member.ID) // Renamed msg and err variables
 	if fetchLoansErr != nil {
-		errLoop = fetchLoansErr // Assign to errLoop for defer rollback
 		utils.RespondWithError(c, http.StatusInternalServerError, "failed to fetch existing loans for eligibility: "+fetchLoansMsg, fetchLoansErr)
 		return
 	}
 
-	isEligible, eligibilityReasons, err := models.CheckLoanEligibility(fetchedLoan, member, savings, existingLoans)
+	contributions, contributionsMsg, contributionsErr := memberContributionStatus(h.contributionRepo, member.ID, time.Now())
+	if contributionsErr != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "failed to fetch contributions for eligibility: "+contributionsMsg, contributionsErr)
+		return
+	}
+
+	isEligible, eligibilityReasons, err := models.CheckLoanEligibility(fetchedLoan, member, savings, existingLoans, contributions)
 	if err != nil {
-		errLoop = err
 		utils.RespondWithError(c, http.StatusInternalServerError, "failed to check loan eligibility: "+err.Error(), err)
 		return
 	}
@@ -262,7 +334,6 @@ func (h *AdminHandler) ApproveLoan(c *gin.Context) {
 
 		updatedLoan, updateMsg, updateErr := h.loanRepo.UpdateLoan(tx, fetchedLoan)
 		if updateErr != nil {
-			errLoop = updateErr
 			utils.RespondWithError(c, http.StatusInternalServerError, "failed to update loan to rejected: "+updateMsg, updateErr)
 			return
 		}
@@ -275,16 +346,16 @@ func (h *AdminHandler) ApproveLoan(c *gin.Context) {
 		}
 
 		if histErr := h.loanRepo.CreateLoanHistory(tx, &rejectionHistory); histErr != nil {
-			errLoop = histErr
 			utils.RespondWithError(c, http.StatusInternalServerError, "failed to create rejection history: "+histErr.Error(), histErr)
 			return
 		}
 
 		if commitErr := tx.Commit().Error; commitErr != nil {
-			errLoop = commitErr
 			utils.RespondWithError(c, http.StatusInternalServerError, "failed to commit rejection transaction: "+commitErr.Error(), commitErr)
 			return
 		}
+		committed = true
+		utils.SuccessResponse(c, http.StatusOK, "loan rejected", "loan", updatedLoan)
 
 	} else {
 
@@ -296,7 +367,6 @@ func (h *AdminHandler) ApproveLoan(c *gin.Context) {
 
 		updatedLoan, updateMsg, updateErr := h.loanRepo.UpdateLoan(tx, fetchedLoan)
 		if updateErr != nil {
-			errLoop = updateErr
 			utils.RespondWithError(c, http.StatusInternalServerError, "failed to update loan to approved: "+updateMsg, updateErr)
 			return
 		}
@@ -308,16 +378,29 @@ func (h *AdminHandler) ApproveLoan(c *gin.Context) {
 			Remarks:   "Loan approved by admin.",
 		}
 		if histErr := h.loanRepo.CreateLoanHistory(tx, &approvalHistory); histErr != nil {
-			errLoop = histErr
 			utils.RespondWithError(c, http.StatusInternalServerError, "failed to create approval history: "+histErr.Error(), histErr)
 			return
 		}
 
+		// loan processing fees are charged on the principal as part of the approval
+		feeContext := models.FeeContext{
+			MemberID:   updatedLoan.MemberID,
+			SourceType: models.FeeSourceLoan,
+			SourceID:   updatedLoan.ID,
+			BaseAmount: updatedLoan.Amount,
+			Currency:   updatedLoan.Currency,
+			PostedBy:   authUser.ID,
+		}
+		if _, feeMsg, feeErr := h.feeRepo.ApplyFeesTx(tx, models.FeeTriggerLoanApproved, feeContext); feeErr != nil {
+			utils.RespondWithError(c, http.StatusInternalServerError, "failed to charge loan approval fees: "+feeMsg, feeErr)
+			return
+		}
+
 		if commitErr := tx.Commit().Error; commitErr != nil {
-			errLoop = commitErr
 			utils.RespondWithError(c, http.StatusInternalServerError, "failed to commit approval transaction: "+commitErr.Error(), commitErr)
 			return
 		}
+		committed = true
 		utils.SuccessResponse(c, http.StatusOK, "loan approved successfully", "loan", updatedLoan)
 
 	}
@@ -328,3 +411,112 @@ func (h *AdminHandler) ApproveLoan(c *gin.Context) {
 	// update it's status within a transaction *
 	//
 }
+
+// DisburseLoan records that an approved loan has been paid out to the member and charges the disbursement fees
+func (h *AdminHandler) DisburseLoan(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermLoansDisburse) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can disburse loans", nil)
+		return
+	}
+
+	loanID, err := strconv.ParseUint(c.Param("loan_id"), 10, 64)
+	if err != nil || authUser.BranchScope() != nil {
+		// a loan account number, or staff limited to a branch who need to see whose loan it is
+		loan, msg, err := h.loanRepo.GetLoanByID(c.Param("loan_id"))
+		if err != nil {
+			utils.RespondWithError(c, http.StatusNotFound, msg, err)
+			return
+		}
+		if !requireMemberInBranch(c, h.memberRepo, &authUser, loan.MemberID) {
+			return
+		}
+		loanID = uint64(loan.ID)
+	}
+
+	loan, charges, msg, err := h.loanRepo.DisburseLoan(uint(loanID), authUser.ID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			status = http.StatusNotFound
+		} else if errors.Is(err, repository.ErrLoanNotDisbursable) {
+			status = http.StatusConflict
+		}
+		utils.RespondWithError(c, status, msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"loan":        models.NewLoanResponse(loan),
+		"fee_charges": models.NewFeeChargeResponses(charges),
+	})
+}
+
+// RoleRequest is the role to give a user
+type RoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// GetRoles lists every role and the permissions it grants
+func (h *AdminHandler) GetRoles(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermUsersManage) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can view roles", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "roles fetched successfully", "data", gin.H{
+		"roles": models.NewRoleResponses(),
+	})
+}
+
+// AssignRole gives the user in the URL a role, which takes effect on their next request. Nobody can change
+// their own role, so the last admin cannot lock everyone out of managing roles by mistake.
+func (h *AdminHandler) AssignRole(c *gin.Context) {
+	authUser, ok := headOfficeAdmin(c, models.PermUsersManage, "change roles")
+	if !ok {
+		return
+	}
+
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid user ID", err)
+		return
+	}
+	if uint(userID) == authUser.ID {
+		utils.RespondWithError(c, http.StatusForbidden, "you cannot change your own role", nil)
+		return
+	}
+
+	var reqBody RoleRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+	if err := models.ValidateRole(reqBody.Role); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	user, msg, err := h.userRepo.FindUserByID(uint(userID))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			status = http.StatusNotFound
+		}
+		utils.RespondWithError(c, status, msg, err)
+		return
+	}
+
+	user, msg, err = h.userRepo.UpdateUser(user, reqBody.Role)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+	user.Role = reqBody.Role
+
+	utils.SuccessResponse(c, http.StatusOK, "role assigned successfully", "data", gin.H{
+		"user":        models.NewUserResponse(user),
+		"permissions": models.RolePermissions(user.Role),
+	})
+}
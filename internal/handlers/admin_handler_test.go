@@ -22,6 +22,7 @@ type mockAdminUserRepo struct {
 	repository.UserRepository
 	FindUserByEmailFunc func(email string) (*models.User, string, error)
 	UpdateUserFunc      func(user *models.User, role string) (*models.User, string, error)
+	FindUserByIDFunc    func(userID uint) (*models.User, string, error)
 }
 
 func (m *mockAdminUserRepo) FindUserByEmail(email string) (*models.User, string, error) {
@@ -30,12 +31,16 @@ func (m *mockAdminUserRepo) FindUserByEmail(email string) (*models.User, string,
 func (m *mockAdminUserRepo) UpdateUser(user *models.User, role string) (*models.User, string, error) {
 	return m.UpdateUserFunc(user, role)
 }
+func (m *mockAdminUserRepo) FindUserByID(userID uint) (*models.User, string, error) {
+	return m.FindUserByIDFunc(userID)
+}
 
 type mockAdminMemberRepo struct {
 	repository.MemberRepository
 	FetchMemberByUserIDFunc func(userID uint) (*models.Member, string, error)
 	DeleteFunc              func(member *models.Member) (*models.Member, string, error)
 	FetchMemberByIDFunc     func(tx *gorm.DB, memberID string) (*models.Member, string, error)
+	FetchByIDFunc           func(memberID string) (*models.Member, string, error)
 }
 
 func (m *mockAdminMemberRepo) FetchMemberByUserID(userID uint) (*models.Member, string, error) {
@@ -47,6 +52,9 @@ func (m *mockAdminMemberRepo) Delete(member *models.Member) (*models.Member, str
 func (m *mockAdminMemberRepo) FetchMemberByID(tx *gorm.DB, memberID string) (*models.Member, string, error) {
 	return m.FetchMemberByIDFunc(tx, memberID)
 }
+func (m *mockAdminMemberRepo) FetchByID(memberID string) (*models.Member, string, error) {
+	return m.FetchByIDFunc(memberID)
+}
 
 type mockAdminLoanRepo struct {
 	repository.LoanRepository
@@ -55,8 +63,13 @@ type mockAdminLoanRepo struct {
 	GetAllLoansByMemberIDFunc func(tx *gorm.DB, memberID uint) ([]models.Loan, string, error)
 	UpdateLoanFunc            func(tx *gorm.DB, loan *models.Loan) (*models.Loan, string, error)
 	CreateLoanHistoryFunc     func(tx *gorm.DB, loanHistory *models.LoanHistory) error
+	DisburseLoanFunc          func(loanID uint, disbursedBy uint) (*models.Loan, []models.FeeCharge, string, error)
+	GetLoanByIDFunc           func(loanID string) (*models.Loan, string, error)
 }
 
+func (m *mockAdminLoanRepo) GetLoanByID(loanID string) (*models.Loan, string, error) {
+	return m.GetLoanByIDFunc(loanID)
+}
 func (m *mockAdminLoanRepo) BeginTransaction() *gorm.DB {
 	return m.BeginTransactionFunc()
 }
@@ -72,17 +85,49 @@ func (m *mockAdminLoanRepo) UpdateLoan(tx *gorm.DB, loan *models.Loan) (*models.
 func (m *mockAdminLoanRepo) CreateLoanHistory(tx *gorm.DB, loanHistory *models.LoanHistory) error {
 	return m.CreateLoanHistoryFunc(tx, loanHistory)
 }
+func (m *mockAdminLoanRepo) DisburseLoan(loanID uint, disbursedBy uint) (*models.Loan, []models.FeeCharge, string, error) {
+	return m.DisburseLoanFunc(loanID, disbursedBy)
+}
 
 type mockAdminSavingsRepo struct {
 	repository.SavingsRepository
-	GetSavingsByMemberIDTxFunc func(tx *gorm.DB, memberID uint) (*models.Savings, string, error)
+	GetSavingsByMemberIDTxFunc func(tx *gorm.DB, memberID uint, currency string) (*models.Savings, string, error)
+}
+
+func (m *mockAdminSavingsRepo) GetSavingsByMemberIDTx(tx *gorm.DB, memberID uint, currency string) (*models.Savings, string, error) {
+	return m.GetSavingsByMemberIDTxFunc(tx, memberID, currency)
 }
 
-func (m *mockAdminSavingsRepo) GetSavingsByMemberIDTx(tx *gorm.DB, memberID uint) (*models.Savings, string, error) {
-	return m.GetSavingsByMemberIDTxFunc(tx, memberID)
+type mockAdminContributionRepo struct {
+	repository.ContributionRepository
+	GetMandatesByMemberIDFunc func(memberID uint) ([]models.ContributionMandate, string, error)
 }
 
-// No mock transaction struct needed
+func (m *mockAdminContributionRepo) GetMandatesByMemberID(memberID uint) ([]models.ContributionMandate, string, error) {
+	return m.GetMandatesByMemberIDFunc(memberID)
+}
+
+// mockTxPool stands in for the database transaction the handler commits or rolls back
+type mockTxPool struct {
+	gorm.ConnPool
+	committed  bool
+	rolledBack bool
+}
+
+func (p *mockTxPool) Commit() error {
+	p.committed = true
+	return nil
+}
+func (p *mockTxPool) Rollback() error {
+	p.rolledBack = true
+	return nil
+}
+
+// newMockTx returns a transaction handle whose commit and rollback are recorded on the pool
+func newMockTx() (*gorm.DB, *mockTxPool) {
+	pool := &mockTxPool{}
+	return &gorm.DB{Config: &gorm.Config{}, Statement: &gorm.Statement{ConnPool: pool}}, pool
+}
 
 func TestCreateAdmin_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
@@ -102,7 +147,8 @@ func TestCreateAdmin_Success(t *testing.T) {
 	mockMemberRepo := &mockAdminMemberRepo{}
 	mockSavingsRepo := &mockAdminSavingsRepo{}
 	mockLoanRepo := &mockAdminLoanRepo{}
-	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, mockSavingsRepo, mockLoanRepo)
+	mockContributionRepo := &mockAdminContributionRepo{}
+	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, mockSavingsRepo, mockLoanRepo, mockContributionRepo, &mockFeeRepo{})
 	r := gin.Default()
 	r.POST("/admins", func(c *gin.Context) {
 		user := models.User{}
@@ -127,7 +173,8 @@ func TestCreateAdmin_Forbidden(t *testing.T) {
 	mockMemberRepo := &mockAdminMemberRepo{}
 	mockSavingsRepo := &mockAdminSavingsRepo{}
 	mockLoanRepo := &mockAdminLoanRepo{}
-	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, mockSavingsRepo, mockLoanRepo)
+	mockContributionRepo := &mockAdminContributionRepo{}
+	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, mockSavingsRepo, mockLoanRepo, mockContributionRepo, &mockFeeRepo{})
 	r := gin.Default()
 	r.POST("/admins", func(c *gin.Context) {
 		user := models.User{}
@@ -172,7 +219,8 @@ func TestDeleteMember_Success(t *testing.T) {
 	}
 	mockSavingsRepo := &mockAdminSavingsRepo{}
 	mockLoanRepo := &mockAdminLoanRepo{}
-	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, mockSavingsRepo, mockLoanRepo)
+	mockContributionRepo := &mockAdminContributionRepo{}
+	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, mockSavingsRepo, mockLoanRepo, mockContributionRepo, &mockFeeRepo{})
 	r := gin.Default()
 	r.DELETE("/admins", func(c *gin.Context) {
 		user := models.User{}
@@ -191,13 +239,48 @@ func TestDeleteMember_Success(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "member deleted successfully")
 }
 
+func TestDeleteMember_ActiveMemberMustExit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockUserRepo := &mockAdminUserRepo{
+		FindUserByEmailFunc: func(email string) (*models.User, string, error) {
+			user := models.User{}
+			user.ID = 2
+			user.Email = email
+			return &user, "success", nil
+		},
+	}
+	deleted := false
+	mockMemberRepo := &mockAdminMemberRepo{
+		FetchMemberByUserIDFunc: func(userID uint) (*models.Member, string, error) {
+			member := models.Member{UserID: userID, Status: models.MemberStatusActive}
+			member.ID = 2
+			return &member, "success", nil
+		},
+		DeleteFunc: func(member *models.Member) (*models.Member, string, error) {
+			deleted = true
+			return member, "deleted", nil
+		},
+	}
+	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, &mockAdminSavingsRepo{}, &mockAdminLoanRepo{}, &mockAdminContributionRepo{}, &mockFeeRepo{})
+	r := gin.Default()
+	r.DELETE("/admins", adminContext(h.DeleteMember))
+	req, _ := http.NewRequest(http.MethodDelete, "/admins", bytes.NewBuffer([]byte(`{"email":"test@example.com"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "member must exit and be settled before being deleted")
+	assert.False(t, deleted)
+}
+
 func TestDeleteMember_Forbidden(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockUserRepo := &mockAdminUserRepo{}
 	mockMemberRepo := &mockAdminMemberRepo{}
 	mockSavingsRepo := &mockAdminSavingsRepo{}
 	mockLoanRepo := &mockAdminLoanRepo{}
-	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, mockSavingsRepo, mockLoanRepo)
+	mockContributionRepo := &mockAdminContributionRepo{}
+	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, mockSavingsRepo, mockLoanRepo, mockContributionRepo, &mockFeeRepo{})
 	r := gin.Default()
 	r.DELETE("/admins", func(c *gin.Context) {
 		user := models.User{}
@@ -220,7 +303,7 @@ func TestApproveLoan_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	// Create mock transaction that will succeed
-	mockTx := &gorm.DB{}
+	mockTx, pool := newMockTx()
 
 	mockLoanRepo := &mockAdminLoanRepo{
 		BeginTransactionFunc: func() *gorm.DB {
@@ -253,6 +336,7 @@ func TestApproveLoan_Success(t *testing.T) {
 			member := &models.Member{
 				Name:        "Test Member",
 				ContactInfo: "123-456-7890",
+				Status:      models.MemberStatusActive,
 			}
 			member.Model.ID = 1
 			return member, "member fetched successfully", nil
@@ -260,7 +344,7 @@ func TestApproveLoan_Success(t *testing.T) {
 	}
 
 	mockSavingsRepo := &mockAdminSavingsRepo{
-		GetSavingsByMemberIDTxFunc: func(tx *gorm.DB, memberID uint) (*models.Savings, string, error) {
+		GetSavingsByMemberIDTxFunc: func(tx *gorm.DB, memberID uint, currency string) (*models.Savings, string, error) {
 			savings := &models.Savings{
 				Balance: 1000, // Sufficient savings for the loan
 			}
@@ -270,7 +354,12 @@ func TestApproveLoan_Success(t *testing.T) {
 
 	mockUserRepo := &mockAdminUserRepo{}
 
-	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, mockSavingsRepo, mockLoanRepo)
+	mockContributionRepo := &mockAdminContributionRepo{
+		GetMandatesByMemberIDFunc: func(memberID uint) ([]models.ContributionMandate, string, error) {
+			return []models.ContributionMandate{}, "mandates fetched successfully", nil
+		},
+	}
+	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, mockSavingsRepo, mockLoanRepo, mockContributionRepo, &mockFeeRepo{})
 	r := gin.Default()
 	r.PUT("/loans/:loan_id/approve", func(c *gin.Context) {
 		user := models.User{}
@@ -286,13 +375,17 @@ func TestApproveLoan_Success(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "loan approved successfully")
+	assert.True(t, pool.committed)
+	assert.False(t, pool.rolledBack)
 }
 
 func TestApproveLoan_RejectIneligibleLoan(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
+	var rejected *models.Loan
+
 	// Create mock transaction that will succeed
-	mockTx := &gorm.DB{}
+	mockTx, pool := newMockTx()
 
 	mockLoanRepo := &mockAdminLoanRepo{
 		BeginTransactionFunc: func() *gorm.DB {
@@ -313,7 +406,7 @@ func TestApproveLoan_RejectIneligibleLoan(t *testing.T) {
 			return []models.Loan{}, "no active loans", nil
 		},
 		UpdateLoanFunc: func(tx *gorm.DB, loan *models.Loan) (*models.Loan, string, error) {
-			// Here we would verify the loan was rejected in a real test
+			rejected = loan
 			return loan, "loan updated successfully", nil
 		},
 		CreateLoanHistoryFunc: func(tx *gorm.DB, loanHistory *models.LoanHistory) error {
@@ -326,6 +419,7 @@ func TestApproveLoan_RejectIneligibleLoan(t *testing.T) {
 			member := &models.Member{
 				Name:        "Test Member",
 				ContactInfo: "123-456-7890",
+				Status:      models.MemberStatusActive,
 			}
 			member.Model.ID = 1
 			return member, "member fetched successfully", nil
@@ -333,7 +427,7 @@ func TestApproveLoan_RejectIneligibleLoan(t *testing.T) {
 	}
 
 	mockSavingsRepo := &mockAdminSavingsRepo{
-		GetSavingsByMemberIDTxFunc: func(tx *gorm.DB, memberID uint) (*models.Savings, string, error) {
+		GetSavingsByMemberIDTxFunc: func(tx *gorm.DB, memberID uint, currency string) (*models.Savings, string, error) {
 			savings := &models.Savings{
 				Balance: 1000, // Not enough savings for the loan amount
 			}
@@ -343,7 +437,12 @@ func TestApproveLoan_RejectIneligibleLoan(t *testing.T) {
 
 	mockUserRepo := &mockAdminUserRepo{}
 
-	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, mockSavingsRepo, mockLoanRepo)
+	mockContributionRepo := &mockAdminContributionRepo{
+		GetMandatesByMemberIDFunc: func(memberID uint) ([]models.ContributionMandate, string, error) {
+			return []models.ContributionMandate{}, "mandates fetched successfully", nil
+		},
+	}
+	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, mockSavingsRepo, mockLoanRepo, mockContributionRepo, &mockFeeRepo{})
 	r := gin.Default()
 	r.PUT("/loans/:loan_id/approve", func(c *gin.Context) {
 		user := models.User{}
@@ -359,6 +458,11 @@ func TestApproveLoan_RejectIneligibleLoan(t *testing.T) {
 
 	// Still returns 200 OK because rejection is a successful operation
 	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "loan rejected")
+	assert.Equal(t, models.LoanStatusRejected, rejected.Status)
+	assert.Contains(t, rejected.RejectionReason, "requested loan exceeds twice the savings balance")
+	assert.True(t, pool.committed)
+	assert.False(t, pool.rolledBack)
 }
 
 func TestApproveLoan_NoAuthUser(t *testing.T) {
@@ -369,7 +473,8 @@ func TestApproveLoan_NoAuthUser(t *testing.T) {
 	mockSavingsRepo := &mockAdminSavingsRepo{}
 	mockUserRepo := &mockAdminUserRepo{}
 
-	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, mockSavingsRepo, mockLoanRepo)
+	mockContributionRepo := &mockAdminContributionRepo{}
+	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, mockSavingsRepo, mockLoanRepo, mockContributionRepo, &mockFeeRepo{})
 	r := gin.Default()
 	// Not setting user in context
 	r.PUT("/loans/:loan_id/approve", h.ApproveLoan)
@@ -390,7 +495,8 @@ func TestApproveLoan_NonAdminUser(t *testing.T) {
 	mockSavingsRepo := &mockAdminSavingsRepo{}
 	mockUserRepo := &mockAdminUserRepo{}
 
-	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, mockSavingsRepo, mockLoanRepo)
+	mockContributionRepo := &mockAdminContributionRepo{}
+	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, mockSavingsRepo, mockLoanRepo, mockContributionRepo, &mockFeeRepo{})
 	r := gin.Default()
 	r.PUT("/loans/:loan_id/approve", func(c *gin.Context) {
 		user := models.User{}
@@ -416,19 +522,17 @@ func TestApproveLoan_InvalidLoanID(t *testing.T) {
 	mockSavingsRepo := &mockAdminSavingsRepo{}
 	mockUserRepo := &mockAdminUserRepo{}
 
-	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, mockSavingsRepo, mockLoanRepo)
-	r := gin.Default()
-	r.PUT("/loans//approve", func(c *gin.Context) { // Empty loan_id
-		user := models.User{}
-		user.ID = 1
-		user.Role = "admin"
-		c.Set("user", user)
-		h.ApproveLoan(c)
-	})
-
-	req, _ := http.NewRequest(http.MethodPut, "/loans//approve", nil)
+	mockContributionRepo := &mockAdminContributionRepo{}
+	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, mockSavingsRepo, mockLoanRepo, mockContributionRepo, &mockFeeRepo{})
+	// the router never matches an empty loan_id, so the handler is called without one
 	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodPut, "/loans//approve", nil)
+	user := models.User{}
+	user.ID = 1
+	user.Role = "admin"
+	c.Set("user", user)
+	h.ApproveLoan(c)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	assert.Contains(t, w.Body.String(), "loan ID is required")
@@ -438,7 +542,7 @@ func TestApproveLoan_LoanNotFound(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	// Create mock transaction
-	mockTx := &gorm.DB{}
+	mockTx, pool := newMockTx()
 
 	mockLoanRepo := &mockAdminLoanRepo{
 		BeginTransactionFunc: func() *gorm.DB {
@@ -452,7 +556,8 @@ func TestApproveLoan_LoanNotFound(t *testing.T) {
 	mockSavingsRepo := &mockAdminSavingsRepo{}
 	mockUserRepo := &mockAdminUserRepo{}
 
-	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, mockSavingsRepo, mockLoanRepo)
+	mockContributionRepo := &mockAdminContributionRepo{}
+	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, mockSavingsRepo, mockLoanRepo, mockContributionRepo, &mockFeeRepo{})
 	r := gin.Default()
 	r.PUT("/loans/:loan_id/approve", func(c *gin.Context) {
 		user := models.User{}
@@ -468,13 +573,14 @@ func TestApproveLoan_LoanNotFound(t *testing.T) {
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	assert.Contains(t, w.Body.String(), "loan not found")
+	assert.True(t, pool.rolledBack)
 }
 
 func TestApproveLoan_AlreadyApproved(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	// Create mock transaction
-	mockTx := &gorm.DB{}
+	mockTx, pool := newMockTx()
 
 	mockLoanRepo := &mockAdminLoanRepo{
 		BeginTransactionFunc: func() *gorm.DB {
@@ -493,7 +599,8 @@ func TestApproveLoan_AlreadyApproved(t *testing.T) {
 	mockSavingsRepo := &mockAdminSavingsRepo{}
 	mockUserRepo := &mockAdminUserRepo{}
 
-	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, mockSavingsRepo, mockLoanRepo)
+	mockContributionRepo := &mockAdminContributionRepo{}
+	h := handlers.NewAdminHandler(mockUserRepo, mockMemberRepo, mockSavingsRepo, mockLoanRepo, mockContributionRepo, &mockFeeRepo{})
 	r := gin.Default()
 	r.PUT("/loans/:loan_id/approve", func(c *gin.Context) {
 		user := models.User{}
@@ -509,4 +616,191 @@ func TestApproveLoan_AlreadyApproved(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	assert.Contains(t, w.Body.String(), "Loan is already approved")
+	assert.True(t, pool.rolledBack)
+	assert.False(t, pool.committed)
+}
+
+func TestDisburseLoan_ReturnsFeeCharges(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockLoanRepo := &mockAdminLoanRepo{
+		DisburseLoanFunc: func(loanID uint, disbursedBy uint) (*models.Loan, []models.FeeCharge, string, error) {
+			loan := &models.Loan{Status: models.LoanStatusDisbursed, MemberID: 1, Amount: 10000000, Currency: "NGN"}
+			loan.Model.ID = loanID
+			charge := models.FeeCharge{MemberID: 1, Trigger: models.FeeTriggerLoanDisbursed, SourceType: models.FeeSourceLoan, SourceID: loanID, Amount: 100000, Currency: "NGN", Status: models.FeeChargeStatusPosted}
+			return loan, []models.FeeCharge{charge}, "loan disbursed successfully", nil
+		},
+	}
+	h := handlers.NewAdminHandler(&mockAdminUserRepo{}, &mockAdminMemberRepo{}, &mockAdminSavingsRepo{}, mockLoanRepo, &mockAdminContributionRepo{}, &mockFeeRepo{})
+	r := gin.Default()
+	r.PUT("/loans/:loan_id/disburse", adminContext(h.DisburseLoan))
+
+	req, _ := http.NewRequest(http.MethodPut, "/loans/7/disburse", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"disbursed"`)
+	assert.Contains(t, w.Body.String(), `"trigger":"loan_disbursed","source_type":"loan","source_id":7,"amount":1000.00`)
+}
+
+func TestDisburseLoan_NotApproved(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockLoanRepo := &mockAdminLoanRepo{
+		DisburseLoanFunc: func(loanID uint, disbursedBy uint) (*models.Loan, []models.FeeCharge, string, error) {
+			return nil, nil, repository.ErrLoanNotDisbursable.Error(), repository.ErrLoanNotDisbursable
+		},
+	}
+	h := handlers.NewAdminHandler(&mockAdminUserRepo{}, &mockAdminMemberRepo{}, &mockAdminSavingsRepo{}, mockLoanRepo, &mockAdminContributionRepo{}, &mockFeeRepo{})
+	r := gin.Default()
+	r.PUT("/loans/:loan_id/disburse", adminContext(h.DisburseLoan))
+
+	req, _ := http.NewRequest(http.MethodPut, "/loans/7/disburse", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+// otherBranchMember is a member of branch 5, outside the branch 4 admin's view
+func otherBranchMember() *models.Member {
+	branchID := uint(5)
+	member := &models.Member{Name: "Other Branch", Status: models.MemberStatusActive, BranchID: &branchID}
+	member.ID = 1
+	return member
+}
+
+func TestApproveLoan_MemberInAnotherBranch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockTx, pool := newMockTx()
+	mockLoanRepo := &mockAdminLoanRepo{
+		BeginTransactionFunc: func() *gorm.DB {
+			return mockTx
+		},
+		GetLoanByIDForUpdateFunc: func(tx *gorm.DB, loanID string) (*models.Loan, string, error) {
+			loan := &models.Loan{Status: models.LoanStatusPending, MemberID: 1, Amount: 1000, Type: "personal", LoanTermMonths: 12}
+			loan.ID = 1
+			return loan, "loan fetched successfully", nil
+		},
+	}
+	mockMemberRepo := &mockAdminMemberRepo{
+		FetchMemberByIDFunc: func(tx *gorm.DB, memberID string) (*models.Member, string, error) {
+			return otherBranchMember(), "member fetched successfully", nil
+		},
+	}
+	h := handlers.NewAdminHandler(&mockAdminUserRepo{}, mockMemberRepo, &mockAdminSavingsRepo{}, mockLoanRepo, &mockAdminContributionRepo{}, &mockFeeRepo{})
+	r := gin.Default()
+	r.PUT("/loans/:loan_id/approve", branchAdminContext(4, h.ApproveLoan))
+
+	req, _ := http.NewRequest(http.MethodPut, "/loans/1/approve", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "member belongs to another branch")
+	assert.True(t, pool.rolledBack)
+	assert.False(t, pool.committed)
+}
+
+func TestDisburseLoan_MemberInAnotherBranch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	disbursed := false
+	mockLoanRepo := &mockAdminLoanRepo{
+		GetLoanByIDFunc: func(loanID string) (*models.Loan, string, error) {
+			loan := &models.Loan{Status: models.LoanStatusApproved, MemberID: 1}
+			loan.ID = 7
+			return loan, "success", nil
+		},
+		DisburseLoanFunc: func(loanID uint, disbursedBy uint) (*models.Loan, []models.FeeCharge, string, error) {
+			disbursed = true
+			return nil, nil, "loan disbursed successfully", nil
+		},
+	}
+	mockMemberRepo := &mockAdminMemberRepo{
+		FetchByIDFunc: func(memberID string) (*models.Member, string, error) {
+			return otherBranchMember(), "member fetched successfully", nil
+		},
+	}
+	h := handlers.NewAdminHandler(&mockAdminUserRepo{}, mockMemberRepo, &mockAdminSavingsRepo{}, mockLoanRepo, &mockAdminContributionRepo{}, &mockFeeRepo{})
+	r := gin.Default()
+	r.PUT("/loans/:loan_id/disburse", branchAdminContext(4, h.DisburseLoan))
+
+	req, _ := http.NewRequest(http.MethodPut, "/loans/7/disburse", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "member belongs to another branch")
+	assert.False(t, disbursed)
+}
+
+func TestAssignRole_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var assigned string
+	mockUserRepo := &mockAdminUserRepo{
+		FindUserByIDFunc: func(userID uint) (*models.User, string, error) {
+			user := &models.User{Email: "teller@example.com", Role: "member"}
+			user.ID = userID
+			return user, "success", nil
+		},
+		UpdateUserFunc: func(user *models.User, role string) (*models.User, string, error) {
+			assigned = role
+			return user, "user updated successfully", nil
+		},
+	}
+	h := handlers.NewAdminHandler(mockUserRepo, &mockAdminMemberRepo{}, &mockAdminSavingsRepo{}, &mockAdminLoanRepo{}, &mockAdminContributionRepo{}, &mockFeeRepo{})
+	r := gin.Default()
+	r.PUT("/users/:user_id/role", adminContext(h.AssignRole))
+
+	req, _ := http.NewRequest(http.MethodPut, "/users/5/role", bytes.NewBufferString(`{"role": "loan_officer"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, models.RoleLoanOfficer, assigned)
+	assert.Contains(t, w.Body.String(), `"role":"loan_officer"`)
+	assert.Contains(t, w.Body.String(), `"permissions":["members:read","loans:approve","documents:verify"]`)
+}
+
+func TestAssignRole_Refusals(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockUserRepo := &mockAdminUserRepo{
+		FindUserByIDFunc: func(userID uint) (*models.User, string, error) {
+			return nil, "user not found", gorm.ErrRecordNotFound
+		},
+	}
+	h := handlers.NewAdminHandler(mockUserRepo, &mockAdminMemberRepo{}, &mockAdminSavingsRepo{}, &mockAdminLoanRepo{}, &mockAdminContributionRepo{}, &mockFeeRepo{})
+	r := gin.Default()
+	r.PUT("/users/:user_id/role", adminContext(h.AssignRole))
+	r.PUT("/treasurer/users/:user_id/role", func(c *gin.Context) {
+		user := models.User{Role: "treasurer"}
+		user.ID = 3
+		c.Set("user", user)
+		h.AssignRole(c)
+	})
+
+	cases := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+	}{
+		{"own role", "/users/1/role", `{"role": "member"}`, http.StatusForbidden},
+		{"unknown role", "/users/5/role", `{"role": "superuser"}`, http.StatusBadRequest},
+		{"unknown user", "/users/5/role", `{"role": "auditor"}`, http.StatusNotFound},
+		{"without users:manage", "/treasurer/users/5/role", `{"role": "admin"}`, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		req, _ := http.NewRequest(http.MethodPut, tc.path, bytes.NewBufferString(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tc.status, w.Code, tc.name)
+	}
 }
@@ -0,0 +1,394 @@
+package handlers
+
+import (
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+	"cooperative-system/pkg/utils"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type BranchRequest struct {
+	Code       string `json:"code" binding:"required"`
+	Name       string `json:"name" binding:"required"`
+	Address    string `json:"address"`
+	HeadOffice bool   `json:"head_office"`
+}
+
+type BranchUpdateRequest struct {
+	Code       *string `json:"code"`
+	Name       *string `json:"name"`
+	Address    *string `json:"address"`
+	HeadOffice *bool   `json:"head_office"`
+}
+
+// MemberGroupRequest creates a group. Without a branch the group takes members from every branch, which
+// only head office can set up.
+type MemberGroupRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Employer string `json:"employer"`
+	BranchID *uint  `json:"branch_id"`
+}
+
+type MemberGroupUpdateRequest struct {
+	Name     *string `json:"name"`
+	Employer *string `json:"employer"`
+}
+
+// MemberAssignmentRequest puts a member in a branch and group. Leaving either out takes the member out of it.
+type MemberAssignmentRequest struct {
+	BranchID *uint `json:"branch_id"`
+	GroupID  *uint `json:"group_id"`
+}
+
+type UserBranchRequest struct {
+	BranchID *uint `json:"branch_id"`
+}
+
+type BranchHandler struct {
+	repo       repository.BranchRepository
+	memberRepo repository.MemberRepository
+}
+
+func NewBranchHandler(branchRepo repository.BranchRepository, memberRepo repository.MemberRepository) *BranchHandler {
+	return &BranchHandler{
+		repo:       branchRepo,
+		memberRepo: memberRepo,
+	}
+}
+
+type BranchService interface {
+	CreateBranch(c *gin.Context)
+	GetBranches(c *gin.Context)
+	UpdateBranch(c *gin.Context)
+	CreateGroup(c *gin.Context)
+	GetGroups(c *gin.Context)
+	UpdateGroup(c *gin.Context)
+	AssignMember(c *gin.Context)
+	AssignUserBranch(c *gin.Context)
+}
+
+func branchErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrBranchCodeTaken):
+		return http.StatusConflict
+	case errors.Is(err, repository.ErrUnknownBranch), errors.Is(err, repository.ErrUnknownGroup), errors.Is(err, repository.ErrGroupNotInBranch):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+// branchFilter reads the optional ?branch_id= of a list or report. Admins limited to a branch always get their
+// own branch and are refused any other.
+func branchFilter(c *gin.Context, authUser *models.User) (*uint, bool) {
+	scope := authUser.BranchScope()
+	value := c.Query("branch_id")
+	if value == "" {
+		return scope, true
+	}
+	branchID, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "branch_id must be a branch ID", err)
+		return nil, false
+	}
+	filter := uint(branchID)
+	if !authUser.CanSeeBranch(&filter) {
+		utils.RespondWithError(c, http.StatusForbidden, "you can only see the members of your own branch", nil)
+		return nil, false
+	}
+	return &filter, true
+}
+
+// headOfficeAdmin responds unless the user's role grants the permission and they see every branch
+func headOfficeAdmin(c *gin.Context, permission string, action string) (models.User, bool) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(permission) || authUser.BranchScope() != nil {
+		utils.RespondWithError(c, http.StatusForbidden, "only head office admins can "+action, nil)
+		return models.User{}, false
+	}
+	return authUser, true
+}
+
+func (h *BranchHandler) CreateBranch(c *gin.Context) {
+	if _, ok := headOfficeAdmin(c, models.PermBranchesManage, "set up branches"); !ok {
+		return
+	}
+
+	var reqBody BranchRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	branch := models.Branch{
+		Code:       reqBody.Code,
+		Name:       reqBody.Name,
+		Address:    strings.TrimSpace(reqBody.Address),
+		HeadOffice: reqBody.HeadOffice,
+	}
+	if err := branch.Validate(); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	created, msg, err := h.repo.CreateBranch(&branch)
+	if err != nil {
+		utils.RespondWithError(c, branchErrorStatus(err), msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, msg, "data", gin.H{
+		"branch": models.NewBranchResponse(created),
+	})
+}
+
+func (h *BranchHandler) GetBranches(c *gin.Context) {
+	branches, msg, err := h.repo.GetBranches()
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	responses := make([]models.BranchResponse, len(branches))
+	for i := range branches {
+		responses[i] = models.NewBranchResponse(&branches[i])
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"branches": responses,
+	})
+}
+
+func (h *BranchHandler) UpdateBranch(c *gin.Context) {
+	if _, ok := headOfficeAdmin(c, models.PermBranchesManage, "change branches"); !ok {
+		return
+	}
+
+	var reqBody BranchUpdateRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	branch, msg, err := h.repo.GetBranchByID(c.Param("branch_id"))
+	if err != nil {
+		utils.RespondWithError(c, branchErrorStatus(err), msg, err)
+		return
+	}
+
+	if reqBody.Code != nil {
+		branch.Code = *reqBody.Code
+	}
+	if reqBody.Name != nil {
+		branch.Name = *reqBody.Name
+	}
+	if reqBody.Address != nil {
+		branch.Address = strings.TrimSpace(*reqBody.Address)
+	}
+	if reqBody.HeadOffice != nil {
+		branch.HeadOffice = *reqBody.HeadOffice
+	}
+	if err := branch.Validate(); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	updated, msg, err := h.repo.UpdateBranch(branch)
+	if err != nil {
+		utils.RespondWithError(c, branchErrorStatus(err), msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"branch": models.NewBranchResponse(updated),
+	})
+}
+
+// CreateGroup sets up a member group. Admins limited to a branch can only add groups to their own branch.
+func (h *BranchHandler) CreateGroup(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermBranchesManage) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can set up member groups", nil)
+		return
+	}
+
+	var reqBody MemberGroupRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	group := models.MemberGroup{
+		Name:     strings.TrimSpace(reqBody.Name),
+		Employer: strings.TrimSpace(reqBody.Employer),
+		BranchID: reqBody.BranchID,
+	}
+	if scope := authUser.BranchScope(); scope != nil {
+		if group.BranchID == nil {
+			group.BranchID = scope
+		} else if *group.BranchID != *scope {
+			utils.RespondWithError(c, http.StatusForbidden, "you can only set up groups in your own branch", nil)
+			return
+		}
+	}
+	if group.Name == "" {
+		utils.RespondWithError(c, http.StatusBadRequest, "name cannot be empty", nil)
+		return
+	}
+
+	created, msg, err := h.repo.CreateGroup(&group)
+	if err != nil {
+		utils.RespondWithError(c, branchErrorStatus(err), msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, msg, "data", gin.H{
+		"group": models.NewMemberGroupResponse(created),
+	})
+}
+
+// GetGroups lists the member groups of a branch, with ?branch_id=, and those open to every branch
+func (h *BranchHandler) GetGroups(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermMembersRead) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can view member groups", nil)
+		return
+	}
+	branchID, ok := branchFilter(c, &authUser)
+	if !ok {
+		return
+	}
+
+	groups, msg, err := h.repo.GetGroups(branchID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	responses := make([]models.MemberGroupResponse, len(groups))
+	for i := range groups {
+		responses[i] = models.NewMemberGroupResponse(&groups[i])
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"groups": responses,
+	})
+}
+
+// UpdateGroup renames a member group. The groups open to every branch can only be changed by head office.
+func (h *BranchHandler) UpdateGroup(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermBranchesManage) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can change member groups", nil)
+		return
+	}
+
+	var reqBody MemberGroupUpdateRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	group, msg, err := h.repo.GetGroupByID(c.Param("group_id"))
+	if err != nil {
+		utils.RespondWithError(c, branchErrorStatus(err), msg, err)
+		return
+	}
+	if !authUser.CanSeeBranch(group.BranchID) {
+		utils.RespondWithError(c, http.StatusForbidden, "you can only change the groups of your own branch", nil)
+		return
+	}
+
+	if reqBody.Name != nil {
+		group.Name = strings.TrimSpace(*reqBody.Name)
+	}
+	if reqBody.Employer != nil {
+		group.Employer = strings.TrimSpace(*reqBody.Employer)
+	}
+	if group.Name == "" {
+		utils.RespondWithError(c, http.StatusBadRequest, "name cannot be empty", nil)
+		return
+	}
+
+	updated, msg, err := h.repo.UpdateGroup(group)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"group": models.NewMemberGroupResponse(updated),
+	})
+}
+
+// AssignMember puts the member in the URL in a branch and group. Admins limited to a branch can only move
+// their own members between the groups their branch can use.
+func (h *BranchHandler) AssignMember(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermBranchesManage) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can assign members to branches", nil)
+		return
+	}
+
+	var reqBody MemberAssignmentRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+	if !authUser.CanSeeBranch(reqBody.BranchID) {
+		utils.RespondWithError(c, http.StatusForbidden, "you can only assign members to your own branch", nil)
+		return
+	}
+
+	memberID, ok := memberIDParam(c, h.memberRepo, &authUser)
+	if !ok {
+		return
+	}
+
+	member, msg, err := h.repo.AssignMember(memberID, reqBody.BranchID, reqBody.GroupID, authUser.ID)
+	if err != nil {
+		utils.RespondWithError(c, branchErrorStatus(err), msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"member": models.NewMemberResponse(member),
+	})
+}
+
+// AssignUserBranch limits a staff user to the members of one branch, or with no branch lets them see every
+// branch
+func (h *BranchHandler) AssignUserBranch(c *gin.Context) {
+	if _, ok := headOfficeAdmin(c, models.PermUsersManage, "assign staff to branches"); !ok {
+		return
+	}
+
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid user ID", err)
+		return
+	}
+
+	var reqBody UserBranchRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	user, msg, err := h.repo.AssignUserBranch(uint(userID), reqBody.BranchID)
+	if err != nil {
+		utils.RespondWithError(c, branchErrorStatus(err), msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"user": models.NewUserResponse(user),
+	})
+}
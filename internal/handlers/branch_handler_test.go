@@ -0,0 +1,128 @@
+// Unit tests for BranchHandler endpoints and branch scoping
+package handlers_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cooperative-system/internal/handlers"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBranchRepo struct {
+	repository.BranchRepository
+	AssignMemberFunc func(memberID uint, branchID *uint, groupID *uint, changedBy uint) (*models.Member, string, error)
+}
+
+func (m *mockBranchRepo) AssignMember(memberID uint, branchID *uint, groupID *uint, changedBy uint) (*models.Member, string, error) {
+	return m.AssignMemberFunc(memberID, branchID, groupID, changedBy)
+}
+
+// branchAdminContext runs the handler as an admin limited to one branch
+func branchAdminContext(branchID uint, handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := models.User{Role: "admin", BranchID: &branchID}
+		user.ID = 2
+		c.Set("user", user)
+		handler(c)
+	}
+}
+
+func TestGetAllMembers_BranchAdminSeesOwnBranch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var received models.MemberQuery
+	mockRepo := &mockMemberRepo{
+		FetchPageFunc: func(query models.MemberQuery) (*models.MemberPage, string, error) {
+			received = query
+			return &models.MemberPage{}, "members fetched successfully", nil
+		},
+	}
+	h := handlers.NewMemberHandler(mockRepo)
+	r := gin.Default()
+	r.GET("/admins/members", branchAdminContext(2, h.GetAllMembers))
+
+	req, _ := http.NewRequest(http.MethodGet, "/admins/members", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	if assert.NotNil(t, received.BranchID) {
+		assert.Equal(t, uint(2), *received.BranchID)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "/admins/members?branch_id=3", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestGetMemberByID_OtherBranchRefused(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepo := &mockMemberRepo{
+		FetchByIDFunc: func(memberID string) (*models.Member, string, error) {
+			branchID := uint(3)
+			member := models.Member{Name: "Ada Obi", BranchID: &branchID}
+			member.ID = 12
+			return &member, "member fetched successfully", nil
+		},
+	}
+	h := handlers.NewMemberHandler(mockRepo)
+	r := gin.Default()
+	r.GET("/members/:id", branchAdminContext(2, h.GetMemberByID))
+	req, _ := http.NewRequest(http.MethodGet, "/members/12", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestAssignMember(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var assignedBranch, assignedGroup *uint
+	branchRepo := &mockBranchRepo{
+		AssignMemberFunc: func(memberID uint, branchID *uint, groupID *uint, changedBy uint) (*models.Member, string, error) {
+			if groupID != nil && *groupID == 9 {
+				return nil, "member group belongs to another branch", repository.ErrGroupNotInBranch
+			}
+			assignedBranch, assignedGroup = branchID, groupID
+			member := models.Member{BranchID: branchID, GroupID: groupID}
+			member.ID = memberID
+			return &member, "member assigned successfully", nil
+		},
+	}
+	memberRepo := &mockMemberRepo{
+		FetchByIDFunc: func(memberID string) (*models.Member, string, error) {
+			branchID := uint(2)
+			member := models.Member{BranchID: &branchID}
+			member.ID = 12
+			return &member, "member fetched successfully", nil
+		},
+	}
+	h := handlers.NewBranchHandler(branchRepo, memberRepo)
+	r := gin.Default()
+	r.PUT("/members/:id/assignment", branchAdminContext(2, h.AssignMember))
+
+	assign := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPut, "/members/12/assignment", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := assign(`{"branch_id": 2, "group_id": 5}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, uint(2), *assignedBranch)
+	assert.Equal(t, uint(5), *assignedGroup)
+
+	// a branch admin cannot hand their member to another branch
+	w = assign(`{"branch_id": 3}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = assign(`{"branch_id": 2, "group_id": 9}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
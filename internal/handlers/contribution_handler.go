@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+	"cooperative-system/pkg/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MandateRequest struct {
+	Amount    models.Money `json:"amount" binding:"required"`
+	Currency  string       `json:"currency"` // ISO 4217 code, defaults to the base currency
+	Frequency string       `json:"frequency" binding:"required"`
+	StartDate string       `json:"start_date"` // YYYY-MM-DD, defaults to today
+}
+
+type ContributionHandler struct {
+	repo       repository.ContributionRepository
+	memberRepo repository.MemberRepository
+}
+
+func NewContributionHandler(contributionRepo repository.ContributionRepository, memberRepo repository.MemberRepository) *ContributionHandler {
+	return &ContributionHandler{
+		repo:       contributionRepo,
+		memberRepo: memberRepo,
+	}
+}
+
+type ContributionService interface {
+	SetMandate(c *gin.Context)
+	GetContributionStatus(c *gin.Context)
+	GetMembersInArrears(c *gin.Context)
+}
+
+// memberContributionStatus returns nil when the member has never had a mandate
+func memberContributionStatus(repo repository.ContributionRepository, memberID uint, asOf time.Time) (*models.ContributionStatus, string, error) {
+	mandates, msg, err := repo.GetMandatesByMemberID(memberID)
+	if err != nil {
+		return nil, msg, err
+	}
+	if len(mandates) == 0 {
+		return nil, "member has no contribution mandate", nil
+	}
+
+	contributions, msg, err := repo.GetContributionsByMemberIDs([]uint{memberID}, mandates[0].StartDate)
+	if err != nil {
+		return nil, msg, err
+	}
+
+	status := models.CalculateContributionStatus(memberID, mandates, contributions[memberID], asOf)
+	return &status, "contribution status calculated successfully", nil
+}
+
+func (h *ContributionHandler) SetMandate(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+
+	member, err := getMemberByIDAndAuthorize(c, h.memberRepo, &authUser, models.PermMembersManage)
+	if err != nil {
+		return
+	}
+
+	var reqBody MandateRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	if reqBody.Amount <= 0 {
+		utils.RespondWithError(c, http.StatusBadRequest, "mandate amount must be greater than zero", nil)
+		return
+	}
+
+	if _, ok := models.AllowedContributionFrequencies[reqBody.Frequency]; !ok {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid contribution frequency", nil)
+		return
+	}
+
+	currency, err := models.NormalizeCurrency(reqBody.Currency)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	startDate := time.Now().Truncate(24 * time.Hour)
+	if reqBody.StartDate != "" {
+		startDate, err = time.Parse(time.DateOnly, reqBody.StartDate)
+		if err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "start date must be in YYYY-MM-DD format", err)
+			return
+		}
+	}
+
+	if current, _, err := h.repo.GetActiveMandateByMemberID(member.ID); err == nil && current != nil && !startDate.After(current.StartDate) {
+		utils.RespondWithError(c, http.StatusBadRequest, "start date must be after the start of the current mandate", nil)
+		return
+	}
+
+	mandate := models.ContributionMandate{
+		MemberID:  member.ID,
+		Amount:    reqBody.Amount,
+		Currency:  currency,
+		Frequency: reqBody.Frequency,
+		StartDate: startDate,
+		Status:    models.MandateStatusActive,
+	}
+
+	createdMandate, msg, err := h.repo.CreateMandate(&mandate)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "contribution mandate set successfully", "data", gin.H{
+		"mandate": models.NewContributionMandateResponse(createdMandate),
+	})
+}
+
+func (h *ContributionHandler) GetContributionStatus(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+
+	member, err := getMemberByIDAndAuthorize(c, h.memberRepo, &authUser, models.PermMembersRead)
+	if err != nil {
+		return
+	}
+
+	status, msg, err := memberContributionStatus(h.repo, member.ID, time.Now())
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+	if status == nil {
+		utils.RespondWithError(c, http.StatusNotFound, msg, nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "contribution status fetched successfully", "data", gin.H{
+		"contributions": status,
+	})
+}
+
+func (h *ContributionHandler) GetMembersInArrears(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermReportsRead) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can view contribution arrears", nil)
+		return
+	}
+	branchID, ok := branchFilter(c, &authUser)
+	if !ok {
+		return
+	}
+	asOf := time.Now()
+
+	mandates, msg, err := h.repo.GetMandatesForActiveMembers(branchID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	// group mandates by member, they come back ordered by member and start date
+	var memberIDs []uint
+	mandatesByMember := make(map[uint][]models.ContributionMandate)
+	members := make(map[uint]models.Member)
+	earliest := asOf
+	for _, mandate := range mandates {
+		if _, seen := mandatesByMember[mandate.MemberID]; !seen {
+			memberIDs = append(memberIDs, mandate.MemberID)
+			members[mandate.MemberID] = mandate.Member
+		}
+		mandatesByMember[mandate.MemberID] = append(mandatesByMember[mandate.MemberID], mandate)
+		if mandate.StartDate.Before(earliest) {
+			earliest = mandate.StartDate
+		}
+	}
+
+	contributions, msg, err := h.repo.GetContributionsByMemberIDs(memberIDs, earliest)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	arrears := []gin.H{}
+	for _, memberID := range memberIDs {
+		status := models.CalculateContributionStatus(memberID, mandatesByMember[memberID], contributions[memberID], asOf)
+		if !status.InArrears {
+			continue
+		}
+		member := members[memberID]
+		arrears = append(arrears, gin.H{
+			"member":             models.NewMemberResponse(&member),
+			"total_expected":     status.TotalExpected,
+			"total_actual":       status.TotalActual,
+			"arrears":            status.Arrears,
+			"periods_in_arrears": status.PeriodsInArrears,
+		})
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "members in arrears fetched successfully", "data", gin.H{
+		"as_of":   asOf,
+		"members": arrears,
+	})
+}
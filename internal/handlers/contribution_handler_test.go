@@ -0,0 +1,177 @@
+// Unit tests for ContributionHandler endpoints
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cooperative-system/internal/handlers"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockContributionRepo struct {
+	repository.ContributionRepository
+	CreateMandateFunc               func(mandate *models.ContributionMandate) (*models.ContributionMandate, string, error)
+	GetActiveMandateByMemberIDFunc  func(memberID uint) (*models.ContributionMandate, string, error)
+	GetMandatesByMemberIDFunc       func(memberID uint) ([]models.ContributionMandate, string, error)
+	GetContributionsByMemberIDsFunc func(memberIDs []uint, since time.Time) (map[uint][]models.SavingTransaction, string, error)
+}
+
+func (m *mockContributionRepo) CreateMandate(mandate *models.ContributionMandate) (*models.ContributionMandate, string, error) {
+	return m.CreateMandateFunc(mandate)
+}
+func (m *mockContributionRepo) GetActiveMandateByMemberID(memberID uint) (*models.ContributionMandate, string, error) {
+	return m.GetActiveMandateByMemberIDFunc(memberID)
+}
+func (m *mockContributionRepo) GetMandatesByMemberID(memberID uint) ([]models.ContributionMandate, string, error) {
+	return m.GetMandatesByMemberIDFunc(memberID)
+}
+func (m *mockContributionRepo) GetContributionsByMemberIDs(memberIDs []uint, since time.Time) (map[uint][]models.SavingTransaction, string, error) {
+	return m.GetContributionsByMemberIDsFunc(memberIDs, since)
+}
+
+func ownMemberRepo() *mockMemberRepoForSavings {
+	return &mockMemberRepoForSavings{
+		FetchByIDFunc: func(memberID string) (*models.Member, string, error) {
+			member := models.Member{}
+			member.ID = 1
+			member.UserID = 1
+			return &member, "member fetched successfully", nil
+		},
+	}
+}
+
+func TestSetMandate_InvalidFrequency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewContributionHandler(&mockContributionRepo{}, ownMemberRepo())
+	r := gin.Default()
+	r.PUT("/members/:id/mandate", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "member"
+		c.Set("user", user)
+		h.SetMandate(c)
+	})
+	body := map[string]interface{}{"amount": 5000, "frequency": "daily"}
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPut, "/members/1/mandate", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid contribution frequency")
+}
+
+func TestSetMandate_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepo := &mockContributionRepo{
+		GetActiveMandateByMemberIDFunc: func(memberID uint) (*models.ContributionMandate, string, error) {
+			return nil, "no active mandate found for the given member ID", nil
+		},
+		CreateMandateFunc: func(mandate *models.ContributionMandate) (*models.ContributionMandate, string, error) {
+			mandate.ID = 1
+			return mandate, "mandate created successfully", nil
+		},
+	}
+	h := handlers.NewContributionHandler(mockRepo, ownMemberRepo())
+	r := gin.Default()
+	r.PUT("/members/:id/mandate", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "member"
+		c.Set("user", user)
+		h.SetMandate(c)
+	})
+	body := map[string]interface{}{"amount": 5000, "frequency": "monthly", "start_date": "2025-01-01"}
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPut, "/members/1/mandate", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), "contribution mandate set successfully")
+}
+
+func TestGetContributionStatus_InArrears(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	start := time.Now().AddDate(0, -3, -1)
+	mockRepo := &mockContributionRepo{
+		GetMandatesByMemberIDFunc: func(memberID uint) ([]models.ContributionMandate, string, error) {
+			mandate := models.ContributionMandate{MemberID: memberID, Amount: 1000, Frequency: "monthly", StartDate: start, Status: "active"}
+			return []models.ContributionMandate{mandate}, "mandates fetched successfully", nil
+		},
+		GetContributionsByMemberIDsFunc: func(memberIDs []uint, since time.Time) (map[uint][]models.SavingTransaction, string, error) {
+			deposit := models.SavingTransaction{MemberID: 1, Amount: 1000, Type: models.TransactionTypeDeposit}
+			deposit.CreatedAt = start.AddDate(0, 0, 1)
+			return map[uint][]models.SavingTransaction{1: {deposit}}, "contributions fetched successfully", nil
+		},
+	}
+	h := handlers.NewContributionHandler(mockRepo, ownMemberRepo())
+	r := gin.Default()
+	r.GET("/members/:id/contributions", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "member"
+		c.Set("user", user)
+		h.GetContributionStatus(c)
+	})
+	req, _ := http.NewRequest(http.MethodGet, "/members/1/contributions", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"arrears":20.00`)
+	assert.Contains(t, w.Body.String(), `"periods_in_arrears":2`)
+	assert.Contains(t, w.Body.String(), `"in_arrears":true`)
+}
+
+func TestGetContributionStatus_OnlyDepositsCount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	start := time.Now().AddDate(0, -3, -1)
+	mockRepo := &mockContributionRepo{
+		GetMandatesByMemberIDFunc: func(memberID uint) ([]models.ContributionMandate, string, error) {
+			mandate := models.ContributionMandate{MemberID: memberID, Amount: 1000, Frequency: "monthly", StartDate: start, Status: "active"}
+			return []models.ContributionMandate{mandate}, "mandates fetched successfully", nil
+		},
+		GetContributionsByMemberIDsFunc: func(memberIDs []uint, since time.Time) (map[uint][]models.SavingTransaction, string, error) {
+			deposit := models.SavingTransaction{MemberID: 1, Amount: 1000, Type: models.TransactionTypeDeposit}
+			deposit.ID = 1
+			reversedDeposit := models.SavingTransaction{MemberID: 1, Amount: 1000, Type: models.TransactionTypeDeposit}
+			reversedDeposit.ID = 2
+			reversalOf := reversedDeposit.ID
+			reversal := models.SavingTransaction{MemberID: 1, Amount: -1000, Type: models.TransactionTypeReversal, ReversalOfID: &reversalOf}
+			reversal.ID = 3
+			interest := models.SavingTransaction{MemberID: 1, Amount: 1000, Type: models.TransactionTypeInterest}
+			interest.ID = 4
+			transferIn := models.SavingTransaction{MemberID: 1, Amount: 1000, Type: models.TransactionTypeTransferIn}
+			transferIn.ID = 5
+			transactions := []models.SavingTransaction{deposit, reversedDeposit, reversal, interest, transferIn}
+			for i := range transactions {
+				transactions[i].CreatedAt = start.AddDate(0, 1, 1)
+			}
+			return map[uint][]models.SavingTransaction{1: transactions}, "contributions fetched successfully", nil
+		},
+	}
+	h := handlers.NewContributionHandler(mockRepo, ownMemberRepo())
+	r := gin.Default()
+	r.GET("/members/:id/contributions", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "member"
+		c.Set("user", user)
+		h.GetContributionStatus(c)
+	})
+	req, _ := http.NewRequest(http.MethodGet, "/members/1/contributions", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"total_actual":10.00`)
+	assert.Contains(t, w.Body.String(), `"arrears":20.00`)
+}
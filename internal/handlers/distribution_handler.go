@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"bytes"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+	"cooperative-system/pkg/utils"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type DistributionRequest struct {
+	FiscalYear    int     `json:"fiscal_year" binding:"required"`
+	PeriodStart   string  `json:"period_start"` // YYYY-MM-DD, defaults to 1 January of the fiscal year
+	PeriodEnd     string  `json:"period_end"`   // YYYY-MM-DD inclusive, defaults to 31 December of the fiscal year
+	DividendRate  float64 `json:"dividend_rate"`
+	PatronageRate float64 `json:"patronage_rate"`
+	PayoutMethod  string  `json:"payout_method" binding:"required"`
+}
+
+type DistributionHandler struct {
+	repo          repository.DistributionRepository
+	shareSettings func() models.ShareSettings
+}
+
+func NewDistributionHandler(distributionRepo repository.DistributionRepository, shareSettings func() models.ShareSettings) *DistributionHandler {
+	return &DistributionHandler{
+		repo:          distributionRepo,
+		shareSettings: shareSettings,
+	}
+}
+
+type DistributionService interface {
+	CreateDistribution(c *gin.Context)
+	GetDistributions(c *gin.Context)
+	GetDistributionByID(c *gin.Context)
+	ApproveDistribution(c *gin.Context)
+	CancelDistribution(c *gin.Context)
+	GetPayoutList(c *gin.Context)
+}
+
+func distributionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrDistributionNotDraft), errors.Is(err, repository.ErrDistributionAlreadyApproved):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// CreateDistribution computes a draft distribution from the rates declared at the AGM
+func (h *DistributionHandler) CreateDistribution(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermDistributionsManage) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can run distributions", nil)
+		return
+	}
+
+	var reqBody DistributionRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	if reqBody.DividendRate < 0 || reqBody.PatronageRate < 0 || reqBody.DividendRate+reqBody.PatronageRate == 0 {
+		utils.RespondWithError(c, http.StatusBadRequest, "declare a dividend rate, a patronage rate or both, neither can be negative", nil)
+		return
+	}
+	if !models.AllowedDistributionPayoutMethods[reqBody.PayoutMethod] {
+		utils.RespondWithError(c, http.StatusBadRequest, "payout method must be savings or payout_list", nil)
+		return
+	}
+
+	periodStart := time.Date(reqBody.FiscalYear, time.January, 1, 0, 0, 0, 0, time.UTC)
+	periodEnd := time.Date(reqBody.FiscalYear, time.December, 31, 0, 0, 0, 0, time.UTC)
+	var err error
+	if reqBody.PeriodStart != "" {
+		if periodStart, err = time.Parse(time.DateOnly, reqBody.PeriodStart); err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "period start must be in YYYY-MM-DD format", err)
+			return
+		}
+	}
+	if reqBody.PeriodEnd != "" {
+		if periodEnd, err = time.Parse(time.DateOnly, reqBody.PeriodEnd); err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "period end must be in YYYY-MM-DD format", err)
+			return
+		}
+	}
+	if periodStart.After(periodEnd) {
+		utils.RespondWithError(c, http.StatusBadRequest, "period start must not be after period end", nil)
+		return
+	}
+
+	distribution := models.Distribution{
+		FiscalYear:    reqBody.FiscalYear,
+		PeriodStart:   periodStart,
+		PeriodEnd:     periodEnd,
+		DividendRate:  reqBody.DividendRate,
+		PatronageRate: reqBody.PatronageRate,
+		SharePrice:    h.shareSettings().Price,
+		PayoutMethod:  reqBody.PayoutMethod,
+		CreatedBy:     authUser.ID,
+	}
+
+	created, msg, err := h.repo.CreateDistribution(&distribution)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, msg, "data", gin.H{
+		"distribution": models.NewDistributionResponse(created),
+	})
+}
+
+func (h *DistributionHandler) GetDistributions(c *gin.Context) {
+	distributions, msg, err := h.repo.GetDistributions()
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	responses := make([]models.DistributionResponse, len(distributions))
+	for i := range distributions {
+		responses[i] = models.NewDistributionResponse(&distributions[i])
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"distributions": responses,
+	})
+}
+
+// GetDistributionByID returns a distribution with every member's entitlement, for review before approval
+func (h *DistributionHandler) GetDistributionByID(c *gin.Context) {
+	distribution, msg, err := h.repo.GetDistributionByID(c.Param("distribution_id"))
+	if err != nil {
+		utils.RespondWithError(c, distributionErrorStatus(err), msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"distribution": models.NewDistributionResponse(distribution),
+	})
+}
+
+func (h *DistributionHandler) ApproveDistribution(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermDistributionsManage) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can approve distributions", nil)
+		return
+	}
+
+	distributionID, err := strconv.ParseUint(c.Param("distribution_id"), 10, 64)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid distribution ID", err)
+		return
+	}
+
+	distribution, msg, err := h.repo.ApproveDistribution(uint(distributionID), authUser.ID)
+	if err != nil {
+		utils.RespondWithError(c, distributionErrorStatus(err), msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "distribution approved successfully", "data", gin.H{
+		"distribution": models.NewDistributionResponse(distribution),
+	})
+}
+
+func (h *DistributionHandler) CancelDistribution(c *gin.Context) {
+	distributionID, err := strconv.ParseUint(c.Param("distribution_id"), 10, 64)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid distribution ID", err)
+		return
+	}
+
+	distribution, msg, err := h.repo.CancelDistribution(uint(distributionID))
+	if err != nil {
+		utils.RespondWithError(c, distributionErrorStatus(err), msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "distribution cancelled successfully", "data", gin.H{
+		"distribution": models.NewDistributionResponse(distribution),
+	})
+}
+
+// GetPayoutList lists what to pay each member for an approved distribution paid outside the system,
+// as JSON or as CSV with ?format=csv. With ?branch_id=, or for an admin limited to a branch, only that
+// branch's members are listed.
+func (h *DistributionHandler) GetPayoutList(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermReportsRead) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can view payout lists", nil)
+		return
+	}
+	branchID, ok := branchFilter(c, &authUser)
+	if !ok {
+		return
+	}
+
+	distribution, msg, err := h.repo.GetDistributionByID(c.Param("distribution_id"))
+	if err != nil {
+		utils.RespondWithError(c, distributionErrorStatus(err), msg, err)
+		return
+	}
+	if distribution.Status != models.DistributionStatusApproved || distribution.PayoutMethod != models.DistributionPayoutList {
+		utils.RespondWithError(c, http.StatusConflict, "only approved distributions paid from a payout list have one", nil)
+		return
+	}
+	distribution.LimitToBranch(branchID)
+
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		utils.SuccessResponse(c, http.StatusOK, "payout list generated successfully", "data", gin.H{
+			"distribution": models.NewDistributionResponse(distribution),
+		})
+	case "csv":
+		var buf bytes.Buffer
+		writer := csv.NewWriter(&buf)
+		writer.Write([]string{"Member ID", "Member Name", "Dividend", "Patronage Refund", "Total", "Currency"})
+		for _, line := range distribution.Lines {
+			writer.Write([]string{fmt.Sprint(line.MemberID), line.Member.Name, line.Dividend.String(), line.Patronage.String(), line.Total.String(), models.BaseCurrency})
+		}
+		writer.Write([]string{"", "Total", distribution.TotalDividend.String(), distribution.TotalPatronage.String(), distribution.Total.String(), models.BaseCurrency})
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			utils.RespondWithError(c, http.StatusInternalServerError, "failed to write payout list", err)
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="distribution-%d-payout-list.csv"`, distribution.ID))
+		c.Data(http.StatusOK, "text/csv", buf.Bytes())
+	default:
+		utils.RespondWithError(c, http.StatusBadRequest, "format must be one of json or csv", nil)
+	}
+}
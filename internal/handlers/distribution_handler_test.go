@@ -0,0 +1,145 @@
+// Unit tests for DistributionHandler endpoints
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cooperative-system/internal/handlers"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockDistributionRepo struct {
+	repository.DistributionRepository
+	CreateDistributionFunc  func(distribution *models.Distribution) (*models.Distribution, string, error)
+	GetDistributionByIDFunc func(distributionID string) (*models.Distribution, string, error)
+	ApproveDistributionFunc func(distributionID uint, approvedBy uint) (*models.Distribution, string, error)
+}
+
+func (m *mockDistributionRepo) CreateDistribution(distribution *models.Distribution) (*models.Distribution, string, error) {
+	return m.CreateDistributionFunc(distribution)
+}
+func (m *mockDistributionRepo) GetDistributionByID(distributionID string) (*models.Distribution, string, error) {
+	return m.GetDistributionByIDFunc(distributionID)
+}
+func (m *mockDistributionRepo) ApproveDistribution(distributionID uint, approvedBy uint) (*models.Distribution, string, error) {
+	return m.ApproveDistributionFunc(distributionID, approvedBy)
+}
+
+func shareTransactionOn(date string, shares int64) models.ShareTransaction {
+	transaction := models.ShareTransaction{MemberID: 1, Shares: shares}
+	transaction.CreatedAt, _ = time.Parse(time.DateOnly, date)
+	return transaction
+}
+
+// computingDistributionRepo runs the real calculation over a fixed history: 10 shares held all year, 10 more
+// bought on 2 July, and a repayment of 1,035.00 on a loan whose interest is 35.00 of 1,035.00
+func computingDistributionRepo() *mockDistributionRepo {
+	return &mockDistributionRepo{
+		CreateDistributionFunc: func(distribution *models.Distribution) (*models.Distribution, string, error) {
+			shares := map[uint][]models.ShareTransaction{
+				1: {shareTransactionOn("2024-06-01", 10), shareTransactionOn("2025-07-02", 10)},
+			}
+			loan := models.Loan{Amount: 1000000, TotalRepayableAmount: 1035000, Currency: "NGN"}
+			loan.ID = 5
+			repayments := map[uint][]models.LoanRepayment{
+				1: {{LoanID: 5, MemberID: 1, Amount: 103500}},
+			}
+			if err := models.CalculateDistribution(distribution, shares, repayments, map[uint]models.Loan{5: loan}); err != nil {
+				return nil, "failed to calculate distribution", err
+			}
+			distribution.Status = models.DistributionStatusDraft
+			return distribution, "distribution draft created successfully", nil
+		},
+	}
+}
+
+func fixedSharePriceSettings() models.ShareSettings {
+	return models.ShareSettings{Price: 100000, MinimumHolding: 10}
+}
+
+func TestCreateDistribution_ComputesDraft(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewDistributionHandler(computingDistributionRepo(), fixedSharePriceSettings)
+	r := gin.Default()
+	r.POST("/distributions", adminContext(h.CreateDistribution))
+	body := map[string]interface{}{"fiscal_year": 2025, "dividend_rate": 0.1, "patronage_rate": 0.2, "payout_method": "savings"}
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPost, "/distributions", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	response := w.Body.String()
+	assert.Contains(t, response, `"status":"draft"`)
+	// 10 shares for 365 days and 10 more for the 183 days from 2 July, at 1,000.00 a share
+	assert.Contains(t, response, `"average_share_capital":15013.70,"dividend":1501.37`)
+	assert.Contains(t, response, `"interest_paid":35.00,"patronage_refund":7.00,"total":1508.37`)
+}
+
+func TestCreateDistribution_RequiresARate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewDistributionHandler(computingDistributionRepo(), fixedSharePriceSettings)
+	r := gin.Default()
+	r.POST("/distributions", adminContext(h.CreateDistribution))
+	body := map[string]interface{}{"fiscal_year": 2025, "payout_method": "savings"}
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPost, "/distributions", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestApproveDistribution_AlreadyApproved(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepo := &mockDistributionRepo{
+		ApproveDistributionFunc: func(distributionID uint, approvedBy uint) (*models.Distribution, string, error) {
+			return nil, repository.ErrDistributionAlreadyApproved.Error(), repository.ErrDistributionAlreadyApproved
+		},
+	}
+	h := handlers.NewDistributionHandler(mockRepo, fixedSharePriceSettings)
+	r := gin.Default()
+	r.POST("/distributions/:distribution_id/approve", adminContext(h.ApproveDistribution))
+	req, _ := http.NewRequest(http.MethodPost, "/distributions/3/approve", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestGetPayoutList_CSV(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepo := &mockDistributionRepo{
+		GetDistributionByIDFunc: func(distributionID string) (*models.Distribution, string, error) {
+			line := models.DistributionLine{MemberID: 1, Dividend: 150137, Patronage: 700, Total: 150837}
+			line.Member.Name = "Ada"
+			distribution := models.Distribution{
+				Status:         models.DistributionStatusApproved,
+				PayoutMethod:   models.DistributionPayoutList,
+				Lines:          []models.DistributionLine{line},
+				TotalDividend:  150137,
+				TotalPatronage: 700,
+				Total:          150837,
+			}
+			distribution.ID = 3
+			return &distribution, "distribution fetched successfully", nil
+		},
+	}
+	h := handlers.NewDistributionHandler(mockRepo, fixedSharePriceSettings)
+	r := gin.Default()
+	r.GET("/distributions/:distribution_id/payout-list", adminContext(h.GetPayoutList))
+	req, _ := http.NewRequest(http.MethodGet, "/distributions/3/payout-list?format=csv", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "1,Ada,1501.37,7.00,1508.37,NGN")
+}
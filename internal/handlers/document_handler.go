@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"bytes"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+	"cooperative-system/internal/storage"
+	"cooperative-system/pkg/utils"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DocumentVerificationRequest records an admin's check of a document against the original
+type DocumentVerificationRequest struct {
+	Status string `json:"status" binding:"required"` // verified or rejected
+	Reason string `json:"reason"`                    // required when rejecting
+}
+
+type DocumentHandler struct {
+	repo       repository.DocumentRepository
+	memberRepo repository.MemberRepository
+	loanRepo   repository.LoanRepository
+	storage    storage.Storage
+	maxSize    func() int64
+}
+
+func NewDocumentHandler(documentRepo repository.DocumentRepository, memberRepo repository.MemberRepository, loanRepo repository.LoanRepository, store storage.Storage, maxSize func() int64) *DocumentHandler {
+	return &DocumentHandler{
+		repo:       documentRepo,
+		memberRepo: memberRepo,
+		loanRepo:   loanRepo,
+		storage:    store,
+		maxSize:    maxSize,
+	}
+}
+
+type DocumentService interface {
+	UploadMemberDocument(c *gin.Context)
+	UploadLoanDocument(c *gin.Context)
+	GetMemberDocuments(c *gin.Context)
+	DownloadDocument(c *gin.Context)
+	VerifyDocument(c *gin.Context)
+}
+
+// parseUpload reads the multipart form, refusing bodies much larger than the document limit before they
+// are spooled to disk
+func (h *DocumentHandler) parseUpload(c *gin.Context) bool {
+	maxSize := h.maxSize()
+	// Allow a megabyte over the limit for the other form fields and the multipart framing
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+	if _, err := c.MultipartForm(); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.RespondWithError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("file is larger than the %d MB limit", maxSize>>20), err)
+		} else {
+			utils.RespondWithError(c, http.StatusBadRequest, "upload must be multipart/form-data with a file", err)
+		}
+		return false
+	}
+	return true
+}
+
+// UploadMemberDocument stores a file for the member in the URL, optionally linked to one of their loans
+// with the loan_id form field
+func (h *DocumentHandler) UploadMemberDocument(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+
+	member, err := getMemberByIDAndAuthorize(c, h.memberRepo, &authUser, models.PermMembersManage)
+	if err != nil {
+		return
+	}
+	if !h.parseUpload(c) {
+		return
+	}
+
+	var loanID *uint
+	if value := c.PostForm("loan_id"); value != "" {
+		loan, ok := h.memberLoan(c, member, value)
+		if !ok {
+			return
+		}
+		loanID = &loan.ID
+	}
+
+	h.upload(c, authUser, member, loanID)
+}
+
+// UploadLoanDocument stores a file supporting the loan in the URL, e.g. a payslip, for the loan's member
+func (h *DocumentHandler) UploadLoanDocument(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+
+	loan, msg, err := h.loanRepo.GetLoanByID(c.Param("loan_id"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, msg, err)
+		return
+	}
+
+	member, msg, err := h.memberRepo.FetchByID(strconv.FormatUint(uint64(loan.MemberID), 10))
+	if err != nil || member == nil {
+		utils.RespondWithError(c, http.StatusNotFound, msg, err)
+		return
+	}
+	if !authUser.Can(models.PermLoansApprove) && member.UserID != authUser.ID {
+		utils.RespondWithError(c, http.StatusNotFound, "loan not found", errors.New("loan belongs to another member"))
+		return
+	}
+	if !h.parseUpload(c) {
+		return
+	}
+
+	h.upload(c, authUser, member, &loan.ID)
+}
+
+// memberLoan fetches a loan by ID and checks it belongs to the member, responding with 404 when it does not
+func (h *DocumentHandler) memberLoan(c *gin.Context, member *models.Member, loanID string) (*models.Loan, bool) {
+	loan, msg, err := h.loanRepo.GetLoanByID(loanID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, msg, err)
+		return nil, false
+	}
+	if loan.MemberID != member.ID {
+		utils.RespondWithError(c, http.StatusNotFound, "loan not found", errors.New("loan belongs to another member"))
+		return nil, false
+	}
+	return loan, true
+}
+
+// upload reads the multipart file, checks its size and detected content type, stores it and records it
+func (h *DocumentHandler) upload(c *gin.Context, authUser models.User, member *models.Member, loanID *uint) {
+	category := strings.ToLower(strings.TrimSpace(c.PostForm("category")))
+	if !models.AllowedDocumentCategories[category] {
+		utils.RespondWithError(c, http.StatusBadRequest, "category must be id_card, passport_photo, payslip or signed_form", nil)
+		return
+	}
+
+	maxSize := h.maxSize()
+	header, err := c.FormFile("file")
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "a file is required", err)
+		return
+	}
+	if header.Size > maxSize {
+		utils.RespondWithError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("file is larger than the %d MB limit", maxSize>>20), nil)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "failed to read file", err)
+		return
+	}
+	defer file.Close()
+
+	// Read one byte past the limit so a file that lied about its size is still caught
+	content, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "failed to read file", err)
+		return
+	}
+	if int64(len(content)) > maxSize {
+		utils.RespondWithError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("file is larger than the %d MB limit", maxSize>>20), nil)
+		return
+	}
+	if len(content) == 0 {
+		utils.RespondWithError(c, http.StatusBadRequest, "file is empty", nil)
+		return
+	}
+
+	contentType := http.DetectContentType(content)
+	extension, ok := models.AllowedDocumentContentTypes[contentType]
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnsupportedMediaType, "only JPEG, PNG and PDF files can be uploaded, got "+contentType, nil)
+		return
+	}
+
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "failed to name document", err)
+		return
+	}
+	key := fmt.Sprintf("members/%d/%s%s", member.ID, hex.EncodeToString(name), extension)
+	if err := h.storage.Put(key, bytes.NewReader(content)); err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "failed to store document", err)
+		return
+	}
+
+	checksum := sha256.Sum256(content)
+	document := models.Document{
+		MemberID:    member.ID,
+		LoanID:      loanID,
+		Category:    category,
+		FileName:    filepath.Base(header.Filename),
+		ContentType: contentType,
+		Size:        int64(len(content)),
+		Checksum:    hex.EncodeToString(checksum[:]),
+		StorageKey:  key,
+		UploadedBy:  authUser.ID,
+		Status:      models.DocumentStatusPending,
+	}
+	created, msg, err := h.repo.CreateDocument(&document)
+	if err != nil {
+		_ = h.storage.Delete(key)
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, msg, "data", gin.H{
+		"document": models.NewDocumentResponse(created),
+	})
+}
+
+// GetMemberDocuments lists a member's documents, only those for one loan with ?loan_id=
+func (h *DocumentHandler) GetMemberDocuments(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+
+	member, err := getMemberByIDAndAuthorize(c, h.memberRepo, &authUser, models.PermMembersRead)
+	if err != nil {
+		return
+	}
+
+	var loanID *uint
+	if value := c.Query("loan_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "invalid loan ID", err)
+			return
+		}
+		loan := uint(id)
+		loanID = &loan
+	}
+
+	documents, msg, err := h.repo.GetDocumentsByMemberID(member.ID, loanID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"documents": models.NewDocumentResponses(documents),
+	})
+}
+
+// DownloadDocument streams a document back to its member or an admin
+func (h *DocumentHandler) DownloadDocument(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+
+	document, ok := h.authorizedDocument(c, authUser, models.PermMembersRead)
+	if !ok {
+		return
+	}
+
+	content, err := h.storage.Get(document.StorageKey)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, storage.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		utils.RespondWithError(c, status, "failed to read document", err)
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(http.StatusOK, document.Size, document.ContentType, content, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", document.FileName),
+	})
+}
+
+// authorizedDocument fetches the document in the URL when it belongs to the user's member or the user's role
+// grants the permission, responding with 404 otherwise so other members' documents cannot be probed
+func (h *DocumentHandler) authorizedDocument(c *gin.Context, authUser models.User, permission string) (*models.Document, bool) {
+	document, msg, err := h.repo.GetDocumentByID(c.Param("document_id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			status = http.StatusNotFound
+		}
+		utils.RespondWithError(c, status, msg, err)
+		return nil, false
+	}
+	if authUser.Can(permission) {
+		return document, true
+	}
+
+	member, _, err := h.memberRepo.FetchMemberByUserID(authUser.ID)
+	if err != nil || member.ID != document.MemberID {
+		utils.RespondWithError(c, http.StatusNotFound, "document not found", err)
+		return nil, false
+	}
+	return document, true
+}
+
+// VerifyDocument lets an admin mark a document verified, or rejected with a reason
+func (h *DocumentHandler) VerifyDocument(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermDocumentsVerify) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can verify documents", nil)
+		return
+	}
+
+	var reqBody DocumentVerificationRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+	status := strings.ToLower(strings.TrimSpace(reqBody.Status))
+	reason := strings.TrimSpace(reqBody.Reason)
+	if status != models.DocumentStatusVerified && status != models.DocumentStatusRejected {
+		utils.RespondWithError(c, http.StatusBadRequest, "status must be verified or rejected", nil)
+		return
+	}
+	if status == models.DocumentStatusRejected && reason == "" {
+		utils.RespondWithError(c, http.StatusBadRequest, "a reason for the rejection is required", nil)
+		return
+	}
+
+	document, ok := h.authorizedDocument(c, authUser, models.PermDocumentsVerify)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	document.Status = status
+	document.VerifiedBy = &authUser.ID
+	document.VerifiedAt = &now
+	document.RejectionReason = ""
+	if status == models.DocumentStatusRejected {
+		document.RejectionReason = reason
+	}
+
+	updated, msg, err := h.repo.UpdateDocument(document)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "document "+status, "data", gin.H{
+		"document": models.NewDocumentResponse(updated),
+	})
+}
@@ -0,0 +1,113 @@
+// Unit tests for DocumentHandler endpoints
+package handlers_test
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cooperative-system/internal/handlers"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+	"cooperative-system/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockDocumentRepo struct {
+	repository.DocumentRepository
+	CreateDocumentFunc func(document *models.Document) (*models.Document, string, error)
+}
+
+func (m *mockDocumentRepo) CreateDocument(document *models.Document) (*models.Document, string, error) {
+	return m.CreateDocumentFunc(document)
+}
+
+func oneMegabyte() int64 {
+	return 1 << 20
+}
+
+func uploadDocument(h *handlers.DocumentHandler, category string, content []byte) *httptest.ResponseRecorder {
+	r := gin.Default()
+	r.POST("/members/:id/documents", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "member"
+		c.Set("user", user)
+		h.UploadMemberDocument(c)
+	})
+
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	_ = form.WriteField("category", category)
+	part, _ := form.CreateFormFile("file", "national-id.pdf")
+	_, _ = part.Write(content)
+	_ = form.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, "/members/1/documents", body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestUploadMemberDocument_StoresPDF(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := storage.NewLocalStorage(t.TempDir())
+	var saved *models.Document
+	mockRepo := &mockDocumentRepo{
+		CreateDocumentFunc: func(document *models.Document) (*models.Document, string, error) {
+			saved = document
+			return document, "document uploaded successfully", nil
+		},
+	}
+	h := handlers.NewDocumentHandler(mockRepo, ownMemberRepo(), &mockLoanRepo{}, store, oneMegabyte)
+	content := []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\n")
+	w := uploadDocument(h, "id_card", content)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "application/pdf", saved.ContentType)
+	assert.Equal(t, models.DocumentStatusPending, saved.Status)
+	assert.Equal(t, "national-id.pdf", saved.FileName)
+	assert.Contains(t, w.Body.String(), `"category":"id_card"`)
+	assert.NotContains(t, w.Body.String(), saved.StorageKey)
+
+	stored, err := store.Get(saved.StorageKey)
+	assert.NoError(t, err)
+	storedContent, _ := io.ReadAll(stored)
+	stored.Close()
+	assert.Equal(t, content, storedContent)
+}
+
+func TestUploadMemberDocument_RejectsUnsupportedType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewDocumentHandler(&mockDocumentRepo{}, ownMemberRepo(), &mockLoanRepo{}, storage.NewLocalStorage(t.TempDir()), oneMegabyte)
+	w := uploadDocument(h, "payslip", []byte("just some text pretending to be a pdf"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	assert.Contains(t, w.Body.String(), "only JPEG, PNG and PDF files can be uploaded")
+}
+
+func TestUploadMemberDocument_TooLarge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewDocumentHandler(&mockDocumentRepo{}, ownMemberRepo(), &mockLoanRepo{}, storage.NewLocalStorage(t.TempDir()), oneMegabyte)
+	content := append([]byte("%PDF-1.4\n"), make([]byte, 1<<20)...)
+	w := uploadDocument(h, "payslip", content)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "file is larger than the 1 MB limit")
+}
+
+func TestVerifyDocument_RejectionRequiresReason(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewDocumentHandler(&mockDocumentRepo{}, ownMemberRepo(), &mockLoanRepo{}, storage.NewLocalStorage(t.TempDir()), oneMegabyte)
+	r := gin.Default()
+	r.PATCH("/admins/documents/:document_id/verification", adminContext(h.VerifyDocument))
+	req, _ := http.NewRequest(http.MethodPatch, "/admins/documents/3/verification", bytes.NewBuffer([]byte(`{"status":"rejected"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "a reason for the rejection is required")
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+	"cooperative-system/pkg/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExchangeRateRequest struct {
+	Currency      string  `json:"currency" binding:"required"`
+	Rate          float64 `json:"rate" binding:"required"`
+	EffectiveDate string  `json:"effective_date"` // YYYY-MM-DD, defaults to today
+}
+
+type ExchangeRateHandler struct {
+	repo       repository.ExchangeRateRepository
+	reportRepo repository.ReportRepository
+}
+
+func NewExchangeRateHandler(rateRepo repository.ExchangeRateRepository, reportRepo repository.ReportRepository) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		repo:       rateRepo,
+		reportRepo: reportRepo,
+	}
+}
+
+type ExchangeRateService interface {
+	SetExchangeRate(c *gin.Context)
+	GetExchangeRates(c *gin.Context)
+	GetConsolidatedReport(c *gin.Context)
+}
+
+// parseDateParam reads an optional YYYY-MM-DD value, defaulting to today
+func parseDateParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Now().UTC().Truncate(24 * time.Hour), nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+func (h *ExchangeRateHandler) SetExchangeRate(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermExchangeRatesManage) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can set exchange rates", nil)
+		return
+	}
+
+	var reqBody ExchangeRateRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	currency, err := models.NormalizeCurrency(reqBody.Currency)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if currency == models.BaseCurrency {
+		utils.RespondWithError(c, http.StatusBadRequest, "the base currency always has a rate of 1", nil)
+		return
+	}
+
+	if reqBody.Rate <= 0 {
+		utils.RespondWithError(c, http.StatusBadRequest, "rate must be greater than zero", nil)
+		return
+	}
+
+	effectiveDate, err := parseDateParam(reqBody.EffectiveDate)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "effective date must be in YYYY-MM-DD format", err)
+		return
+	}
+
+	rate := models.ExchangeRate{
+		Currency:      currency,
+		Rate:          reqBody.Rate,
+		EffectiveDate: effectiveDate,
+		SetBy:         authUser.ID,
+	}
+
+	savedRate, msg, err := h.repo.SetExchangeRate(&rate)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "exchange rate set successfully", "data", gin.H{
+		"exchange_rate": models.NewExchangeRateResponse(savedRate),
+	})
+}
+
+func (h *ExchangeRateHandler) GetExchangeRates(c *gin.Context) {
+	var currency string
+	if c.Query("currency") != "" {
+		normalized, err := models.NormalizeCurrency(c.Query("currency"))
+		if err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		currency = normalized
+	}
+
+	rates, msg, err := h.repo.GetExchangeRates(currency)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	rateResponses := make([]models.ExchangeRateResponse, len(rates))
+	for i, rate := range rates {
+		currentRate := rate
+		rateResponses[i] = models.NewExchangeRateResponse(&currentRate)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "exchange rates fetched successfully", "data", gin.H{
+		"exchange_rates": rateResponses,
+	})
+}
+
+// GetConsolidatedReport totals savings and running loans in every currency and converts them to the
+// base currency with the rates in force at the end of the requested date, over one branch with ?branch_id=
+func (h *ExchangeRateHandler) GetConsolidatedReport(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermReportsRead) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can view the consolidated report", nil)
+		return
+	}
+	branchID, ok := branchFilter(c, &authUser)
+	if !ok {
+		return
+	}
+
+	asOf, err := parseDateParam(c.Query("date"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "date must be in YYYY-MM-DD format", err)
+		return
+	}
+	cutOff := asOf.AddDate(0, 0, 1)
+
+	rates, msg, err := h.repo.GetExchangeRatesOn(asOf)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	savingsTotals, msg, err := h.reportRepo.GetSavingsTotalsByCurrency(cutOff, branchID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	loanTotals, msg, err := h.reportRepo.GetLoanTotalsByCurrency(cutOff, branchID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	report, err := models.ConsolidateTotals(asOf, savingsTotals, loanTotals, rates)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "failed to consolidate totals", err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "consolidated report generated successfully", "data", gin.H{
+		"report": report,
+	})
+}
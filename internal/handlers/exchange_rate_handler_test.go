@@ -0,0 +1,121 @@
+// Unit tests for ExchangeRateHandler endpoints
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cooperative-system/internal/handlers"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockExchangeRateRepo struct {
+	repository.ExchangeRateRepository
+	SetExchangeRateFunc    func(rate *models.ExchangeRate) (*models.ExchangeRate, string, error)
+	GetExchangeRatesOnFunc func(date time.Time) (map[string]models.ExchangeRate, string, error)
+}
+
+func (m *mockExchangeRateRepo) SetExchangeRate(rate *models.ExchangeRate) (*models.ExchangeRate, string, error) {
+	return m.SetExchangeRateFunc(rate)
+}
+func (m *mockExchangeRateRepo) GetExchangeRatesOn(date time.Time) (map[string]models.ExchangeRate, string, error) {
+	return m.GetExchangeRatesOnFunc(date)
+}
+
+type mockReportRepo struct {
+	repository.ReportRepository
+	GetSavingsTotalsByCurrencyFunc func(before time.Time, branchID *uint) (map[string]models.Money, string, error)
+	GetLoanTotalsByCurrencyFunc    func(before time.Time, branchID *uint) (map[string]models.Money, string, error)
+}
+
+func (m *mockReportRepo) GetSavingsTotalsByCurrency(before time.Time, branchID *uint) (map[string]models.Money, string, error) {
+	return m.GetSavingsTotalsByCurrencyFunc(before, branchID)
+}
+func (m *mockReportRepo) GetLoanTotalsByCurrency(before time.Time, branchID *uint) (map[string]models.Money, string, error) {
+	return m.GetLoanTotalsByCurrencyFunc(before, branchID)
+}
+
+func adminContext(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "admin"
+		c.Set("user", user)
+		handler(c)
+	}
+}
+
+func TestSetExchangeRate_RejectsBaseCurrency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewExchangeRateHandler(&mockExchangeRateRepo{}, &mockReportRepo{})
+	r := gin.Default()
+	r.POST("/exchange-rates", adminContext(h.SetExchangeRate))
+	body := map[string]interface{}{"currency": "ngn", "rate": 1.5}
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPost, "/exchange-rates", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "base currency")
+}
+
+func TestSetExchangeRate_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepo := &mockExchangeRateRepo{
+		SetExchangeRateFunc: func(rate *models.ExchangeRate) (*models.ExchangeRate, string, error) {
+			rate.ID = 1
+			return rate, "exchange rate set successfully", nil
+		},
+	}
+	h := handlers.NewExchangeRateHandler(mockRepo, &mockReportRepo{})
+	r := gin.Default()
+	r.POST("/exchange-rates", adminContext(h.SetExchangeRate))
+	body := map[string]interface{}{"currency": "usd", "rate": 1520.5, "effective_date": "2025-03-01"}
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPost, "/exchange-rates", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"currency":"USD"`)
+	assert.Contains(t, w.Body.String(), `"effective_date":"2025-03-01T00:00:00Z"`)
+}
+
+func TestGetConsolidatedReport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRates := &mockExchangeRateRepo{
+		GetExchangeRatesOnFunc: func(date time.Time) (map[string]models.ExchangeRate, string, error) {
+			return map[string]models.ExchangeRate{
+				"USD": {Currency: "USD", Rate: 1500, EffectiveDate: date},
+			}, "exchange rates fetched successfully", nil
+		},
+	}
+	mockReports := &mockReportRepo{
+		GetSavingsTotalsByCurrencyFunc: func(before time.Time, branchID *uint) (map[string]models.Money, string, error) {
+			return map[string]models.Money{"NGN": 100000, "USD": 1050, "GBP": 500}, "savings totals fetched successfully", nil
+		},
+		GetLoanTotalsByCurrencyFunc: func(before time.Time, branchID *uint) (map[string]models.Money, string, error) {
+			return map[string]models.Money{"NGN": 20000}, "loan totals fetched successfully", nil
+		},
+	}
+	h := handlers.NewExchangeRateHandler(mockRates, mockReports)
+	r := gin.Default()
+	r.GET("/reports/consolidated", adminContext(h.GetConsolidatedReport))
+	req, _ := http.NewRequest(http.MethodGet, "/reports/consolidated?date=2025-03-31", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	// 1000.00 NGN + 10.50 USD × 1500, GBP has no rate and is left out
+	assert.Contains(t, w.Body.String(), `"total_savings":16750.00`)
+	assert.Contains(t, w.Body.String(), `"total_loans":200.00`)
+	assert.Contains(t, w.Body.String(), `"missing_rates":["GBP"]`)
+}
@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+	"cooperative-system/pkg/utils"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type FeeDefinitionRequest struct {
+	Code            string       `json:"code" binding:"required"`
+	Name            string       `json:"name" binding:"required"`
+	Trigger         string       `json:"trigger" binding:"required"`
+	CalculationType string       `json:"calculation_type" binding:"required"`
+	Amount          models.Money `json:"amount"`
+	Rate            float64      `json:"rate"`
+	MinimumAmount   models.Money `json:"minimum_amount"`
+	MaximumAmount   models.Money `json:"maximum_amount"`
+	Currency        string       `json:"currency"`
+	Active          *bool        `json:"active"`
+}
+
+// FeeDefinitionUpdateRequest changes how much a fee charges or switches it off. The code, trigger,
+// calculation type and currency are fixed once the fee exists so past charges stay comparable.
+type FeeDefinitionUpdateRequest struct {
+	Name          *string       `json:"name"`
+	Amount        *models.Money `json:"amount"`
+	Rate          *float64      `json:"rate"`
+	MinimumAmount *models.Money `json:"minimum_amount"`
+	MaximumAmount *models.Money `json:"maximum_amount"`
+	Active        *bool         `json:"active"`
+}
+
+type FeeWaiverRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type FeeHandler struct {
+	repo       repository.FeeRepository
+	memberRepo repository.MemberRepository
+}
+
+func NewFeeHandler(feeRepo repository.FeeRepository, memberRepo repository.MemberRepository) *FeeHandler {
+	return &FeeHandler{
+		repo:       feeRepo,
+		memberRepo: memberRepo,
+	}
+}
+
+type FeeService interface {
+	CreateFeeDefinition(c *gin.Context)
+	GetFeeDefinitions(c *gin.Context)
+	UpdateFeeDefinition(c *gin.Context)
+	GetMemberFeeCharges(c *gin.Context)
+	PayFeeCharge(c *gin.Context)
+	WaiveFeeCharge(c *gin.Context)
+}
+
+func feeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrFeeAlreadyWaived), errors.Is(err, repository.ErrFeeNotOutstanding):
+		return http.StatusConflict
+	case errors.Is(err, repository.ErrInsufficientBalance):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+func feeChargeID(c *gin.Context) (uint, bool) {
+	chargeID, err := strconv.ParseUint(c.Param("charge_id"), 10, 64)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid fee charge ID", err)
+		return 0, false
+	}
+	return uint(chargeID), true
+}
+
+func (h *FeeHandler) CreateFeeDefinition(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermFeesManage) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can set up fees", nil)
+		return
+	}
+
+	var reqBody FeeDefinitionRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	currency, err := models.NormalizeCurrency(reqBody.Currency)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	fee := models.FeeDefinition{
+		Code:            strings.ToLower(strings.TrimSpace(reqBody.Code)),
+		Name:            strings.TrimSpace(reqBody.Name),
+		Trigger:         reqBody.Trigger,
+		CalculationType: reqBody.CalculationType,
+		Amount:          reqBody.Amount,
+		Rate:            reqBody.Rate,
+		MinimumAmount:   reqBody.MinimumAmount,
+		MaximumAmount:   reqBody.MaximumAmount,
+		Currency:        currency,
+		Active:          reqBody.Active == nil || *reqBody.Active,
+		CreatedBy:       authUser.ID,
+	}
+	if err := fee.Validate(); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	created, msg, err := h.repo.CreateFeeDefinition(&fee)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, msg, "data", gin.H{
+		"fee": models.NewFeeDefinitionResponse(created),
+	})
+}
+
+func (h *FeeHandler) GetFeeDefinitions(c *gin.Context) {
+	fees, msg, err := h.repo.GetFeeDefinitions()
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	responses := make([]models.FeeDefinitionResponse, len(fees))
+	for i := range fees {
+		responses[i] = models.NewFeeDefinitionResponse(&fees[i])
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"fees": responses,
+	})
+}
+
+func (h *FeeHandler) UpdateFeeDefinition(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermFeesManage) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can change fees", nil)
+		return
+	}
+
+	var reqBody FeeDefinitionUpdateRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	fee, msg, err := h.repo.GetFeeDefinitionByID(c.Param("fee_id"))
+	if err != nil {
+		utils.RespondWithError(c, feeErrorStatus(err), msg, err)
+		return
+	}
+
+	if reqBody.Name != nil {
+		fee.Name = strings.TrimSpace(*reqBody.Name)
+	}
+	if reqBody.Amount != nil {
+		fee.Amount = *reqBody.Amount
+	}
+	if reqBody.Rate != nil {
+		fee.Rate = *reqBody.Rate
+	}
+	if reqBody.MinimumAmount != nil {
+		fee.MinimumAmount = *reqBody.MinimumAmount
+	}
+	if reqBody.MaximumAmount != nil {
+		fee.MaximumAmount = *reqBody.MaximumAmount
+	}
+	if reqBody.Active != nil {
+		fee.Active = *reqBody.Active
+	}
+	if fee.Name == "" {
+		utils.RespondWithError(c, http.StatusBadRequest, "name cannot be empty", nil)
+		return
+	}
+	if err := fee.Validate(); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	fee.UpdatedBy = authUser.ID
+
+	updated, msg, err := h.repo.UpdateFeeDefinition(fee)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"fee": models.NewFeeDefinitionResponse(updated),
+	})
+}
+
+// GetMemberFeeCharges lists every fee charged to a member, including outstanding and waived ones
+func (h *FeeHandler) GetMemberFeeCharges(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+
+	member, err := getMemberByIDAndAuthorize(c, h.memberRepo, &authUser, models.PermMembersRead)
+	if err != nil {
+		return
+	}
+
+	charges, msg, err := h.repo.GetFeeChargesByMemberID(member.ID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	outstanding := map[string]models.Money{}
+	for _, charge := range charges {
+		if charge.Status == models.FeeChargeStatusOutstanding {
+			outstanding[charge.Currency] += charge.Amount
+		}
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"fee_charges": models.NewFeeChargeResponses(charges),
+		"outstanding": outstanding,
+	})
+}
+
+// PayFeeCharge settles an outstanding fee from savings, members can only pay their own fees
+func (h *FeeHandler) PayFeeCharge(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+
+	chargeID, ok := feeChargeID(c)
+	if !ok {
+		return
+	}
+
+	charge, msg, err := h.repo.GetFeeChargeByID(c.Param("charge_id"))
+	if err != nil {
+		utils.RespondWithError(c, feeErrorStatus(err), msg, err)
+		return
+	}
+
+	if !authUser.Can(models.PermFeesManage) {
+		member, msg, err := h.memberRepo.FetchMemberByUserID(authUser.ID)
+		if err != nil {
+			utils.RespondWithError(c, http.StatusNotFound, msg, err)
+			return
+		}
+		if charge.MemberID != member.ID {
+			utils.RespondWithError(c, http.StatusNotFound, "fee charge not found", errors.New("fee charge belongs to another member"))
+			return
+		}
+	}
+
+	paid, msg, err := h.repo.PayFeeCharge(chargeID, authUser.ID)
+	if err != nil {
+		utils.RespondWithError(c, feeErrorStatus(err), msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"fee_charge": models.NewFeeChargeResponse(paid),
+	})
+}
+
+// WaiveFeeCharge waives a fee with a reason, giving back the money if it was already taken from savings
+func (h *FeeHandler) WaiveFeeCharge(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermFeesManage) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can waive fees", nil)
+		return
+	}
+
+	chargeID, ok := feeChargeID(c)
+	if !ok {
+		return
+	}
+
+	var reqBody FeeWaiverRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil || strings.TrimSpace(reqBody.Reason) == "" {
+		utils.RespondWithError(c, http.StatusBadRequest, "a reason for the waiver is required", err)
+		return
+	}
+
+	charge, msg, err := h.repo.WaiveFeeCharge(chargeID, strings.TrimSpace(reqBody.Reason), authUser.ID)
+	if err != nil {
+		utils.RespondWithError(c, feeErrorStatus(err), msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"fee_charge": models.NewFeeChargeResponse(charge),
+	})
+}
@@ -0,0 +1,246 @@
+// Unit tests for FeeHandler endpoints and the fees charged by other handlers
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cooperative-system/internal/handlers"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockFeeRepo struct {
+	repository.FeeRepository
+	CreateFeeDefinitionFunc func(fee *models.FeeDefinition) (*models.FeeDefinition, string, error)
+	ApplyFeesFunc           func(trigger string, feeContext models.FeeContext) ([]models.FeeCharge, string, error)
+	GetFeeChargeByIDFunc    func(chargeID string) (*models.FeeCharge, string, error)
+	PayFeeChargeFunc        func(chargeID uint, paidBy uint) (*models.FeeCharge, string, error)
+	WaiveFeeChargeFunc      func(chargeID uint, reason string, waivedBy uint) (*models.FeeCharge, string, error)
+}
+
+func (m *mockFeeRepo) CreateFeeDefinition(fee *models.FeeDefinition) (*models.FeeDefinition, string, error) {
+	return m.CreateFeeDefinitionFunc(fee)
+}
+
+// ApplyFees and ApplyFeesTx charge nothing unless a test sets ApplyFeesFunc
+func (m *mockFeeRepo) ApplyFees(trigger string, feeContext models.FeeContext) ([]models.FeeCharge, string, error) {
+	if m.ApplyFeesFunc == nil {
+		return nil, "fees applied successfully", nil
+	}
+	return m.ApplyFeesFunc(trigger, feeContext)
+}
+func (m *mockFeeRepo) ApplyFeesTx(tx *gorm.DB, trigger string, feeContext models.FeeContext) ([]models.FeeCharge, string, error) {
+	return m.ApplyFees(trigger, feeContext)
+}
+func (m *mockFeeRepo) GetFeeChargeByID(chargeID string) (*models.FeeCharge, string, error) {
+	return m.GetFeeChargeByIDFunc(chargeID)
+}
+func (m *mockFeeRepo) PayFeeCharge(chargeID uint, paidBy uint) (*models.FeeCharge, string, error) {
+	return m.PayFeeChargeFunc(chargeID, paidBy)
+}
+func (m *mockFeeRepo) WaiveFeeCharge(chargeID uint, reason string, waivedBy uint) (*models.FeeCharge, string, error) {
+	return m.WaiveFeeChargeFunc(chargeID, reason, waivedBy)
+}
+
+func postFeeDefinition(h *handlers.FeeHandler, body map[string]interface{}) *httptest.ResponseRecorder {
+	r := gin.Default()
+	r.POST("/admins/fees", adminContext(h.CreateFeeDefinition))
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPost, "/admins/fees", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateFeeDefinition_FlatWithoutAmount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewFeeHandler(&mockFeeRepo{}, ownMemberRepo())
+	w := postFeeDefinition(h, map[string]interface{}{
+		"code": "entry", "name": "Membership entry fee", "trigger": "member_created", "calculation_type": "flat",
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "a flat fee needs an amount greater than zero")
+}
+
+func TestCreateFeeDefinition_Percentage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepo := &mockFeeRepo{
+		CreateFeeDefinitionFunc: func(fee *models.FeeDefinition) (*models.FeeDefinition, string, error) {
+			fee.ID = 2
+			return fee, "fee created successfully", nil
+		},
+	}
+	h := handlers.NewFeeHandler(mockRepo, ownMemberRepo())
+	w := postFeeDefinition(h, map[string]interface{}{
+		"code": "LOAN_PROCESSING", "name": "Loan processing fee", "trigger": "loan_approved",
+		"calculation_type": "percentage", "rate": 0.01, "minimum_amount": "500", "maximum_amount": 10000,
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `"code":"loan_processing"`)
+	assert.Contains(t, body, `"rate":0.01,"minimum_amount":500.00,"maximum_amount":10000.00,"currency":"NGN","active":true`)
+}
+
+func TestFeeDefinition_Calculate(t *testing.T) {
+	fee := models.FeeDefinition{CalculationType: models.FeeCalculationPercentage, Rate: 0.01, MinimumAmount: 50000, MaximumAmount: 1000000}
+	for _, tc := range []struct {
+		base     models.Money
+		expected models.Money
+	}{
+		{2000000, 50000},     // 1% of 20,000.00 is below the 500.00 minimum
+		{25000000, 250000},   // 1% of 250,000.00
+		{500000000, 1000000}, // capped at 10,000.00
+	} {
+		amount, err := fee.Calculate(tc.base)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, amount)
+	}
+}
+
+func TestPayFeeCharge_OtherMembersCharge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepo := &mockFeeRepo{
+		GetFeeChargeByIDFunc: func(chargeID string) (*models.FeeCharge, string, error) {
+			charge := models.FeeCharge{MemberID: 2, Status: models.FeeChargeStatusOutstanding}
+			charge.ID = 5
+			return &charge, "fee charge fetched successfully", nil
+		},
+	}
+	h := handlers.NewFeeHandler(mockRepo, transferMemberRepo())
+	r := gin.Default()
+	r.POST("/fees/charges/:charge_id/pay", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "member"
+		c.Set("user", user)
+		h.PayFeeCharge(c)
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/fees/charges/5/pay", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestWaiveFeeCharge_RequiresReason(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewFeeHandler(&mockFeeRepo{}, ownMemberRepo())
+	r := gin.Default()
+	r.POST("/admins/fees/charges/:charge_id/waive", adminContext(h.WaiveFeeCharge))
+	jsonBody, _ := json.Marshal(map[string]interface{}{"reason": "  "})
+	req, _ := http.NewRequest(http.MethodPost, "/admins/fees/charges/5/waive", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestWaiveFeeCharge_AlreadyWaived(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepo := &mockFeeRepo{
+		WaiveFeeChargeFunc: func(chargeID uint, reason string, waivedBy uint) (*models.FeeCharge, string, error) {
+			return nil, repository.ErrFeeAlreadyWaived.Error(), repository.ErrFeeAlreadyWaived
+		},
+	}
+	h := handlers.NewFeeHandler(mockRepo, ownMemberRepo())
+	r := gin.Default()
+	r.POST("/admins/fees/charges/:charge_id/waive", adminContext(h.WaiveFeeCharge))
+	jsonBody, _ := json.Marshal(map[string]interface{}{"reason": "board resolution"})
+	req, _ := http.NewRequest(http.MethodPost, "/admins/fees/charges/5/waive", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestGetSavingsStatement_ChargesStatementFee(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var trigger string
+	var feeContext models.FeeContext
+	mockRepo := &mockFeeRepo{
+		ApplyFeesFunc: func(feeTrigger string, context models.FeeContext) ([]models.FeeCharge, string, error) {
+			trigger, feeContext = feeTrigger, context
+			return nil, "fees applied successfully", nil
+		},
+	}
+	h := handlers.NewStatementHandler(statementSavingsRepo(), &mockLoanRepo{}, ownMemberRepo(), mockRepo)
+	r := gin.Default()
+	r.GET("/members/:id/statements/savings", memberContext(h.GetSavingsStatement))
+	req, _ := http.NewRequest(http.MethodGet, "/members/1/statements/savings?from=2025-02-01&to=2025-02-28", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, models.FeeTriggerStatementGenerated, trigger)
+	assert.Equal(t, models.FeeSourceSavings, feeContext.SourceType)
+	assert.Equal(t, uint(3), feeContext.SourceID)
+	assert.Equal(t, "2025-02-01/2025-02-28", feeContext.Period)
+}
+
+func TestGetSavingsStatement_RepeatedDownloadChargesOnce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// like the charges' unique index, a source is charged once per period
+	charged := make(map[string]bool)
+	charges := 0
+	mockRepo := &mockFeeRepo{
+		ApplyFeesFunc: func(feeTrigger string, context models.FeeContext) ([]models.FeeCharge, string, error) {
+			key := fmt.Sprintf("%s/%d/%s", context.SourceType, context.SourceID, context.Period)
+			if context.Period == "" || !charged[key] {
+				charged[key] = true
+				charges++
+			}
+			return nil, "fees applied successfully", nil
+		},
+	}
+	h := handlers.NewStatementHandler(statementSavingsRepo(), &mockLoanRepo{}, ownMemberRepo(), mockRepo)
+	r := gin.Default()
+	r.GET("/members/:id/statements/savings", memberContext(h.GetSavingsStatement))
+
+	for _, tc := range []struct {
+		query   string
+		charges int
+	}{
+		{"from=2025-02-01&to=2025-02-28", 1},
+		{"from=2025-02-01&to=2025-02-28", 1},            // retried
+		{"from=2025-02-01&to=2025-02-28&format=pdf", 1}, // downloaded again as a PDF
+		{"from=2025-03-01&to=2025-03-31", 2},            // another period
+	} {
+		req, _ := http.NewRequest(http.MethodGet, "/members/1/statements/savings?"+tc.query, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, tc.charges, charges, tc.query)
+	}
+}
+
+func TestGetSavingsStatement_StaffDoNotChargeStatementFee(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	charged := false
+	mockRepo := &mockFeeRepo{
+		ApplyFeesFunc: func(feeTrigger string, context models.FeeContext) ([]models.FeeCharge, string, error) {
+			charged = true
+			return nil, "fees applied successfully", nil
+		},
+	}
+	h := handlers.NewStatementHandler(statementSavingsRepo(), &mockLoanRepo{}, ownMemberRepo(), mockRepo)
+	r := gin.Default()
+	r.GET("/members/:id/statements/savings", func(c *gin.Context) {
+		user := models.User{Role: models.RoleAuditor}
+		user.ID = 9
+		c.Set("user", user)
+		h.GetSavingsStatement(c)
+	})
+	req, _ := http.NewRequest(http.MethodGet, "/members/1/statements/savings?from=2025-02-01&to=2025-02-28", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, charged)
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"cooperative-system/internal/jobs"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+	"cooperative-system/pkg/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type FixedDepositRequest struct {
+	Principal           models.Money `json:"principal" binding:"required"`
+	Currency            string       `json:"currency"` // ISO 4217 code, defaults to the base currency
+	TermMonths          uint         `json:"term_months" binding:"required"`
+	MaturityInstruction string       `json:"maturity_instruction" binding:"required"`
+}
+
+type FixedDepositHandler struct {
+	repo       repository.FixedDepositRepository
+	memberRepo repository.MemberRepository
+}
+
+func NewFixedDepositHandler(depositRepo repository.FixedDepositRepository, memberRepo repository.MemberRepository) *FixedDepositHandler {
+	return &FixedDepositHandler{
+		repo:       depositRepo,
+		memberRepo: memberRepo,
+	}
+}
+
+type FixedDepositService interface {
+	CreateFixedDeposit(c *gin.Context)
+	GetFixedDeposits(c *gin.Context)
+	GetFixedDepositByID(c *gin.Context)
+	BreakFixedDeposit(c *gin.Context)
+	ProcessMaturedDeposits(c *gin.Context)
+}
+
+func (f *FixedDepositHandler) CreateFixedDeposit(c *gin.Context) {
+	var reqBody FixedDepositRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	if reqBody.Principal <= 0 {
+		utils.RespondWithError(c, http.StatusBadRequest, "principal must be greater than zero", nil)
+		return
+	}
+
+	if _, ok := models.AllowedMaturityInstructions[reqBody.MaturityInstruction]; !ok {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid maturity instruction", nil)
+		return
+	}
+
+	currency, err := models.NormalizeCurrency(reqBody.Currency)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	interestRate, err := models.GetFixedDepositRate(reqBody.TermMonths)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "unsupported fixed deposit term", err)
+		return
+	}
+
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+
+	member, msg, err := f.memberRepo.FetchMemberByUserID(authUser.ID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, msg, err)
+		return
+	}
+	if !requireActiveMember(c, member) {
+		return
+	}
+
+	startDate := time.Now()
+	deposit := models.FixedDeposit{
+		MemberID:            member.ID,
+		Principal:           reqBody.Principal,
+		Currency:            currency,
+		InterestRate:        interestRate,
+		TermMonths:          reqBody.TermMonths,
+		StartDate:           startDate,
+		MaturityDate:        models.CalculateMaturityDate(startDate, reqBody.TermMonths),
+		MaturityInstruction: reqBody.MaturityInstruction,
+		Status:              models.FixedDepositStatusActive,
+	}
+	if _, err := models.CalculateFixedDepositInterest(deposit.Principal, deposit.InterestRate, deposit.StartDate, deposit.MaturityDate); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "principal is too large", err)
+		return
+	}
+
+	createdDeposit, msg, err := f.repo.CreateFixedDeposit(&deposit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrInsufficientBalance) {
+			status = http.StatusUnprocessableEntity
+		}
+		utils.RespondWithError(c, status, msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "fixed deposit created successfully", "data", gin.H{
+		"fixed_deposit": models.NewFixedDepositResponse(createdDeposit),
+	})
+}
+
+func (f *FixedDepositHandler) GetFixedDeposits(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+
+	member, msg, err := f.memberRepo.FetchMemberByUserID(authUser.ID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, msg, err)
+		return
+	}
+
+	deposits, msg, err := f.repo.GetFixedDepositsByMemberID(member.ID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	depositResponses := make([]models.FixedDepositResponse, len(deposits))
+	for i, deposit := range deposits {
+		currentDeposit := deposit
+		depositResponses[i] = models.NewFixedDepositResponse(&currentDeposit)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "fixed deposits fetched successfully", "data", gin.H{
+		"fixed_deposits": depositResponses,
+	})
+}
+
+func (f *FixedDepositHandler) GetFixedDepositByID(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+
+	deposit, err := f.getFixedDepositAndAuthorize(c, &authUser, models.PermMembersRead)
+	if err != nil {
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "fixed deposit fetched successfully", "data", gin.H{
+		"fixed_deposit": models.NewFixedDepositResponse(deposit),
+	})
+}
+
+func (f *FixedDepositHandler) BreakFixedDeposit(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+
+	deposit, err := f.getFixedDepositAndAuthorize(c, &authUser, models.PermMembersManage)
+	if err != nil {
+		return
+	}
+
+	if deposit.Status != models.FixedDepositStatusActive {
+		utils.RespondWithError(c, http.StatusBadRequest, "only active fixed deposits can be broken", nil)
+		return
+	}
+
+	brokenDeposit, msg, err := f.repo.BreakFixedDeposit(deposit.ID, time.Now())
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, models.ErrFixedDepositMatured) || errors.Is(err, models.ErrFixedDepositNotActive) {
+			status = http.StatusConflict
+		}
+		utils.RespondWithError(c, status, msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "fixed deposit broken successfully", "data", gin.H{
+		"fixed_deposit": models.NewFixedDepositResponse(brokenDeposit),
+	})
+}
+
+func (f *FixedDepositHandler) ProcessMaturedDeposits(c *gin.Context) {
+	result, msg, err := jobs.ProcessMaturedFixedDeposits(f.repo, time.Now())
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	processedResponses := make([]models.FixedDepositResponse, len(result.Processed))
+	for i, deposit := range result.Processed {
+		currentDeposit := deposit
+		processedResponses[i] = models.NewFixedDepositResponse(&currentDeposit)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"processed": processedResponses,
+		"failed":    result.Failed,
+	})
+}
+
+func (f *FixedDepositHandler) getFixedDepositAndAuthorize(c *gin.Context, authUser *models.User, permission string) (*models.FixedDeposit, error) {
+	deposit, msg, err := f.repo.GetFixedDepositByID(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondWithError(c, http.StatusNotFound, msg, err)
+		} else {
+			utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		}
+		return nil, err
+	}
+
+	if authUser.Can(permission) {
+		if !authUser.CanSeeBranch(deposit.Member.BranchID) {
+			utils.RespondWithError(c, http.StatusForbidden, "fixed deposit belongs to a member of another branch", nil)
+			return nil, errors.New("fixed deposit outside branch")
+		}
+		return deposit, nil
+	}
+
+	member, msg, err := f.memberRepo.FetchMemberByUserID(authUser.ID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, msg, err)
+		return nil, err
+	}
+
+	if deposit.MemberID != member.ID {
+		utils.RespondWithError(c, http.StatusForbidden, "you are not authorized to access this fixed deposit", nil)
+		return nil, errors.New("unauthorized access")
+	}
+
+	return deposit, nil
+}
@@ -0,0 +1,289 @@
+// Unit tests for FixedDepositHandler endpoints
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cooperative-system/internal/handlers"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockFixedDepositRepo struct {
+	repository.FixedDepositRepository
+	CreateFixedDepositFunc  func(deposit *models.FixedDeposit) (*models.FixedDeposit, string, error)
+	GetFixedDepositByIDFunc func(depositID string) (*models.FixedDeposit, string, error)
+	BreakFixedDepositFunc   func(depositID uint, breakDate time.Time) (*models.FixedDeposit, string, error)
+}
+
+func (m *mockFixedDepositRepo) CreateFixedDeposit(deposit *models.FixedDeposit) (*models.FixedDeposit, string, error) {
+	return m.CreateFixedDepositFunc(deposit)
+}
+func (m *mockFixedDepositRepo) GetFixedDepositByID(depositID string) (*models.FixedDeposit, string, error) {
+	return m.GetFixedDepositByIDFunc(depositID)
+}
+func (m *mockFixedDepositRepo) BreakFixedDeposit(depositID uint, breakDate time.Time) (*models.FixedDeposit, string, error) {
+	return m.BreakFixedDepositFunc(depositID, breakDate)
+}
+
+func TestCreateFixedDeposit_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockDeposits := &mockFixedDepositRepo{
+		CreateFixedDepositFunc: func(deposit *models.FixedDeposit) (*models.FixedDeposit, string, error) {
+			deposit.ID = 1
+			return deposit, "fixed deposit created successfully", nil
+		},
+	}
+	mockMember := &mockMemberRepoForLoan{
+		FetchMemberByUserIDFunc: func(userID uint) (*models.Member, string, error) {
+			member := models.Member{Status: models.MemberStatusActive}
+			member.ID = 1
+			member.UserID = userID
+			return &member, "success", nil
+		},
+	}
+	h := handlers.NewFixedDepositHandler(mockDeposits, mockMember)
+	r := gin.Default()
+	r.POST("/fixed-deposits", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "member"
+		c.Set("user", user)
+		h.CreateFixedDeposit(c)
+	})
+	body := map[string]interface{}{"principal": 50000, "term_months": 12, "maturity_instruction": "rollover"}
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPost, "/fixed-deposits", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), "fixed deposit created successfully")
+}
+
+func TestCreateFixedDeposit_UnsupportedTerm(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewFixedDepositHandler(&mockFixedDepositRepo{}, &mockMemberRepoForLoan{})
+	r := gin.Default()
+	r.POST("/fixed-deposits", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "member"
+		c.Set("user", user)
+		h.CreateFixedDeposit(c)
+	})
+	body := map[string]interface{}{"principal": 50000, "term_months": 7, "maturity_instruction": "payout"}
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPost, "/fixed-deposits", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "unsupported fixed deposit term")
+}
+
+func TestBreakFixedDeposit_NotOwner(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockDeposits := &mockFixedDepositRepo{
+		GetFixedDepositByIDFunc: func(depositID string) (*models.FixedDeposit, string, error) {
+			deposit := &models.FixedDeposit{MemberID: 2, Status: models.FixedDepositStatusActive}
+			deposit.ID = 1
+			return deposit, "fixed deposit fetched successfully", nil
+		},
+	}
+	mockMember := &mockMemberRepoForLoan{
+		FetchMemberByUserIDFunc: func(userID uint) (*models.Member, string, error) {
+			member := models.Member{Status: models.MemberStatusActive}
+			member.ID = 1
+			member.UserID = userID
+			return &member, "success", nil
+		},
+	}
+	h := handlers.NewFixedDepositHandler(mockDeposits, mockMember)
+	r := gin.Default()
+	r.POST("/fixed-deposits/:id/break", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "member"
+		c.Set("user", user)
+		h.BreakFixedDeposit(c)
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/fixed-deposits/1/break", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "you are not authorized to access this fixed deposit")
+}
+
+func TestBreakFixedDeposit_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	start := time.Now().AddDate(0, -6, 0)
+	mockDeposits := &mockFixedDepositRepo{
+		GetFixedDepositByIDFunc: func(depositID string) (*models.FixedDeposit, string, error) {
+			deposit := &models.FixedDeposit{
+				MemberID:     1,
+				Principal:    100000,
+				InterestRate: 0.10,
+				TermMonths:   12,
+				StartDate:    start,
+				MaturityDate: models.CalculateMaturityDate(start, 12),
+				Status:       models.FixedDepositStatusActive,
+			}
+			deposit.ID = 1
+			return deposit, "fixed deposit fetched successfully", nil
+		},
+		BreakFixedDepositFunc: func(depositID uint, breakDate time.Time) (*models.FixedDeposit, string, error) {
+			deposit := &models.FixedDeposit{MemberID: 1, Status: models.FixedDepositStatusBroken, PenaltyAmount: 1000}
+			deposit.ID = depositID
+			return deposit, "fixed deposit broken successfully", nil
+		},
+	}
+	mockMember := &mockMemberRepoForLoan{
+		FetchMemberByUserIDFunc: func(userID uint) (*models.Member, string, error) {
+			member := models.Member{Status: models.MemberStatusActive}
+			member.ID = 1
+			member.UserID = userID
+			return &member, "success", nil
+		},
+	}
+	h := handlers.NewFixedDepositHandler(mockDeposits, mockMember)
+	r := gin.Default()
+	r.POST("/fixed-deposits/:id/break", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "member"
+		c.Set("user", user)
+		h.BreakFixedDeposit(c)
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/fixed-deposits/1/break", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "fixed deposit broken successfully")
+}
+
+func TestCreateFixedDeposit_InsufficientSavings(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockDeposits := &mockFixedDepositRepo{
+		CreateFixedDepositFunc: func(deposit *models.FixedDeposit) (*models.FixedDeposit, string, error) {
+			return nil, "a fixed deposit of 50000.00 NGN is more than your savings balance", repository.ErrInsufficientBalance
+		},
+	}
+	mockMember := &mockMemberRepoForLoan{
+		FetchMemberByUserIDFunc: func(userID uint) (*models.Member, string, error) {
+			member := models.Member{Status: models.MemberStatusActive}
+			member.ID = 1
+			member.UserID = userID
+			return &member, "success", nil
+		},
+	}
+	h := handlers.NewFixedDepositHandler(mockDeposits, mockMember)
+	r := gin.Default()
+	r.POST("/fixed-deposits", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "member"
+		c.Set("user", user)
+		h.CreateFixedDeposit(c)
+	})
+	body := map[string]interface{}{"principal": 50000, "term_months": 12, "maturity_instruction": "move_to_savings"}
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPost, "/fixed-deposits", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "more than your savings balance")
+}
+
+func TestGetFixedDepositByID_OtherBranch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	otherBranch := uint(4)
+	mockDeposits := &mockFixedDepositRepo{
+		GetFixedDepositByIDFunc: func(depositID string) (*models.FixedDeposit, string, error) {
+			deposit := &models.FixedDeposit{MemberID: 2, Status: models.FixedDepositStatusActive}
+			deposit.ID = 1
+			deposit.Member.ID = 2
+			deposit.Member.BranchID = &otherBranch
+			return deposit, "fixed deposit fetched successfully", nil
+		},
+	}
+	h := handlers.NewFixedDepositHandler(mockDeposits, &mockMemberRepoForLoan{})
+	r := gin.Default()
+	r.GET("/fixed-deposits/:id", branchAdminContext(3, h.GetFixedDepositByID))
+	req, _ := http.NewRequest(http.MethodGet, "/fixed-deposits/1", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "another branch")
+}
+
+func TestBreakFixedDeposit_AlreadyMatured(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	start := time.Now().AddDate(0, -6, 0)
+	mockDeposits := &mockFixedDepositRepo{
+		GetFixedDepositByIDFunc: func(depositID string) (*models.FixedDeposit, string, error) {
+			deposit := &models.FixedDeposit{MemberID: 1, Principal: 100000, TermMonths: 6, StartDate: start, MaturityDate: models.CalculateMaturityDate(start, 6), Status: models.FixedDepositStatusActive}
+			deposit.ID = 1
+			return deposit, "fixed deposit fetched successfully", nil
+		},
+		BreakFixedDepositFunc: func(depositID uint, breakDate time.Time) (*models.FixedDeposit, string, error) {
+			return nil, models.ErrFixedDepositMatured.Error(), models.ErrFixedDepositMatured
+		},
+	}
+	mockMember := &mockMemberRepoForLoan{
+		FetchMemberByUserIDFunc: func(userID uint) (*models.Member, string, error) {
+			member := models.Member{Status: models.MemberStatusActive}
+			member.ID = 1
+			member.UserID = userID
+			return &member, "success", nil
+		},
+	}
+	h := handlers.NewFixedDepositHandler(mockDeposits, mockMember)
+	r := gin.Default()
+	r.POST("/fixed-deposits/:id/break", memberContext(h.BreakFixedDeposit))
+	req, _ := http.NewRequest(http.MethodPost, "/fixed-deposits/1/break", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "already matured")
+}
+
+func TestGetFixedDepositByID_LookupErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "not found", err: gorm.ErrRecordNotFound, expected: http.StatusNotFound},
+		{name: "database failure", err: errors.New("connection refused"), expected: http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDeposits := &mockFixedDepositRepo{
+				GetFixedDepositByIDFunc: func(depositID string) (*models.FixedDeposit, string, error) {
+					return nil, "failed to fetch fixed deposit", tc.err
+				},
+			}
+			h := handlers.NewFixedDepositHandler(mockDeposits, &mockMemberRepoForLoan{})
+			r := gin.Default()
+			r.GET("/fixed-deposits/:id", memberContext(h.GetFixedDepositByID))
+			req, _ := http.NewRequest(http.MethodGet, "/fixed-deposits/1", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.expected, w.Code)
+		})
+	}
+}
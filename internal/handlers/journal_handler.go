@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+	"cooperative-system/pkg/utils"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JournalHandler struct {
+	repo       repository.JournalRepository
+	memberRepo repository.MemberRepository
+}
+
+func NewJournalHandler(journalRepo repository.JournalRepository, memberRepo repository.MemberRepository) *JournalHandler {
+	return &JournalHandler{
+		repo:       journalRepo,
+		memberRepo: memberRepo,
+	}
+}
+
+type JournalService interface {
+	GetMemberJournal(c *gin.Context)
+}
+
+// journalFilter reads ?type= (comma separated, may be repeated), ?from= and ?to= (YYYY-MM-DD, inclusive).
+// Every parameter is optional, without them the whole journal is returned.
+func journalFilter(c *gin.Context) (models.JournalFilter, bool) {
+	var filter models.JournalFilter
+	for _, value := range c.QueryArray("type") {
+		for _, transactionType := range strings.Split(value, ",") {
+			transactionType = strings.ToLower(strings.TrimSpace(transactionType))
+			if transactionType == "" {
+				continue
+			}
+			if !models.AllowedTransactionTypes[transactionType] {
+				utils.RespondWithError(c, http.StatusBadRequest, "unknown transaction type: "+transactionType, nil)
+				return filter, false
+			}
+			filter.Types = append(filter.Types, transactionType)
+		}
+	}
+
+	var ok bool
+	if filter.From, ok = optionalDateParam(c, "from"); !ok {
+		return filter, false
+	}
+	if filter.To, ok = optionalDateParam(c, "to"); !ok {
+		return filter, false
+	}
+
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		utils.RespondWithError(c, http.StatusBadRequest, "from must not be after to", nil)
+		return filter, false
+	}
+	return filter, true
+}
+
+// optionalDateParam reads a YYYY-MM-DD query parameter, returning nil when it is not given
+func optionalDateParam(c *gin.Context, name string) (*time.Time, bool) {
+	if c.Query(name) == "" {
+		return nil, true
+	}
+	date, err := time.Parse(time.DateOnly, c.Query(name))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, name+" must be in YYYY-MM-DD format", err)
+		return nil, false
+	}
+	return &date, true
+}
+
+func (h *JournalHandler) GetMemberJournal(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+
+	member, err := getMemberByIDAndAuthorize(c, h.memberRepo, &authUser, models.PermMembersRead)
+	if err != nil {
+		return
+	}
+
+	filter, ok := journalFilter(c)
+	if !ok {
+		return
+	}
+
+	entries, msg, err := h.repo.GetJournal(member.ID, filter)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"member_id": member.ID,
+		"entries":   entries,
+	})
+}
@@ -0,0 +1,106 @@
+// Unit tests for JournalHandler endpoints
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cooperative-system/internal/handlers"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockJournalRepo struct {
+	repository.JournalRepository
+	GetJournalFunc func(memberID uint, filter models.JournalFilter) ([]models.JournalEntry, string, error)
+}
+
+func (m *mockJournalRepo) GetJournal(memberID uint, filter models.JournalFilter) ([]models.JournalEntry, string, error) {
+	return m.GetJournalFunc(memberID, filter)
+}
+
+func journalDate(value string) time.Time {
+	date, _ := time.Parse(time.DateOnly, value)
+	return date
+}
+
+// journalRepo builds the journal from a fixed history, so the filter is applied the same way the repository does
+func journalRepo() *mockJournalRepo {
+	return &mockJournalRepo{
+		GetJournalFunc: func(memberID uint, filter models.JournalFilter) ([]models.JournalEntry, string, error) {
+			deposit := models.SavingTransaction{SavingsID: 3, Amount: 10000, Currency: "NGN", Type: models.TransactionTypeDeposit, Channel: models.TransactionChannelBankTransfer, ExternalReference: "BNK-1"}
+			deposit.ID = 1
+			deposit.CreatedAt = journalDate("2025-01-05")
+			interest := models.SavingTransaction{SavingsID: 3, Amount: 150, Currency: "NGN", Type: models.TransactionTypeInterest}
+			interest.ID = 2
+			interest.CreatedAt = journalDate("2025-02-01")
+
+			disbursedAt := journalDate("2025-01-10")
+			loan := models.Loan{Amount: 50000, Currency: "NGN", Type: "personal", DisbursedAt: &disbursedAt}
+			loan.ID = 7
+			repayment := models.LoanRepayment{LoanID: 7, Amount: 5000, Currency: "NGN", PaidAt: journalDate("2025-02-10")}
+			repayment.ID = 9
+
+			entries := models.BuildJournal([]models.SavingTransaction{deposit, interest}, []models.Loan{loan}, []models.LoanRepayment{repayment}, filter)
+			return entries, "journal fetched successfully", nil
+		},
+	}
+}
+
+func journalRouter(h *handlers.JournalHandler) *gin.Engine {
+	r := gin.Default()
+	r.GET("/members/:id/journal", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "member"
+		c.Set("user", user)
+		h.GetMemberJournal(c)
+	})
+	return r
+}
+
+func TestGetMemberJournal_MergesSavingsAndLoans(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := journalRouter(handlers.NewJournalHandler(journalRepo(), ownMemberRepo()))
+	req, _ := http.NewRequest(http.MethodGet, "/members/1/journal", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `"reference":"TXN-1","type":"deposit","channel":"bank_transfer","external_reference":"BNK-1"`)
+	assert.Contains(t, body, `"reference":"LOAN-7","type":"loan_disbursement"`)
+	assert.Contains(t, body, `"reference":"RPY-9","type":"loan_repayment"`)
+	assert.Contains(t, body, `"amount":-50.00`)
+	// oldest first, the loan sits between the two savings entries
+	assert.Less(t, strings.Index(body, "TXN-1"), strings.Index(body, "LOAN-7"))
+	assert.Less(t, strings.Index(body, "LOAN-7"), strings.Index(body, "TXN-2"))
+}
+
+func TestGetMemberJournal_FiltersByTypeAndDate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := journalRouter(handlers.NewJournalHandler(journalRepo(), ownMemberRepo()))
+	req, _ := http.NewRequest(http.MethodGet, "/members/1/journal?type=interest,loan_repayment&from=2025-02-01&to=2025-02-05", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, "TXN-2")
+	assert.NotContains(t, body, "TXN-1")
+	assert.NotContains(t, body, "LOAN-7")
+	assert.NotContains(t, body, "RPY-9")
+}
+
+func TestGetMemberJournal_UnknownType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := journalRouter(handlers.NewJournalHandler(journalRepo(), ownMemberRepo()))
+	req, _ := http.NewRequest(http.MethodGet, "/members/1/journal?type=bonus", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
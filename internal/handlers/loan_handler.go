@@ -4,15 +4,17 @@ import (
 	"cooperative-system/internal/models"
 	"cooperative-system/internal/repository"
 	"cooperative-system/pkg/utils"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
 type LoanRequest struct {
-	Amount      float64 `json:"amount" binding:"required"`
-	Description string  `json:"description"`
-	Type        string  `json:"type" binding:"required"`
+	Amount      models.Money `json:"amount" binding:"required"`
+	Currency    string       `json:"currency"` // ISO 4217 code, defaults to the base currency
+	Description string       `json:"description"`
+	Type        string       `json:"type" binding:"required"`
 	// InterestRate   float64 `json:"interest_rate" binding:"required"`
 	LoanTermMonths uint `json:"loan_term_months" binding:"required"`
 }
@@ -58,6 +60,12 @@ func (l *LoanHandler) ApplyLoan(c *gin.Context) {
 		return
 	}
 
+	currency, err := models.NormalizeCurrency(reqBody.Currency)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
 	authUser, ok := getAuthUser(c)
 	if !ok {
 		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
@@ -70,6 +78,9 @@ func (l *LoanHandler) ApplyLoan(c *gin.Context) {
 		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
 		return
 	}
+	if !requireActiveMember(c, member) {
+		return
+	}
 
 	// Calculate the interest rate based on the loan type and term
 	calculatedInterestRate := models.GetInterestRate(reqBody.Type, reqBody.LoanTermMonths)
@@ -92,6 +103,7 @@ func (l *LoanHandler) ApplyLoan(c *gin.Context) {
 
 	loan := models.Loan{
 		Amount:               reqBody.Amount,
+		Currency:             currency,
 		Description:          reqBody.Description,
 		MemberID:             member.ID,
 		InterestRate:         calculatedInterestRate,
@@ -200,7 +212,7 @@ func (l LoanHandler) TrackLoanApproval(c *gin.Context) {
 		return
 	}
 
-	loanHistories, msg, err := l.repo.GetLoanHistoryByID(loanID)
+	loanHistories, msg, err := l.repo.GetLoanHistoryByID(fmt.Sprint(loan.ID))
 	if err != nil {
 		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
 		return
@@ -19,18 +19,28 @@ import (
 
 type mockLoanRepo struct {
 	repository.LoanRepository
-	CreateLoanRequestObjectFunc func(loan *models.Loan) (*models.Loan, string, error)
-	GetLoanByIDFunc             func(loanID string) (*models.Loan, string, error)
+	CreateLoanWithInitialHistoryFunc func(loan *models.Loan, loanHistory *models.LoanHistory) (*models.Loan, *models.LoanHistory, string, error)
+	GetLoanByIDFunc                  func(loanID string) (*models.Loan, string, error)
+	GetRepaymentsByLoanIDFunc        func(loanID uint) ([]models.LoanRepayment, string, error)
+	RecordRepaymentFunc              func(repayment *models.LoanRepayment) (*models.LoanRepayment, *models.Loan, string, error)
 }
 
-func (m *mockLoanRepo) CreateLoanRequestObject(loan *models.Loan) (*models.Loan, string, error) {
-	return m.CreateLoanRequestObjectFunc(loan)
+func (m *mockLoanRepo) CreateLoanWithInitialHistory(loan *models.Loan, loanHistory *models.LoanHistory) (*models.Loan, *models.LoanHistory, string, error) {
+	return m.CreateLoanWithInitialHistoryFunc(loan, loanHistory)
 }
 
 func (m *mockLoanRepo) GetLoanByID(loanID string) (*models.Loan, string, error) {
 	return m.GetLoanByIDFunc(loanID)
 }
 
+func (m *mockLoanRepo) GetRepaymentsByLoanID(loanID uint) ([]models.LoanRepayment, string, error) {
+	return m.GetRepaymentsByLoanIDFunc(loanID)
+}
+
+func (m *mockLoanRepo) RecordRepayment(repayment *models.LoanRepayment) (*models.LoanRepayment, *models.Loan, string, error) {
+	return m.RecordRepaymentFunc(repayment)
+}
+
 type mockMemberRepoForLoan struct {
 	repository.MemberRepository
 	FetchMemberByUserIDFunc func(userID uint) (*models.Member, string, error)
@@ -43,14 +53,15 @@ func (m *mockMemberRepoForLoan) FetchMemberByUserID(userID uint) (*models.Member
 func TestApplyLoan_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockLoan := &mockLoanRepo{
-		CreateLoanRequestObjectFunc: func(loan *models.Loan) (*models.Loan, string, error) {
+		CreateLoanWithInitialHistoryFunc: func(loan *models.Loan, loanHistory *models.LoanHistory) (*models.Loan, *models.LoanHistory, string, error) {
 			loan.ID = 1
-			return loan, "Loan created successfully.", nil
+			loanHistory.LoanID = loan.ID
+			return loan, loanHistory, "loan created successfully", nil
 		},
 	}
 	mockMember := &mockMemberRepoForLoan{
 		FetchMemberByUserIDFunc: func(userID uint) (*models.Member, string, error) {
-			member := models.Member{}
+			member := models.Member{Status: models.MemberStatusActive}
 			member.ID = 1
 			member.UserID = userID
 			member.Name = "Test"
@@ -75,6 +86,8 @@ func TestApplyLoan_Success(t *testing.T) {
 	r.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusCreated, w.Code)
 	assert.Contains(t, w.Body.String(), "loan application submitted successfully")
+	assert.Contains(t, w.Body.String(), `"total_repayable_amount":1035.00`)
+	assert.Contains(t, w.Body.String(), `"installment_amount":86.25`)
 }
 
 func TestApplyLoan_InvalidBody(t *testing.T) {
@@ -114,13 +127,13 @@ func TestApplyLoan_Unauthenticated(t *testing.T) {
 func TestApplyLoan_RepoError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockLoan := &mockLoanRepo{
-		CreateLoanRequestObjectFunc: func(loan *models.Loan) (*models.Loan, string, error) {
-			return nil, "failed to apply for loan", errors.New("db error")
+		CreateLoanWithInitialHistoryFunc: func(loan *models.Loan, loanHistory *models.LoanHistory) (*models.Loan, *models.LoanHistory, string, error) {
+			return nil, nil, "failed to apply for loan", errors.New("db error")
 		},
 	}
 	mockMember := &mockMemberRepoForLoan{
 		FetchMemberByUserIDFunc: func(userID uint) (*models.Member, string, error) {
-			member := models.Member{}
+			member := models.Member{Status: models.MemberStatusActive}
 			member.ID = 1
 			member.UserID = userID
 			member.Name = "Test"
@@ -160,7 +173,7 @@ func TestGetLoanStatus_Success(t *testing.T) {
 	}
 	mockMember := &mockMemberRepoForLoan{
 		FetchMemberByUserIDFunc: func(userID uint) (*models.Member, string, error) {
-			member := models.Member{}
+			member := models.Member{Status: models.MemberStatusActive}
 			member.Model.ID = 1 // Same as loan.MemberID
 			member.UserID = userID
 			member.Name = "Test"
@@ -193,7 +206,7 @@ func TestGetLoanStatus_LoanNotFound(t *testing.T) {
 	}
 	mockMember := &mockMemberRepoForLoan{
 		FetchMemberByUserIDFunc: func(userID uint) (*models.Member, string, error) {
-			member := models.Member{}
+			member := models.Member{Status: models.MemberStatusActive}
 			member.Model.ID = 1
 			member.UserID = userID
 			return &member, "success", nil
@@ -228,7 +241,7 @@ func TestGetLoanStatus_Unauthorized(t *testing.T) {
 	}
 	mockMember := &mockMemberRepoForLoan{
 		FetchMemberByUserIDFunc: func(userID uint) (*models.Member, string, error) {
-			member := models.Member{}
+			member := models.Member{Status: models.MemberStatusActive}
 			member.Model.ID = 1 // Different from loan.MemberID
 			member.UserID = userID
 			member.Name = "Test"
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+	"cooperative-system/pkg/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type MeHandler struct {
+	repo          repository.PositionRepository
+	shareSettings func() models.ShareSettings
+}
+
+func NewMeHandler(positionRepo repository.PositionRepository, shareSettings func() models.ShareSettings) *MeHandler {
+	return &MeHandler{
+		repo:          positionRepo,
+		shareSettings: shareSettings,
+	}
+}
+
+type MeService interface {
+	GetMe(c *gin.Context)
+}
+
+// GetMe shows the authenticated member their whole position in one response: their profile, savings balances,
+// share holding, running loans with the next installment due, and applications still waiting for a decision
+func (h *MeHandler) GetMe(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+
+	position, msg, err := h.repo.GetMemberPosition(authUser.ID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			status = http.StatusNotFound
+		}
+		utils.RespondWithError(c, status, msg, err)
+		return
+	}
+
+	response, err := models.NewMemberPositionResponse(position, h.shareSettings(), time.Now())
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "failed to work out loan installments", err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", response)
+}
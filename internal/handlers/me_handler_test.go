@@ -0,0 +1,143 @@
+// Unit tests for MeHandler endpoints
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cooperative-system/internal/handlers"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockPositionRepo struct {
+	repository.PositionRepository
+	GetMemberPositionFunc func(userID uint) (*models.MemberPosition, string, error)
+}
+
+func (m *mockPositionRepo) GetMemberPosition(userID uint) (*models.MemberPosition, string, error) {
+	return m.GetMemberPositionFunc(userID)
+}
+
+func memberContext(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := models.User{Role: "member"}
+		user.ID = 1
+		c.Set("user", user)
+		handler(c)
+	}
+}
+
+func TestGetMe_AggregatesPosition(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	disbursed := time.Now().AddDate(0, -5, 0)
+	nextMonth := time.Now().AddDate(0, 1, 0)
+
+	// 1,242.00 over 12 months with two installments of 103.50 repaid, the third fell due two months ago
+	running := models.Loan{Type: "personal", Amount: 120000, TotalRepayableAmount: 124200, LoanTermMonths: 12, Status: models.LoanStatusDisbursed, DisbursedAt: &disbursed}
+	running.ID = 7
+	// migrated with 200.00 left over two installments, 50.00 of which has been repaid since
+	batchID := uint(3)
+	migrated := models.Loan{Type: "business", Amount: 90000, TotalRepayableAmount: 100000, LoanTermMonths: 10, Status: models.LoanStatusDisbursed, DisbursedAt: &disbursed, MigrationBatchID: &batchID, LegacyReference: "L-9",
+		Installments: []models.LoanInstallment{{Number: 9, DueDate: nextMonth, Amount: 10000}, {Number: 10, DueDate: nextMonth.AddDate(0, 1, 0), Amount: 10000}}}
+	migrated.ID = 8
+	pending := models.Loan{Type: "education", Amount: 50000, Status: models.LoanStatusPending}
+	pending.ID = 9
+
+	var requestedUser uint
+	mockRepo := &mockPositionRepo{
+		GetMemberPositionFunc: func(userID uint) (*models.MemberPosition, string, error) {
+			requestedUser = userID
+			member := models.Member{UserID: userID, Name: "Ada Obi", Status: models.MemberStatusActive}
+			member.ID = 4
+			return &models.MemberPosition{
+				Member:       member,
+				Savings:      []models.Savings{{MemberID: 4, Currency: "NGN", Balance: 250000}, {MemberID: 4, Currency: "USD", Balance: 1500}},
+				ShareAccount: models.ShareAccount{MemberID: 4, Shares: 10},
+				ActiveLoans: []models.LoanPosition{
+					{Loan: running, Repaid: 20700},
+					{Loan: migrated, Repaid: 85000},
+				},
+				PendingLoans: []models.Loan{pending},
+			}, "member position fetched successfully", nil
+		},
+	}
+	h := handlers.NewMeHandler(mockRepo, func() models.ShareSettings { return models.ShareSettings{Price: 10000, MinimumHolding: 5} })
+	r := gin.Default()
+	r.GET("/me", memberContext(h.GetMe))
+	req, _ := http.NewRequest(http.MethodGet, "/me", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, uint(1), requestedUser)
+
+	var body struct {
+		Data struct {
+			Member  models.MemberResponse    `json:"member"`
+			Savings []models.SavingsResponse `json:"savings"`
+			Shares  struct {
+				Shares int64 `json:"shares"`
+			} `json:"shares"`
+			ActiveLoans []struct {
+				ID              uint                            `json:"id"`
+				Outstanding     json.Number                     `json:"outstanding"`
+				NextInstallment *models.NextInstallmentResponse `json:"next_installment"`
+			} `json:"active_loans"`
+			PendingApplications struct {
+				Membership string                `json:"membership"`
+				Loans      []models.LoanResponse `json:"loans"`
+			} `json:"pending_applications"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, uint(4), body.Data.Member.ID)
+	assert.Len(t, body.Data.Savings, 2)
+	assert.Equal(t, int64(10), body.Data.Shares.Shares)
+	assert.Contains(t, w.Body.String(), `"value":1000.00`)
+
+	if assert.Len(t, body.Data.ActiveLoans, 2) {
+		next := body.Data.ActiveLoans[0].NextInstallment
+		if assert.NotNil(t, next) {
+			assert.Equal(t, 3, next.Number)
+			assert.Equal(t, models.Money(10350), next.Amount)
+			assert.Equal(t, disbursed.AddDate(0, 3, 0).Format(time.DateOnly), next.DueDate)
+			assert.True(t, next.Overdue)
+		}
+		assert.Equal(t, "1035.00", body.Data.ActiveLoans[0].Outstanding.String())
+
+		next = body.Data.ActiveLoans[1].NextInstallment
+		if assert.NotNil(t, next) {
+			assert.Equal(t, 9, next.Number)
+			assert.Equal(t, models.Money(5000), next.Amount)
+			assert.False(t, next.Overdue)
+		}
+	}
+	assert.Empty(t, body.Data.PendingApplications.Membership)
+	if assert.Len(t, body.Data.PendingApplications.Loans, 1) {
+		assert.Equal(t, uint(9), body.Data.PendingApplications.Loans[0].ID)
+	}
+}
+
+func TestGetMe_UserWithoutMembership(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepo := &mockPositionRepo{
+		GetMemberPositionFunc: func(userID uint) (*models.MemberPosition, string, error) {
+			return nil, "no membership found for this user", gorm.ErrRecordNotFound
+		},
+	}
+	h := handlers.NewMeHandler(mockRepo, func() models.ShareSettings { return models.ShareSettings{} })
+	r := gin.Default()
+	r.GET("/me", memberContext(h.GetMe))
+	req, _ := http.NewRequest(http.MethodGet, "/me", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "no membership found for this user")
+}
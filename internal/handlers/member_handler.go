@@ -1,17 +1,40 @@
 package handlers
 
 import (
+	"cooperative-system/internal/imports"
 	"cooperative-system/internal/models"
 	"cooperative-system/internal/repository"
 	"cooperative-system/pkg/utils"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// MemberRequestBody carries the KYC fields too. They are all required on an application, except
+// occupation and employer, and optional on an update. An applicant can pick their branch and group,
+// which an update ignores, members are moved through the assignment endpoint.
 type MemberRequestBody struct {
 	Name        string `json:"name" binding:"required"`
 	ContactInfo string `json:"contact_info" binding:"required"`
+	Phone       string `json:"phone"`
+	Address     string `json:"address"`
+	DateOfBirth string `json:"date_of_birth"` // YYYY-MM-DD
+	IDType      string `json:"id_type"`
+	IDNumber    string `json:"id_number"`
+	Occupation  string `json:"occupation"`
+	Employer    string `json:"employer"`
+	BranchID    *uint  `json:"branch_id"`
+	GroupID     *uint  `json:"group_id"`
+}
+
+type MemberStatusRequest struct {
+	Reason string `json:"reason"`
 }
 
 type MemberHandler struct {
@@ -31,8 +54,18 @@ type MemberService interface {
 	GetMemberByID(c *gin.Context)
 	UpdateAMember(c *gin.Context)
 	DeleteAMember(c *gin.Context)
+	GetMemberApplications(c *gin.Context)
+	ReviewMemberApplication(c *gin.Context)
+	ApproveMember(c *gin.Context)
+	RejectMember(c *gin.Context)
+	SuspendMember(c *gin.Context)
+	ReactivateMember(c *gin.Context)
+	ImportMembers(c *gin.Context)
 }
 
+// memberImportMaxSize caps an import file, which comfortably fits tens of thousands of members
+const memberImportMaxSize = 5 << 20
+
 // Helper function to extract authenticated user
 func getAuthUser(c *gin.Context) (models.User, bool) {
 	// get the user from the auth middleware/token
@@ -50,6 +83,57 @@ func getAuthUser(c *gin.Context) (models.User, bool) {
 
 }
 
+// applyKYC copies the KYC fields that were sent onto the member
+func applyKYC(member *models.Member, reqBody *MemberRequestBody) error {
+	if phone := models.NormalizePhone(reqBody.Phone); phone != "" {
+		member.Phone = phone
+	}
+	if address := strings.TrimSpace(reqBody.Address); address != "" {
+		member.Address = address
+	}
+	if reqBody.DateOfBirth != "" {
+		dateOfBirth, err := time.Parse(time.DateOnly, reqBody.DateOfBirth)
+		if err != nil {
+			return errors.New("date of birth must be in YYYY-MM-DD format")
+		}
+		member.DateOfBirth = &dateOfBirth
+	}
+	if idType := strings.TrimSpace(reqBody.IDType); idType != "" {
+		member.IDType = strings.ToLower(idType)
+	}
+	if idNumber := strings.TrimSpace(reqBody.IDNumber); idNumber != "" {
+		member.IDNumber = idNumber
+	}
+	if occupation := strings.TrimSpace(reqBody.Occupation); occupation != "" {
+		member.Occupation = occupation
+	}
+	if employer := strings.TrimSpace(reqBody.Employer); employer != "" {
+		member.Employer = employer
+	}
+	return nil
+}
+
+// requireActiveMember stops members whose application has not been approved from saving or borrowing
+func requireActiveMember(c *gin.Context, member *models.Member) bool {
+	if !member.IsActive() {
+		utils.RespondWithError(c, http.StatusForbidden, "membership is "+member.Status+", "+models.ErrMemberNotActive.Error(), models.ErrMemberNotActive)
+		return false
+	}
+	return true
+}
+
+// requireDeletableMember stops members who may still hold savings, shares or loans from being deleted
+// before they have exited
+func requireDeletableMember(c *gin.Context, member *models.Member) bool {
+	if !member.CanBeDeleted() {
+		utils.RespondWithError(c, http.StatusConflict, "membership is "+member.Status+", "+models.ErrMemberHasAccounts.Error(), models.ErrMemberHasAccounts)
+		return false
+	}
+	return true
+}
+
+// CreateMember submits a membership application for the authenticated user, it is reviewed by an admin
+// before the member can save or borrow
 func (m *MemberHandler) CreateMember(c *gin.Context) {
 	// Parse the request body into struct
 	var reqBody MemberRequestBody
@@ -70,12 +154,24 @@ func (m *MemberHandler) CreateMember(c *gin.Context) {
 		UserID:      authUser.ID,
 		Name:        reqBody.Name,
 		ContactInfo: reqBody.ContactInfo,
+		Status:      models.MemberStatusApplied,
+		BranchID:    reqBody.BranchID,
+		GroupID:     reqBody.GroupID,
+	}
+	if err := applyKYC(&member, &reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if err := member.ValidateKYC(); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+		return
 	}
 
 	// Create an initial savings record
 	savings := models.Savings{
 		UserID:       authUser.ID,
 		MemberID:     member.ID,
+		Currency:     models.BaseCurrency,
 		Balance:      0,
 		AmountToSave: 0,
 		Description:  "Initial savings record",
@@ -83,7 +179,7 @@ func (m *MemberHandler) CreateMember(c *gin.Context) {
 
 	createdMember, createdSavings, message, err := m.MemberRepo.CreateMemberWithSavings(&member, &savings)
 	if err != nil {
-		utils.RespondWithError(c, http.StatusInternalServerError, message, err)
+		utils.RespondWithError(c, branchErrorStatus(err), message, err)
 		return
 	}
 
@@ -91,7 +187,7 @@ func (m *MemberHandler) CreateMember(c *gin.Context) {
 	savingsResponse := models.NewSavingsResponse(createdSavings)
 
 	// Return the newly created member and savings
-	utils.SuccessResponse(c, http.StatusCreated, "member created successfully", "data", gin.H{
+	utils.SuccessResponse(c, http.StatusCreated, "membership application submitted successfully", "data", gin.H{
 		"member":  memberReponse,
 		"savings": savingsResponse,
 	})
@@ -100,31 +196,122 @@ func (m *MemberHandler) CreateMember(c *gin.Context) {
 func (m *MemberHandler) GetAllMembers(c *gin.Context) {
 	// get authenticated user
 	authUser, ok := getAuthUser(c)
-	if !ok || authUser.Role != "admin" {
+	if !ok || !authUser.Can(models.PermMembersRead) {
 		utils.RespondWithError(c, http.StatusForbidden, "you are not authorized to view all members", nil)
 		return
 	}
 
-	// fetch all members with their linked users
-	var members []models.Member
-	fetchedMembersList, message, err := m.MemberRepo.FetchAll(members)
+	query, err := memberQuery(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if query.BranchID, ok = branchFilter(c, &authUser); !ok {
+		return
+	}
+
+	page, message, err := m.MemberRepo.FetchPage(query)
 	if err != nil {
 		utils.RespondWithError(c, http.StatusInternalServerError, message, err)
 		return
 	}
 
-	memberReponses := make([]models.MemberResponse, len(fetchedMembersList))
-	for i, member := range fetchedMembersList {
-		currentMember := member
-		memberReponses[i] = models.NewMemberResponse(&currentMember)
+	memberReponses := make([]models.MemberResponse, len(page.Members))
+	for i := range page.Members {
+		memberReponses[i] = models.NewMemberResponse(&page.Members[i])
 	}
 
 	// send response
 	utils.SuccessResponse(c, http.StatusOK, message, "data", gin.H{
 		"members": memberReponses,
+		"pagination": gin.H{
+			"total":           page.Total,
+			"limit":           query.Limit,
+			"next_cursor":     page.NextCursor.Encode(),
+			"previous_cursor": page.PreviousCursor.Encode(),
+		},
 	})
 }
 
+// memberQuery reads the directory options: ?q= to search, ?status=active,suspended, ?joined_from= and
+// ?joined_to= (YYYY-MM-DD, inclusive), ?sort=joined|name|id with ?order=asc|desc, ?limit= and ?cursor=
+func memberQuery(c *gin.Context) (models.MemberQuery, error) {
+	query := models.MemberQuery{
+		Search: strings.TrimSpace(c.Query("q")),
+		Sort:   models.MemberSortJoined,
+		Limit:  models.DefaultMemberPageSize,
+	}
+
+	if value := c.Query("status"); value != "" {
+		for _, status := range strings.Split(value, ",") {
+			status = strings.TrimSpace(status)
+			if !models.AllowedMemberStatuses[status] {
+				return query, errors.New("unknown member status " + status)
+			}
+			query.Statuses = append(query.Statuses, status)
+		}
+	}
+
+	if value := c.Query("joined_from"); value != "" {
+		joinedFrom, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return query, errors.New("joined_from must be in YYYY-MM-DD format")
+		}
+		query.JoinedFrom = &joinedFrom
+	}
+	if value := c.Query("joined_to"); value != "" {
+		joinedTo, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return query, errors.New("joined_to must be in YYYY-MM-DD format")
+		}
+		joinedTo = joinedTo.AddDate(0, 0, 1)
+		query.JoinedTo = &joinedTo
+	}
+
+	if value := c.Query("group_id"); value != "" {
+		groupID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return query, errors.New("group_id must be a member group ID")
+		}
+		group := uint(groupID)
+		query.GroupID = &group
+	}
+
+	if value := c.Query("sort"); value != "" {
+		if !models.AllowedMemberSorts[value] {
+			return query, errors.New("sort must be joined, name or id")
+		}
+		query.Sort = value
+	}
+	switch strings.ToLower(c.Query("order")) {
+	case "", "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		return query, errors.New("order must be asc or desc")
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > models.MaxMemberPageSize {
+			return query, fmt.Errorf("limit must be between 1 and %d", models.MaxMemberPageSize)
+		}
+		query.Limit = limit
+	}
+
+	if value := c.Query("cursor"); value != "" {
+		cursor, err := models.DecodeMemberCursor(value)
+		if err != nil {
+			return query, err
+		}
+		if cursor.Sort != query.Sort || cursor.Descending != query.Descending {
+			return query, fmt.Errorf("%w, it was issued for a different sort order", models.ErrInvalidCursor)
+		}
+		query.Cursor = cursor
+	}
+	return query, nil
+}
+
 func (m *MemberHandler) GetMemberByID(c *gin.Context) {
 	// Get authenticated user
 	authUser, ok := getAuthUser(c)
@@ -133,7 +320,7 @@ func (m *MemberHandler) GetMemberByID(c *gin.Context) {
 		return
 	}
 
-	member, err := getMemberByIDAndAuthorize(c, m.MemberRepo, &authUser)
+	member, err := getMemberByIDAndAuthorize(c, m.MemberRepo, &authUser, models.PermMembersRead)
 	if err != nil {
 		return
 	}
@@ -160,7 +347,7 @@ func (m *MemberHandler) UpdateAMember(c *gin.Context) {
 		return
 	}
 
-	member, err := getMemberByIDAndAuthorize(c, m.MemberRepo, &authUser)
+	member, err := getMemberByIDAndAuthorize(c, m.MemberRepo, &authUser, models.PermMembersManage)
 	if err != nil {
 		return
 	}
@@ -174,6 +361,42 @@ func (m *MemberHandler) UpdateAMember(c *gin.Context) {
 		updateFields["contact_info"] = reqBody.ContactInfo
 	}
 
+	// identity details are fixed once a member is approved, only an admin can correct them then
+	if member.IsActive() && !authUser.Can(models.PermMembersManage) && (reqBody.DateOfBirth != "" || reqBody.IDType != "" || reqBody.IDNumber != "") {
+		utils.RespondWithError(c, http.StatusForbidden, "date of birth and ID can only be changed by an admin after approval", nil)
+		return
+	}
+	updated := *member
+	if err := applyKYC(&updated, &reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if err := updated.ValidateKYCFormat(); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if updated.Phone != member.Phone {
+		updateFields["phone"] = updated.Phone
+	}
+	if updated.Address != member.Address {
+		updateFields["address"] = updated.Address
+	}
+	if reqBody.DateOfBirth != "" {
+		updateFields["date_of_birth"] = updated.DateOfBirth
+	}
+	if updated.IDType != member.IDType {
+		updateFields["id_type"] = updated.IDType
+	}
+	if updated.IDNumber != member.IDNumber {
+		updateFields["id_number"] = updated.IDNumber
+	}
+	if updated.Occupation != member.Occupation {
+		updateFields["occupation"] = updated.Occupation
+	}
+	if updated.Employer != member.Employer {
+		updateFields["employer"] = updated.Employer
+	}
+
 	if len(updateFields) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "no fields to update"})
 		return
@@ -200,11 +423,15 @@ func (m *MemberHandler) DeleteAMember(c *gin.Context) {
 		return
 	}
 
-	member, err := getMemberByIDAndAuthorize(c, m.MemberRepo, &authUser)
+	member, err := getMemberByIDAndAuthorize(c, m.MemberRepo, &authUser, models.PermMembersManage)
 	if err != nil {
 		return
 	}
 
+	if !requireDeletableMember(c, member) {
+		return
+	}
+
 	// Delete the member
 	deleteMember, message, err := m.MemberRepo.Delete(member)
 	if err != nil {
@@ -215,3 +442,189 @@ func (m *MemberHandler) DeleteAMember(c *gin.Context) {
 	memberResponse := models.NewMemberResponse(deleteMember)
 	utils.SuccessResponse(c, http.StatusOK, "deleted member successfully", "data", memberResponse)
 }
+
+// ImportMembers creates members from a CSV file, sent as the multipart field "file" or as a text/csv body.
+// Every line is validated and the errors are reported per line. With ?dry_run=true nothing is created and
+// the report shows what would happen. With ?approve=true the members are active straight away.
+func (m *MemberHandler) ImportMembers(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermMembersImport) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can import members", nil)
+		return
+	}
+
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "dry_run must be true or false", err)
+		return
+	}
+	approve, err := strconv.ParseBool(c.DefaultQuery("approve", "false"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "approve must be true or false", err)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, memberImportMaxSize)
+	file := c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				utils.RespondWithError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("file is larger than the %d MB limit", memberImportMaxSize>>20), err)
+				return
+			}
+			utils.RespondWithError(c, http.StatusBadRequest, "a CSV file is required", err)
+			return
+		}
+		upload, err := header.Open()
+		if err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "failed to read the file", err)
+			return
+		}
+		defer upload.Close()
+		file = upload
+	}
+
+	report, msg, err := imports.ImportMembers(m.MemberRepo, file, imports.MemberImportOptions{
+		DryRun:      dryRun,
+		Approve:     approve,
+		ImportedBy:  authUser.ID,
+		Branch:      authUser.BranchID,
+		BranchScope: authUser.BranchScope(),
+	})
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			utils.RespondWithError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("file is larger than the %d MB limit", memberImportMaxSize>>20), err)
+		case errors.Is(err, imports.ErrInvalidFile):
+			utils.RespondWithError(c, http.StatusBadRequest, msg, err)
+		default:
+			utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		}
+		return
+	}
+
+	status := http.StatusCreated
+	if dryRun {
+		status = http.StatusOK
+	}
+	utils.SuccessResponse(c, status, msg, "data", gin.H{
+		"report": report,
+	})
+}
+
+// GetMemberApplications lists membership applications waiting for a decision, or any of
+// ?status=applied,under_review,rejected, for one branch with ?branch_id=
+func (m *MemberHandler) GetMemberApplications(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermMembersRead) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can view membership applications", nil)
+		return
+	}
+	branchID, ok := branchFilter(c, &authUser)
+	if !ok {
+		return
+	}
+
+	statuses := []string{models.MemberStatusApplied, models.MemberStatusUnderReview}
+	if c.Query("status") != "" {
+		statuses = strings.Split(c.Query("status"), ",")
+		for _, status := range statuses {
+			if status != models.MemberStatusApplied && status != models.MemberStatusUnderReview && status != models.MemberStatusRejected {
+				utils.RespondWithError(c, http.StatusBadRequest, "status must be applied, under_review or rejected", nil)
+				return
+			}
+		}
+	}
+
+	members, msg, err := m.MemberRepo.FetchByStatus(statuses, branchID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	memberResponses := make([]models.MemberResponse, len(members))
+	for i := range members {
+		memberResponses[i] = models.NewMemberResponse(&members[i])
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"members": memberResponses,
+	})
+}
+
+// changeMemberStatus moves the member in the URL to a new status on behalf of an admin
+func (m *MemberHandler) changeMemberStatus(c *gin.Context, status string, remarks string, message string) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermMembersManage) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can change a member's status", nil)
+		return
+	}
+
+	memberID, ok := memberIDParam(c, m.MemberRepo, &authUser)
+	if !ok {
+		return
+	}
+
+	member, msg, err := m.MemberRepo.ChangeStatus(memberID, status, authUser.ID, remarks)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			status = http.StatusNotFound
+		} else if errors.Is(err, models.ErrInvalidMemberTransition) {
+			status = http.StatusConflict
+		}
+		utils.RespondWithError(c, status, msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, message, "data", gin.H{
+		"member": models.NewMemberResponse(member),
+	})
+}
+
+func (m *MemberHandler) ReviewMemberApplication(c *gin.Context) {
+	m.changeMemberStatus(c, models.MemberStatusUnderReview, "Application under review", "membership application under review")
+}
+
+// ApproveMember approves an application, the member becomes active and can save and borrow
+func (m *MemberHandler) ApproveMember(c *gin.Context) {
+	var reqBody MemberStatusRequest
+	_ = c.ShouldBindJSON(&reqBody)
+	remarks := strings.TrimSpace(reqBody.Reason)
+	if remarks == "" {
+		remarks = "Application approved"
+	}
+	m.changeMemberStatus(c, models.MemberStatusActive, remarks, "membership application approved")
+}
+
+func (m *MemberHandler) RejectMember(c *gin.Context) {
+	var reqBody MemberStatusRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil || strings.TrimSpace(reqBody.Reason) == "" {
+		utils.RespondWithError(c, http.StatusBadRequest, "a reason for the rejection is required", err)
+		return
+	}
+	m.changeMemberStatus(c, models.MemberStatusRejected, strings.TrimSpace(reqBody.Reason), "membership application rejected")
+}
+
+// SuspendMember stops an active member from saving or borrowing until they are reactivated
+func (m *MemberHandler) SuspendMember(c *gin.Context) {
+	var reqBody MemberStatusRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil || strings.TrimSpace(reqBody.Reason) == "" {
+		utils.RespondWithError(c, http.StatusBadRequest, "a reason for the suspension is required", err)
+		return
+	}
+	m.changeMemberStatus(c, models.MemberStatusSuspended, strings.TrimSpace(reqBody.Reason), "member suspended")
+}
+
+func (m *MemberHandler) ReactivateMember(c *gin.Context) {
+	var reqBody MemberStatusRequest
+	_ = c.ShouldBindJSON(&reqBody)
+	remarks := strings.TrimSpace(reqBody.Reason)
+	if remarks == "" {
+		remarks = "Membership reactivated"
+	}
+	m.changeMemberStatus(c, models.MemberStatusActive, remarks, "member reactivated")
+}
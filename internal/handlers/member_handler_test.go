@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"cooperative-system/internal/handlers"
 	"cooperative-system/internal/models"
@@ -20,18 +21,20 @@ import (
 type mockMemberRepo struct {
 	repository.MemberRepository
 	CreateMemberWithSavingsFunc func(member *models.Member, savings *models.Savings) (*models.Member, *models.Savings, string, error)
-	FetchAllFunc                func(members []models.Member) ([]models.Member, string, error)
+	FetchPageFunc               func(query models.MemberQuery) (*models.MemberPage, string, error)
 	FetchByIDFunc               func(memberID string) (*models.Member, string, error)
 	UpdateFunc                  func(member *models.Member, updateFields interface{}) (*models.Member, string, error)
 	DeleteFunc                  func(member *models.Member) (*models.Member, string, error)
 	FetchMemberByUserIDFunc     func(userID uint) (*models.Member, string, error)
+	ChangeStatusFunc            func(memberID uint, status string, changedBy uint, remarks string) (*models.Member, string, error)
+	ImportMembersFunc           func(rows []models.MemberImportRow, branchScope *uint, importedBy uint, dryRun bool) ([]models.MemberImportResult, string, error)
 }
 
 func (m *mockMemberRepo) CreateMemberWithSavings(member *models.Member, savings *models.Savings) (*models.Member, *models.Savings, string, error) {
 	return m.CreateMemberWithSavingsFunc(member, savings)
 }
-func (m *mockMemberRepo) FetchAll(members []models.Member) ([]models.Member, string, error) {
-	return m.FetchAllFunc(members)
+func (m *mockMemberRepo) FetchPage(query models.MemberQuery) (*models.MemberPage, string, error) {
+	return m.FetchPageFunc(query)
 }
 func (m *mockMemberRepo) FetchByID(memberID string) (*models.Member, string, error) {
 	return m.FetchByIDFunc(memberID)
@@ -45,6 +48,21 @@ func (m *mockMemberRepo) Delete(member *models.Member) (*models.Member, string,
 func (m *mockMemberRepo) FetchMemberByUserID(userID uint) (*models.Member, string, error) {
 	return m.FetchMemberByUserIDFunc(userID)
 }
+func (m *mockMemberRepo) ChangeStatus(memberID uint, status string, changedBy uint, remarks string) (*models.Member, string, error) {
+	return m.ChangeStatusFunc(memberID, status, changedBy, remarks)
+}
+
+func (m *mockMemberRepo) ImportMembers(rows []models.MemberImportRow, branchScope *uint, importedBy uint, dryRun bool) ([]models.MemberImportResult, string, error) {
+	return m.ImportMembersFunc(rows, branchScope, importedBy, dryRun)
+}
+
+// memberApplication is a complete membership application body
+func memberApplication() map[string]interface{} {
+	return map[string]interface{}{
+		"name": "John Doe", "contact_info": "123456", "phone": "+234 803 000 0000", "address": "12 Broad Street, Lagos",
+		"date_of_birth": "1990-04-12", "id_type": "national_id", "id_number": "12345678901",
+	}
+}
 
 func TestCreateMember_MissingFields(t *testing.T) {
 	gin.SetMode(gin.TestMode)
@@ -186,8 +204,7 @@ func TestCreateMember_RepoError(t *testing.T) {
 		c.Set("user", user)
 		h.CreateMember(c)
 	})
-	body := map[string]interface{}{"name": "John Doe", "contact_info": "123456"}
-	jsonBody, _ := json.Marshal(body)
+	jsonBody, _ := json.Marshal(memberApplication())
 	req, _ := http.NewRequest(http.MethodPost, "/members", bytes.NewBuffer(jsonBody))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
@@ -195,3 +212,312 @@ func TestCreateMember_RepoError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 	assert.Contains(t, w.Body.String(), "repo error")
 }
+
+func postMemberApplication(h *handlers.MemberHandler, body map[string]interface{}) *httptest.ResponseRecorder {
+	r := gin.Default()
+	r.POST("/members", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "member"
+		c.Set("user", user)
+		h.CreateMember(c)
+	})
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPost, "/members", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateMember_MissingKYC(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewMemberHandler(&mockMemberRepo{})
+	body := memberApplication()
+	delete(body, "id_number")
+	w := postMemberApplication(h, body)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "id number is required")
+
+	body = memberApplication()
+	body["id_type"] = "library_card"
+	w = postMemberApplication(h, body)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "id type must be")
+}
+
+func TestCreateMember_SubmitsApplication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var created models.Member
+	mockRepo := &mockMemberRepo{
+		CreateMemberWithSavingsFunc: func(member *models.Member, savings *models.Savings) (*models.Member, *models.Savings, string, error) {
+			created = *member
+			member.ID = 9
+			return member, savings, "member created successfully", nil
+		},
+	}
+	h := handlers.NewMemberHandler(mockRepo)
+	w := postMemberApplication(h, memberApplication())
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, models.MemberStatusApplied, created.Status)
+	assert.Equal(t, "+2348030000000", created.Phone)
+	assert.Contains(t, w.Body.String(), `"status":"applied","phone":"+2348030000000"`)
+	assert.Contains(t, w.Body.String(), `"date_of_birth":"1990-04-12","id_type":"national_id"`)
+}
+
+func TestApproveMember_InvalidTransition(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepo := &mockMemberRepo{
+		ChangeStatusFunc: func(memberID uint, status string, changedBy uint, remarks string) (*models.Member, string, error) {
+			return nil, "a member who is rejected cannot become active", models.ErrInvalidMemberTransition
+		},
+	}
+	h := handlers.NewMemberHandler(mockRepo)
+	r := gin.Default()
+	r.POST("/admins/members/:id/approve", adminContext(h.ApproveMember))
+	req, _ := http.NewRequest(http.MethodPost, "/admins/members/9/approve", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestRejectMember_RequiresReason(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewMemberHandler(&mockMemberRepo{})
+	r := gin.Default()
+	r.POST("/admins/members/:id/reject", adminContext(h.RejectMember))
+	req, _ := http.NewRequest(http.MethodPost, "/admins/members/9/reject", bytes.NewBuffer([]byte(`{}`)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "a reason for the rejection is required")
+}
+
+func TestSuspendMember_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var changedTo, reason string
+	mockRepo := &mockMemberRepo{
+		ChangeStatusFunc: func(memberID uint, status string, changedBy uint, remarks string) (*models.Member, string, error) {
+			changedTo, reason = status, remarks
+			member := models.Member{Name: "Test", Status: status}
+			member.ID = memberID
+			return &member, "member status updated successfully", nil
+		},
+	}
+	h := handlers.NewMemberHandler(mockRepo)
+	r := gin.Default()
+	r.POST("/admins/members/:id/suspend", adminContext(h.SuspendMember))
+	req, _ := http.NewRequest(http.MethodPost, "/admins/members/9/suspend", bytes.NewBuffer([]byte(`{"reason":"contributions in arrears"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, models.MemberStatusSuspended, changedTo)
+	assert.Equal(t, "contributions in arrears", reason)
+	assert.Contains(t, w.Body.String(), `"status":"suspended"`)
+}
+
+func TestSuspendMember_ByMemberNumber(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var changedID uint
+	number := "HQ26000174"
+	mockRepo := &mockMemberRepo{
+		FetchByIDFunc: func(memberID string) (*models.Member, string, error) {
+			if memberID != number {
+				return nil, "member not found", nil
+			}
+			member := models.Member{Name: "Test", Status: models.MemberStatusActive, MemberNumber: &number}
+			member.ID = 9
+			return &member, "member fetched successfully", nil
+		},
+		ChangeStatusFunc: func(memberID uint, status string, changedBy uint, remarks string) (*models.Member, string, error) {
+			changedID = memberID
+			member := models.Member{Name: "Test", Status: status, MemberNumber: &number}
+			member.ID = memberID
+			return &member, "member status updated successfully", nil
+		},
+	}
+	h := handlers.NewMemberHandler(mockRepo)
+	r := gin.Default()
+	r.POST("/admins/members/:id/suspend", adminContext(h.SuspendMember))
+	req, _ := http.NewRequest(http.MethodPost, "/admins/members/HQ26000174/suspend", bytes.NewBuffer([]byte(`{"reason":"contributions in arrears"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, uint(9), changedID)
+	assert.Contains(t, w.Body.String(), `"member_number":"HQ26000174"`)
+
+	req, _ = http.NewRequest(http.MethodPost, "/admins/members/HQ26000182/suspend", bytes.NewBuffer([]byte(`{"reason":"contributions in arrears"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetAllMembers_FiltersAndPages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var received models.MemberQuery
+	mockRepo := &mockMemberRepo{
+		FetchPageFunc: func(query models.MemberQuery) (*models.MemberPage, string, error) {
+			received = query
+			member := models.Member{Name: "Ada Obi", Status: models.MemberStatusActive}
+			member.ID = 12
+			page := models.MemberPage{Members: []models.Member{member}, Total: 41}
+			page.NextCursor = models.NewMemberCursor(&query, &page.Members[0], false)
+			return &page, "members fetched successfully", nil
+		},
+	}
+	h := handlers.NewMemberHandler(mockRepo)
+	r := gin.Default()
+	r.GET("/admins/members", adminContext(h.GetAllMembers))
+	req, _ := http.NewRequest(http.MethodGet, "/admins/members?q=ada&status=active,suspended&joined_to=2025-06-30&sort=name&order=desc&limit=1", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ada", received.Search)
+	assert.Equal(t, []string{models.MemberStatusActive, models.MemberStatusSuspended}, received.Statuses)
+	assert.Equal(t, "2025-07-01", received.JoinedTo.Format(time.DateOnly))
+	assert.Equal(t, models.MemberSortName, received.Sort)
+	assert.True(t, received.Descending)
+	assert.Equal(t, 1, received.Limit)
+
+	var body struct {
+		Data struct {
+			Pagination struct {
+				Total      int64  `json:"total"`
+				NextCursor string `json:"next_cursor"`
+			} `json:"pagination"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+	assert.Equal(t, int64(41), body.Data.Pagination.Total)
+
+	// The cursor brings back the same sort and continues after member 12
+	req, _ = http.NewRequest(http.MethodGet, "/admins/members?sort=name&order=desc&cursor="+body.Data.Pagination.NextCursor, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, uint(12), received.Cursor.ID)
+	assert.Equal(t, "Ada Obi", received.Cursor.Name)
+}
+
+func TestGetAllMembers_CursorForAnotherSort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewMemberHandler(&mockMemberRepo{})
+	r := gin.Default()
+	r.GET("/admins/members", adminContext(h.GetAllMembers))
+	cursor := (&models.MemberCursor{Sort: models.MemberSortName, ID: 12, Name: "Ada Obi"}).Encode()
+	req, _ := http.NewRequest(http.MethodGet, "/admins/members?sort=joined&cursor="+cursor, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid cursor, it was issued for a different sort order")
+}
+
+const memberImportCSV = `email,name,contact_info,phone,address,date_of_birth,id_type,id_number,employer
+ada@example.com,Ada Obi,Accounts,+234 803 000 0001,12 Broad Street,1990-04-12,national_id,111,Acme
+bola@example.com,Bola Ade,Stores,0803-000-0002,3 Marina,1988-01-30,passport,222,Acme
+not-an-email,,Sales,0803,4 Marina,30/01/1988,passport,333,Acme
+ada@example.com,Ada Again,Accounts,08030000001,12 Broad Street,1990-04-12,national_id,111,Acme
+`
+
+func TestImportMembers_DryRunReportsEveryLine(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var imported []models.MemberImportRow
+	var dryRun bool
+	mockRepo := &mockMemberRepo{
+		ImportMembersFunc: func(rows []models.MemberImportRow, branchScope *uint, importedBy uint, isDryRun bool) ([]models.MemberImportResult, string, error) {
+			imported, dryRun = rows, isDryRun
+			// bola was imported by an earlier run
+			return []models.MemberImportResult{
+				{Line: rows[0].Line, Email: rows[0].Email, Status: models.MemberImportWouldCreate, UserCreated: true},
+				{Line: rows[1].Line, Email: rows[1].Email, Status: models.MemberImportSkipped, MemberID: 7},
+			}, "import checked successfully", nil
+		},
+	}
+	h := handlers.NewMemberHandler(mockRepo)
+	r := gin.Default()
+	r.POST("/admins/members/import", adminContext(h.ImportMembers))
+	req, _ := http.NewRequest(http.MethodPost, "/admins/members/import?dry_run=true", bytes.NewBufferString(memberImportCSV))
+	req.Header.Set("Content-Type", "text/csv")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, dryRun)
+	assert.Len(t, imported, 2)
+	assert.Equal(t, "+2348030000001", imported[0].Member.Phone)
+	assert.Equal(t, models.MemberStatusApplied, imported[0].Member.Status)
+
+	var body struct {
+		Data struct {
+			Report models.MemberImportReport `json:"report"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+	report := body.Data.Report
+	assert.Equal(t, 4, report.Total)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, 2, report.Invalid)
+	if assert.Len(t, report.Rows, 4) {
+		assert.Equal(t, 4, report.Rows[2].Line)
+		assert.Contains(t, report.Rows[2].Errors, "email is not a valid address")
+		assert.Contains(t, report.Rows[2].Errors, "name is required")
+		assert.Contains(t, report.Rows[2].Errors, "date of birth must be in YYYY-MM-DD format")
+		assert.Equal(t, []string{"email is repeated from line 2"}, report.Rows[3].Errors)
+		assert.Empty(t, report.Rows[0].TemporaryPassword)
+	}
+}
+
+func TestImportMembers_MissingColumns(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewMemberHandler(&mockMemberRepo{})
+	r := gin.Default()
+	r.POST("/admins/members/import", adminContext(h.ImportMembers))
+	req, _ := http.NewRequest(http.MethodPost, "/admins/members/import", bytes.NewBufferString("email,name\nada@example.com,Ada Obi\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "missing columns contact_info, phone, address, date_of_birth, id_type, id_number")
+}
+
+func TestImportMembers_BranchAdminImportsIntoOwnBranchWithoutPasswords(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var imported []models.MemberImportRow
+	var scope *uint
+	mockRepo := &mockMemberRepo{
+		ImportMembersFunc: func(rows []models.MemberImportRow, branchScope *uint, importedBy uint, isDryRun bool) ([]models.MemberImportResult, string, error) {
+			imported, scope = rows, branchScope
+			results := make([]models.MemberImportResult, len(rows))
+			for i, row := range rows {
+				results[i] = models.MemberImportResult{Line: row.Line, Email: row.Email, Status: models.MemberImportCreated, MemberID: uint(i + 1), UserCreated: true}
+			}
+			return results, "members imported successfully", nil
+		},
+	}
+	h := handlers.NewMemberHandler(mockRepo)
+	r := gin.Default()
+	r.POST("/admins/members/import", branchAdminContext(4, h.ImportMembers))
+	csv := "email,name,contact_info,phone,address,date_of_birth,id_type,id_number,branch,group\n" +
+		"ada@example.com,Ada Obi,Accounts,08030000001,12 Broad Street,1990-04-12,national_id,111,,\n" +
+		"bola@example.com,Bola Ade,Stores,08030000002,3 Marina,1988-01-30,passport,222,ikj,Acme Staff\n"
+	req, _ := http.NewRequest(http.MethodPost, "/admins/members/import", bytes.NewBufferString(csv))
+	req.Header.Set("Content-Type", "text/csv")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	if assert.NotNil(t, scope) {
+		assert.Equal(t, uint(4), *scope)
+	}
+	if assert.Len(t, imported, 2) {
+		assert.Equal(t, uint(4), *imported[0].Member.BranchID)
+		assert.Empty(t, imported[0].BranchCode)
+		assert.Equal(t, "IKJ", imported[1].BranchCode)
+		assert.Equal(t, "Acme Staff", imported[1].GroupName)
+	}
+	assert.Contains(t, w.Body.String(), `"user_created":true`)
+	assert.NotContains(t, w.Body.String(), "temporary_password")
+}
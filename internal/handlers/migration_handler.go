@@ -0,0 +1,450 @@
+package handlers
+
+import (
+	"cooperative-system/internal/imports"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+	"cooperative-system/pkg/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// MigrationRequest is a batch exported from the legacy system. Every date is YYYY-MM-DD.
+type MigrationRequest struct {
+	Reference string `json:"reference" binding:"required"`
+	Source    string `json:"source"`
+	AsOf      string `json:"as_of" binding:"required"` // the date the legacy balances were taken
+	// ControlTotals are the legacy system's own totals per currency, to reconcile the batch against
+	ControlTotals []MigrationControlTotalRequest `json:"control_totals"`
+	Members       []MigrationMemberRequest       `json:"members" binding:"required"`
+}
+
+type MigrationControlTotalRequest struct {
+	Currency         string        `json:"currency"`
+	Savings          *models.Money `json:"savings"`
+	LoansOutstanding *models.Money `json:"loans_outstanding"`
+}
+
+type MigrationMemberRequest struct {
+	LegacyReference string `json:"legacy_reference"`
+	Email           string `json:"email"`
+	JoinedAt        string `json:"joined_at"` // defaults to the batch date
+	MemberRequestBody
+	Savings []MigrationSavingsRequest `json:"savings"`
+	Loans   []MigrationLoanRequest    `json:"loans"`
+}
+
+type MigrationSavingsRequest struct {
+	Currency       string                        `json:"currency"`
+	OpeningBalance models.Money                  `json:"opening_balance"`
+	OpeningDate    string                        `json:"opening_date"` // defaults to the batch date
+	Transactions   []MigrationTransactionRequest `json:"transactions"`
+	ClosingBalance *models.Money                 `json:"closing_balance"`
+}
+
+// MigrationTransactionRequest is a legacy savings transaction after the opening balance. The amount is
+// always positive, withdrawals and fees are taken off the balance.
+type MigrationTransactionRequest struct {
+	Date        string       `json:"date"`
+	Type        string       `json:"type"`
+	Amount      models.Money `json:"amount"`
+	Description string       `json:"description"`
+	Reference   string       `json:"reference"`
+}
+
+// MigrationLoanRequest is a loan still running in the legacy system. The interest rate and total repayable
+// default to what this system would work out. Without a remaining schedule, what was paid to date is taken
+// off the equal monthly installments from the disbursement date.
+type MigrationLoanRequest struct {
+	LegacyReference      string                        `json:"legacy_reference"`
+	Type                 string                        `json:"type"`
+	Description          string                        `json:"description"`
+	Currency             string                        `json:"currency"`
+	Amount               models.Money                  `json:"amount"`
+	InterestRate         *float64                      `json:"interest_rate"`
+	LoanTermMonths       uint                          `json:"loan_term_months"`
+	DisbursedAt          string                        `json:"disbursed_at"`
+	TotalRepayableAmount *models.Money                 `json:"total_repayable_amount"`
+	PaidToDate           models.Money                  `json:"paid_to_date"`
+	LastPaymentAt        string                        `json:"last_payment_at"` // defaults to the batch date
+	RemainingSchedule    []MigrationInstallmentRequest `json:"remaining_schedule"`
+}
+
+type MigrationInstallmentRequest struct {
+	DueDate string       `json:"due_date"`
+	Amount  models.Money `json:"amount"`
+}
+
+// migrationTransactionTypes are the legacy transactions that can follow an opening balance, and whether
+// they take money off it
+var migrationTransactionTypes = map[string]bool{
+	models.TransactionTypeDeposit:    false,
+	models.TransactionTypeInterest:   false,
+	models.TransactionTypeDividend:   false,
+	models.TransactionTypeWithdrawal: true,
+	models.TransactionTypeFee:        true,
+}
+
+type MigrationHandler struct {
+	repo repository.MigrationRepository
+}
+
+func NewMigrationHandler(migrationRepo repository.MigrationRepository) *MigrationHandler {
+	return &MigrationHandler{
+		repo: migrationRepo,
+	}
+}
+
+type MigrationService interface {
+	ImportMigration(c *gin.Context)
+	GetMigrationBatches(c *gin.Context)
+	GetMigrationReconciliation(c *gin.Context)
+}
+
+// migrationDate reads an optional date, falling back to the batch date
+func migrationDate(value string, fallback time.Time, field string, problems *[]string) time.Time {
+	if value == "" {
+		return fallback
+	}
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		*problems = append(*problems, field+" must be in YYYY-MM-DD format")
+		return fallback
+	}
+	return date
+}
+
+// migrationMember turns one member of the request into what the repository loads, listing every problem
+// with it
+func migrationMember(reqBody *MigrationMemberRequest, asOf time.Time) (models.MigrationMember, []string) {
+	var problems []string
+	migration := models.MigrationMember{
+		LegacyReference: strings.TrimSpace(reqBody.LegacyReference),
+		Email:           strings.TrimSpace(reqBody.Email),
+		Member: models.Member{
+			Name:        strings.TrimSpace(reqBody.Name),
+			ContactInfo: strings.TrimSpace(reqBody.ContactInfo),
+		},
+	}
+	if err := applyKYC(&migration.Member, &reqBody.MemberRequestBody); err != nil {
+		problems = append(problems, err.Error())
+	}
+	joinedAt := migrationDate(reqBody.JoinedAt, asOf, "joined date", &problems)
+	migration.Member.CreatedAt = joinedAt
+	migration.Member.ReviewedAt = &joinedAt
+	migration.Member.ApprovedAt = &joinedAt
+
+	for _, savingsBody := range reqBody.Savings {
+		currency, err := models.NormalizeCurrency(savingsBody.Currency)
+		if err != nil {
+			problems = append(problems, "savings: "+err.Error())
+			continue
+		}
+		savings := models.MigrationSavings{
+			Currency:       currency,
+			OpeningBalance: savingsBody.OpeningBalance,
+			OpeningDate:    migrationDate(savingsBody.OpeningDate, asOf, "savings opening date", &problems),
+			ClosingBalance: savingsBody.ClosingBalance,
+		}
+		for _, transactionBody := range savingsBody.Transactions {
+			transactionType := strings.ToLower(strings.TrimSpace(transactionBody.Type))
+			debit, ok := migrationTransactionTypes[transactionType]
+			if !ok {
+				problems = append(problems, "savings transaction type must be deposit, withdrawal, interest, dividend or fee")
+				continue
+			}
+			if transactionBody.Amount <= 0 {
+				problems = append(problems, "savings transaction amounts must be greater than zero")
+				continue
+			}
+			if transactionBody.Date == "" {
+				problems = append(problems, "savings transaction date is required")
+				continue
+			}
+			transaction := models.SavingTransaction{
+				Amount:            transactionBody.Amount,
+				Description:       transactionBody.Description,
+				Type:              transactionType,
+				ExternalReference: transactionBody.Reference,
+			}
+			if debit {
+				transaction.Amount = -transaction.Amount
+			}
+			if transaction.Description == "" {
+				transaction.Description = "Legacy " + transactionType
+			}
+			transaction.CreatedAt = migrationDate(transactionBody.Date, asOf, "savings transaction date", &problems)
+			savings.Transactions = append(savings.Transactions, transaction)
+		}
+		migration.Savings = append(migration.Savings, savings)
+	}
+
+	for _, loanBody := range reqBody.Loans {
+		migrated, loanProblems := migrationLoan(&loanBody, asOf)
+		problems = append(problems, loanProblems...)
+		if len(loanProblems) == 0 {
+			migration.Loans = append(migration.Loans, migrated)
+		}
+	}
+
+	return migration, append(problems, migration.Validate(asOf)...)
+}
+
+// migrationLoan builds a running loan as it stood in the legacy system
+func migrationLoan(loanBody *MigrationLoanRequest, asOf time.Time) (models.MigrationLoan, []string) {
+	var problems []string
+	reference := strings.TrimSpace(loanBody.LegacyReference)
+	if reference == "" {
+		return models.MigrationLoan{}, []string{"loan legacy reference is required"}
+	}
+	prefix := "loan " + reference + ": "
+
+	currency, err := models.NormalizeCurrency(loanBody.Currency)
+	if err != nil {
+		problems = append(problems, prefix+err.Error())
+	}
+	if loanBody.DisbursedAt == "" {
+		problems = append(problems, prefix+"disbursement date is required")
+	}
+	disbursedAt := migrationDate(loanBody.DisbursedAt, asOf, prefix+"disbursement date", &problems)
+	lastPaymentAt := migrationDate(loanBody.LastPaymentAt, asOf, prefix+"last payment date", &problems)
+	if loanBody.LoanTermMonths == 0 {
+		problems = append(problems, prefix+"term must be at least one month")
+	}
+	if len(problems) > 0 {
+		return models.MigrationLoan{}, problems
+	}
+
+	loanType := strings.ToLower(strings.TrimSpace(loanBody.Type))
+	interestRate := models.GetInterestRate(loanType, loanBody.LoanTermMonths)
+	if loanBody.InterestRate != nil {
+		interestRate = *loanBody.InterestRate
+	}
+	total, err := models.CalculateTotalRepayableAmount(loanBody.Amount, interestRate, loanBody.LoanTermMonths)
+	if err != nil {
+		return models.MigrationLoan{}, []string{prefix + err.Error()}
+	}
+	if loanBody.TotalRepayableAmount != nil {
+		total = *loanBody.TotalRepayableAmount
+	}
+	installment, err := models.CalculateInstallmentAmount(total, loanBody.LoanTermMonths)
+	if err != nil {
+		return models.MigrationLoan{}, []string{prefix + err.Error()}
+	}
+
+	migrated := models.MigrationLoan{
+		Loan: models.Loan{
+			Description:          loanBody.Description,
+			Type:                 loanType,
+			Amount:               loanBody.Amount,
+			Currency:             currency,
+			InterestRate:         interestRate,
+			LoanTermMonths:       loanBody.LoanTermMonths,
+			Status:               models.LoanStatusDisbursed,
+			IsActive:             true,
+			ApprovalDate:         &disbursedAt,
+			InstallmentAmount:    installment,
+			TotalRepayableAmount: total,
+			SubmittedAt:          disbursedAt,
+			ReviewedAt:           &disbursedAt,
+			ApprovedAt:           &disbursedAt,
+			DisbursedAt:          &disbursedAt,
+			LegacyReference:      reference,
+		},
+		PaidToDate:    loanBody.PaidToDate,
+		LastPaymentAt: lastPaymentAt,
+	}
+	if migrated.Loan.Description == "" {
+		migrated.Loan.Description = "Migrated loan " + reference
+	}
+
+	if len(loanBody.RemainingSchedule) == 0 {
+		migrated.Schedule, err = models.RemainingSchedule(total, loanBody.LoanTermMonths, disbursedAt, loanBody.PaidToDate)
+		if err != nil {
+			return models.MigrationLoan{}, []string{prefix + err.Error()}
+		}
+	}
+	for i, installmentBody := range loanBody.RemainingSchedule {
+		if installmentBody.DueDate == "" {
+			problems = append(problems, prefix+"installment due date is required")
+			break
+		}
+		migrated.Schedule = append(migrated.Schedule, models.LoanInstallment{
+			Number:  i + 1,
+			DueDate: migrationDate(installmentBody.DueDate, asOf, prefix+"installment due date", &problems),
+			Amount:  installmentBody.Amount,
+		})
+	}
+	return migrated, problems
+}
+
+// ImportMigration loads a batch of members, opening savings balances and running loans from the legacy system.
+// The whole batch is checked first and nothing is loaded if any member has a problem. With ?dry_run=true the
+// batch is checked and reconciled without loading it.
+func (h *MigrationHandler) ImportMigration(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermMembersImport) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can migrate legacy records", nil)
+		return
+	}
+
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "dry_run must be true or false", err)
+		return
+	}
+
+	var reqBody MigrationRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+	asOf, err := time.Parse(time.DateOnly, reqBody.AsOf)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "as_of must be in YYYY-MM-DD format", err)
+		return
+	}
+
+	var controls []models.MigrationTotal
+	for _, control := range reqBody.ControlTotals {
+		currency, err := models.NormalizeCurrency(control.Currency)
+		if err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "control totals: "+err.Error(), err)
+			return
+		}
+		controls = append(controls, models.MigrationTotal{Currency: currency, ControlSavings: control.Savings, ControlLoansOutstanding: control.LoansOutstanding})
+	}
+
+	members := make([]models.MigrationMember, len(reqBody.Members))
+	var invalid []models.MigrationMemberErrors
+	emails, references, loanReferences := map[string]int{}, map[string]int{}, map[string]int{}
+	for i := range reqBody.Members {
+		migration, problems := migrationMember(&reqBody.Members[i], asOf)
+		if first, ok := emails[strings.ToLower(migration.Email)]; ok && migration.Email != "" {
+			problems = append(problems, fmt.Sprintf("email is repeated from member %d", first))
+		}
+		if first, ok := references[migration.LegacyReference]; ok && migration.LegacyReference != "" {
+			problems = append(problems, fmt.Sprintf("legacy reference is repeated from member %d", first))
+		}
+		emails[strings.ToLower(migration.Email)], references[migration.LegacyReference] = i, i
+		for _, loan := range migration.Loans {
+			if first, ok := loanReferences[loan.Loan.LegacyReference]; ok {
+				problems = append(problems, fmt.Sprintf("loan %s is repeated from member %d", loan.Loan.LegacyReference, first))
+			}
+			loanReferences[loan.Loan.LegacyReference] = i
+		}
+
+		if len(problems) > 0 {
+			invalid = append(invalid, models.MigrationMemberErrors{Index: i, LegacyReference: migration.LegacyReference, Errors: problems})
+		}
+		members[i] = migration
+	}
+	if len(invalid) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "some members of the batch are invalid, nothing was migrated", "members": invalid})
+		return
+	}
+
+	passwords := make([]string, len(members))
+	for i := range members {
+		if dryRun {
+			members[i].PasswordHash = "dry-run"
+			continue
+		}
+		password, hash, err := imports.TemporaryPassword()
+		if err != nil {
+			utils.RespondWithError(c, http.StatusInternalServerError, "failed to create temporary passwords", err)
+			return
+		}
+		passwords[i], members[i].PasswordHash = password, hash
+	}
+
+	batch := models.MigrationBatch{
+		Reference:  strings.TrimSpace(reqBody.Reference),
+		Source:     reqBody.Source,
+		AsOf:       asOf,
+		ImportedBy: authUser.ID,
+		Totals:     models.CalculateMigrationTotals(members, controls),
+	}
+	reconciliation, conflicts, msg, err := h.repo.ImportMigration(&batch, members, dryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrMigrationConflicts):
+			c.JSON(http.StatusConflict, gin.H{"error": msg, "members": conflicts})
+		case errors.Is(err, repository.ErrMigrationBatchExists):
+			utils.RespondWithError(c, http.StatusConflict, msg, err)
+		default:
+			utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		}
+		return
+	}
+
+	migrated := make([]gin.H, len(members))
+	for i := range members {
+		migrated[i] = gin.H{
+			"legacy_reference": members[i].LegacyReference,
+			"email":            members[i].Email,
+		}
+		if !dryRun {
+			migrated[i]["member_id"] = members[i].Member.ID
+			if members[i].UserCreated {
+				migrated[i]["temporary_password"] = passwords[i]
+			}
+		}
+	}
+
+	status := http.StatusCreated
+	response := gin.H{"reconciliation": reconciliation, "members": migrated}
+	if dryRun {
+		status = http.StatusOK
+	} else {
+		response["batch"] = models.NewMigrationBatchResponse(&batch)
+	}
+	utils.SuccessResponse(c, status, msg, "data", response)
+}
+
+// GetMigrationBatches lists the batches loaded from the legacy system
+func (h *MigrationHandler) GetMigrationBatches(c *gin.Context) {
+	batches, msg, err := h.repo.GetMigrationBatches()
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	responses := make([]models.MigrationBatchResponse, len(batches))
+	for i := range batches {
+		responses[i] = models.NewMigrationBatchResponse(&batches[i])
+	}
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"batches": responses,
+	})
+}
+
+// GetMigrationReconciliation compares a batch's control and imported totals with what is posted for it
+func (h *MigrationHandler) GetMigrationReconciliation(c *gin.Context) {
+	batchID, err := strconv.ParseUint(c.Param("batch_id"), 10, 64)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid migration batch ID", err)
+		return
+	}
+
+	reconciliation, msg, err := h.repo.ReconcileMigration(uint(batchID))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			status = http.StatusNotFound
+		}
+		utils.RespondWithError(c, status, msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"reconciliation": reconciliation,
+	})
+}
@@ -0,0 +1,132 @@
+// Unit tests for MigrationHandler endpoints
+package handlers_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cooperative-system/internal/handlers"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockMigrationRepo struct {
+	repository.MigrationRepository
+	ImportMigrationFunc func(batch *models.MigrationBatch, members []models.MigrationMember, dryRun bool) (*models.MigrationReconciliation, []models.MigrationMemberErrors, string, error)
+}
+
+func (m *mockMigrationRepo) ImportMigration(batch *models.MigrationBatch, members []models.MigrationMember, dryRun bool) (*models.MigrationReconciliation, []models.MigrationMemberErrors, string, error) {
+	return m.ImportMigrationFunc(batch, members, dryRun)
+}
+
+// legacyMigration is a batch of one member with 530.00 of savings and a 1,200.00 personal loan over 12 months,
+// 1,242.00 with interest, of which 311.50 is paid: three installments of 103.50 and 1.00 of the fourth
+const legacyMigration = `{
+	"reference": "legacy-2025-09", "source": "CoopBooks", "as_of": "2025-09-30",
+	"control_totals": [{"currency": "NGN", "savings": "530.00", "loans_outstanding": "930.50"}],
+	"members": [{
+		"legacy_reference": "M-0042", "email": "ada@example.com", "name": "Ada Obi", "contact_info": "Accounts",
+		"phone": "0803 000 0001", "joined_at": "2015-03-01",
+		"savings": [{
+			"currency": "NGN", "opening_balance": "500.00", "opening_date": "2024-01-31", "closing_balance": "530.00",
+			"transactions": [
+				{"date": "2024-05-01", "type": "deposit", "amount": "50.00"},
+				{"date": "2024-06-01", "type": "withdrawal", "amount": "20.00", "reference": "WD-88"}
+			]
+		}],
+		"loans": [{
+			"legacy_reference": "L-9", "type": "personal", "amount": "1200.00", "loan_term_months": 12,
+			"disbursed_at": "2025-01-15", "paid_to_date": "311.50", "last_payment_at": "2025-05-15"
+		}]
+	}]
+}`
+
+func TestImportMigration_DryRunBuildsLegacyPositions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var received []models.MigrationMember
+	var receivedBatch models.MigrationBatch
+	mockRepo := &mockMigrationRepo{
+		ImportMigrationFunc: func(batch *models.MigrationBatch, members []models.MigrationMember, dryRun bool) (*models.MigrationReconciliation, []models.MigrationMemberErrors, string, error) {
+			assert.True(t, dryRun)
+			received, receivedBatch = members, *batch
+			reconciliation := batch.Reconcile(map[string]models.Money{"NGN": 53000}, map[string]models.Money{"NGN": 93050})
+			return &reconciliation, nil, "migration checked successfully", nil
+		},
+	}
+	h := handlers.NewMigrationHandler(mockRepo)
+	r := gin.Default()
+	r.POST("/admins/migrations", adminContext(h.ImportMigration))
+	req, _ := http.NewRequest(http.MethodPost, "/admins/migrations?dry_run=true", bytes.NewBufferString(legacyMigration))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	if assert.Len(t, received, 1) {
+		migration := received[0]
+		assert.Equal(t, "2015-03-01", migration.Member.CreatedAt.Format(time.DateOnly))
+		assert.Equal(t, "08030000001", migration.Member.Phone)
+		assert.Equal(t, models.Money(53000), migration.Savings[0].Balance())
+		assert.Equal(t, models.Money(-2000), migration.Savings[0].Transactions[1].Amount)
+
+		loan := migration.Loans[0]
+		assert.Equal(t, models.Money(124200), loan.Loan.TotalRepayableAmount)
+		assert.Equal(t, models.LoanStatusDisbursed, loan.Loan.Status)
+		assert.Equal(t, "L-9", loan.Loan.LegacyReference)
+		assert.Equal(t, models.Money(93050), loan.Outstanding())
+		if assert.Len(t, loan.Schedule, 9) {
+			assert.Equal(t, 4, loan.Schedule[0].Number)
+			assert.Equal(t, "2025-05-15", loan.Schedule[0].DueDate.Format(time.DateOnly))
+			assert.Equal(t, models.Money(10250), loan.Schedule[0].Amount)
+		}
+	}
+	assert.Equal(t, []models.MigrationTotal{{Currency: "NGN", ControlSavings: receivedBatch.Totals[0].ControlSavings, ControlLoansOutstanding: receivedBatch.Totals[0].ControlLoansOutstanding, ImportedSavings: 53000, ImportedLoansOutstanding: 93050}}, receivedBatch.Totals)
+	assert.Contains(t, w.Body.String(), `"savings_difference":0.00`)
+	assert.Contains(t, w.Body.String(), `"balanced":true`)
+	assert.NotContains(t, w.Body.String(), "temporary_password")
+}
+
+func TestImportMigration_RejectsWholeBatchWithInvalidMember(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewMigrationHandler(&mockMigrationRepo{})
+	r := gin.Default()
+	r.POST("/admins/migrations", adminContext(h.ImportMigration))
+	body := `{"reference": "legacy-2025-09", "as_of": "2025-09-30", "members": [{
+		"legacy_reference": "M-1", "email": "bola@example.com", "name": "Bola Ade", "contact_info": "Stores",
+		"savings": [{"currency": "NGN", "opening_balance": "100.00", "closing_balance": "90.00"}],
+		"loans": [{"legacy_reference": "L-1", "type": "personal", "amount": "1200.00", "loan_term_months": 12,
+			"disbursed_at": "2025-01-15", "paid_to_date": "100.00", "remaining_schedule": [{"due_date": "2025-10-15", "amount": "500.00"}]}]
+	}]}`
+	req, _ := http.NewRequest(http.MethodPost, "/admins/migrations", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "nothing was migrated")
+	assert.Contains(t, w.Body.String(), "savings NGN: closing balance 90.00 does not match the opening balance and transactions, which come to 100.00")
+	assert.Contains(t, w.Body.String(), "loan L-1: remaining schedule adds up to 500.00, but 1142.00 is outstanding")
+}
+
+func TestImportMigration_BatchAlreadyImported(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepo := &mockMigrationRepo{
+		ImportMigrationFunc: func(batch *models.MigrationBatch, members []models.MigrationMember, dryRun bool) (*models.MigrationReconciliation, []models.MigrationMemberErrors, string, error) {
+			return nil, nil, repository.ErrMigrationBatchExists.Error(), repository.ErrMigrationBatchExists
+		},
+	}
+	h := handlers.NewMigrationHandler(mockRepo)
+	r := gin.Default()
+	r.POST("/admins/migrations", adminContext(h.ImportMigration))
+	req, _ := http.NewRequest(http.MethodPost, "/admins/migrations?dry_run=true", bytes.NewBufferString(legacyMigration))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "already been imported")
+}
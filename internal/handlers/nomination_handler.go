@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+	"cooperative-system/pkg/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NominationRequest struct {
+	Name         string  `json:"name"`
+	Relationship string  `json:"relationship"` // e.g. spouse, child, parent, sibling
+	Phone        string  `json:"phone"`
+	Email        string  `json:"email"`
+	Address      string  `json:"address"`
+	Allocation   float64 `json:"allocation"` // percentage, e.g. 50 for 50%
+}
+
+// NominationsRequest replaces all of a member's nominations. Each list that is sent must allocate 100%.
+type NominationsRequest struct {
+	NextOfKin     []NominationRequest `json:"next_of_kin"`
+	Beneficiaries []NominationRequest `json:"beneficiaries"`
+}
+
+type NominationHandler struct {
+	repo       repository.NominationRepository
+	memberRepo repository.MemberRepository
+}
+
+func NewNominationHandler(nominationRepo repository.NominationRepository, memberRepo repository.MemberRepository) *NominationHandler {
+	return &NominationHandler{
+		repo:       nominationRepo,
+		memberRepo: memberRepo,
+	}
+}
+
+type NominationService interface {
+	GetNominations(c *gin.Context)
+	SetNominations(c *gin.Context)
+}
+
+// newNomination copies a requested nomination of the given type, trimming what people type
+func newNomination(nominationType string, reqBody NominationRequest, updatedBy uint) models.Nomination {
+	return models.Nomination{
+		Type:         nominationType,
+		Name:         strings.TrimSpace(reqBody.Name),
+		Relationship: strings.ToLower(strings.TrimSpace(reqBody.Relationship)),
+		Phone:        models.NormalizePhone(reqBody.Phone),
+		Email:        strings.TrimSpace(reqBody.Email),
+		Address:      strings.TrimSpace(reqBody.Address),
+		Allocation:   reqBody.Allocation,
+		UpdatedBy:    updatedBy,
+	}
+}
+
+// GetNominations lists a member's next of kin and beneficiaries, to the member or an admin
+func (h *NominationHandler) GetNominations(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+
+	member, err := getMemberByIDAndAuthorize(c, h.memberRepo, &authUser, models.PermMembersRead)
+	if err != nil {
+		return
+	}
+
+	nominations, msg, err := h.repo.GetNominationsByMemberID(member.ID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	nextOfKin, beneficiaries := models.NewNominationResponses(nominations)
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"next_of_kin":   nextOfKin,
+		"beneficiaries": beneficiaries,
+	})
+}
+
+// SetNominations replaces the member's next of kin and beneficiaries. Only the member can change them.
+func (h *NominationHandler) SetNominations(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+
+	member, err := getMemberByIDAndAuthorize(c, h.memberRepo, &authUser, models.PermMembersManage)
+	if err != nil {
+		return
+	}
+	if member.UserID != authUser.ID {
+		utils.RespondWithError(c, http.StatusForbidden, "members manage their own nominations", nil)
+		return
+	}
+
+	var reqBody NominationsRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	nominations := make([]models.Nomination, 0, len(reqBody.NextOfKin)+len(reqBody.Beneficiaries))
+	for _, nomination := range reqBody.NextOfKin {
+		nominations = append(nominations, newNomination(models.NominationTypeNextOfKin, nomination, authUser.ID))
+	}
+	for _, nomination := range reqBody.Beneficiaries {
+		nominations = append(nominations, newNomination(models.NominationTypeBeneficiary, nomination, authUser.ID))
+	}
+	for i := range nominations {
+		if err := nominations[i].Validate(); err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, nominations[i].Type+": "+err.Error(), err)
+			return
+		}
+	}
+	if err := models.ValidateAllocations(nominations); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	saved, msg, err := h.repo.ReplaceNominations(member.ID, nominations)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	nextOfKin, beneficiaries := models.NewNominationResponses(saved)
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"next_of_kin":   nextOfKin,
+		"beneficiaries": beneficiaries,
+	})
+}
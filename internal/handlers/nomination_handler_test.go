@@ -0,0 +1,94 @@
+// Unit tests for NominationHandler endpoints
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cooperative-system/internal/handlers"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockNominationRepo struct {
+	repository.NominationRepository
+	ReplaceNominationsFunc func(memberID uint, nominations []models.Nomination) ([]models.Nomination, string, error)
+}
+
+func (m *mockNominationRepo) ReplaceNominations(memberID uint, nominations []models.Nomination) ([]models.Nomination, string, error) {
+	return m.ReplaceNominationsFunc(memberID, nominations)
+}
+
+func putNominations(h *handlers.NominationHandler, role string, body map[string]interface{}) *httptest.ResponseRecorder {
+	r := gin.Default()
+	r.PUT("/members/:id/nominations", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = role
+		if role == "admin" {
+			user.ID = 7
+		}
+		c.Set("user", user)
+		h.SetNominations(c)
+	})
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPut, "/members/1/nominations", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestSetNominations_AllocationsMustTotal100(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewNominationHandler(&mockNominationRepo{}, ownMemberRepo())
+	w := putNominations(h, "member", map[string]interface{}{
+		"beneficiaries": []map[string]interface{}{
+			{"name": "Ada Obi", "relationship": "spouse", "phone": "08030000001", "allocation": 60},
+			{"name": "Chidi Obi", "relationship": "child", "phone": "08030000002", "allocation": 30},
+		},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "allocations must total 100%, beneficiary allocations total 90.00%")
+}
+
+func TestSetNominations_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var saved []models.Nomination
+	mockRepo := &mockNominationRepo{
+		ReplaceNominationsFunc: func(memberID uint, nominations []models.Nomination) ([]models.Nomination, string, error) {
+			saved = nominations
+			return nominations, "nominations updated successfully", nil
+		},
+	}
+	h := handlers.NewNominationHandler(mockRepo, ownMemberRepo())
+	w := putNominations(h, "member", map[string]interface{}{
+		"next_of_kin": []map[string]interface{}{
+			{"name": "Ada Obi", "relationship": "Spouse", "phone": "0803 000 0001", "allocation": 100},
+		},
+		"beneficiaries": []map[string]interface{}{
+			{"name": "Ada Obi", "relationship": "spouse", "phone": "08030000001", "allocation": 33.34},
+			{"name": "Chidi Obi", "relationship": "child", "email": "chidi@example.com", "allocation": 66.66},
+		},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, saved, 3)
+	assert.Equal(t, "spouse", saved[0].Relationship)
+	assert.Equal(t, "08030000001", saved[0].Phone)
+	assert.Contains(t, w.Body.String(), `"next_of_kin":[{"id":0`)
+	assert.Contains(t, w.Body.String(), `"allocation":66.66`)
+}
+
+func TestSetNominations_AdminCannotChange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewNominationHandler(&mockNominationRepo{}, ownMemberRepo())
+	w := putNominations(h, "admin", map[string]interface{}{})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "members manage their own nominations")
+}
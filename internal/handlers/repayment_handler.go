@@ -1 +1,115 @@
 package handlers
+
+import (
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+	"cooperative-system/pkg/utils"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RepaymentRequest struct {
+	Amount   models.Money `json:"amount" binding:"required"`
+	Currency string       `json:"currency"` // defaults to the loan's currency
+	PaidAt   string       `json:"paid_at"`  // YYYY-MM-DD, defaults to now
+	Note     string       `json:"note"`
+	// Channel is how the repayment was received (cash, bank_transfer, card or online), defaults to online
+	Channel           string `json:"channel"`
+	ExternalReference string `json:"external_reference"`
+}
+
+type RepaymentHandler struct {
+	loanRepo repository.LoanRepository
+}
+
+func NewRepaymentHandler(loanRepo repository.LoanRepository) *RepaymentHandler {
+	return &RepaymentHandler{
+		loanRepo: loanRepo,
+	}
+}
+
+type RepaymentService interface {
+	RecordRepayment(c *gin.Context)
+}
+
+func (h *RepaymentHandler) RecordRepayment(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermRepaymentsRecord) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can record repayments", nil)
+		return
+	}
+
+	var reqBody RepaymentRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	if reqBody.Amount <= 0 {
+		utils.RespondWithError(c, http.StatusBadRequest, "repayment amount must be greater than zero", nil)
+		return
+	}
+
+	loan, msg, err := h.loanRepo.GetLoanByID(c.Param("loan_id"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, msg, err)
+		return
+	}
+
+	currency := loan.Currency
+	if reqBody.Currency != "" {
+		currency, err = models.NormalizeCurrency(reqBody.Currency)
+		if err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+	}
+
+	channel, err := models.NormalizeChannel(reqBody.Channel)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	paidAt := time.Now()
+	if reqBody.PaidAt != "" {
+		paidAt, err = time.Parse(time.DateOnly, reqBody.PaidAt)
+		if err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "paid at must be in YYYY-MM-DD format", err)
+			return
+		}
+	}
+
+	repayment := models.LoanRepayment{
+		LoanID:   loan.ID,
+		Amount:   reqBody.Amount,
+		Currency: currency,
+		PaidAt:   paidAt,
+		PostedBy: authUser.ID,
+		Note:     strings.TrimSpace(reqBody.Note),
+
+		Channel:           channel,
+		ExternalReference: strings.TrimSpace(reqBody.ExternalReference),
+	}
+
+	createdRepayment, updatedLoan, msg, err := h.loanRepo.RecordRepayment(&repayment)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, models.ErrCurrencyMismatch) || errors.Is(err, repository.ErrRepaymentExceedsBalance) {
+			status = http.StatusUnprocessableEntity
+		} else if errors.Is(err, repository.ErrLoanNotRepayable) {
+			status = http.StatusConflict
+		}
+		utils.RespondWithError(c, status, msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "repayment recorded successfully", "data", gin.H{
+		"repayment": models.NewLoanRepaymentResponse(createdRepayment),
+		"loan":      models.NewLoanResponse(updatedLoan),
+	})
+}
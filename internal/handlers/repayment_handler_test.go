@@ -0,0 +1,87 @@
+// Unit tests for RepaymentHandler endpoints
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cooperative-system/internal/handlers"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func repayableLoanRepo() *mockLoanRepo {
+	return &mockLoanRepo{
+		GetLoanByIDFunc: func(loanID string) (*models.Loan, string, error) {
+			loan := models.Loan{MemberID: 1, Currency: "NGN", Amount: 100000, TotalRepayableAmount: 103500, Status: models.LoanStatusApproved}
+			loan.ID = 9
+			return &loan, "success", nil
+		},
+	}
+}
+
+func TestRecordRepayment_CurrencyMismatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockLoan := repayableLoanRepo()
+	mockLoan.RecordRepaymentFunc = func(repayment *models.LoanRepayment) (*models.LoanRepayment, *models.Loan, string, error) {
+		err := models.CheckSameCurrency("NGN", repayment.Currency)
+		return nil, nil, "repayment currency does not match the loan", err
+	}
+	h := handlers.NewRepaymentHandler(mockLoan)
+	r := gin.Default()
+	r.POST("/loans/:loan_id/repayments", adminContext(h.RecordRepayment))
+	body := map[string]interface{}{"amount": "86.25", "currency": "USD"}
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPost, "/loans/9/repayments", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestRecordRepayment_ExceedsBalance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockLoan := repayableLoanRepo()
+	mockLoan.RecordRepaymentFunc = func(repayment *models.LoanRepayment) (*models.LoanRepayment, *models.Loan, string, error) {
+		return nil, nil, "repayment is more than the outstanding balance of 1035.00", repository.ErrRepaymentExceedsBalance
+	}
+	h := handlers.NewRepaymentHandler(mockLoan)
+	r := gin.Default()
+	r.POST("/loans/:loan_id/repayments", adminContext(h.RecordRepayment))
+	body := map[string]interface{}{"amount": 2000}
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPost, "/loans/9/repayments", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "outstanding balance")
+}
+
+func TestRecordRepayment_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockLoan := repayableLoanRepo()
+	mockLoan.RecordRepaymentFunc = func(repayment *models.LoanRepayment) (*models.LoanRepayment, *models.Loan, string, error) {
+		repayment.ID = 1
+		loan := models.Loan{MemberID: 1, Currency: repayment.Currency, Status: models.LoanStatusApproved}
+		loan.ID = repayment.LoanID
+		return repayment, &loan, "repayment recorded successfully", nil
+	}
+	h := handlers.NewRepaymentHandler(mockLoan)
+	r := gin.Default()
+	r.POST("/loans/:loan_id/repayments", adminContext(h.RecordRepayment))
+	body := map[string]interface{}{"amount": "86.25", "paid_at": "2025-02-15"}
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPost, "/loans/9/repayments", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"amount":86.25,"currency":"NGN"`)
+}
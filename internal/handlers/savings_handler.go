@@ -4,14 +4,34 @@ import (
 	"cooperative-system/internal/models"
 	"cooperative-system/internal/repository"
 	"cooperative-system/pkg/utils"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type CreateSavingRequest struct {
-	Amount      int    `json:"amount" binding:"required"`
-	Description string `json:"description"`
+	Amount      models.Money `json:"amount" binding:"required"`
+	Currency    string       `json:"currency"` // ISO 4217 code, defaults to the base currency
+	Description string       `json:"description"`
+	// Channel is how the money was paid in (cash, bank_transfer, card or online), defaults to online
+	Channel           string `json:"channel"`
+	ExternalReference string `json:"external_reference"`
+}
+
+// UpdateSavingsRequest changes a savings account's description. The amount a member commits to save is
+// set with their contribution mandate, so it is refused here.
+type UpdateSavingsRequest struct {
+	Currency    string       `json:"currency"` // picks the account when the URL names a member
+	Description string       `json:"description"`
+	Amount      models.Money `json:"amount"`
+}
+
+type ReverseTransactionRequest struct {
+	Reason string `json:"reason" binding:"required"`
 }
 
 type SavingsHandler struct {
@@ -26,12 +46,69 @@ func NewSavingsHandler(savingsRepo repository.SavingsRepository, memberRepo repo
 	}
 }
 
+// savingsCurrency reads the optional currency query parameter that picks which of a member's
+// savings accounts a request is about, responding with 400 when it is not a valid code
+func savingsCurrency(c *gin.Context) (string, bool) {
+	currency, err := models.NormalizeCurrency(c.Query("currency"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+		return "", false
+	}
+	return currency, true
+}
+
+// savingsInURL finds the savings account in the URL, named by its account number, or by its member's ID or
+// member number with the currency picking the account. It responds when the account is missing or is neither
+// the user's own nor one their role grants the permission for.
+func (s *SavingsHandler) savingsInURL(c *gin.Context, authUser *models.User, permission string, currency func() (string, bool)) (*models.Savings, bool) {
+	param := c.Param("id")
+	if _, err := strconv.ParseUint(param, 10, 64); err != nil {
+		savings, msg, err := s.repo.GetSavingsByAccountNumber(param)
+		if err == nil {
+			if !authUser.Can(permission) && savings.Member.UserID != authUser.ID {
+				utils.RespondWithError(c, http.StatusUnauthorized, "you are not authorized to view this savings account", nil)
+				return nil, false
+			}
+			if !authUser.CanSeeBranch(savings.Member.BranchID) {
+				utils.RespondWithError(c, http.StatusForbidden, "savings account belongs to a member of another branch", nil)
+				return nil, false
+			}
+			return savings, true
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+			return nil, false
+		}
+		// not an account number, so a member number
+	}
+
+	member, err := getMemberByIDAndAuthorize(c, s.MemberRepo, authUser, permission)
+	if err != nil {
+		return nil, false
+	}
+	memberCurrency, ok := currency()
+	if !ok {
+		return nil, false
+	}
+	savings, msg, err := s.repo.GetSavingsByMemberID(member.ID, memberCurrency)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			status = http.StatusNotFound
+		}
+		utils.RespondWithError(c, status, msg, err)
+		return nil, false
+	}
+	return savings, true
+}
+
 type SavingsService interface {
 	CreateSavings(c *gin.Context)
 	GetSavingByID(c *gin.Context)
 	UpdateSavings(c *gin.Context)
 	DeleteSavings(c *gin.Context)
 	GetTransactionsForMember(c *gin.Context)
+	ReverseTransaction(c *gin.Context)
 }
 
 func (s *SavingsHandler) CreateSavings(c *gin.Context) {
@@ -52,6 +129,9 @@ func (s *SavingsHandler) CreateSavings(c *gin.Context) {
 		utils.RespondWithError(c, status, msg, err)
 		return
 	}
+	if !requireActiveMember(c, member) {
+		return
+	}
 
 	// Bind the incoming JSON request to CreateSavingRequest struct
 	var reqBody CreateSavingRequest
@@ -60,50 +140,39 @@ func (s *SavingsHandler) CreateSavings(c *gin.Context) {
 		return
 	}
 
-	// Use repository to get or create savings
-	savings, created, msg, err := s.repo.GetOrCreateSavings(member, authUser.ID, reqBody.Amount, reqBody.Description)
-	if err != nil {
-		status := http.StatusInternalServerError
-		if created {
-			status = http.StatusInternalServerError
-		}
-		utils.RespondWithError(c, status, msg, err)
+	if reqBody.Amount <= 0 {
+		utils.RespondWithError(c, http.StatusBadRequest, "amount must be greater than zero", nil)
 		return
 	}
 
-	var finalSavingsState *models.Savings = savings
-
-	if !created {
-		// Savings record exists, update its balance and other fields using repository
-		updateData := map[string]interface{}{
-			"Balance":      savings.Balance + reqBody.Amount, // Calculate the new total balance
-			"AmountToSave": reqBody.Amount,                   // This seems to track the last amount saved
-			"Description":  reqBody.Description,              // Update with the new description
-		}
-
-		tempUpdatedSavings, msg, err := s.repo.UpdateSavings(savings, updateData)
-		if err != nil {
-			utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
-			return
-		}
-		finalSavingsState = tempUpdatedSavings
+	currency, err := models.NormalizeCurrency(reqBody.Currency)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+		return
 	}
 
-	// Create a new saving transaction history using repository
-	transaction := &models.SavingTransaction{
-		SavingsID:   finalSavingsState.ID,
-		MemberID:    member.ID,
-		Amount:      reqBody.Amount,
-		Description: reqBody.Description,
+	channel, err := models.NormalizeChannel(reqBody.Channel)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+		return
 	}
 
-	createdTransaction, msg, err := s.repo.CreateTransaction(transaction)
+	// the balance and the ledger entry are posted together, with the account locked
+	deposit := &models.SavingTransaction{
+		Amount:            reqBody.Amount,
+		Currency:          currency,
+		Description:       reqBody.Description,
+		Type:              models.TransactionTypeDeposit,
+		Channel:           channel,
+		ExternalReference: strings.TrimSpace(reqBody.ExternalReference),
+	}
+	savings, createdTransaction, msg, err := s.repo.Deposit(member.ID, deposit, reqBody.Description)
 	if err != nil {
 		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
 		return
 	}
 
-	savingsResponse := models.NewSavingsResponse(finalSavingsState)
+	savingsResponse := models.NewSavingsResponse(savings)
 	transactionResponse := models.NewSavingTransactionResponse(createdTransaction)
 
 	// Respond with the updated savings and new transaction
@@ -122,16 +191,8 @@ func (s *SavingsHandler) GetSavingByID(c *gin.Context) {
 		return
 	}
 
-	// Get the member ID from the URL parameter using MemberRepo
-	member, err := getMemberByIDAndAuthorize(c, s.MemberRepo, &authUser)
-	if err != nil {
-		return
-	}
-
-	// Use repository to get savings by member ID
-	savings, msg, err := s.repo.GetSavingsByMemberID(member.ID)
-	if err != nil {
-		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+	savings, ok := s.savingsInURL(c, &authUser, models.PermMembersRead, func() (string, bool) { return savingsCurrency(c) })
+	if !ok {
 		return
 	}
 
@@ -151,28 +212,30 @@ func (s *SavingsHandler) UpdateSavings(c *gin.Context) {
 		return
 	}
 
-	member, err := getMemberByIDAndAuthorize(c, s.MemberRepo, &authUser)
-	if err != nil {
-		return
-	}
-
-	var savingsReq CreateSavingRequest
+	var savingsReq UpdateSavingsRequest
 	if err := c.ShouldBindJSON(&savingsReq); err != nil {
 		utils.RespondWithError(c, http.StatusBadRequest, "invalid request body", err)
 		return
 	}
+	if savingsReq.Amount != 0 {
+		utils.RespondWithError(c, http.StatusBadRequest, "the amount to save is set with the contribution mandate at PUT /api/v1/members/{id}/mandate", nil)
+		return
+	}
 
-	savings, msg, err := s.repo.GetSavingsByMemberID(member.ID)
-	if err != nil {
-		utils.RespondWithError(c, http.StatusNotFound, msg, err)
+	savings, ok := s.savingsInURL(c, &authUser, models.PermMembersManage, func() (string, bool) {
+		currency, err := models.NormalizeCurrency(savingsReq.Currency)
+		if err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+			return "", false
+		}
+		return currency, true
+	})
+	if !ok {
 		return
 	}
 
 	updateData := make(map[string]interface{})
 	// Prepare fields to update
-	if savingsReq.Amount != 0 {
-		updateData["AmountToSave"] = savingsReq.Amount
-	}
 	if savingsReq.Description != "" {
 		updateData["Description"] = savingsReq.Description
 	}
@@ -197,14 +260,8 @@ func (s *SavingsHandler) DeleteSavings(c *gin.Context) {
 		return
 	}
 
-	member, err := getMemberByIDAndAuthorize(c, s.MemberRepo, &authUser)
-	if err != nil {
-		return
-	}
-
-	savings, msg, err := s.repo.GetSavingsByMemberID(member.ID)
-	if err != nil {
-		utils.RespondWithError(c, http.StatusNotFound, msg, err)
+	savings, ok := s.savingsInURL(c, &authUser, models.PermMembersManage, func() (string, bool) { return savingsCurrency(c) })
+	if !ok {
 		return
 	}
 
@@ -224,12 +281,12 @@ func (s *SavingsHandler) DeleteSavings(c *gin.Context) {
 func (s *SavingsHandler) GetTransactionsForMember(c *gin.Context) {
 	// get authenticated user
 	authUser, ok := getAuthUser(c)
-	if !ok || authUser.Role != "admin" {
+	if !ok || !authUser.Can(models.PermMembersRead) {
 		utils.RespondWithError(c, http.StatusForbidden, "you are not authorized to view transactions", nil)
 		return
 	}
 
-	member, err := getMemberByIDAndAuthorize(c, s.MemberRepo, &authUser)
+	member, err := getMemberByIDAndAuthorize(c, s.MemberRepo, &authUser, models.PermMembersRead)
 	if err != nil {
 		return
 	}
@@ -252,3 +309,44 @@ func (s *SavingsHandler) GetTransactionsForMember(c *gin.Context) {
 	})
 
 }
+
+func (s *SavingsHandler) ReverseTransaction(c *gin.Context) {
+	// get authenticated user
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermTransactionsReverse) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can reverse transactions", nil)
+		return
+	}
+
+	var reqBody ReverseTransactionRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil || strings.TrimSpace(reqBody.Reason) == "" {
+		utils.RespondWithError(c, http.StatusBadRequest, "a reason is required to reverse a transaction", err)
+		return
+	}
+
+	original, msg, err := s.repo.GetTransactionByID(c.Param("transaction_id"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, msg, err)
+		return
+	}
+	if !requireMemberInBranch(c, s.MemberRepo, &authUser, original.MemberID) {
+		return
+	}
+
+	reversal, msg, err := s.repo.ReverseTransaction(original.ID, strings.TrimSpace(reqBody.Reason), authUser.ID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrTransactionAlreadyReversed) || errors.Is(err, repository.ErrCannotReverseReversal) || errors.Is(err, repository.ErrTransferLeg) || errors.Is(err, repository.ErrFeeTransaction) || errors.Is(err, repository.ErrFixedDepositTransaction) || errors.Is(err, repository.ErrShareTransaction) {
+			status = http.StatusConflict
+		} else if errors.Is(err, repository.ErrInsufficientBalance) {
+			status = http.StatusUnprocessableEntity
+		}
+		utils.RespondWithError(c, status, msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "transaction reversed successfully", "data", gin.H{
+		"original": models.NewSavingTransactionResponse(original),
+		"reversal": models.NewSavingTransactionResponse(reversal),
+	})
+}
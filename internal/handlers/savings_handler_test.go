@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"cooperative-system/internal/handlers"
 	"cooperative-system/internal/models"
@@ -15,33 +16,34 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 )
 
 type mockSavingsRepo struct {
 	repository.SavingsRepository
-	FetchMemberByUserIDFunc       func(userID uint) (*models.Member, string, error)
-	GetOrCreateSavingsFunc        func(member *models.Member, userID uint, amount int, description string) (*models.Savings, bool, string, error)
-	UpdateSavingsFunc             func(savings *models.Savings, updateFields interface{}) (*models.Savings, string, error)
-	CreateTransactionFunc         func(transaction *models.SavingTransaction) (*models.SavingTransaction, string, error)
-	GetSavingsByMemberIDFunc      func(memberID uint) (*models.Savings, string, error)
-	DeleteSavingsFunc             func(savings *models.Savings) (*models.Savings, string, error)
-	GetTransactionsByMemberIDFunc func(memberID uint) ([]models.SavingTransaction, string, error)
+	FetchMemberByUserIDFunc        func(userID uint) (*models.Member, string, error)
+	DepositFunc                    func(memberID uint, deposit *models.SavingTransaction, description string) (*models.Savings, *models.SavingTransaction, string, error)
+	UpdateSavingsFunc              func(savings *models.Savings, updateFields interface{}) (*models.Savings, string, error)
+	GetSavingsByMemberIDFunc       func(memberID uint, currency string) (*models.Savings, string, error)
+	DeleteSavingsFunc              func(savings *models.Savings) (*models.Savings, string, error)
+	GetTransactionsByMemberIDFunc  func(memberID uint) ([]models.SavingTransaction, string, error)
+	GetTransactionByIDFunc         func(transactionID string) (*models.SavingTransaction, string, error)
+	ReverseTransactionFunc         func(transactionID uint, reason string, postedBy uint) (*models.SavingTransaction, string, error)
+	GetTransactionsBySavingsIDFunc func(savingsID uint, before time.Time) ([]models.SavingTransaction, string, error)
+	GetSavingsByAccountNumberFunc  func(accountNumber string) (*models.Savings, string, error)
 }
 
 func (m *mockSavingsRepo) FetchMemberByUserID(userID uint) (*models.Member, string, error) {
 	return m.FetchMemberByUserIDFunc(userID)
 }
-func (m *mockSavingsRepo) GetOrCreateSavings(member *models.Member, userID uint, amount int, description string) (*models.Savings, bool, string, error) {
-	return m.GetOrCreateSavingsFunc(member, userID, amount, description)
+func (m *mockSavingsRepo) Deposit(memberID uint, deposit *models.SavingTransaction, description string) (*models.Savings, *models.SavingTransaction, string, error) {
+	return m.DepositFunc(memberID, deposit, description)
 }
 func (m *mockSavingsRepo) UpdateSavings(savings *models.Savings, updateFields interface{}) (*models.Savings, string, error) {
 	return m.UpdateSavingsFunc(savings, updateFields)
 }
-func (m *mockSavingsRepo) CreateTransaction(transaction *models.SavingTransaction) (*models.SavingTransaction, string, error) {
-	return m.CreateTransactionFunc(transaction)
-}
-func (m *mockSavingsRepo) GetSavingsByMemberID(memberID uint) (*models.Savings, string, error) {
-	return m.GetSavingsByMemberIDFunc(memberID)
+func (m *mockSavingsRepo) GetSavingsByMemberID(memberID uint, currency string) (*models.Savings, string, error) {
+	return m.GetSavingsByMemberIDFunc(memberID, currency)
 }
 func (m *mockSavingsRepo) DeleteSavings(savings *models.Savings) (*models.Savings, string, error) {
 	return m.DeleteSavingsFunc(savings)
@@ -49,6 +51,19 @@ func (m *mockSavingsRepo) DeleteSavings(savings *models.Savings) (*models.Saving
 func (m *mockSavingsRepo) GetTransactionsByMemberID(memberID uint) ([]models.SavingTransaction, string, error) {
 	return m.GetTransactionsByMemberIDFunc(memberID)
 }
+func (m *mockSavingsRepo) GetTransactionByID(transactionID string) (*models.SavingTransaction, string, error) {
+	return m.GetTransactionByIDFunc(transactionID)
+}
+func (m *mockSavingsRepo) ReverseTransaction(transactionID uint, reason string, postedBy uint) (*models.SavingTransaction, string, error) {
+	return m.ReverseTransactionFunc(transactionID, reason, postedBy)
+}
+func (m *mockSavingsRepo) GetTransactionsBySavingsID(savingsID uint, before time.Time) ([]models.SavingTransaction, string, error) {
+	return m.GetTransactionsBySavingsIDFunc(savingsID, before)
+}
+
+func (m *mockSavingsRepo) GetSavingsByAccountNumber(accountNumber string) (*models.Savings, string, error) {
+	return m.GetSavingsByAccountNumberFunc(accountNumber)
+}
 
 type mockMemberRepoForSavings struct {
 	repository.MemberRepository
@@ -63,31 +78,23 @@ func TestCreateSavings_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockSavings := &mockSavingsRepo{
 		FetchMemberByUserIDFunc: func(userID uint) (*models.Member, string, error) {
-			member := models.Member{}
+			member := models.Member{Status: models.MemberStatusActive}
 			member.ID = 1
 			member.UserID = userID
 			member.Name = "Test"
 			member.ContactInfo = "123"
 			return &member, "success", nil
 		},
-		GetOrCreateSavingsFunc: func(member *models.Member, userID uint, amount int, description string) (*models.Savings, bool, string, error) {
+		DepositFunc: func(memberID uint, deposit *models.SavingTransaction, description string) (*models.Savings, *models.SavingTransaction, string, error) {
 			savings := models.Savings{}
 			savings.ID = 1
-			savings.UserID = userID
-			savings.MemberID = member.ID
-			savings.Balance = amount
-			savings.AmountToSave = amount
+			savings.MemberID = memberID
+			savings.Balance = deposit.Amount
 			savings.Description = description
-			return &savings, true, "savings created", nil
-		},
-		CreateTransactionFunc: func(transaction *models.SavingTransaction) (*models.SavingTransaction, string, error) {
-			t := models.SavingTransaction{}
-			t.ID = 1
-			t.MemberID = transaction.MemberID
-			t.Amount = transaction.Amount
-			t.Description = transaction.Description
-			t.SavingsID = transaction.SavingsID
-			return &t, "transaction created", nil
+			deposit.ID = 1
+			deposit.MemberID = memberID
+			deposit.SavingsID = savings.ID
+			return &savings, deposit, "deposit posted successfully", nil
 		},
 	}
 	mockMember := &mockMemberRepoForSavings{}
@@ -108,13 +115,15 @@ func TestCreateSavings_Success(t *testing.T) {
 	r.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusCreated, w.Code)
 	assert.Contains(t, w.Body.String(), "savings created successfully")
+	assert.Contains(t, w.Body.String(), `"balance":100.00`)
+	assert.Contains(t, w.Body.String(), `"type":"deposit","channel":"online"`)
 }
 
 func TestCreateSavings_InvalidBody(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockSavings := &mockSavingsRepo{
 		FetchMemberByUserIDFunc: func(userID uint) (*models.Member, string, error) {
-			member := models.Member{}
+			member := models.Member{Status: models.MemberStatusActive}
 			member.ID = 1
 			member.UserID = userID
 			member.Name = "Test"
@@ -139,11 +148,38 @@ func TestCreateSavings_InvalidBody(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "invalid request body")
 }
 
+func TestCreateSavings_RejectsInternalChannel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSavings := &mockSavingsRepo{
+		FetchMemberByUserIDFunc: func(userID uint) (*models.Member, string, error) {
+			member := models.Member{Status: models.MemberStatusActive}
+			member.ID = 1
+			member.UserID = userID
+			return &member, "success", nil
+		},
+	}
+	h := handlers.NewSavingsHandler(mockSavings, &mockMemberRepoForSavings{})
+	r := gin.Default()
+	r.POST("/savings", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "member"
+		c.Set("user", user)
+		h.CreateSavings(c)
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/savings", bytes.NewBuffer([]byte(`{"amount": 100, "channel": "internal"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "channel must be one of")
+}
+
 func TestCreateSavings_Unauthenticated(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockSavings := &mockSavingsRepo{
 		FetchMemberByUserIDFunc: func(userID uint) (*models.Member, string, error) {
-			member := models.Member{}
+			member := models.Member{Status: models.MemberStatusActive}
 			member.ID = 1
 			member.UserID = userID
 			member.Name = "Test"
@@ -168,15 +204,15 @@ func TestCreateSavings_RepoError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockSavings := &mockSavingsRepo{
 		FetchMemberByUserIDFunc: func(userID uint) (*models.Member, string, error) {
-			member := models.Member{}
+			member := models.Member{Status: models.MemberStatusActive}
 			member.ID = 1
 			member.UserID = userID
 			member.Name = "Test"
 			member.ContactInfo = "123"
 			return &member, "success", nil
 		},
-		GetOrCreateSavingsFunc: func(member *models.Member, userID uint, amount int, description string) (*models.Savings, bool, string, error) {
-			return nil, true, "repo error", errors.New("db error")
+		DepositFunc: func(memberID uint, deposit *models.SavingTransaction, description string) (*models.Savings, *models.SavingTransaction, string, error) {
+			return nil, nil, "repo error", errors.New("db error")
 		},
 	}
 	mockMember := &mockMemberRepoForSavings{}
@@ -198,3 +234,267 @@ func TestCreateSavings_RepoError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 	assert.Contains(t, w.Body.String(), "repo error")
 }
+
+func TestReverseTransaction_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSavings := &mockSavingsRepo{
+		GetTransactionByIDFunc: func(transactionID string) (*models.SavingTransaction, string, error) {
+			transaction := models.SavingTransaction{SavingsID: 1, MemberID: 1, Amount: 500}
+			transaction.ID = 7
+			return &transaction, "transaction fetched successfully", nil
+		},
+		ReverseTransactionFunc: func(transactionID uint, reason string, postedBy uint) (*models.SavingTransaction, string, error) {
+			reversal := models.SavingTransaction{SavingsID: 1, MemberID: 1, Amount: -500, ReversalOfID: &transactionID, ReversalReason: reason, PostedBy: &postedBy}
+			reversal.ID = 8
+			return &reversal, "transaction reversed successfully", nil
+		},
+	}
+	h := handlers.NewSavingsHandler(mockSavings, &mockMemberRepoForSavings{})
+	r := gin.Default()
+	r.POST("/savings/transactions/:transaction_id/reverse", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 9
+		user.Role = "admin"
+		c.Set("user", user)
+		h.ReverseTransaction(c)
+	})
+	jsonBody, _ := json.Marshal(map[string]interface{}{"reason": "posted to the wrong member"})
+	req, _ := http.NewRequest(http.MethodPost, "/savings/transactions/7/reverse", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"reversal_of_id":7`)
+	assert.Contains(t, w.Body.String(), `"amount":-5.00`)
+}
+
+func TestReverseTransaction_MissingReason(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewSavingsHandler(&mockSavingsRepo{}, &mockMemberRepoForSavings{})
+	r := gin.Default()
+	r.POST("/savings/transactions/:transaction_id/reverse", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 9
+		user.Role = "admin"
+		c.Set("user", user)
+		h.ReverseTransaction(c)
+	})
+	jsonBody, _ := json.Marshal(map[string]interface{}{"reason": "  "})
+	req, _ := http.NewRequest(http.MethodPost, "/savings/transactions/7/reverse", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "a reason is required to reverse a transaction")
+}
+
+func TestReverseTransaction_AlreadyReversed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSavings := &mockSavingsRepo{
+		GetTransactionByIDFunc: func(transactionID string) (*models.SavingTransaction, string, error) {
+			transaction := models.SavingTransaction{SavingsID: 1, MemberID: 1, Amount: 500}
+			transaction.ID = 7
+			return &transaction, "transaction fetched successfully", nil
+		},
+		ReverseTransactionFunc: func(transactionID uint, reason string, postedBy uint) (*models.SavingTransaction, string, error) {
+			return nil, repository.ErrTransactionAlreadyReversed.Error(), repository.ErrTransactionAlreadyReversed
+		},
+	}
+	h := handlers.NewSavingsHandler(mockSavings, &mockMemberRepoForSavings{})
+	r := gin.Default()
+	r.POST("/savings/transactions/:transaction_id/reverse", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 9
+		user.Role = "admin"
+		c.Set("user", user)
+		h.ReverseTransaction(c)
+	})
+	jsonBody, _ := json.Marshal(map[string]interface{}{"reason": "duplicate posting"})
+	req, _ := http.NewRequest(http.MethodPost, "/savings/transactions/7/reverse", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "transaction has already been reversed")
+}
+
+func TestReverseTransaction_FixedDepositEntry(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSavings := &mockSavingsRepo{
+		GetTransactionByIDFunc: func(transactionID string) (*models.SavingTransaction, string, error) {
+			fixedDepositID := uint(3)
+			transaction := models.SavingTransaction{SavingsID: 1, MemberID: 1, Amount: -500, Type: models.TransactionTypeFixedDeposit, FixedDepositID: &fixedDepositID}
+			transaction.ID = 7
+			return &transaction, "transaction fetched successfully", nil
+		},
+		ReverseTransactionFunc: func(transactionID uint, reason string, postedBy uint) (*models.SavingTransaction, string, error) {
+			return nil, repository.ErrFixedDepositTransaction.Error(), repository.ErrFixedDepositTransaction
+		},
+	}
+	h := handlers.NewSavingsHandler(mockSavings, &mockMemberRepoForSavings{})
+	r := gin.Default()
+	r.POST("/savings/transactions/:transaction_id/reverse", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 9
+		user.Role = "admin"
+		c.Set("user", user)
+		h.ReverseTransaction(c)
+	})
+	jsonBody, _ := json.Marshal(map[string]interface{}{"reason": "member asked for the money back"})
+	req, _ := http.NewRequest(http.MethodPost, "/savings/transactions/7/reverse", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "cannot be reversed on its own")
+}
+
+func TestReverseTransaction_SharePurchaseEntry(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSavings := &mockSavingsRepo{
+		GetTransactionByIDFunc: func(transactionID string) (*models.SavingTransaction, string, error) {
+			transaction := models.SavingTransaction{SavingsID: 1, MemberID: 1, Amount: -500, Type: models.TransactionTypeShares}
+			transaction.ID = 7
+			return &transaction, "transaction fetched successfully", nil
+		},
+		ReverseTransactionFunc: func(transactionID uint, reason string, postedBy uint) (*models.SavingTransaction, string, error) {
+			return nil, repository.ErrShareTransaction.Error(), repository.ErrShareTransaction
+		},
+	}
+	h := handlers.NewSavingsHandler(mockSavings, &mockMemberRepoForSavings{})
+	r := gin.Default()
+	r.POST("/savings/transactions/:transaction_id/reverse", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 9
+		user.Role = "admin"
+		c.Set("user", user)
+		h.ReverseTransaction(c)
+	})
+	jsonBody, _ := json.Marshal(map[string]interface{}{"reason": "member changed their mind"})
+	req, _ := http.NewRequest(http.MethodPost, "/savings/transactions/7/reverse", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "cannot be reversed on its own")
+}
+
+func TestReverseTransaction_MemberInAnotherBranch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reversed := false
+	mockSavings := &mockSavingsRepo{
+		GetTransactionByIDFunc: func(transactionID string) (*models.SavingTransaction, string, error) {
+			transaction := models.SavingTransaction{SavingsID: 1, MemberID: 1, Amount: 500}
+			transaction.ID = 7
+			return &transaction, "transaction fetched successfully", nil
+		},
+		ReverseTransactionFunc: func(transactionID uint, reason string, postedBy uint) (*models.SavingTransaction, string, error) {
+			reversed = true
+			return nil, "transaction reversed successfully", nil
+		},
+	}
+	memberRepo := &mockMemberRepoForSavings{
+		FetchByIDFunc: func(memberID string) (*models.Member, string, error) {
+			branchID := uint(5)
+			member := models.Member{BranchID: &branchID}
+			member.ID = 1
+			return &member, "member fetched successfully", nil
+		},
+	}
+	h := handlers.NewSavingsHandler(mockSavings, memberRepo)
+	r := gin.Default()
+	r.POST("/savings/transactions/:transaction_id/reverse", branchAdminContext(4, h.ReverseTransaction))
+	jsonBody, _ := json.Marshal(map[string]interface{}{"reason": "duplicate posting"})
+	req, _ := http.NewRequest(http.MethodPost, "/savings/transactions/7/reverse", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "member belongs to another branch")
+	assert.False(t, reversed)
+}
+
+func TestCreateSavings_MemberNotApproved(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSavings := &mockSavingsRepo{
+		FetchMemberByUserIDFunc: func(userID uint) (*models.Member, string, error) {
+			member := models.Member{UserID: userID, Status: models.MemberStatusUnderReview}
+			member.ID = 1
+			return &member, "success", nil
+		},
+	}
+	h := handlers.NewSavingsHandler(mockSavings, &mockMemberRepoForSavings{})
+	r := gin.Default()
+	r.POST("/savings", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "member"
+		c.Set("user", user)
+		h.CreateSavings(c)
+	})
+	jsonBody, _ := json.Marshal(map[string]interface{}{"amount": 100})
+	req, _ := http.NewRequest(http.MethodPost, "/savings", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "membership is under_review")
+}
+
+func TestGetSavingByID_AccountNumber(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	number := "SVHQ0000125"
+	mockRepo := &mockSavingsRepo{
+		GetSavingsByAccountNumberFunc: func(accountNumber string) (*models.Savings, string, error) {
+			if models.NormalizeNumber(accountNumber) != number {
+				return nil, "savings not found for the given account number", gorm.ErrRecordNotFound
+			}
+			savings := models.Savings{MemberID: 4, Currency: "USD", Balance: 1500, AccountNumber: &number}
+			savings.ID = 6
+			savings.Member.UserID = 1
+			return &savings, "savings fetched successfully", nil
+		},
+	}
+	h := handlers.NewSavingsHandler(mockRepo, &mockMemberRepoForSavings{})
+
+	r := gin.Default()
+	r.GET("/savings/:id", memberContext(h.GetSavingByID))
+	req, _ := http.NewRequest(http.MethodGet, "/savings/svhq0000125", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"account_number":"SVHQ0000125"`)
+	assert.Contains(t, w.Body.String(), `"currency":"USD"`)
+
+	// another member's account is refused
+	r = gin.Default()
+	r.GET("/savings/:id", func(c *gin.Context) {
+		user := models.User{Role: "member"}
+		user.ID = 2
+		c.Set("user", user)
+		h.GetSavingByID(c)
+	})
+	req, _ = http.NewRequest(http.MethodGet, "/savings/SVHQ0000125", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestUpdateSavings_RefusesAmountToSave(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewSavingsHandler(&mockSavingsRepo{}, &mockMemberRepoForSavings{})
+	r := gin.Default()
+	r.PUT("/savings/:id", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "member"
+		c.Set("user", user)
+		h.UpdateSavings(c)
+	})
+	req, _ := http.NewRequest(http.MethodPut, "/savings/1", bytes.NewBufferString(`{"amount": 5000}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "contribution mandate")
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+	"cooperative-system/pkg/utils"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ExitMemberRequest struct {
+	Reason string `json:"reason" binding:"required"`
+	// Channel is how the final payout leaves the cooperative (cash, bank_transfer, card or online), defaults to online
+	Channel           string `json:"channel"`
+	ExternalReference string `json:"external_reference"`
+}
+
+type SettlementHandler struct {
+	repo          repository.SettlementRepository
+	memberRepo    repository.MemberRepository
+	shareSettings func() models.ShareSettings
+}
+
+func NewSettlementHandler(settlementRepo repository.SettlementRepository, memberRepo repository.MemberRepository, shareSettings func() models.ShareSettings) *SettlementHandler {
+	return &SettlementHandler{
+		repo:          settlementRepo,
+		memberRepo:    memberRepo,
+		shareSettings: shareSettings,
+	}
+}
+
+type SettlementService interface {
+	GetExitSettlement(c *gin.Context)
+	ExitMember(c *gin.Context)
+}
+
+func settlementErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrMemberCannotExit), errors.Is(err, repository.ErrActiveFixedDeposits):
+		return http.StatusConflict
+	case errors.Is(err, repository.ErrSettlementShortfall):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+// settlementMemberID reads the member in the URL for an admin, responding when either is missing
+func (h *SettlementHandler) settlementMemberID(c *gin.Context) (models.User, uint, bool) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermExitsManage) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can settle member exits", nil)
+		return models.User{}, 0, false
+	}
+
+	memberID, ok := memberIDParam(c, h.memberRepo, &authUser)
+	if !ok {
+		return models.User{}, 0, false
+	}
+	return authUser, memberID, true
+}
+
+// GetExitSettlement previews what a member would be paid if they exited now, or shows the posted settlement
+// once they have exited
+func (h *SettlementHandler) GetExitSettlement(c *gin.Context) {
+	_, memberID, ok := h.settlementMemberID(c)
+	if !ok {
+		return
+	}
+
+	settlement, msg, err := h.repo.PreviewSettlement(memberID, h.shareSettings())
+	if err != nil {
+		utils.RespondWithError(c, settlementErrorStatus(err), msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"settlement": models.NewMemberSettlementResponse(settlement),
+	})
+}
+
+// ExitMember posts the final settlement, closes the member's accounts and marks them exited
+func (h *SettlementHandler) ExitMember(c *gin.Context) {
+	authUser, memberID, ok := h.settlementMemberID(c)
+	if !ok {
+		return
+	}
+
+	var reqBody ExitMemberRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil || strings.TrimSpace(reqBody.Reason) == "" {
+		utils.RespondWithError(c, http.StatusBadRequest, "a reason for the exit is required", err)
+		return
+	}
+
+	channel, err := models.NormalizeChannel(reqBody.Channel)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	settlement, member, msg, err := h.repo.ExitMember(memberID, h.shareSettings(), authUser.ID, strings.TrimSpace(reqBody.Reason), channel, strings.TrimSpace(reqBody.ExternalReference))
+	if err != nil {
+		utils.RespondWithError(c, settlementErrorStatus(err), msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, msg, "data", gin.H{
+		"member":     models.NewMemberResponse(member),
+		"settlement": models.NewMemberSettlementResponse(settlement),
+	})
+}
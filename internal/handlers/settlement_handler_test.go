@@ -0,0 +1,111 @@
+// Unit tests for SettlementHandler endpoints
+package handlers_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cooperative-system/internal/handlers"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSettlementRepo struct {
+	repository.SettlementRepository
+	PreviewSettlementFunc func(memberID uint, settings models.ShareSettings) (*models.MemberSettlement, string, error)
+	ExitMemberFunc        func(memberID uint, settings models.ShareSettings, exitedBy uint, reason string, channel string, externalReference string) (*models.MemberSettlement, *models.Member, string, error)
+}
+
+func (m *mockSettlementRepo) PreviewSettlement(memberID uint, settings models.ShareSettings) (*models.MemberSettlement, string, error) {
+	return m.PreviewSettlementFunc(memberID, settings)
+}
+func (m *mockSettlementRepo) ExitMember(memberID uint, settings models.ShareSettings, exitedBy uint, reason string, channel string, externalReference string) (*models.MemberSettlement, *models.Member, string, error) {
+	return m.ExitMemberFunc(memberID, settings, exitedBy, reason, channel, externalReference)
+}
+
+func settlementShareSettings() models.ShareSettings {
+	return models.ShareSettings{Price: 10000, MinimumHolding: 5}
+}
+
+// computingSettlementRepo nets 500.00 NGN and 100.00 USD of savings and 10 shares against a loan with
+// 2,000.00 NGN outstanding and a 5.00 USD fee
+func computingSettlementRepo() *mockSettlementRepo {
+	return &mockSettlementRepo{
+		PreviewSettlementFunc: func(memberID uint, settings models.ShareSettings) (*models.MemberSettlement, string, error) {
+			savings := []models.Savings{
+				{MemberID: memberID, Currency: "USD", Balance: 10000},
+				{MemberID: memberID, Currency: "NGN", Balance: 50000},
+			}
+			lines := models.CalculateSettlement(savings, 10, settings, map[string]models.Money{"NGN": 200000}, map[string]models.Money{"USD": 500})
+			return &models.MemberSettlement{MemberID: memberID, SharesRedeemed: 10, SharePrice: settings.Price, Lines: lines}, "settlement calculated successfully", nil
+		},
+	}
+}
+
+func TestGetExitSettlement_ShowsShortfall(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewSettlementHandler(computingSettlementRepo(), &mockMemberRepo{}, settlementShareSettings)
+	r := gin.Default()
+	r.GET("/admins/members/:id/exit-settlement", adminContext(h.GetExitSettlement))
+	req, _ := http.NewRequest(http.MethodGet, "/admins/members/4/exit-settlement", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `{"currency":"NGN","savings":500.00,"share_value":1000.00,"loans_outstanding":2000.00,"fees_outstanding":0.00,"payout":-500.00,"shortfall":500.00}`)
+	assert.Contains(t, w.Body.String(), `{"currency":"USD","savings":100.00,"share_value":0.00,"loans_outstanding":0.00,"fees_outstanding":5.00,"payout":95.00,"shortfall":0.00}`)
+}
+
+func TestExitMember_Shortfall(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := &mockSettlementRepo{
+		ExitMemberFunc: func(memberID uint, settings models.ShareSettings, exitedBy uint, reason string, channel string, externalReference string) (*models.MemberSettlement, *models.Member, string, error) {
+			return nil, nil, "member is short by 500.00 NGN", repository.ErrSettlementShortfall
+		},
+	}
+	h := handlers.NewSettlementHandler(repo, &mockMemberRepo{}, settlementShareSettings)
+	r := gin.Default()
+	r.POST("/admins/members/:id/exit", adminContext(h.ExitMember))
+	req, _ := http.NewRequest(http.MethodPost, "/admins/members/4/exit", bytes.NewBuffer([]byte(`{"reason":"relocating"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "member is short by 500.00 NGN")
+}
+
+func TestExitMember_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var payoutChannel, payoutReference string
+	repo := &mockSettlementRepo{
+		ExitMemberFunc: func(memberID uint, settings models.ShareSettings, exitedBy uint, reason string, channel string, externalReference string) (*models.MemberSettlement, *models.Member, string, error) {
+			payoutChannel, payoutReference = channel, externalReference
+			member := models.Member{Name: "Test", Status: models.MemberStatusExited}
+			member.ID = memberID
+			settlement := models.MemberSettlement{
+				MemberID: memberID,
+				Channel:  channel,
+				Reason:   reason,
+				Lines:    []models.MemberSettlementLine{{Currency: "NGN", Savings: 150000, Payout: 150000}},
+			}
+			return &settlement, &member, "member exited successfully", nil
+		},
+	}
+	h := handlers.NewSettlementHandler(repo, &mockMemberRepo{}, settlementShareSettings)
+	r := gin.Default()
+	r.POST("/admins/members/:id/exit", adminContext(h.ExitMember))
+	body := `{"reason":"relocating","channel":"bank_transfer","external_reference":" TRF-991 "}`
+	req, _ := http.NewRequest(http.MethodPost, "/admins/members/4/exit", bytes.NewBuffer([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, models.TransactionChannelBankTransfer, payoutChannel)
+	assert.Equal(t, "TRF-991", payoutReference)
+	assert.Contains(t, w.Body.String(), `"status":"exited"`)
+	assert.Contains(t, w.Body.String(), `"payout":1500.00`)
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+	"cooperative-system/pkg/utils"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SharePurchaseRequest struct {
+	Shares int64 `json:"shares" binding:"required"`
+}
+
+type ShareTransferRequest struct {
+	ToMemberID models.MemberReference `json:"to_member_id" binding:"required"` // member ID or member number
+	Shares     int64                  `json:"shares" binding:"required"`
+	Note       string                 `json:"note"`
+}
+
+type ShareRedemptionRequest struct {
+	Shares int64  `json:"shares" binding:"required"`
+	Note   string `json:"note"`
+}
+
+type ShareHandler struct {
+	repo       repository.ShareRepository
+	memberRepo repository.MemberRepository
+	settings   func() models.ShareSettings
+}
+
+func NewShareHandler(shareRepo repository.ShareRepository, memberRepo repository.MemberRepository, settings func() models.ShareSettings) *ShareHandler {
+	return &ShareHandler{
+		repo:       shareRepo,
+		memberRepo: memberRepo,
+		settings:   settings,
+	}
+}
+
+type ShareService interface {
+	GetMyShares(c *gin.Context)
+	PurchaseShares(c *gin.Context)
+	TransferShares(c *gin.Context)
+	RedeemShares(c *gin.Context)
+	GetShareRegister(c *gin.Context)
+}
+
+// shareErrorStatus maps the errors a share movement can fail with onto a response status
+func shareErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrBelowMinimumHolding), errors.Is(err, models.ErrInsufficientShares), errors.Is(err, repository.ErrInsufficientBalance):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func respondWithShareMovement(c *gin.Context, status int, msg string, account *models.ShareAccount, transaction *models.ShareTransaction, settings models.ShareSettings) {
+	utils.SuccessResponse(c, status, msg, "data", gin.H{
+		"share_account": models.NewShareAccountResponse(account, settings),
+		"transaction":   models.NewShareTransactionResponse(transaction),
+	})
+}
+
+// GetMyShares returns the authenticated member's share account and active certificates
+func (h *ShareHandler) GetMyShares(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+
+	member, msg, err := h.memberRepo.FetchMemberByUserID(authUser.ID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, msg, err)
+		return
+	}
+
+	account, msg, err := h.repo.GetShareAccountByMemberID(member.ID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+			return
+		}
+		// no shares bought yet, show an empty holding
+		account = &models.ShareAccount{MemberID: member.ID}
+	}
+
+	settings := h.settings()
+	utils.SuccessResponse(c, http.StatusOK, "share account fetched successfully", "data", gin.H{
+		"share_account": models.NewShareAccountResponse(account, settings),
+		"settings":      settings,
+	})
+}
+
+// PurchaseShares buys shares for the authenticated member, paid from their base currency savings
+func (h *ShareHandler) PurchaseShares(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+
+	var reqBody SharePurchaseRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+	if reqBody.Shares <= 0 {
+		utils.RespondWithError(c, http.StatusBadRequest, "shares must be greater than zero", nil)
+		return
+	}
+
+	member, msg, err := h.memberRepo.FetchMemberByUserID(authUser.ID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, msg, err)
+		return
+	}
+	if !requireActiveMember(c, member) {
+		return
+	}
+
+	settings := h.settings()
+	account, transaction, msg, err := h.repo.PurchaseShares(member.ID, reqBody.Shares, settings, authUser.ID)
+	if err != nil {
+		utils.RespondWithError(c, shareErrorStatus(err), msg, err)
+		return
+	}
+
+	respondWithShareMovement(c, http.StatusCreated, msg, account, transaction, settings)
+}
+
+// TransferShares moves shares from the authenticated member to another member
+func (h *ShareHandler) TransferShares(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+
+	var reqBody ShareTransferRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+	if reqBody.Shares <= 0 {
+		utils.RespondWithError(c, http.StatusBadRequest, "shares must be greater than zero", nil)
+		return
+	}
+
+	sender, msg, err := h.memberRepo.FetchMemberByUserID(authUser.ID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, msg, err)
+		return
+	}
+	if !requireActiveMember(c, sender) {
+		return
+	}
+	recipient, msg, err := h.memberRepo.FetchByID(string(reqBody.ToMemberID))
+	if err != nil || recipient == nil {
+		utils.RespondWithError(c, http.StatusNotFound, "recipient member not found", errors.New(msg))
+		return
+	}
+	if sender.ID == recipient.ID {
+		utils.RespondWithError(c, http.StatusBadRequest, "you cannot transfer shares to yourself", nil)
+		return
+	}
+	if !recipient.IsActive() {
+		utils.RespondWithError(c, http.StatusUnprocessableEntity, "recipient is not an active member", models.ErrMemberNotActive)
+		return
+	}
+
+	settings := h.settings()
+	account, transaction, msg, err := h.repo.TransferShares(sender.ID, recipient.ID, reqBody.Shares, settings, authUser.ID, strings.TrimSpace(reqBody.Note))
+	if err != nil {
+		utils.RespondWithError(c, shareErrorStatus(err), msg, err)
+		return
+	}
+
+	respondWithShareMovement(c, http.StatusCreated, msg, account, transaction, settings)
+}
+
+// RedeemShares buys a member's shares back into their savings, admins only
+func (h *ShareHandler) RedeemShares(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermSharesManage) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can redeem shares", nil)
+		return
+	}
+
+	var reqBody ShareRedemptionRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+	if reqBody.Shares <= 0 {
+		utils.RespondWithError(c, http.StatusBadRequest, "shares must be greater than zero", nil)
+		return
+	}
+
+	member, msg, err := h.memberRepo.FetchByID(c.Param("id"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, msg, err)
+		return
+	}
+	if member == nil {
+		utils.RespondWithError(c, http.StatusNotFound, msg, nil)
+		return
+	}
+	if !authUser.CanSeeBranch(member.BranchID) {
+		utils.RespondWithError(c, http.StatusForbidden, "member belongs to another branch", nil)
+		return
+	}
+
+	settings := h.settings()
+	account, transaction, msg, err := h.repo.RedeemShares(member.ID, reqBody.Shares, settings, authUser.ID, strings.TrimSpace(reqBody.Note))
+	if err != nil {
+		utils.RespondWithError(c, shareErrorStatus(err), msg, err)
+		return
+	}
+
+	respondWithShareMovement(c, http.StatusOK, msg, account, transaction, settings)
+}
+
+// GetShareRegister lists every member's holding and certificates, admins only, for one branch with ?branch_id=
+func (h *ShareHandler) GetShareRegister(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermReportsRead) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can view the share register", nil)
+		return
+	}
+	branchID, ok := branchFilter(c, &authUser)
+	if !ok {
+		return
+	}
+
+	accounts, msg, err := h.repo.GetShareAccounts(branchID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "share register fetched successfully", "data", gin.H{
+		"register": models.BuildShareRegister(accounts, h.settings()),
+	})
+}
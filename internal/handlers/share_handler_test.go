@@ -0,0 +1,132 @@
+// Unit tests for ShareHandler endpoints
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cooperative-system/internal/handlers"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockShareRepo struct {
+	repository.ShareRepository
+	GetShareAccountsFunc func(branchID *uint) ([]models.ShareAccount, string, error)
+	PurchaseSharesFunc   func(memberID uint, shares int64, settings models.ShareSettings, postedBy uint) (*models.ShareAccount, *models.ShareTransaction, string, error)
+	TransferSharesFunc   func(fromMemberID uint, toMemberID uint, shares int64, settings models.ShareSettings, postedBy uint, note string) (*models.ShareAccount, *models.ShareTransaction, string, error)
+}
+
+func (m *mockShareRepo) GetShareAccounts(branchID *uint) ([]models.ShareAccount, string, error) {
+	return m.GetShareAccountsFunc(branchID)
+}
+func (m *mockShareRepo) PurchaseShares(memberID uint, shares int64, settings models.ShareSettings, postedBy uint) (*models.ShareAccount, *models.ShareTransaction, string, error) {
+	return m.PurchaseSharesFunc(memberID, shares, settings, postedBy)
+}
+func (m *mockShareRepo) TransferShares(fromMemberID uint, toMemberID uint, shares int64, settings models.ShareSettings, postedBy uint, note string) (*models.ShareAccount, *models.ShareTransaction, string, error) {
+	return m.TransferSharesFunc(fromMemberID, toMemberID, shares, settings, postedBy, note)
+}
+
+func fixedShareSettings() models.ShareSettings {
+	return models.ShareSettings{Price: 100000, MinimumHolding: 10}
+}
+
+func postShares(handler gin.HandlerFunc, role string, body map[string]interface{}) *httptest.ResponseRecorder {
+	r := gin.Default()
+	r.POST("/shares", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = role
+		c.Set("user", user)
+		handler(c)
+	})
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPost, "/shares", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestPurchaseShares_IssuesCertificate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepo := &mockShareRepo{
+		PurchaseSharesFunc: func(memberID uint, shares int64, settings models.ShareSettings, postedBy uint) (*models.ShareAccount, *models.ShareTransaction, string, error) {
+			certificate := models.ShareCertificate{MemberID: memberID, Shares: shares, Status: models.ShareCertificateStatusActive}
+			certificate.ID = 42
+			account := models.ShareAccount{MemberID: memberID, Shares: shares, Certificates: []models.ShareCertificate{certificate}}
+			transaction := models.ShareTransaction{MemberID: memberID, Type: models.ShareTransactionPurchase, Shares: shares, PricePerShare: settings.Price, Amount: settings.Value(shares), Certificate: &certificate}
+			return &account, &transaction, "shares purchased successfully", nil
+		},
+	}
+	h := handlers.NewShareHandler(mockRepo, transferMemberRepo(), fixedShareSettings)
+	w := postShares(h.PurchaseShares, "member", map[string]interface{}{"shares": 12})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `"number":"SC-000042"`)
+	assert.Contains(t, body, `"amount":12000.00`)
+	assert.Contains(t, body, `"meets_minimum":true`)
+}
+
+func TestPurchaseShares_RejectsZeroShares(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewShareHandler(&mockShareRepo{}, transferMemberRepo(), fixedShareSettings)
+	w := postShares(h.PurchaseShares, "member", map[string]interface{}{"shares": -1})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestTransferShares_BelowMinimum(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepo := &mockShareRepo{
+		TransferSharesFunc: func(fromMemberID uint, toMemberID uint, shares int64, settings models.ShareSettings, postedBy uint, note string) (*models.ShareAccount, *models.ShareTransaction, string, error) {
+			err := settings.CheckHolding(12-shares, false)
+			return nil, nil, "you must keep at least 10 shares", err
+		},
+	}
+	h := handlers.NewShareHandler(mockRepo, transferMemberRepo(), fixedShareSettings)
+	w := postShares(h.TransferShares, "member", map[string]interface{}{"to_member_id": 2, "shares": 5})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "you must keep at least 10 shares")
+}
+
+func TestRedeemShares_AdminOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewShareHandler(&mockShareRepo{}, transferMemberRepo(), fixedShareSettings)
+	w := postShares(h.RedeemShares, "member", map[string]interface{}{"shares": 5})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestGetShareRegister_ListsHoldings(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepo := &mockShareRepo{
+		GetShareAccountsFunc: func(branchID *uint) ([]models.ShareAccount, string, error) {
+			var accounts []models.ShareAccount
+			for i, shares := range []int64{15, 4} {
+				certificate := models.ShareCertificate{Shares: shares, Status: models.ShareCertificateStatusActive}
+				certificate.ID = uint(i + 1)
+				account := models.ShareAccount{MemberID: uint(i + 1), Shares: shares, Certificates: []models.ShareCertificate{certificate}}
+				account.Member.Name = fmt.Sprintf("Member %d", i+1)
+				accounts = append(accounts, account)
+			}
+			return accounts, "share accounts fetched successfully", nil
+		},
+	}
+	h := handlers.NewShareHandler(mockRepo, transferMemberRepo(), fixedShareSettings)
+	r := gin.Default()
+	r.GET("/shares/register", adminContext(h.GetShareRegister))
+	req, _ := http.NewRequest(http.MethodGet, "/shares/register", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `"member_name":"Member 1","shares":15,"value":15000.00,"meets_minimum":true,"certificates":["SC-000001"]`)
+	assert.Contains(t, body, `"member_name":"Member 2","shares":4,"value":4000.00,"meets_minimum":false`)
+	assert.Contains(t, body, `"total_shares":19,"total_value":19000.00`)
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"bytes"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+	"cooperative-system/internal/statements"
+	"cooperative-system/pkg/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StatementHandler struct {
+	savingsRepo repository.SavingsRepository
+	loanRepo    repository.LoanRepository
+	memberRepo  repository.MemberRepository
+	feeRepo     repository.FeeRepository
+}
+
+func NewStatementHandler(savingsRepo repository.SavingsRepository, loanRepo repository.LoanRepository, memberRepo repository.MemberRepository, feeRepo repository.FeeRepository) *StatementHandler {
+	return &StatementHandler{
+		savingsRepo: savingsRepo,
+		loanRepo:    loanRepo,
+		memberRepo:  memberRepo,
+		feeRepo:     feeRepo,
+	}
+}
+
+type StatementService interface {
+	GetSavingsStatement(c *gin.Context)
+	GetLoanStatement(c *gin.Context)
+}
+
+// statementPeriod reads the from and to dates (YYYY-MM-DD, both inclusive), defaulting to the current month so far
+func statementPeriod(c *gin.Context) (time.Time, time.Time, bool) {
+	to, err := parseDateParam(c.Query("to"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "to must be in YYYY-MM-DD format", err)
+		return time.Time{}, time.Time{}, false
+	}
+
+	from := time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if c.Query("from") != "" {
+		from, err = time.Parse(time.DateOnly, c.Query("from"))
+		if err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "from must be in YYYY-MM-DD format", err)
+			return time.Time{}, time.Time{}, false
+		}
+	}
+
+	if from.After(to) {
+		utils.RespondWithError(c, http.StatusBadRequest, "from must not be after to", nil)
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// chargeStatementFee applies the statement fees when members generate their own statement. A statement is
+// charged once per account and period, so retries and downloads in another format are free. Staff looking at
+// a member's statement never charge the member for it.
+func (h *StatementHandler) chargeStatementFee(c *gin.Context, member *models.Member, authUser *models.User, from time.Time, to time.Time, feeContext models.FeeContext) bool {
+	if member.UserID != authUser.ID {
+		return true
+	}
+	feeContext.Period = from.Format(time.DateOnly) + "/" + to.Format(time.DateOnly)
+	if _, msg, err := h.feeRepo.ApplyFees(models.FeeTriggerStatementGenerated, feeContext); err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return false
+	}
+	return true
+}
+
+// respondWithStatement sends the statement in the format asked for with ?format=json|csv|pdf
+func respondWithStatement(c *gin.Context, statement *models.Statement) {
+	var buf bytes.Buffer
+	var contentType, extension string
+
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		utils.SuccessResponse(c, http.StatusOK, "statement generated successfully", "data", gin.H{
+			"statement": statement,
+		})
+		return
+	case "csv":
+		if err := statements.WriteCSV(&buf, statement); err != nil {
+			utils.RespondWithError(c, http.StatusInternalServerError, "failed to write statement", err)
+			return
+		}
+		contentType, extension = "text/csv", "csv"
+	case "pdf":
+		if err := statements.WritePDF(&buf, statement); err != nil {
+			utils.RespondWithError(c, http.StatusInternalServerError, "failed to write statement", err)
+			return
+		}
+		contentType, extension = "application/pdf", "pdf"
+	default:
+		utils.RespondWithError(c, http.StatusBadRequest, "format must be one of json, csv or pdf", nil)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+statements.Filename(statement, extension)+`"`)
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+func (h *StatementHandler) GetSavingsStatement(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+
+	member, err := getMemberByIDAndAuthorize(c, h.memberRepo, &authUser, models.PermMembersRead)
+	if err != nil {
+		return
+	}
+
+	currency, ok := savingsCurrency(c)
+	if !ok {
+		return
+	}
+
+	from, to, ok := statementPeriod(c)
+	if !ok {
+		return
+	}
+
+	savings, msg, err := h.savingsRepo.GetSavingsByMemberID(member.ID, currency)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, msg, err)
+		return
+	}
+
+	transactions, msg, err := h.savingsRepo.GetTransactionsBySavingsID(savings.ID, to.AddDate(0, 0, 1))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	statement := models.BuildSavingsStatement(member, savings, transactions, from, to)
+	if !h.chargeStatementFee(c, member, &authUser, from, to, models.FeeContext{MemberID: member.ID, SourceType: models.FeeSourceSavings, SourceID: savings.ID, Currency: savings.Currency, PostedBy: authUser.ID}) {
+		return
+	}
+	respondWithStatement(c, &statement)
+}
+
+func (h *StatementHandler) GetLoanStatement(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+
+	member, err := getMemberByIDAndAuthorize(c, h.memberRepo, &authUser, models.PermMembersRead)
+	if err != nil {
+		return
+	}
+
+	from, to, ok := statementPeriod(c)
+	if !ok {
+		return
+	}
+
+	loan, msg, err := h.loanRepo.GetLoanByID(c.Param("loan_id"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, msg, err)
+		return
+	}
+	if loan.MemberID != member.ID {
+		utils.RespondWithError(c, http.StatusNotFound, "loan not found", errors.New("loan belongs to another member"))
+		return
+	}
+
+	repayments, msg, err := h.loanRepo.GetRepaymentsByLoanID(loan.ID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	statement := models.BuildLoanStatement(member, loan, repayments, from, to)
+	if !h.chargeStatementFee(c, member, &authUser, from, to, models.FeeContext{MemberID: member.ID, SourceType: models.FeeSourceLoan, SourceID: loan.ID, Currency: loan.Currency, PostedBy: authUser.ID}) {
+		return
+	}
+	respondWithStatement(c, &statement)
+}
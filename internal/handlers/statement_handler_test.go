@@ -0,0 +1,169 @@
+// Unit tests for StatementHandler endpoints
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cooperative-system/internal/handlers"
+	"cooperative-system/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func statementSavingsRepo() *mockSavingsRepo {
+	return &mockSavingsRepo{
+		GetSavingsByMemberIDFunc: func(memberID uint, currency string) (*models.Savings, string, error) {
+			savings := models.Savings{MemberID: memberID, Currency: currency}
+			savings.ID = 3
+			return &savings, "savings fetched successfully", nil
+		},
+		GetTransactionsBySavingsIDFunc: func(savingsID uint, before time.Time) ([]models.SavingTransaction, string, error) {
+			var transactions []models.SavingTransaction
+			for i, posting := range []struct {
+				date   string
+				amount models.Money
+			}{{"2025-01-20", 10000}, {"2025-02-03", 5000}, {"2025-02-10", -2500}, {"2025-03-01", 700}} {
+				transaction := models.SavingTransaction{SavingsID: savingsID, Amount: posting.amount, Currency: "NGN", Description: "posting"}
+				transaction.ID = uint(i + 1)
+				transaction.CreatedAt, _ = time.Parse(time.DateOnly, posting.date)
+				if transaction.CreatedAt.Before(before) {
+					transactions = append(transactions, transaction)
+				}
+			}
+			return transactions, "transactions fetched successfully", nil
+		},
+	}
+}
+
+func TestGetSavingsStatement_RunningBalance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewStatementHandler(statementSavingsRepo(), &mockLoanRepo{}, ownMemberRepo(), &mockFeeRepo{})
+	r := gin.Default()
+	r.GET("/members/:id/statements/savings", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "member"
+		c.Set("user", user)
+		h.GetSavingsStatement(c)
+	})
+	req, _ := http.NewRequest(http.MethodGet, "/members/1/statements/savings?from=2025-02-01&to=2025-02-28", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `"opening_balance":100.00`)
+	assert.Contains(t, body, `"credit":50.00,"balance":150.00`)
+	assert.Contains(t, body, `"debit":25.00,"credit":0.00,"balance":125.00`)
+	assert.Contains(t, body, `"closing_balance":125.00`)
+	assert.NotContains(t, body, "TXN-4")
+}
+
+func TestGetSavingsStatement_CSVAndPDF(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewStatementHandler(statementSavingsRepo(), &mockLoanRepo{}, ownMemberRepo(), &mockFeeRepo{})
+	r := gin.Default()
+	r.GET("/members/:id/statements/savings", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "member"
+		c.Set("user", user)
+		h.GetSavingsStatement(c)
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/members/1/statements/savings?from=2025-02-01&to=2025-02-28&format=csv", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "statement-savings-3-2025-02-01-2025-02-28.csv")
+	assert.Contains(t, w.Body.String(), "2025-02-10,TXN-3,posting,25.00,,125.00")
+
+	req, _ = http.NewRequest(http.MethodGet, "/members/1/statements/savings?from=2025-02-01&to=2025-02-28&format=pdf", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(w.Body.String(), "%PDF-1.4"))
+	assert.True(t, strings.HasSuffix(w.Body.String(), "%%EOF\n"))
+}
+
+func TestGetSavingsStatement_InvalidPeriod(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewStatementHandler(statementSavingsRepo(), &mockLoanRepo{}, ownMemberRepo(), &mockFeeRepo{})
+	r := gin.Default()
+	r.GET("/members/:id/statements/savings", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "member"
+		c.Set("user", user)
+		h.GetSavingsStatement(c)
+	})
+	req, _ := http.NewRequest(http.MethodGet, "/members/1/statements/savings?from=2025-03-01&to=2025-02-01", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "from must not be after to")
+}
+
+func TestGetLoanStatement_OtherMembersLoan(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockLoan := &mockLoanRepo{
+		GetLoanByIDFunc: func(loanID string) (*models.Loan, string, error) {
+			loan := models.Loan{MemberID: 2}
+			loan.ID = 9
+			return &loan, "success", nil
+		},
+	}
+	h := handlers.NewStatementHandler(&mockSavingsRepo{}, mockLoan, ownMemberRepo(), &mockFeeRepo{})
+	r := gin.Default()
+	r.GET("/members/:id/statements/loans/:loan_id", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "member"
+		c.Set("user", user)
+		h.GetLoanStatement(c)
+	})
+	req, _ := http.NewRequest(http.MethodGet, "/members/1/statements/loans/9", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetLoanStatement_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	disbursedAt, _ := time.Parse(time.DateOnly, "2025-01-15")
+	mockLoan := &mockLoanRepo{
+		GetLoanByIDFunc: func(loanID string) (*models.Loan, string, error) {
+			loan := models.Loan{MemberID: 1, Type: "personal", Amount: 100000, TotalRepayableAmount: 103500, LoanTermMonths: 12, Status: models.LoanStatusDisbursed, DisbursedAt: &disbursedAt}
+			loan.ID = 9
+			return &loan, "success", nil
+		},
+		GetRepaymentsByLoanIDFunc: func(loanID uint) ([]models.LoanRepayment, string, error) {
+			paidAt, _ := time.Parse(time.DateOnly, "2025-02-15")
+			repayment := models.LoanRepayment{LoanID: loanID, Amount: 8625, PaidAt: paidAt}
+			repayment.ID = 1
+			return []models.LoanRepayment{repayment}, "repayments fetched successfully", nil
+		},
+	}
+	h := handlers.NewStatementHandler(&mockSavingsRepo{}, mockLoan, ownMemberRepo(), &mockFeeRepo{})
+	r := gin.Default()
+	r.GET("/members/:id/statements/loans/:loan_id", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "member"
+		c.Set("user", user)
+		h.GetLoanStatement(c)
+	})
+	req, _ := http.NewRequest(http.MethodGet, "/members/1/statements/loans/9?from=2025-01-01&to=2025-02-28", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"opening_balance":0.00`)
+	assert.Contains(t, w.Body.String(), `"total_debits":1035.00`)
+	assert.Contains(t, w.Body.String(), `"closing_balance":948.75`)
+}
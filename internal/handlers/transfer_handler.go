@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+	"cooperative-system/pkg/utils"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TransferRequest struct {
+	ToMemberID models.MemberReference `json:"to_member_id" binding:"required"` // member ID or member number
+	Amount     models.Money           `json:"amount" binding:"required"`
+	Currency   string                 `json:"currency"` // ISO 4217 code, defaults to the base currency
+	Note       string                 `json:"note"`
+}
+
+type TransferHandler struct {
+	repo       repository.TransferRepository
+	memberRepo repository.MemberRepository
+	dailyLimit func(currency string) models.Money
+}
+
+func NewTransferHandler(transferRepo repository.TransferRepository, memberRepo repository.MemberRepository, dailyLimit func(currency string) models.Money) *TransferHandler {
+	return &TransferHandler{
+		repo:       transferRepo,
+		memberRepo: memberRepo,
+		dailyLimit: dailyLimit,
+	}
+}
+
+type TransferService interface {
+	CreateTransfer(c *gin.Context)
+	ReverseTransfer(c *gin.Context)
+}
+
+// CreateTransfer moves money from the authenticated member's savings to another member's savings
+func (h *TransferHandler) CreateTransfer(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+
+	var reqBody TransferRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	if reqBody.Amount <= 0 {
+		utils.RespondWithError(c, http.StatusBadRequest, "transfer amount must be greater than zero", nil)
+		return
+	}
+
+	currency, err := models.NormalizeCurrency(reqBody.Currency)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// only the sender can move money out of their own savings
+	sender, msg, err := h.memberRepo.FetchMemberByUserID(authUser.ID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, msg, err)
+		return
+	}
+	if !requireActiveMember(c, sender) {
+		return
+	}
+
+	recipient, msg, err := h.memberRepo.FetchByID(string(reqBody.ToMemberID))
+	if err != nil || recipient == nil {
+		utils.RespondWithError(c, http.StatusNotFound, "recipient member not found", errors.New(msg))
+		return
+	}
+	if sender.ID == recipient.ID {
+		utils.RespondWithError(c, http.StatusBadRequest, "you cannot transfer to yourself", nil)
+		return
+	}
+	if !recipient.IsActive() {
+		utils.RespondWithError(c, http.StatusUnprocessableEntity, "recipient is not an active member", models.ErrMemberNotActive)
+		return
+	}
+
+	transfer := models.SavingsTransfer{
+		FromMemberID: sender.ID,
+		ToMemberID:   recipient.ID,
+		Amount:       reqBody.Amount,
+		Currency:     currency,
+		Note:         strings.TrimSpace(reqBody.Note),
+	}
+
+	createdTransfer, msg, err := h.repo.CreateTransfer(&transfer, h.dailyLimit(currency))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrInsufficientBalance) || errors.Is(err, repository.ErrTransferLimitExceeded) || errors.Is(err, repository.ErrRecipientAccountNotFound) {
+			status = http.StatusUnprocessableEntity
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			status = http.StatusNotFound
+		}
+		utils.RespondWithError(c, status, msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "transfer completed successfully", "data", gin.H{
+		"transfer": models.NewSavingsTransferResponse(createdTransfer),
+	})
+}
+
+func (h *TransferHandler) ReverseTransfer(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermTransactionsReverse) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can reverse transfers", nil)
+		return
+	}
+
+	var reqBody ReverseTransactionRequest
+	if err := c.ShouldBindJSON(&reqBody); err != nil || strings.TrimSpace(reqBody.Reason) == "" {
+		utils.RespondWithError(c, http.StatusBadRequest, "a reason is required to reverse a transfer", err)
+		return
+	}
+
+	transfer, msg, err := h.repo.GetTransferByID(c.Param("transfer_id"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, msg, err)
+		return
+	}
+
+	reversed, msg, err := h.repo.ReverseTransfer(transfer.ID, strings.TrimSpace(reqBody.Reason), authUser.ID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrTransferAlreadyReversed) || errors.Is(err, repository.ErrTransactionAlreadyReversed) {
+			status = http.StatusConflict
+		} else if errors.Is(err, repository.ErrInsufficientBalance) {
+			status = http.StatusUnprocessableEntity
+		}
+		utils.RespondWithError(c, status, msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "transfer reversed successfully", "data", gin.H{
+		"transfer": models.NewSavingsTransferResponse(reversed),
+	})
+}
@@ -0,0 +1,163 @@
+// Unit tests for TransferHandler endpoints
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cooperative-system/internal/handlers"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockTransferRepo struct {
+	repository.TransferRepository
+	CreateTransferFunc  func(transfer *models.SavingsTransfer, dailyLimit models.Money) (*models.SavingsTransfer, string, error)
+	GetTransferByIDFunc func(transferID string) (*models.SavingsTransfer, string, error)
+	ReverseTransferFunc func(transferID uint, reason string, postedBy uint) (*models.SavingsTransfer, string, error)
+}
+
+func (m *mockTransferRepo) CreateTransfer(transfer *models.SavingsTransfer, dailyLimit models.Money) (*models.SavingsTransfer, string, error) {
+	return m.CreateTransferFunc(transfer, dailyLimit)
+}
+func (m *mockTransferRepo) GetTransferByID(transferID string) (*models.SavingsTransfer, string, error) {
+	return m.GetTransferByIDFunc(transferID)
+}
+func (m *mockTransferRepo) ReverseTransfer(transferID uint, reason string, postedBy uint) (*models.SavingsTransfer, string, error) {
+	return m.ReverseTransferFunc(transferID, reason, postedBy)
+}
+
+func transferMemberRepo() *mockMemberRepo {
+	return &mockMemberRepo{
+		FetchMemberByUserIDFunc: func(userID uint) (*models.Member, string, error) {
+			member := models.Member{UserID: userID, Status: models.MemberStatusActive}
+			member.ID = 1
+			return &member, "member fetched successfully", nil
+		},
+		// members are looked up by ID or member number, the sender is member 1 and everyone else member 2
+		FetchByIDFunc: func(memberID string) (*models.Member, string, error) {
+			member := models.Member{Status: models.MemberStatusActive}
+			member.ID = 2
+			if memberID == "1" {
+				member.ID = 1
+			}
+			return &member, "member fetched successfully", nil
+		},
+	}
+}
+
+func fixedTransferLimit(currency string) models.Money {
+	return 100000
+}
+
+func postTransfer(h *handlers.TransferHandler, body map[string]interface{}) *httptest.ResponseRecorder {
+	r := gin.Default()
+	r.POST("/savings/transfers", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		user.Role = "member"
+		c.Set("user", user)
+		h.CreateTransfer(c)
+	})
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPost, "/savings/transfers", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateTransfer_ToSelf(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewTransferHandler(&mockTransferRepo{}, transferMemberRepo(), fixedTransferLimit)
+	w := postTransfer(h, map[string]interface{}{"to_member_id": 1, "amount": 100})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "cannot transfer to yourself")
+}
+
+func TestCreateTransfer_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var usedLimit models.Money
+	mockRepo := &mockTransferRepo{
+		CreateTransferFunc: func(transfer *models.SavingsTransfer, dailyLimit models.Money) (*models.SavingsTransfer, string, error) {
+			usedLimit = dailyLimit
+			transfer.ID = 4
+			transfer.Status = models.TransferStatusCompleted
+			transfer.DebitTransactionID = 10
+			transfer.CreditTransactionID = 11
+			return transfer, "transfer completed successfully", nil
+		},
+	}
+	h := handlers.NewTransferHandler(mockRepo, transferMemberRepo(), fixedTransferLimit)
+	w := postTransfer(h, map[string]interface{}{"to_member_id": 2, "amount": "250.50", "note": "school fees"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, models.Money(100000), usedLimit)
+	assert.Contains(t, w.Body.String(), `"from_member_id":1,"to_member_id":2,"amount":250.50,"currency":"NGN"`)
+	assert.Contains(t, w.Body.String(), `"debit_transaction_id":10,"credit_transaction_id":11`)
+}
+
+func TestCreateTransfer_ToMemberNumber(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var created *models.SavingsTransfer
+	mockRepo := &mockTransferRepo{
+		CreateTransferFunc: func(transfer *models.SavingsTransfer, dailyLimit models.Money) (*models.SavingsTransfer, string, error) {
+			created = transfer
+			return transfer, "transfer completed successfully", nil
+		},
+	}
+	var lookedUp string
+	memberRepo := transferMemberRepo()
+	fetchByID := memberRepo.FetchByIDFunc
+	memberRepo.FetchByIDFunc = func(memberID string) (*models.Member, string, error) {
+		lookedUp = memberID
+		return fetchByID(memberID)
+	}
+	h := handlers.NewTransferHandler(mockRepo, memberRepo, fixedTransferLimit)
+	w := postTransfer(h, map[string]interface{}{"to_member_id": "HQ26000024", "amount": 100})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "HQ26000024", lookedUp)
+	if assert.NotNil(t, created) {
+		assert.Equal(t, uint(2), created.ToMemberID)
+	}
+}
+
+func TestCreateTransfer_LimitExceeded(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepo := &mockTransferRepo{
+		CreateTransferFunc: func(transfer *models.SavingsTransfer, dailyLimit models.Money) (*models.SavingsTransfer, string, error) {
+			return nil, repository.ErrTransferLimitExceeded.Error(), repository.ErrTransferLimitExceeded
+		},
+	}
+	h := handlers.NewTransferHandler(mockRepo, transferMemberRepo(), fixedTransferLimit)
+	w := postTransfer(h, map[string]interface{}{"to_member_id": 2, "amount": 2000})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestReverseTransfer_AlreadyReversed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepo := &mockTransferRepo{
+		GetTransferByIDFunc: func(transferID string) (*models.SavingsTransfer, string, error) {
+			transfer := models.SavingsTransfer{Status: models.TransferStatusReversed}
+			transfer.ID = 4
+			return &transfer, "transfer fetched successfully", nil
+		},
+		ReverseTransferFunc: func(transferID uint, reason string, postedBy uint) (*models.SavingsTransfer, string, error) {
+			return nil, repository.ErrTransferAlreadyReversed.Error(), repository.ErrTransferAlreadyReversed
+		},
+	}
+	h := handlers.NewTransferHandler(mockRepo, transferMemberRepo(), fixedTransferLimit)
+	r := gin.Default()
+	r.POST("/savings/transfers/:transfer_id/reverse", adminContext(h.ReverseTransfer))
+	jsonBody, _ := json.Marshal(map[string]interface{}{"reason": "sent to the wrong member"})
+	req, _ := http.NewRequest(http.MethodPost, "/savings/transfers/4/reverse", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
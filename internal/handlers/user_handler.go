@@ -4,10 +4,15 @@ import (
 	"cooperative-system/internal/models"
 	"cooperative-system/internal/repository"
 	"cooperative-system/pkg/utils"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type RequestBody struct {
@@ -15,19 +20,31 @@ type RequestBody struct {
 	Password string `json:"password"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 type UserHandler struct {
-	UserRepo repository.UserRepository
+	UserRepo    repository.UserRepository
+	SessionRepo repository.SessionRepository
+	settings    func() models.TokenSettings
 }
 
-func NewUserHandler(userRepo repository.UserRepository) *UserHandler {
+func NewUserHandler(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, tokenSettings func() models.TokenSettings) *UserHandler {
 	return &UserHandler{
-		UserRepo: userRepo,
+		UserRepo:    userRepo,
+		SessionRepo: sessionRepo,
+		settings:    tokenSettings,
 	}
 }
 
 type UserService interface {
 	Signup(c *gin.Context)
 	Login(c *gin.Context)
+	Refresh(c *gin.Context)
+	Logout(c *gin.Context)
+	LogoutAllSessions(c *gin.Context)
+	RevokeUserSessions(c *gin.Context)
 }
 
 func (u *UserHandler) Signup(c *gin.Context) {
@@ -85,17 +102,166 @@ func (u *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	tokenString, err := utils.CreateToken(user.Email)
+	settings := u.settings()
+
+	token, refreshToken, tokenHash, ok := issueTokens(c, user, settings)
+	if !ok {
+		return
+	}
+	_, msg, err = u.SessionRepo.CreateRefreshToken(&models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  tokenHash, // the family is named after the login's first token
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(settings.RefreshTTL),
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	})
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	respondWithTokens(c, "login successful", user, token, refreshToken, settings)
+}
+
+// issueTokens makes a new access token and refresh token for the user, responding when either fails
+func issueTokens(c *gin.Context, user *models.User, settings models.TokenSettings) (string, string, string, bool) {
+	token, err := utils.CreateToken(user.Email, settings.AccessTTL)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "unable to create token", err)
+		return "", "", "", false
+	}
+	refreshToken, tokenHash, err := utils.NewRefreshToken()
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "unable to create refresh token", err)
+		return "", "", "", false
+	}
+	return token, refreshToken, tokenHash, true
+}
+
+// respondWithTokens sends the tokens in the body for mobile and server clients, and sets them as cookies
+// for browsers
+func respondWithTokens(c *gin.Context, message string, user *models.User, token string, refreshToken string, settings models.TokenSettings) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie("Authorization", token, int(settings.AccessTTL.Seconds()), "", "", false, true)
+	c.SetCookie(models.RefreshTokenCookie, refreshToken, int(settings.RefreshTTL.Seconds()), "", "", false, true)
+	utils.SuccessResponse(c, http.StatusOK, message, "data", gin.H{
+		"user_id":       user.ID,
+		"token":         token,
+		"token_type":    "Bearer",
+		"expires_in":    int64(settings.AccessTTL.Seconds()),
+		"refresh_token": refreshToken,
+	})
+}
+
+// refreshTokenFromRequest reads the refresh token from the body, for mobile and server clients, or else from
+// the cookie set at login
+func refreshTokenFromRequest(c *gin.Context) string {
+	var reqBody RefreshRequest
+	_ = c.ShouldBindJSON(&reqBody)
+	if token := strings.TrimSpace(reqBody.RefreshToken); token != "" {
+		return token
+	}
+	token, _ := c.Cookie(models.RefreshTokenCookie)
+	return token
+}
+
+// Refresh swaps a refresh token for a new access token and a new refresh token. Each refresh token works
+// once: using one again logs out the whole session, as it means the token was stolen.
+func (u *UserHandler) Refresh(c *gin.Context) {
+	current := refreshTokenFromRequest(c)
+	if current == "" {
+		utils.RespondWithError(c, http.StatusUnauthorized, "refresh token is required", nil)
+		return
+	}
+
+	settings := u.settings()
+	refreshToken, tokenHash, err := utils.NewRefreshToken()
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "unable to create refresh token", err)
+		return
+	}
+	next := models.RefreshToken{
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(settings.RefreshTTL),
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+	rotated, msg, err := u.SessionRepo.RotateRefreshToken(utils.HashRefreshToken(current), &next)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrRefreshTokenInvalid) || errors.Is(err, repository.ErrRefreshTokenReused) {
+			status = http.StatusUnauthorized
+			msg = err.Error()
+		}
+		utils.RespondWithError(c, status, msg, err)
+		return
+	}
+
+	token, err := utils.CreateToken(rotated.User.Email, settings.AccessTTL)
 	if err != nil {
-		utils.RespondWithError(c, http.StatusUnauthorized, "unable to create token", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "unable to create token", err)
 		return
 	}
+	respondWithTokens(c, "token refreshed successfully", &rotated.User, token, refreshToken, settings)
+}
+
+// Logout ends the session of the refresh token and clears the cookies. The access token still works until it
+// expires, a few minutes at most, use LogoutAllSessions to stop it straight away.
+func (u *UserHandler) Logout(c *gin.Context) {
+	if current := refreshTokenFromRequest(c); current != "" {
+		if msg, err := u.SessionRepo.RevokeRefreshToken(utils.HashRefreshToken(current)); err != nil {
+			utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+			return
+		}
+	}
 
 	c.SetSameSite(http.SameSiteLaxMode)
-	c.SetCookie("Authorization", tokenString, 3600*24*30, "", "", false, true)
-	utils.SuccessResponse(c, http.StatusOK, "login successful", "data", gin.H{
-		"user_id": user.ID,
-		"token":  tokenString,
+	c.SetCookie("Authorization", "", -1, "", "", false, true)
+	c.SetCookie(models.RefreshTokenCookie, "", -1, "", "", false, true)
+	utils.SuccessResponse(c, http.StatusOK, "logged out successfully", "data", gin.H{})
+}
+
+// LogoutAllSessions logs the signed in user out on every device, refusing every token already issued to them
+func (u *UserHandler) LogoutAllSessions(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusUnauthorized, "unauthenticated user", nil)
+		return
+	}
+	u.revokeSessions(c, authUser.ID, models.RevokedLogoutAll)
+}
+
+// RevokeUserSessions logs a user out on every device on behalf of an admin, for an account that has been
+// compromised
+func (u *UserHandler) RevokeUserSessions(c *gin.Context) {
+	authUser, ok := getAuthUser(c)
+	if !ok || !authUser.Can(models.PermUsersManage) {
+		utils.RespondWithError(c, http.StatusForbidden, "only admins can log out other users", nil)
+		return
+	}
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "invalid user ID", err)
+		return
+	}
+	u.revokeSessions(c, uint(userID), models.RevokedByAdmin)
+}
+
+func (u *UserHandler) revokeSessions(c *gin.Context, userID uint, reason string) {
+	sessions, msg, err := u.SessionRepo.RevokeUserSessions(userID, reason)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			status = http.StatusNotFound
+		}
+		utils.RespondWithError(c, status, msg, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, msg, "data", gin.H{
+		"user_id":          userID,
+		"sessions_revoked": sessions,
 	})
 }
 
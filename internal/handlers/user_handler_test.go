@@ -8,10 +8,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"cooperative-system/internal/handlers"
 	"cooperative-system/internal/models"
 	"cooperative-system/internal/repository"
+	"cooperative-system/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -43,7 +45,7 @@ func TestSignup_Success(t *testing.T) {
 			return user, "user created successfully", nil
 		},
 	}
-	h := handlers.NewUserHandler(mockRepo)
+	h := handlers.NewUserHandler(mockRepo, newMemorySessionRepo(), models.DefaultTokenSettings)
 	r := gin.Default()
 	r.POST("/signup", h.Signup)
 	body := map[string]interface{}{"email": "test@example.com", "password": "password"}
@@ -58,7 +60,7 @@ func TestSignup_Success(t *testing.T) {
 
 func TestSignup_InvalidBody(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	h := handlers.NewUserHandler(&mockUserRepo{})
+	h := handlers.NewUserHandler(&mockUserRepo{}, newMemorySessionRepo(), models.DefaultTokenSettings)
 	r := gin.Default()
 	r.POST("/signup", h.Signup)
 	req, _ := http.NewRequest(http.MethodPost, "/signup", bytes.NewBuffer([]byte(`{"email":123}`)))
@@ -76,7 +78,7 @@ func TestSignup_RepoError(t *testing.T) {
 			return nil, "repo error", errors.New("db error")
 		},
 	}
-	h := handlers.NewUserHandler(mockRepo)
+	h := handlers.NewUserHandler(mockRepo, newMemorySessionRepo(), models.DefaultTokenSettings)
 	r := gin.Default()
 	r.POST("/signup", h.Signup)
 	body := map[string]interface{}{"email": "test@example.com", "password": "password"}
@@ -103,7 +105,7 @@ func TestLogin_Success(t *testing.T) {
 			return &user, "success", nil
 		},
 	}
-	h := handlers.NewUserHandler(mockRepo)
+	h := handlers.NewUserHandler(mockRepo, newMemorySessionRepo(), models.DefaultTokenSettings)
 	r := gin.Default()
 	r.POST("/login", h.Login)
 	// Using a valid bcrypt hash that matches the password "password"
@@ -119,7 +121,7 @@ func TestLogin_Success(t *testing.T) {
 
 func TestLogin_InvalidBody(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	h := handlers.NewUserHandler(&mockUserRepo{})
+	h := handlers.NewUserHandler(&mockUserRepo{}, newMemorySessionRepo(), models.DefaultTokenSettings)
 	r := gin.Default()
 	r.POST("/login", h.Login)
 	req, _ := http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer([]byte(`{"email":123}`)))
@@ -137,7 +139,7 @@ func TestLogin_UserNotFound(t *testing.T) {
 			return nil, "not found", errors.New("not found")
 		},
 	}
-	h := handlers.NewUserHandler(mockRepo)
+	h := handlers.NewUserHandler(mockRepo, newMemorySessionRepo(), models.DefaultTokenSettings)
 	r := gin.Default()
 	r.POST("/login", h.Login)
 	body := map[string]interface{}{"email": "test@example.com", "password": "password"}
@@ -149,3 +151,128 @@ func TestLogin_UserNotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	assert.Contains(t, w.Body.String(), "not found")
 }
+
+// memorySessionRepo keeps refresh tokens in a map by hash, rotating them the way the database does
+type memorySessionRepo struct {
+	repository.SessionRepository
+	tokens map[string]*models.RefreshToken
+}
+
+func newMemorySessionRepo() *memorySessionRepo {
+	return &memorySessionRepo{tokens: make(map[string]*models.RefreshToken)}
+}
+
+func (m *memorySessionRepo) CreateRefreshToken(token *models.RefreshToken) (*models.RefreshToken, string, error) {
+	m.tokens[token.TokenHash] = token
+	return token, "refresh token created successfully", nil
+}
+func (m *memorySessionRepo) RotateRefreshToken(tokenHash string, next *models.RefreshToken) (*models.RefreshToken, string, error) {
+	current, ok := m.tokens[tokenHash]
+	if !ok {
+		return nil, "refresh token not found", repository.ErrRefreshTokenInvalid
+	}
+	now := time.Now()
+	if current.RotatedAt != nil {
+		m.revokeFamily(current.FamilyID, now)
+		return nil, "refresh token reused", repository.ErrRefreshTokenReused
+	}
+	if !current.Usable(now) {
+		return nil, "refresh token expired or revoked", repository.ErrRefreshTokenInvalid
+	}
+	current.RotatedAt = &now
+	next.UserID, next.FamilyID = current.UserID, current.FamilyID
+	next.User = models.User{Email: "test@example.com"}
+	next.User.ID = current.UserID
+	m.tokens[next.TokenHash] = next
+	return next, "refresh token rotated successfully", nil
+}
+func (m *memorySessionRepo) RevokeRefreshToken(tokenHash string) (string, error) {
+	if token, ok := m.tokens[tokenHash]; ok {
+		m.revokeFamily(token.FamilyID, time.Now())
+	}
+	return "logged out successfully", nil
+}
+func (m *memorySessionRepo) revokeFamily(familyID string, now time.Time) {
+	for _, token := range m.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+}
+
+func postRefreshToken(r *gin.Engine, path string, refreshToken string) *httptest.ResponseRecorder {
+	jsonBody, _ := json.Marshal(map[string]string{"refresh_token": refreshToken})
+	req, _ := http.NewRequest(http.MethodPost, path, bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+type tokenBody struct {
+	Data struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	} `json:"data"`
+}
+
+func TestRefresh_RotatesAndDetectsReuse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	mockRepo := &mockUserRepo{
+		FindUserByEmailFunc: func(email string) (*models.User, string, error) {
+			user := models.User{Email: email, Password: string(hashedPassword), Role: "member"}
+			user.ID = 1
+			return &user, "success", nil
+		},
+	}
+	sessions := newMemorySessionRepo()
+	h := handlers.NewUserHandler(mockRepo, sessions, models.DefaultTokenSettings)
+	r := gin.Default()
+	r.POST("/login", h.Login)
+	r.POST("/refresh", h.Refresh)
+	r.POST("/logout", h.Logout)
+
+	jsonBody, _ := json.Marshal(map[string]string{"email": "test@example.com", "password": "password"})
+	req, _ := http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var login tokenBody
+	_ = json.Unmarshal(w.Body.Bytes(), &login)
+	assert.NotEmpty(t, login.Data.Token)
+	assert.Equal(t, int64(15*60), login.Data.ExpiresIn)
+	assert.Contains(t, w.Header().Values("Set-Cookie")[1], models.RefreshTokenCookie+"=")
+
+	w = postRefreshToken(r, "/refresh", login.Data.RefreshToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var refreshed tokenBody
+	_ = json.Unmarshal(w.Body.Bytes(), &refreshed)
+	assert.NotEqual(t, login.Data.RefreshToken, refreshed.Data.RefreshToken)
+
+	// replaying the first token logs the whole session out, so the new one stops working too
+	w = postRefreshToken(r, "/refresh", login.Data.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "already used")
+	w = postRefreshToken(r, "/refresh", refreshed.Data.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestLogout_RevokesRefreshToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sessions := newMemorySessionRepo()
+	_, _, _ = sessions.CreateRefreshToken(&models.RefreshToken{UserID: 1, FamilyID: "family", TokenHash: utils.HashRefreshToken("refresh-me"), ExpiresAt: time.Now().Add(time.Hour)})
+	h := handlers.NewUserHandler(&mockUserRepo{}, sessions, models.DefaultTokenSettings)
+	r := gin.Default()
+	r.POST("/refresh", h.Refresh)
+	r.POST("/logout", h.Logout)
+
+	w := postRefreshToken(r, "/logout", "refresh-me")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Set-Cookie"), "Max-Age=0")
+
+	w = postRefreshToken(r, "/refresh", "refresh-me")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
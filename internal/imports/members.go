@@ -0,0 +1,286 @@
+package imports
+
+import (
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const DefaultBatchSize = 50
+
+// ErrInvalidFile is returned when the file cannot be read as a member import at all, such as when a
+// required column is missing. Problems with single lines are reported per line instead.
+var ErrInvalidFile = errors.New("invalid member import file")
+
+// requiredColumns are the columns every import file needs, occupation, employer, branch and group are optional
+var requiredColumns = []string{"email", "name", "contact_info", "phone", "address", "date_of_birth", "id_type", "id_number"}
+
+type MemberImportOptions struct {
+	// DryRun validates the file and reports what would happen without creating anything
+	DryRun bool
+	// Approve makes the imported members active straight away instead of leaving them as applications
+	Approve    bool
+	BatchSize  int
+	ImportedBy uint
+	// Branch is where members go when their line names no branch, the importing admin's own branch
+	Branch *uint
+	// BranchScope limits the branches lines can name to the one the importing admin is limited to
+	BranchScope *uint
+}
+
+// ImportMembers reads a CSV of members, validates every line and creates the valid ones in batches, each
+// batch in its own transaction. Lines whose email already has a member are skipped, so a file can be run
+// again after fixing the lines that were invalid. If a batch fails, the report covers the batches that
+// were imported before it.
+func ImportMembers(repo repository.MemberRepository, r io.Reader, options MemberImportOptions) (*models.MemberImportReport, string, error) {
+	rows, invalid, err := parseMemberCSV(r, options)
+	if err != nil {
+		return nil, err.Error(), err
+	}
+
+	report := &models.MemberImportReport{
+		DryRun:  options.DryRun,
+		Total:   len(rows) + len(invalid),
+		Invalid: len(invalid),
+		Rows:    invalid,
+	}
+
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	for start := 0; start < len(rows); start += batchSize {
+		batch := rows[start:min(start+batchSize, len(rows))]
+
+		passwords, err := setTemporaryPasswords(batch, options.DryRun)
+		if err != nil {
+			return report, "failed to create temporary passwords", err
+		}
+
+		results, msg, err := repo.ImportMembers(batch, options.BranchScope, options.ImportedBy, options.DryRun)
+		if err != nil {
+			sortReport(report)
+			return report, msg, err
+		}
+		for i, result := range results {
+			switch result.Status {
+			case models.MemberImportSkipped:
+				report.Skipped++
+			case models.MemberImportInvalid:
+				report.Invalid++
+			default:
+				report.Created++
+			}
+			if result.UserCreated && !options.DryRun {
+				result.TemporaryPassword = passwords[i]
+			}
+			report.Rows = append(report.Rows, result)
+		}
+	}
+
+	sortReport(report)
+	if options.DryRun {
+		return report, "import checked successfully", nil
+	}
+	return report, "members imported successfully", nil
+}
+
+// parseMemberCSV turns each line of the file into an import row, or into an invalid result listing
+// everything wrong with the line
+func parseMemberCSV(r io.Reader, options MemberImportOptions) ([]models.MemberImportRow, []models.MemberImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, fmt.Errorf("%w: the file is empty", ErrInvalidFile)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	var missing []string
+	for _, name := range requiredColumns {
+		if _, ok := columns[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, nil, fmt.Errorf("%w: missing columns %s", ErrInvalidFile, strings.Join(missing, ", "))
+	}
+
+	var rows []models.MemberImportRow
+	var invalid []models.MemberImportResult
+	emailLines := make(map[string]int)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			invalid = append(invalid, models.MemberImportResult{
+				Line:   parseErr.StartLine,
+				Status: models.MemberImportInvalid,
+				Errors: []string{parseErr.Err.Error()},
+			})
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row, problems := memberImportRow(line, field, options)
+		if row.Email != "" {
+			key := strings.ToLower(row.Email)
+			if first, ok := emailLines[key]; ok {
+				problems = append(problems, "email is repeated from line "+strconv.Itoa(first))
+			} else {
+				emailLines[key] = line
+			}
+		}
+		if len(problems) > 0 {
+			invalid = append(invalid, models.MemberImportResult{
+				Line:   line,
+				Email:  row.Email,
+				Status: models.MemberImportInvalid,
+				Errors: problems,
+			})
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, invalid, nil
+}
+
+// memberImportRow builds the member for one line and lists every problem with it, so a file can be fixed
+// in one go
+func memberImportRow(line int, field func(string) string, options MemberImportOptions) (models.MemberImportRow, []string) {
+	var problems []string
+
+	email := field("email")
+	if email == "" {
+		problems = append(problems, "email is required")
+	} else if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		problems = append(problems, "email is not a valid address")
+	}
+
+	member := models.Member{
+		Name:        field("name"),
+		ContactInfo: field("contact_info"),
+		Status:      models.MemberStatusApplied,
+		Phone:       models.NormalizePhone(field("phone")),
+		Address:     field("address"),
+		IDType:      strings.ToLower(field("id_type")),
+		IDNumber:    field("id_number"),
+		Occupation:  field("occupation"),
+		Employer:    field("employer"),
+		BranchID:    options.Branch,
+	}
+	if member.Name == "" {
+		problems = append(problems, "name is required")
+	}
+	if member.ContactInfo == "" {
+		problems = append(problems, "contact info is required")
+	}
+	dateOfBirth := field("date_of_birth")
+	if dateOfBirth != "" {
+		parsed, err := time.Parse(time.DateOnly, dateOfBirth)
+		if err != nil {
+			problems = append(problems, "date of birth must be in YYYY-MM-DD format")
+		} else {
+			member.DateOfBirth = &parsed
+		}
+	}
+	validate := member.ValidateKYC
+	if dateOfBirth != "" && member.DateOfBirth == nil {
+		// the date has been reported already, so only check the format of the rest
+		validate = member.ValidateKYCFormat
+	}
+	if err := validate(); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if options.Approve {
+		now := time.Now()
+		member.Status = models.MemberStatusActive
+		member.ReviewedBy = &options.ImportedBy
+		member.ReviewedAt = &now
+		member.ApprovedAt = &now
+	}
+
+	return models.MemberImportRow{
+		Line:       line,
+		Email:      email,
+		BranchCode: models.NormalizeNumber(field("branch")),
+		GroupName:  field("group"),
+		Member:     member,
+	}, problems
+}
+
+// setTemporaryPasswords gives every row in the batch a random password for the user the import may create.
+// A dry run rolls the users back, so it skips the hashing.
+func setTemporaryPasswords(rows []models.MemberImportRow, dryRun bool) ([]string, error) {
+	passwords := make([]string, len(rows))
+	for i := range rows {
+		if dryRun {
+			rows[i].PasswordHash = "dry-run"
+			continue
+		}
+
+		password, hash, err := TemporaryPassword()
+		if err != nil {
+			return nil, err
+		}
+		passwords[i] = password
+		rows[i].PasswordHash = hash
+	}
+	return passwords, nil
+}
+
+// TemporaryPassword makes a random password for a user created on a member's behalf, with its bcrypt hash
+func TemporaryPassword() (string, string, error) {
+	random := make([]byte, 8)
+	if _, err := rand.Read(random); err != nil {
+		return "", "", err
+	}
+	password := hex.EncodeToString(random)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", err
+	}
+	return password, string(hash), nil
+}
+
+func sortReport(report *models.MemberImportReport) {
+	sort.SliceStable(report.Rows, func(i, j int) bool {
+		return report.Rows[i].Line < report.Rows[j].Line
+	})
+}
@@ -0,0 +1,187 @@
+// Unit tests for the member CSV import
+package imports_test
+
+import (
+	"strings"
+	"testing"
+
+	"cooperative-system/internal/imports"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const memberImportHeader = "email,name,contact_info,phone,address,date_of_birth,id_type,id_number\n"
+
+const memberImportFile = memberImportHeader +
+	"ada@example.com,Ada Obi,ada contact,+2348012345678,1 Marina Lagos,1990-04-12,national_id,12345678901\n" +
+	"ben@example.com,Ben Eze,ben contact,0802 345 6789,2 Broad Street,1985-01-30,passport,A1234567\n"
+
+// mockImportRepo keeps the members an import creates by email, like the database it skips emails that
+// already have a member and keeps nothing from a dry run
+type mockImportRepo struct {
+	repository.MemberRepository
+	members map[string]uint
+	hashes  []string
+}
+
+func newMockImportRepo() *mockImportRepo {
+	return &mockImportRepo{members: make(map[string]uint)}
+}
+
+func (m *mockImportRepo) ImportMembers(rows []models.MemberImportRow, branchScope *uint, importedBy uint, dryRun bool) ([]models.MemberImportResult, string, error) {
+	results := make([]models.MemberImportResult, len(rows))
+	for i, row := range rows {
+		m.hashes = append(m.hashes, row.PasswordHash)
+		results[i] = models.MemberImportResult{Line: row.Line, Email: row.Email}
+		key := strings.ToLower(row.Email)
+		if id, ok := m.members[key]; ok {
+			results[i].Status = models.MemberImportSkipped
+			results[i].MemberID = id
+			continue
+		}
+		if dryRun {
+			results[i].Status = models.MemberImportWouldCreate
+			continue
+		}
+		m.members[key] = uint(len(m.members) + 1)
+		results[i].Status = models.MemberImportCreated
+		results[i].MemberID = m.members[key]
+		results[i].UserCreated = true
+	}
+	return results, "members imported successfully", nil
+}
+
+func TestImportMembers_BadRows(t *testing.T) {
+	valid := "ada@example.com,Ada Obi,ada contact,+2348012345678,1 Marina Lagos,1990-04-12,national_id,12345678901\n"
+	tests := []struct {
+		name     string
+		line     string
+		expected string
+	}{
+		{name: "no email", line: ",Ada Obi,ada contact,+2348012345678,1 Marina Lagos,1990-04-12,national_id,1\n", expected: "email is required"},
+		{name: "invalid email", line: "not-an-email,Ada Obi,ada contact,+2348012345678,1 Marina Lagos,1990-04-12,national_id,1\n", expected: "email is not a valid address"},
+		{name: "repeated email", line: "ADA@example.com,Ada Obi,ada contact,+2348012345678,1 Marina Lagos,1990-04-12,national_id,1\n", expected: "email is repeated from line 2"},
+		{name: "no name", line: "cy@example.com,,cy contact,+2348012345678,1 Marina Lagos,1990-04-12,national_id,1\n", expected: "name is required"},
+		{name: "no contact info", line: "cy@example.com,Cy Ade,,+2348012345678,1 Marina Lagos,1990-04-12,national_id,1\n", expected: "contact info is required"},
+		{name: "badly formatted date of birth", line: "cy@example.com,Cy Ade,cy contact,+2348012345678,1 Marina Lagos,12/04/1990,national_id,1\n", expected: "date of birth must be in YYYY-MM-DD format"},
+		{name: "date of birth in the future", line: "cy@example.com,Cy Ade,cy contact,+2348012345678,1 Marina Lagos,2999-01-01,national_id,1\n", expected: "date of birth must be in the past"},
+		{name: "unknown id type", line: "cy@example.com,Cy Ade,cy contact,+2348012345678,1 Marina Lagos,1990-04-12,library_card,1\n", expected: "id type must be"},
+		{name: "bad phone", line: "cy@example.com,Cy Ade,cy contact,12ab,1 Marina Lagos,1990-04-12,national_id,1\n", expected: "phone must be 7 to 15 digits"},
+		{name: "no id number", line: "cy@example.com,Cy Ade,cy contact,+2348012345678,1 Marina Lagos,1990-04-12,national_id,\n", expected: "id number is required"},
+		{name: "unterminated quote", line: "cy@example.com,\"Cy Ade,cy contact\n", expected: "extraneous or missing"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newMockImportRepo()
+			report, _, err := imports.ImportMembers(repo, strings.NewReader(memberImportHeader+valid+tc.line), imports.MemberImportOptions{DryRun: true})
+			assert.NoError(t, err)
+			assert.Equal(t, 2, report.Total)
+			assert.Equal(t, 1, report.Invalid)
+			assert.Equal(t, 1, report.Created)
+
+			// the valid line still goes through, the bad one is reported on its own line
+			assert.Len(t, report.Rows, 2)
+			assert.Equal(t, models.MemberImportWouldCreate, report.Rows[0].Status)
+			bad := report.Rows[1]
+			assert.Equal(t, 3, bad.Line)
+			assert.Equal(t, models.MemberImportInvalid, bad.Status)
+			assert.Contains(t, strings.Join(bad.Errors, "; "), tc.expected)
+			assert.Len(t, repo.hashes, 1)
+		})
+	}
+}
+
+func TestImportMembers_InvalidFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		expected string
+	}{
+		{name: "empty", file: "", expected: "the file is empty"},
+		{name: "missing columns", file: "email,name\nada@example.com,Ada Obi\n", expected: "missing columns contact_info, phone"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newMockImportRepo()
+			report, msg, err := imports.ImportMembers(repo, strings.NewReader(tc.file), imports.MemberImportOptions{})
+			assert.ErrorIs(t, err, imports.ErrInvalidFile)
+			assert.Nil(t, report)
+			assert.Contains(t, msg, tc.expected)
+			assert.Empty(t, repo.hashes)
+		})
+	}
+}
+
+func TestImportMembers_Reruns(t *testing.T) {
+	type run struct {
+		dryRun  bool
+		created int
+		skipped int
+		status  string
+	}
+	tests := []struct {
+		name    string
+		runs    []run
+		members int
+	}{
+		{
+			name:    "dry run writes nothing",
+			runs:    []run{{dryRun: true, created: 2, status: models.MemberImportWouldCreate}},
+			members: 0,
+		},
+		{
+			name: "dry run then import",
+			runs: []run{
+				{dryRun: true, created: 2, status: models.MemberImportWouldCreate},
+				{dryRun: false, created: 2, status: models.MemberImportCreated},
+			},
+			members: 2,
+		},
+		{
+			name: "second import of the same file creates nothing",
+			runs: []run{
+				{dryRun: false, created: 2, status: models.MemberImportCreated},
+				{dryRun: false, skipped: 2, status: models.MemberImportSkipped},
+			},
+			members: 2,
+		},
+		{
+			name: "dry run after an import reports the skips",
+			runs: []run{
+				{dryRun: false, created: 2, status: models.MemberImportCreated},
+				{dryRun: true, skipped: 2, status: models.MemberImportSkipped},
+			},
+			members: 2,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newMockImportRepo()
+			for i, r := range tc.runs {
+				repo.hashes = nil
+				report, _, err := imports.ImportMembers(repo, strings.NewReader(memberImportFile), imports.MemberImportOptions{DryRun: r.dryRun, ImportedBy: 1})
+				assert.NoError(t, err, "run %d", i+1)
+				assert.Equal(t, r.dryRun, report.DryRun, "run %d", i+1)
+				assert.Equal(t, 2, report.Total, "run %d", i+1)
+				assert.Equal(t, r.created, report.Created, "run %d", i+1)
+				assert.Equal(t, r.skipped, report.Skipped, "run %d", i+1)
+				assert.Equal(t, 0, report.Invalid, "run %d", i+1)
+				for _, row := range report.Rows {
+					assert.Equal(t, r.status, row.Status, "run %d line %d", i+1, row.Line)
+					// only users a real run created get a temporary password
+					assert.Equal(t, row.Status == models.MemberImportCreated, row.TemporaryPassword != "", "run %d line %d", i+1, row.Line)
+				}
+				if r.dryRun {
+					// a dry run is rolled back, so it does not hash passwords for users it will not keep
+					assert.Equal(t, []string{"dry-run", "dry-run"}, repo.hashes, "run %d", i+1)
+				}
+			}
+			assert.Len(t, repo.members, tc.members)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+	"fmt"
+	"log"
+	"time"
+)
+
+// MaturityRunResult summarises a single pass over the matured fixed deposits
+type MaturityRunResult struct {
+	Processed []models.FixedDeposit
+	Failed    map[uint]string
+}
+
+// ProcessMaturedFixedDeposits settles every active deposit whose maturity date is on or before asOf.
+// A failure on one deposit is recorded and does not stop the others from being processed.
+func ProcessMaturedFixedDeposits(repo repository.FixedDepositRepository, asOf time.Time) (*MaturityRunResult, string, error) {
+	deposits, msg, err := repo.GetMaturedFixedDeposits(asOf)
+	if err != nil {
+		return nil, msg, err
+	}
+
+	result := &MaturityRunResult{Failed: make(map[uint]string)}
+	for _, deposit := range deposits {
+		matured, msg, err := repo.MatureFixedDeposit(deposit.ID, asOf)
+		if err != nil {
+			log.Printf("Error maturing fixed deposit %d: %v", deposit.ID, err)
+			result.Failed[deposit.ID] = msg
+			continue
+		}
+		result.Processed = append(result.Processed, *matured)
+	}
+
+	return result, fmt.Sprintf("processed %d matured fixed deposits", len(result.Processed)), nil
+}
+
+// StartFixedDepositMaturityJob runs ProcessMaturedFixedDeposits every interval until the returned stop func is called
+func StartFixedDepositMaturityJob(repo repository.FixedDepositRepository, interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				result, msg, err := ProcessMaturedFixedDeposits(repo, now)
+				if err != nil {
+					log.Printf("Error running fixed deposit maturity job: %s: %v", msg, err)
+					continue
+				}
+				if len(result.Processed) > 0 || len(result.Failed) > 0 {
+					log.Printf("fixed deposit maturity job: %s, %d failed", msg, len(result.Failed))
+				}
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
@@ -4,43 +4,106 @@ import (
 	"cooperative-system/internal/config"
 	"cooperative-system/internal/models"
 	"cooperative-system/pkg/utils"
+	"errors"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// AuthCookie is the cookie Login leaves the token in for browsers
+const AuthCookie = "Authorization"
+
+var (
+	errNoToken        = errors.New("no token in the Authorization header or cookie")
+	errMalformedToken = errors.New("Authorization header must be Bearer followed by the token")
+)
+
+// UserFinder loads the user a verified token was issued to
+type UserFinder func(email string) (models.User, error)
+
+// findUserByEmail loads the user with their branch, which their view of the members depends on
+func findUserByEmail(email string) (models.User, error) {
+	var user models.User
+	err := config.DB.Preload("Branch").Where("email = ?", email).First(&user).Error
+	return user, err
+}
+
+// RequireAuth lets the request through when it carries a valid token for an existing user, see Authenticate
 func RequireAuth(c *gin.Context) {
-	// Get the token off the in the request cookie
-	tokenString, err := c.Cookie("Authorization")
+	authenticate(c, findUserByEmail)
+}
+
+// Authenticate reads the token from an "Authorization: Bearer <token>" header, for mobile and server clients,
+// or else from the Authorization cookie set at login, for browsers. Both are verified the same way and the
+// user is put in the context as "user". Tokens issued before the user's sessions were all logged out are
+// refused. Every refusal is a 401 with a body of {"error": "<message>"} and a WWW-Authenticate header saying
+// a bearer token is expected.
+func Authenticate(findUser UserFinder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authenticate(c, findUser)
+	}
+}
+
+func authenticate(c *gin.Context, findUser UserFinder) {
+	tokenString, err := requestToken(c)
 	if err != nil {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-			"error":   "operation not allowed, could not get token from cookie",
-			"details": err.Error(),
-		})
+		bearerError := ""
+		if errors.Is(err, errMalformedToken) {
+			bearerError = "invalid_request"
+		}
+		abortUnauthorized(c, bearerError, "operation not allowed, "+err.Error(), err)
 		return
 	}
 
-	// verify the token extracted from the cookie
-	email, err := utils.VerifyToken(tokenString)
+	claims, err := utils.VerifyToken(tokenString)
 	if err != nil {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-			"error":   "operation not allowed, could not verify token",
-			"details": err.Error(),
-		})
+		abortUnauthorized(c, "invalid_token", "operation not allowed, could not verify token", err)
 		return
 	}
 
-	var user models.User
-	config.DB.Where("email = ?", email).First(&user)
-	if user.ID == 0 {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-			"error":   "operation not allowed, user not found",
-			"details": err.Error(),
-		})
-
+	user, err := findUser(claims.Email)
+	if err != nil || user.ID == 0 {
+		abortUnauthorized(c, "invalid_token", "operation not allowed, user not found", err)
+		return
+	}
+	// tokens carry their issue time in whole seconds, so one issued in the second the user's tokens were
+	// revoked may have been issued before the revocation and is refused too
+	if user.TokensRevokedAt != nil && !claims.IssuedAt.After(user.TokensRevokedAt.Truncate(time.Second)) {
+		abortUnauthorized(c, "invalid_token", "operation not allowed, token has been revoked", nil)
 		return
 	}
 
 	c.Set("user", user)
 	c.Next()
 }
+
+// requestToken takes the token from the Authorization header when there is one, falling back to the cookie
+func requestToken(c *gin.Context) (string, error) {
+	if header := c.GetHeader("Authorization"); header != "" {
+		scheme, token, found := strings.Cut(strings.TrimSpace(header), " ")
+		token = strings.TrimSpace(token)
+		if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			return "", errMalformedToken
+		}
+		return token, nil
+	}
+
+	token, err := c.Cookie(AuthCookie)
+	if err != nil || token == "" {
+		return "", errNoToken
+	}
+	return token, nil
+}
+
+// abortUnauthorized refuses the request, naming the bearer error from RFC 6750 when the token was there but bad
+func abortUnauthorized(c *gin.Context, bearerError string, message string, details error) {
+	challenge := "Bearer"
+	if bearerError != "" {
+		challenge += ` error="` + bearerError + `"`
+	}
+	c.Header("WWW-Authenticate", challenge)
+	utils.RespondWithError(c, http.StatusUnauthorized, message, details)
+	c.Abort()
+}
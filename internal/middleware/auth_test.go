@@ -0,0 +1,128 @@
+// Unit tests for the RequireAuth middleware
+package middleware_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cooperative-system/internal/middleware"
+	"cooperative-system/internal/models"
+	"cooperative-system/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func authRouter() *gin.Engine {
+	findUser := func(email string) (models.User, error) {
+		user := models.User{Email: email, Role: "member"}
+		switch email {
+		case "ada@example.com":
+			user.ID = 7
+		case "revoked@example.com":
+			// logged out everywhere after the token was issued
+			revokedAt := time.Now().Add(time.Hour)
+			user.ID = 8
+			user.TokensRevokedAt = &revokedAt
+		default:
+			return models.User{}, errors.New("record not found")
+		}
+		return user, nil
+	}
+	r := gin.Default()
+	r.GET("/me", middleware.Authenticate(findUser), func(c *gin.Context) {
+		user, _ := utils.GetAuthUser(c)
+		c.JSON(http.StatusOK, gin.H{"user_id": user.ID})
+	})
+	return r
+}
+
+func TestAuthenticate_BearerHeaderAndCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := authRouter()
+	token, err := utils.CreateToken("ada@example.com", time.Minute)
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"user_id": 7}`, w.Body.String())
+
+	req, _ = http.NewRequest(http.MethodGet, "/me", nil)
+	req.AddCookie(&http.Cookie{Name: middleware.AuthCookie, Value: token})
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAuthenticate_Refusals(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := authRouter()
+	unknown, _ := utils.CreateToken("grace@example.com", time.Minute)
+	revoked, _ := utils.CreateToken("revoked@example.com", time.Minute)
+
+	cases := []struct {
+		name      string
+		header    string
+		challenge string
+	}{
+		{"no token", "", "Bearer"},
+		{"not bearer", "Basic YWRhOnNlY3JldA==", `Bearer error="invalid_request"`},
+		{"bad token", "Bearer not-a-token", `Bearer error="invalid_token"`},
+		{"unknown user", "Bearer " + unknown, `Bearer error="invalid_token"`},
+		{"revoked", "Bearer " + revoked, `Bearer error="invalid_token"`},
+	}
+	for _, tc := range cases {
+		req, _ := http.NewRequest(http.MethodGet, "/me", nil)
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, tc.name)
+		assert.Equal(t, tc.challenge, w.Header().Get("WWW-Authenticate"), tc.name)
+		assert.Contains(t, w.Body.String(), `"error":"operation not allowed`, tc.name)
+		assert.NotContains(t, w.Body.String(), "details", tc.name)
+	}
+}
+
+func TestAuthenticate_RevocationTime(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	token, err := utils.CreateToken("ada@example.com", time.Minute)
+	assert.NoError(t, err)
+	claims, err := utils.VerifyToken(token)
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name      string
+		revokedAt time.Time
+		expected  int
+	}{
+		{"revoked the second before", claims.IssuedAt.Add(-time.Second), http.StatusOK},
+		{"revoked in the same second", claims.IssuedAt.Add(500 * time.Millisecond), http.StatusUnauthorized},
+		{"revoked at the start of the same second", claims.IssuedAt, http.StatusUnauthorized},
+		{"revoked the second after", claims.IssuedAt.Add(time.Second), http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		findUser := func(email string) (models.User, error) {
+			user := models.User{Email: email, Role: "member", TokensRevokedAt: &tc.revokedAt}
+			user.ID = 7
+			return user, nil
+		}
+		r := gin.Default()
+		r.GET("/me", middleware.Authenticate(findUser), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		req, _ := http.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tc.expected, w.Code, tc.name)
+	}
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"bytes"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+	"cooperative-system/pkg/utils"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+)
+
+// responseRecorder keeps a copy of everything the handler writes so it can be replayed later
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+func requestHash(c *gin.Context, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + "\n" + c.Request.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Idempotency makes a POST safe to retry when the client sends an Idempotency-Key header. The first request
+// with a key runs normally and its response is stored, a retry with the same key and body gets that response
+// back without running the handler again, and the same key with a different body is rejected. Requests that
+// fail with a server error are forgotten so they can be retried, so the handlers behind it must post their
+// changes in one database transaction: a server error then means nothing was written. Must run after
+// RequireAuth.
+func Idempotency(store repository.IdempotencyRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			utils.RespondWithError(c, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters", nil)
+			c.Abort()
+			return
+		}
+
+		user, exist := c.Get("user")
+		authUser, ok := user.(models.User)
+		if !exist || !ok {
+			utils.RespondWithError(c, http.StatusInternalServerError, "unable to get user from token", nil)
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "could not read request body", err)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		record := &models.IdempotencyKey{
+			UserID:      authUser.ID,
+			Key:         key,
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			RequestHash: requestHash(c, body),
+			ExpiresAt:   time.Now().Add(models.IdempotencyKeyTTL),
+		}
+
+		stored, reserved, msg, err := store.ReserveIdempotencyKey(record)
+		if err != nil {
+			utils.RespondWithError(c, http.StatusInternalServerError, msg, err)
+			c.Abort()
+			return
+		}
+
+		if !reserved {
+			switch {
+			case stored.RequestHash != record.RequestHash:
+				utils.RespondWithError(c, http.StatusUnprocessableEntity, "Idempotency-Key has already been used for a different request", nil)
+				c.Abort()
+			case !stored.Completed:
+				utils.RespondWithError(c, http.StatusConflict, "a request with this Idempotency-Key is still being processed", nil)
+				c.Abort()
+			default:
+				c.Header(IdempotencyReplayedHeader, "true")
+				c.Data(stored.StatusCode, stored.ContentType, stored.Response)
+				c.Abort()
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		defer func() {
+			if rcv := recover(); rcv != nil {
+				if err := store.ReleaseIdempotencyKey(stored.ID); err != nil {
+					log.Printf("failed to release idempotency key %d: %v", stored.ID, err)
+				}
+				panic(rcv)
+			}
+		}()
+
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			err = store.ReleaseIdempotencyKey(stored.ID)
+		} else {
+			err = store.CompleteIdempotencyKey(stored.ID, status, recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+		}
+		if err != nil {
+			log.Printf("failed to save idempotency key %d: %v", stored.ID, err)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// Unit tests for the Idempotency middleware
+package middleware_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cooperative-system/internal/handlers"
+	"cooperative-system/internal/middleware"
+	"cooperative-system/internal/models"
+	"cooperative-system/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryIdempotencyRepo keeps keys in a map, keyed the same way as the unique index
+type memoryIdempotencyRepo struct {
+	repository.IdempotencyRepository
+	records map[string]*models.IdempotencyKey
+	nextID  uint
+}
+
+func newMemoryIdempotencyRepo() *memoryIdempotencyRepo {
+	return &memoryIdempotencyRepo{records: make(map[string]*models.IdempotencyKey)}
+}
+
+func (m *memoryIdempotencyRepo) ReserveIdempotencyKey(record *models.IdempotencyKey) (*models.IdempotencyKey, bool, string, error) {
+	if existing, ok := m.records[record.Key]; ok {
+		return existing, false, "idempotency key already used", nil
+	}
+	m.nextID++
+	record.ID = m.nextID
+	m.records[record.Key] = record
+	return record, true, "idempotency key reserved", nil
+}
+func (m *memoryIdempotencyRepo) CompleteIdempotencyKey(id uint, statusCode int, contentType string, response []byte) error {
+	for _, record := range m.records {
+		if record.ID == id {
+			record.Completed = true
+			record.StatusCode = statusCode
+			record.ContentType = contentType
+			record.Response = response
+		}
+	}
+	return nil
+}
+func (m *memoryIdempotencyRepo) ReleaseIdempotencyKey(id uint) error {
+	for key, record := range m.records {
+		if record.ID == id {
+			delete(m.records, key)
+		}
+	}
+	return nil
+}
+
+func idempotentRouter(store repository.IdempotencyRepository, calls *int, status int) *gin.Engine {
+	r := gin.Default()
+	r.POST("/savings", func(c *gin.Context) {
+		user := models.User{}
+		user.ID = 1
+		c.Set("user", user)
+		c.Next()
+	}, middleware.Idempotency(store), func(c *gin.Context) {
+		*calls++
+		c.JSON(status, gin.H{"call": *calls})
+	})
+	return r
+}
+
+func postWithKey(r *gin.Engine, key string, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodPost, "/savings", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(middleware.IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_ReplaysDuplicate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	r := idempotentRouter(newMemoryIdempotencyRepo(), &calls, http.StatusCreated)
+
+	first := postWithKey(r, "abc", `{"amount":100}`)
+	second := postWithKey(r, "abc", `{"amount":100}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get(middleware.IdempotencyReplayedHeader))
+}
+
+func TestIdempotency_RejectsDifferentBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	r := idempotentRouter(newMemoryIdempotencyRepo(), &calls, http.StatusCreated)
+
+	postWithKey(r, "abc", `{"amount":100}`)
+	w := postWithKey(r, "abc", `{"amount":200}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestIdempotency_WithoutKeyAlwaysRuns(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	r := idempotentRouter(newMemoryIdempotencyRepo(), &calls, http.StatusCreated)
+
+	postWithKey(r, "", `{"amount":100}`)
+	postWithKey(r, "", `{"amount":100}`)
+
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_ServerErrorCanBeRetried(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	r := idempotentRouter(newMemoryIdempotencyRepo(), &calls, http.StatusInternalServerError)
+
+	postWithKey(r, "abc", `{"amount":100}`)
+	postWithKey(r, "abc", `{"amount":100}`)
+
+	assert.Equal(t, 2, calls)
+}
+
+// ledgerSavingsRepo posts deposits to an in-memory ledger. The first deposit fails after moving the
+// balance and is rolled back whole, the way the database transaction in Deposit is.
+type ledgerSavingsRepo struct {
+	repository.SavingsRepository
+	balance  models.Money
+	postings []models.SavingTransaction
+	failNext bool
+}
+
+func (m *ledgerSavingsRepo) FetchMemberByUserID(userID uint) (*models.Member, string, error) {
+	member := models.Member{UserID: userID, Status: models.MemberStatusActive}
+	member.ID = 1
+	return &member, "member fetched successfully", nil
+}
+
+func (m *ledgerSavingsRepo) Deposit(memberID uint, deposit *models.SavingTransaction, description string) (*models.Savings, *models.SavingTransaction, string, error) {
+	balance, postings := m.balance+deposit.Amount, append(m.postings, *deposit)
+	if m.failNext {
+		m.failNext = false
+		return nil, nil, "failed to commit transaction", errors.New("connection reset")
+	}
+	m.balance, m.postings = balance, postings
+	savings := models.Savings{MemberID: memberID, Balance: m.balance, Currency: deposit.Currency}
+	return &savings, deposit, "deposit posted successfully", nil
+}
+
+func TestIdempotency_DepositRetryAfterFailurePostsOnce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	savings := &ledgerSavingsRepo{failNext: true}
+	h := handlers.NewSavingsHandler(savings, nil)
+	r := gin.Default()
+	r.POST("/savings", func(c *gin.Context) {
+		user := models.User{Role: models.RoleMember}
+		user.ID = 1
+		c.Set("user", user)
+		c.Next()
+	}, middleware.Idempotency(newMemoryIdempotencyRepo()), h.CreateSavings)
+
+	failed := postWithKey(r, "deposit-1", `{"amount":100}`)
+	retried := postWithKey(r, "deposit-1", `{"amount":100}`)
+	replayed := postWithKey(r, "deposit-1", `{"amount":100}`)
+
+	assert.Equal(t, http.StatusInternalServerError, failed.Code)
+	assert.Equal(t, http.StatusCreated, retried.Code)
+	assert.Equal(t, http.StatusCreated, replayed.Code)
+	assert.Equal(t, "true", replayed.Header().Get(middleware.IdempotencyReplayedHeader))
+	assert.Len(t, savings.postings, 1)
+	assert.Equal(t, models.Money(10000), savings.balance)
+}
+
+// failingIdempotencyRepo cannot reserve keys, as when the database is down
+type failingIdempotencyRepo struct {
+	repository.IdempotencyRepository
+}
+
+func (m *failingIdempotencyRepo) ReserveIdempotencyKey(record *models.IdempotencyKey) (*models.IdempotencyKey, bool, string, error) {
+	return nil, false, "failed to reserve idempotency key", errors.New("connection refused")
+}
+
+func TestIdempotency_StoreFailureHidesDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	r := idempotentRouter(&failingIdempotencyRepo{}, &calls, http.StatusCreated)
+
+	w := postWithKey(r, "key-1", `{"amount": 100}`)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"error": "failed to reserve idempotency key"}`, w.Body.String())
+	assert.Equal(t, 0, calls)
+}
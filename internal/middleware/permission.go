@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"cooperative-system/internal/models"
+	"cooperative-system/pkg/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission lets the request through only when the authenticated user's role grants the permission.
+// It runs after RequireAuth.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exist := c.Get("user")
+		if !exist {
+			utils.RespondWithError(c, http.StatusInternalServerError, "unable to get user from token", nil)
+			c.Abort()
+			return
+		}
+
+		authUser, ok := user.(models.User)
+		if !ok {
+			utils.RespondWithError(c, http.StatusInternalServerError, "invalid user data in context", nil)
+			c.Abort()
+			return
+		}
+
+		if !authUser.Can(permission) {
+			utils.RespondWithError(c, http.StatusForbidden, "you are not authorized to perform this action, it needs the "+permission+" permission", nil)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,51 @@
+// Unit tests for the RequirePermission middleware
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cooperative-system/internal/middleware"
+	"cooperative-system/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequirePermission_ByRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		role       string
+		permission string
+		status     int
+	}{
+		{models.RoleAdmin, models.PermUsersManage, http.StatusOK},
+		{models.RoleTreasurer, models.PermLoansDisburse, http.StatusOK},
+		{models.RoleTreasurer, models.PermLoansApprove, http.StatusForbidden},
+		{models.RoleLoanOfficer, models.PermLoansApprove, http.StatusOK},
+		{models.RoleAuditor, models.PermReportsRead, http.StatusOK},
+		{models.RoleAuditor, models.PermTransactionsReverse, http.StatusForbidden},
+		{models.RoleTeller, models.PermRepaymentsRecord, http.StatusOK},
+		{models.RoleTeller, models.PermReportsRead, http.StatusForbidden},
+		{models.RoleMember, models.PermMembersRead, http.StatusForbidden},
+		{"superuser", models.PermMembersRead, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		r := gin.Default()
+		r.GET("/", func(c *gin.Context) {
+			c.Set("user", models.User{Role: tc.role})
+		}, middleware.RequirePermission(tc.permission), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tc.status, w.Code, tc.role+" "+tc.permission)
+		if tc.status == http.StatusForbidden {
+			assert.Contains(t, w.Body.String(), `"error":"you are not authorized`, tc.role)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Branch is an office of the cooperative. Staff at a branch only see its members, head office sees every branch.
+type Branch struct {
+	gorm.Model
+	Code       string `gorm:"size:10;not null;uniqueIndex"` // used in member and account numbers
+	Name       string `gorm:"not null"`
+	Address    string
+	HeadOffice bool
+}
+
+// MemberGroup is a group members are organised into, usually their employer. A group either belongs to one
+// branch or, without a branch, takes members from every branch.
+type MemberGroup struct {
+	gorm.Model
+	Name     string `gorm:"not null"`
+	Employer string
+	BranchID *uint   `gorm:"index"`
+	Branch   *Branch `gorm:"foreignKey:BranchID"`
+}
+
+// Validate checks a branch has a usable code and a name
+func (branch *Branch) Validate() error {
+	branch.Code = NormalizeNumber(branch.Code)
+	branch.Name = strings.TrimSpace(branch.Name)
+	if err := ValidateBranchCode(branch.Code); err != nil {
+		return err
+	}
+	if branch.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+// AcceptsBranch reports whether members of the given branch can join the group
+func (group *MemberGroup) AcceptsBranch(branchID *uint) bool {
+	return group.BranchID == nil || branchID != nil && *group.BranchID == *branchID
+}
+
+// BranchScope is the branch the user's view is limited to, or nil when they see every branch. Head office
+// staff and staff not assigned to a branch see everything. Members only ever see themselves, which the
+// handlers check separately.
+func (user *User) BranchScope() *uint {
+	if !user.IsStaff() || user.BranchID == nil || user.Branch != nil && user.Branch.HeadOffice {
+		return nil
+	}
+	return user.BranchID
+}
+
+// CanSeeBranch reports whether the user's view covers members of the given branch
+func (user *User) CanSeeBranch(branchID *uint) bool {
+	scope := user.BranchScope()
+	return scope == nil || branchID != nil && *branchID == *scope
+}
+
+type BranchResponse struct {
+	ID         uint      `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Code       string    `json:"code"`
+	Name       string    `json:"name"`
+	Address    string    `json:"address,omitempty"`
+	HeadOffice bool      `json:"head_office"`
+}
+
+func NewBranchResponse(branch *Branch) BranchResponse {
+	return BranchResponse{
+		ID:         branch.ID,
+		CreatedAt:  branch.CreatedAt,
+		UpdatedAt:  branch.UpdatedAt,
+		Code:       branch.Code,
+		Name:       branch.Name,
+		Address:    branch.Address,
+		HeadOffice: branch.HeadOffice,
+	}
+}
+
+type MemberGroupResponse struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+	Employer  string    `json:"employer,omitempty"`
+	BranchID  *uint     `json:"branch_id,omitempty"`
+}
+
+func NewMemberGroupResponse(group *MemberGroup) MemberGroupResponse {
+	return MemberGroupResponse{
+		ID:        group.ID,
+		CreatedAt: group.CreatedAt,
+		UpdatedAt: group.UpdatedAt,
+		Name:      group.Name,
+		Employer:  group.Employer,
+		BranchID:  group.BranchID,
+	}
+}
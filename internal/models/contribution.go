@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ContributionMandate is a member's committed regular contribution to their savings
+type ContributionMandate struct {
+	gorm.Model
+	MemberID  uint      `gorm:"not null;index"`
+	Amount    Money     `gorm:"not null"`
+	Currency  string    `gorm:"size:3;not null;default:NGN"`
+	Frequency string    `gorm:"not null"` // e.g., "weekly", "monthly"
+	StartDate time.Time `gorm:"not null"`
+	EndDate   *time.Time
+	Status    string `gorm:"not null"` // e.g., "active", "ended"
+	Member    Member `gorm:"foreignKey:MemberID"`
+}
+
+type ContributionMandateResponse struct {
+	ID        uint       `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	MemberID  uint       `json:"member_id"`
+	Amount    Money      `json:"amount"`
+	Currency  string     `json:"currency"`
+	Frequency string     `json:"frequency"`
+	StartDate time.Time  `json:"start_date"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
+	Status    string     `json:"status"`
+}
+
+func NewContributionMandateResponse(mandate *ContributionMandate) ContributionMandateResponse {
+	return ContributionMandateResponse{
+		ID:        mandate.ID,
+		CreatedAt: mandate.CreatedAt,
+		UpdatedAt: mandate.UpdatedAt,
+		MemberID:  mandate.MemberID,
+		Amount:    mandate.Amount,
+		Currency:  mandate.Currency,
+		Frequency: mandate.Frequency,
+		StartDate: mandate.StartDate,
+		EndDate:   mandate.EndDate,
+		Status:    mandate.Status,
+	}
+}
+
+// ContributionPeriod compares what was expected in one mandate period with what was actually saved
+type ContributionPeriod struct {
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Expected    Money     `json:"expected"`
+	Actual      Money     `json:"actual"`
+	Shortfall   Money     `json:"shortfall"`
+	Due         bool      `json:"due"` // false while the period is still running
+}
+
+// ContributionStatus is the contribution position of a member as of a given date
+type ContributionStatus struct {
+	MemberID         uint                 `json:"member_id"`
+	AsOf             time.Time            `json:"as_of"`
+	Periods          []ContributionPeriod `json:"periods"`
+	TotalExpected    Money                `json:"total_expected"`
+	TotalActual      Money                `json:"total_actual"`
+	Arrears          Money                `json:"arrears"`
+	PeriodsInArrears int                  `json:"periods_in_arrears"`
+	InArrears        bool                 `json:"in_arrears"`
+}
+
+const (
+	MandateStatusActive = "active"
+	MandateStatusEnded  = "ended"
+)
+
+const (
+	ContributionFrequencyWeekly  = "weekly"
+	ContributionFrequencyMonthly = "monthly"
+)
+
+var AllowedContributionFrequencies = map[string]bool{
+	ContributionFrequencyWeekly:  true,
+	ContributionFrequencyMonthly: true,
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+func mandatePeriodStart(mandate *ContributionMandate, index int) time.Time {
+	if mandate.Frequency == ContributionFrequencyWeekly {
+		return mandate.StartDate.AddDate(0, 0, 7*index)
+	}
+	return mandate.StartDate.AddDate(0, index, 0)
+}
+
+// CalculateContributionStatus compares the expected contributions of every mandate period that has
+// started by asOf with the deposits made in that period. A period that would run past the end of its
+// mandate is not counted, the replacing mandate takes over from that date. Arrears are cumulative so a
+// member can catch up on a missed period by saving more in a later one. Only deposits in the mandate's
+// currency that have not been reversed count towards it. Interest, transfers, dividends, fixed deposit
+// payouts and opening balances are not contributions.
+func CalculateContributionStatus(memberID uint, mandates []ContributionMandate, transactions []SavingTransaction, asOf time.Time) ContributionStatus {
+	status := ContributionStatus{
+		MemberID: memberID,
+		AsOf:     asOf,
+		Periods:  []ContributionPeriod{},
+	}
+
+	reversed := make(map[uint]bool)
+	for _, transaction := range transactions {
+		if transaction.ReversalOfID != nil {
+			reversed[*transaction.ReversalOfID] = true
+		}
+	}
+
+	sort.Slice(mandates, func(i, j int) bool {
+		return mandates[i].StartDate.Before(mandates[j].StartDate)
+	})
+
+	var latestAmount Money
+	for i := range mandates {
+		mandate := &mandates[i]
+		if mandate.Amount <= 0 || mandate.StartDate.After(asOf) {
+			continue
+		}
+		latestAmount = mandate.Amount
+
+		for k := 0; ; k++ {
+			periodStart := mandatePeriodStart(mandate, k)
+			periodEnd := mandatePeriodStart(mandate, k+1)
+			if periodStart.After(asOf) {
+				break
+			}
+			if mandate.EndDate != nil && periodEnd.After(*mandate.EndDate) {
+				break
+			}
+
+			var actual Money
+			for _, transaction := range transactions {
+				if transaction.Type != TransactionTypeDeposit || reversed[transaction.ID] || transaction.Amount <= 0 {
+					continue
+				}
+				if transaction.Currency == mandate.Currency && !transaction.CreatedAt.Before(periodStart) && transaction.CreatedAt.Before(periodEnd) {
+					actual += transaction.Amount
+				}
+			}
+
+			period := ContributionPeriod{
+				PeriodStart: periodStart,
+				PeriodEnd:   periodEnd,
+				Expected:    mandate.Amount,
+				Actual:      actual,
+				Due:         !periodEnd.After(asOf),
+			}
+			if actual < mandate.Amount {
+				period.Shortfall = mandate.Amount - actual
+			}
+			status.Periods = append(status.Periods, period)
+
+			if period.Due {
+				status.TotalExpected += period.Expected
+				status.TotalActual += period.Actual
+			}
+		}
+	}
+
+	if status.TotalExpected > status.TotalActual {
+		status.Arrears = status.TotalExpected - status.TotalActual
+		status.InArrears = true
+		if latestAmount > 0 {
+			status.PeriodsInArrears = int((status.Arrears + latestAmount - 1) / latestAmount)
+		}
+	}
+
+	return status
+}
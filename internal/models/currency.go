@@ -0,0 +1,99 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BaseCurrency is the cooperative's reporting currency and the default for new accounts, loans and deposits
+const BaseCurrency = "NGN"
+
+var (
+	ErrInvalidCurrency  = errors.New("currency must be a three letter ISO 4217 code")
+	ErrCurrencyMismatch = errors.New("currencies do not match")
+)
+
+// NormalizeCurrency upper-cases a currency code and checks it looks like an ISO 4217 code.
+// An empty code means the base currency.
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return BaseCurrency, nil
+	}
+	if len(code) != 3 {
+		return "", ErrInvalidCurrency
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return "", ErrInvalidCurrency
+		}
+	}
+	return code, nil
+}
+
+// CheckSameCurrency rejects an operation that would move money between two different currencies
+func CheckSameCurrency(expected string, actual string) error {
+	if expected != actual {
+		return fmt.Errorf("%w: expected %s, got %s", ErrCurrencyMismatch, expected, actual)
+	}
+	return nil
+}
+
+// ExchangeRate is the admin-maintained value of one unit of Currency in the base currency,
+// effective from EffectiveDate until the next rate for the same currency
+type ExchangeRate struct {
+	gorm.Model
+	Currency      string    `gorm:"size:3;not null;uniqueIndex:idx_exchange_rates_currency_date"`
+	Rate          float64   `gorm:"not null"` // base currency units per one unit of Currency
+	EffectiveDate time.Time `gorm:"not null;uniqueIndex:idx_exchange_rates_currency_date"`
+	SetBy         uint      `gorm:"not null"`
+}
+
+type ExchangeRateResponse struct {
+	ID            uint      `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Currency      string    `json:"currency"`
+	BaseCurrency  string    `json:"base_currency"`
+	Rate          float64   `json:"rate"`
+	EffectiveDate time.Time `json:"effective_date"`
+	SetBy         uint      `json:"set_by"`
+}
+
+func NewExchangeRateResponse(rate *ExchangeRate) ExchangeRateResponse {
+	return ExchangeRateResponse{
+		ID:            rate.ID,
+		CreatedAt:     rate.CreatedAt,
+		UpdatedAt:     rate.UpdatedAt,
+		Currency:      rate.Currency,
+		BaseCurrency:  BaseCurrency,
+		Rate:          rate.Rate,
+		EffectiveDate: rate.EffectiveDate,
+		SetBy:         rate.SetBy,
+	}
+}
+
+// CurrencyTotal is one line of a consolidation report, an amount in its own currency and in the base currency
+type CurrencyTotal struct {
+	Currency   string     `json:"currency"`
+	Amount     Money      `json:"amount"`
+	Rate       float64    `json:"rate"`
+	RateDate   *time.Time `json:"rate_date,omitempty"`
+	BaseAmount Money      `json:"base_amount"`
+}
+
+// ConsolidatedReport totals balances held in several currencies in the base currency on a given date.
+// Currencies without a rate on that date are listed in MissingRates and left out of the totals.
+type ConsolidatedReport struct {
+	AsOf         time.Time       `json:"as_of"`
+	BaseCurrency string          `json:"base_currency"`
+	Savings      []CurrencyTotal `json:"savings"`
+	Loans        []CurrencyTotal `json:"loans"`
+	TotalSavings Money           `json:"total_savings"`
+	TotalLoans   Money           `json:"total_loans"`
+	MissingRates []string        `json:"missing_rates,omitempty"`
+}
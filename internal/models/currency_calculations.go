@@ -0,0 +1,72 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ConvertToBase converts an amount to the base currency using a rate of base units per unit of its currency
+func ConvertToBase(amount Money, rate float64) (Money, error) {
+	return amount.MulRate(rate)
+}
+
+// ConsolidateTotals converts per-currency savings and loan totals to the base currency using the
+// rates in force on asOf. The base currency always converts at 1.
+func ConsolidateTotals(asOf time.Time, savingsTotals map[string]Money, loanTotals map[string]Money, rates map[string]ExchangeRate) (ConsolidatedReport, error) {
+	report := ConsolidatedReport{
+		AsOf:         asOf,
+		BaseCurrency: BaseCurrency,
+		Savings:      []CurrencyTotal{},
+		Loans:        []CurrencyTotal{},
+	}
+
+	missing := make(map[string]bool)
+	convert := func(totals map[string]Money) ([]CurrencyTotal, Money, error) {
+		currencies := make([]string, 0, len(totals))
+		for currency := range totals {
+			currencies = append(currencies, currency)
+		}
+		sort.Strings(currencies)
+
+		lines := make([]CurrencyTotal, 0, len(currencies))
+		var sum Money
+		for _, currency := range currencies {
+			line := CurrencyTotal{Currency: currency, Amount: totals[currency]}
+			if currency == BaseCurrency {
+				line.Rate = 1
+				line.BaseAmount = line.Amount
+			} else if rate, ok := rates[currency]; ok {
+				effective := rate.EffectiveDate
+				line.Rate = rate.Rate
+				line.RateDate = &effective
+				baseAmount, err := ConvertToBase(line.Amount, rate.Rate)
+				if err != nil {
+					return nil, 0, fmt.Errorf("converting %s: %w", currency, err)
+				}
+				line.BaseAmount = baseAmount
+			} else {
+				missing[currency] = true
+				lines = append(lines, line)
+				continue
+			}
+			sum += line.BaseAmount
+			lines = append(lines, line)
+		}
+		return lines, sum, nil
+	}
+
+	var err error
+	if report.Savings, report.TotalSavings, err = convert(savingsTotals); err != nil {
+		return ConsolidatedReport{}, err
+	}
+	if report.Loans, report.TotalLoans, err = convert(loanTotals); err != nil {
+		return ConsolidatedReport{}, err
+	}
+
+	for currency := range missing {
+		report.MissingRates = append(report.MissingRates, currency)
+	}
+	sort.Strings(report.MissingRates)
+	return report, nil
+}
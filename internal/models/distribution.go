@@ -0,0 +1,125 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DistributionStatusDraft     = "draft"
+	DistributionStatusApproved  = "approved"
+	DistributionStatusCancelled = "cancelled"
+)
+
+const (
+	DistributionPayoutSavings = "savings"     // entitlements are credited to base currency savings on approval
+	DistributionPayoutList    = "payout_list" // entitlements are paid outside the system from the payout list
+)
+
+var AllowedDistributionPayoutMethods = map[string]bool{
+	DistributionPayoutSavings: true,
+	DistributionPayoutList:    true,
+}
+
+// Distribution is a year-end run of the dividend on share capital and the patronage refund on loan interest
+// declared by the AGM. It is computed as a draft, reviewed, then approved or cancelled. Amounts are in the
+// base currency.
+type Distribution struct {
+	gorm.Model
+	FiscalYear     int       `gorm:"not null;index"`
+	PeriodStart    time.Time `gorm:"not null"`
+	PeriodEnd      time.Time `gorm:"not null"` // inclusive
+	DividendRate   float64   `gorm:"not null"` // on average share capital, e.g. 0.08 for 8%
+	PatronageRate  float64   `gorm:"not null"` // on loan interest paid in the period
+	SharePrice     Money     `gorm:"not null"` // price the share capital was valued at
+	PayoutMethod   string    `gorm:"not null"`
+	Status         string    `gorm:"not null;index"` // e.g., "draft", "approved", "cancelled"
+	CreatedBy      uint      `gorm:"not null"`
+	ApprovedBy     *uint
+	ApprovedAt     *time.Time
+	TotalDividend  Money              `gorm:"not null"`
+	TotalPatronage Money              `gorm:"not null"`
+	Total          Money              `gorm:"not null"`
+	Lines          []DistributionLine `gorm:"foreignKey:DistributionID"`
+}
+
+// DistributionLine is one member's entitlement in a distribution
+type DistributionLine struct {
+	gorm.Model
+	DistributionID      uint   `gorm:"not null;index"`
+	MemberID            uint   `gorm:"not null;index"`
+	Member              Member `gorm:"foreignKey:MemberID"`
+	ShareDays           int64  `gorm:"not null"` // shares held multiplied by the days they were held in the period
+	AverageShareCapital Money  `gorm:"not null"`
+	Dividend            Money  `gorm:"not null"`
+	InterestPaid        Money  `gorm:"not null"`
+	Patronage           Money  `gorm:"not null"`
+	Total               Money  `gorm:"not null"`
+}
+
+type DistributionLineResponse struct {
+	MemberID            uint   `json:"member_id"`
+	MemberName          string `json:"member_name,omitempty"`
+	AverageShareCapital Money  `json:"average_share_capital"`
+	Dividend            Money  `json:"dividend"`
+	InterestPaid        Money  `json:"interest_paid"`
+	Patronage           Money  `json:"patronage_refund"`
+	Total               Money  `json:"total"`
+}
+
+type DistributionResponse struct {
+	ID             uint                       `json:"id"`
+	CreatedAt      time.Time                  `json:"created_at"`
+	FiscalYear     int                        `json:"fiscal_year"`
+	PeriodStart    time.Time                  `json:"period_start"`
+	PeriodEnd      time.Time                  `json:"period_end"`
+	DividendRate   float64                    `json:"dividend_rate"`
+	PatronageRate  float64                    `json:"patronage_rate"`
+	SharePrice     Money                      `json:"share_price"`
+	Currency       string                     `json:"currency"`
+	PayoutMethod   string                     `json:"payout_method"`
+	Status         string                     `json:"status"`
+	CreatedBy      uint                       `json:"created_by"`
+	ApprovedBy     *uint                      `json:"approved_by,omitempty"`
+	ApprovedAt     *time.Time                 `json:"approved_at,omitempty"`
+	TotalDividend  Money                      `json:"total_dividend"`
+	TotalPatronage Money                      `json:"total_patronage_refund"`
+	Total          Money                      `json:"total"`
+	Lines          []DistributionLineResponse `json:"lines,omitempty"`
+}
+
+func NewDistributionResponse(distribution *Distribution) DistributionResponse {
+	lines := make([]DistributionLineResponse, len(distribution.Lines))
+	for i, line := range distribution.Lines {
+		lines[i] = DistributionLineResponse{
+			MemberID:            line.MemberID,
+			MemberName:          line.Member.Name,
+			AverageShareCapital: line.AverageShareCapital,
+			Dividend:            line.Dividend,
+			InterestPaid:        line.InterestPaid,
+			Patronage:           line.Patronage,
+			Total:               line.Total,
+		}
+	}
+	return DistributionResponse{
+		ID:             distribution.ID,
+		CreatedAt:      distribution.CreatedAt,
+		FiscalYear:     distribution.FiscalYear,
+		PeriodStart:    distribution.PeriodStart,
+		PeriodEnd:      distribution.PeriodEnd,
+		DividendRate:   distribution.DividendRate,
+		PatronageRate:  distribution.PatronageRate,
+		SharePrice:     distribution.SharePrice,
+		Currency:       BaseCurrency,
+		PayoutMethod:   distribution.PayoutMethod,
+		Status:         distribution.Status,
+		CreatedBy:      distribution.CreatedBy,
+		ApprovedBy:     distribution.ApprovedBy,
+		ApprovedAt:     distribution.ApprovedAt,
+		TotalDividend:  distribution.TotalDividend,
+		TotalPatronage: distribution.TotalPatronage,
+		Total:          distribution.Total,
+		Lines:          lines,
+	}
+}
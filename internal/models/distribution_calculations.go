@@ -0,0 +1,126 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// periodDays is the number of whole days from start up to and including end
+func periodDays(start time.Time, end time.Time) int64 {
+	return int64(end.AddDate(0, 0, 1).Sub(start).Hours() / 24)
+}
+
+// ShareDays weighs a member's holding by how long it was held in the period: every share held at the end of
+// a day counts one for that day. Transactions must cover the whole life of the account up to the end of the period.
+func ShareDays(transactions []ShareTransaction, start time.Time, end time.Time) int64 {
+	sorted := append([]ShareTransaction{}, transactions...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+
+	periodEnd := end.AddDate(0, 0, 1)
+	var shares, shareDays int64
+	since := start
+	for _, transaction := range sorted {
+		day := time.Date(transaction.CreatedAt.Year(), transaction.CreatedAt.Month(), transaction.CreatedAt.Day(), 0, 0, 0, 0, start.Location())
+		if !day.Before(periodEnd) {
+			break
+		}
+		if day.After(since) {
+			shareDays += shares * periodDays(since, day.AddDate(0, 0, -1))
+			since = day
+		}
+		shares += transaction.Shares
+	}
+	return shareDays + shares*periodDays(since, end)
+}
+
+// InterestPaid is the interest part of repayments made against loans in the base currency. Loans are repaid
+// principal and interest together, so each repayment is split in the loan's ratio of interest to total repayable.
+func InterestPaid(repayments []LoanRepayment, loans map[uint]Loan) (Money, error) {
+	var interest Money
+	for _, repayment := range repayments {
+		loan, ok := loans[repayment.LoanID]
+		if !ok || loan.Currency != BaseCurrency || loan.TotalRepayableAmount <= 0 {
+			continue
+		}
+		portion, err := repayment.Amount.Portion(loan.TotalRepayableAmount-loan.Amount, loan.TotalRepayableAmount)
+		if err != nil {
+			return 0, err
+		}
+		interest += portion
+	}
+	return interest, nil
+}
+
+// CalculateDistribution fills in every member's dividend and patronage refund and the distribution totals.
+// Members with nothing to receive are left out.
+func CalculateDistribution(distribution *Distribution, shareTransactions map[uint][]ShareTransaction, repayments map[uint][]LoanRepayment, loans map[uint]Loan) error {
+	days := periodDays(distribution.PeriodStart, distribution.PeriodEnd)
+
+	memberIDs := make(map[uint]bool)
+	for memberID := range shareTransactions {
+		memberIDs[memberID] = true
+	}
+	for memberID := range repayments {
+		memberIDs[memberID] = true
+	}
+	ordered := make([]uint, 0, len(memberIDs))
+	for memberID := range memberIDs {
+		ordered = append(ordered, memberID)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i] < ordered[j] })
+
+	distribution.Lines = nil
+	distribution.TotalDividend, distribution.TotalPatronage, distribution.Total = 0, 0, 0
+	for _, memberID := range ordered {
+		line := DistributionLine{MemberID: memberID}
+		var err error
+		if days > 0 {
+			line.ShareDays = ShareDays(shareTransactions[memberID], distribution.PeriodStart, distribution.PeriodEnd)
+			if line.AverageShareCapital, err = distribution.SharePrice.Interest(1, line.ShareDays, days); err != nil {
+				return err
+			}
+			if line.Dividend, err = distribution.SharePrice.Interest(distribution.DividendRate, line.ShareDays, days); err != nil {
+				return err
+			}
+		}
+		if line.InterestPaid, err = InterestPaid(repayments[memberID], loans); err != nil {
+			return err
+		}
+		if line.Patronage, err = line.InterestPaid.MulRate(distribution.PatronageRate); err != nil {
+			return err
+		}
+		line.Total = line.Dividend + line.Patronage
+		if line.Total <= 0 {
+			continue
+		}
+
+		distribution.Lines = append(distribution.Lines, line)
+		distribution.TotalDividend += line.Dividend
+		distribution.TotalPatronage += line.Patronage
+		distribution.Total += line.Total
+	}
+	return nil
+}
+
+// LimitToBranch keeps only the lines of members of one branch, with the totals over those lines, for a
+// branch's share of the payout list. The lines must have their members loaded. Nothing changes when
+// branchID is nil.
+func (distribution *Distribution) LimitToBranch(branchID *uint) {
+	if branchID == nil {
+		return
+	}
+	lines := distribution.Lines
+	distribution.Lines = nil
+	distribution.TotalDividend, distribution.TotalPatronage, distribution.Total = 0, 0, 0
+	for _, line := range lines {
+		if line.Member.BranchID == nil || *line.Member.BranchID != *branchID {
+			continue
+		}
+		distribution.Lines = append(distribution.Lines, line)
+		distribution.TotalDividend += line.Dividend
+		distribution.TotalPatronage += line.Patronage
+		distribution.Total += line.Total
+	}
+}
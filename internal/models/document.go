@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DocumentCategoryIDCard        = "id_card"
+	DocumentCategoryPassportPhoto = "passport_photo"
+	DocumentCategoryPayslip       = "payslip"
+	DocumentCategorySignedForm    = "signed_form"
+)
+
+var AllowedDocumentCategories = map[string]bool{
+	DocumentCategoryIDCard:        true,
+	DocumentCategoryPassportPhoto: true,
+	DocumentCategoryPayslip:       true,
+	DocumentCategorySignedForm:    true,
+}
+
+// AllowedDocumentContentTypes maps each content type that can be uploaded to the extension it is stored with.
+// The type is detected from the file itself, not taken from the upload.
+var AllowedDocumentContentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+// A document waits for an admin to check it against the original and is then verified or rejected
+const (
+	DocumentStatusPending  = "pending"
+	DocumentStatusVerified = "verified"
+	DocumentStatusRejected = "rejected"
+)
+
+// Document is a file a member has handed in, e.g. an ID card, or a payslip for a loan application
+type Document struct {
+	gorm.Model
+	MemberID        uint   `gorm:"not null;index"`
+	LoanID          *uint  `gorm:"index"` // set when the document supports a loan application
+	Category        string `gorm:"size:30;not null"`
+	FileName        string `gorm:"not null"` // the name it was uploaded with
+	ContentType     string `gorm:"size:100;not null"`
+	Size            int64  `gorm:"not null"`
+	Checksum        string `gorm:"size:64;not null"` // hex SHA-256 of the content
+	StorageKey      string `gorm:"not null;uniqueIndex"`
+	UploadedBy      uint   `gorm:"not null"`
+	Status          string `gorm:"size:20;not null;default:pending;index"`
+	VerifiedBy      *uint
+	VerifiedAt      *time.Time
+	RejectionReason string
+}
+
+type DocumentResponse struct {
+	ID              uint       `json:"id"`
+	CreatedAt       time.Time  `json:"created_at"`
+	MemberID        uint       `json:"member_id"`
+	LoanID          *uint      `json:"loan_id,omitempty"`
+	Category        string     `json:"category"`
+	FileName        string     `json:"file_name"`
+	ContentType     string     `json:"content_type"`
+	Size            int64      `json:"size"`
+	Checksum        string     `json:"checksum"`
+	UploadedBy      uint       `json:"uploaded_by"`
+	Status          string     `json:"status"`
+	VerifiedBy      *uint      `json:"verified_by,omitempty"`
+	VerifiedAt      *time.Time `json:"verified_at,omitempty"`
+	RejectionReason string     `json:"rejection_reason,omitempty"`
+}
+
+func NewDocumentResponse(document *Document) DocumentResponse {
+	return DocumentResponse{
+		ID:              document.ID,
+		CreatedAt:       document.CreatedAt,
+		MemberID:        document.MemberID,
+		LoanID:          document.LoanID,
+		Category:        document.Category,
+		FileName:        document.FileName,
+		ContentType:     document.ContentType,
+		Size:            document.Size,
+		Checksum:        document.Checksum,
+		UploadedBy:      document.UploadedBy,
+		Status:          document.Status,
+		VerifiedBy:      document.VerifiedBy,
+		VerifiedAt:      document.VerifiedAt,
+		RejectionReason: document.RejectionReason,
+	}
+}
+
+func NewDocumentResponses(documents []Document) []DocumentResponse {
+	responses := make([]DocumentResponse, len(documents))
+	for i := range documents {
+		responses[i] = NewDocumentResponse(&documents[i])
+	}
+	return responses
+}
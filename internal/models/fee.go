@@ -0,0 +1,231 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Fee triggers are the events that charge a fee automatically
+const (
+	FeeTriggerMemberCreated      = "member_created"
+	FeeTriggerLoanApproved       = "loan_approved"
+	FeeTriggerLoanDisbursed      = "loan_disbursed"
+	FeeTriggerStatementGenerated = "statement_generated"
+)
+
+var AllowedFeeTriggers = map[string]bool{
+	FeeTriggerMemberCreated:      true,
+	FeeTriggerLoanApproved:       true,
+	FeeTriggerLoanDisbursed:      true,
+	FeeTriggerStatementGenerated: true,
+}
+
+const (
+	FeeCalculationFlat       = "flat"
+	FeeCalculationPercentage = "percentage"
+)
+
+var AllowedFeeCalculations = map[string]bool{
+	FeeCalculationFlat:       true,
+	FeeCalculationPercentage: true,
+}
+
+const (
+	FeeChargeStatusPosted      = "posted"      // debited from the member's savings
+	FeeChargeStatusOutstanding = "outstanding" // savings could not cover it when it was charged
+	FeeChargeStatusWaived      = "waived"
+)
+
+// FeeSource says what a fee was charged on, SourceID is the id of that record
+const (
+	FeeSourceMember  = "member"
+	FeeSourceLoan    = "loan"
+	FeeSourceSavings = "savings"
+)
+
+// FeeDefinition is an admin-maintained fee. A flat fee charges Amount in Currency, a percentage fee charges
+// Rate of the amount it is charged on (e.g. the loan principal) in that amount's currency, kept between
+// MinimumAmount and MaximumAmount when they are set.
+type FeeDefinition struct {
+	gorm.Model
+	Code            string  `gorm:"size:50;not null;uniqueIndex"`
+	Name            string  `gorm:"not null"`
+	Trigger         string  `gorm:"size:30;not null;index"`
+	CalculationType string  `gorm:"size:20;not null"`
+	Amount          Money   `gorm:"not null;default:0"`
+	Rate            float64 `gorm:"not null;default:0"` // e.g. 0.01 for 1%
+	MinimumAmount   Money   `gorm:"not null;default:0"`
+	MaximumAmount   Money   `gorm:"not null;default:0"` // 0 means no cap
+	Currency        string  `gorm:"size:3;not null;default:NGN"`
+	Active          bool    `gorm:"not null;default:true"`
+	CreatedBy       uint    `gorm:"not null"`
+	UpdatedBy       uint
+}
+
+// Validate checks the fee has what its calculation type needs and sensible limits
+func (fee *FeeDefinition) Validate() error {
+	if !AllowedFeeTriggers[fee.Trigger] {
+		return errors.New("trigger must be member_created, loan_approved, loan_disbursed or statement_generated")
+	}
+	switch fee.CalculationType {
+	case FeeCalculationFlat:
+		if fee.Amount <= 0 {
+			return errors.New("a flat fee needs an amount greater than zero")
+		}
+	case FeeCalculationPercentage:
+		if fee.Rate <= 0 || fee.Rate > 1 {
+			return errors.New("a percentage fee needs a rate greater than 0 and at most 1")
+		}
+	default:
+		return errors.New("calculation type must be flat or percentage")
+	}
+	if fee.MinimumAmount < 0 || fee.MaximumAmount < 0 {
+		return errors.New("minimum and maximum amounts cannot be negative")
+	}
+	if fee.MaximumAmount > 0 && fee.MaximumAmount < fee.MinimumAmount {
+		return errors.New("maximum amount must not be below the minimum amount")
+	}
+	return nil
+}
+
+// Calculate works out the fee on an amount, percentage fees are rounded once and then kept within the limits
+func (fee *FeeDefinition) Calculate(base Money) (Money, error) {
+	if fee.CalculationType == FeeCalculationFlat {
+		return fee.Amount, nil
+	}
+	amount, err := base.MulRate(fee.Rate)
+	if err != nil {
+		return 0, err
+	}
+	if amount < fee.MinimumAmount {
+		amount = fee.MinimumAmount
+	}
+	if fee.MaximumAmount > 0 && amount > fee.MaximumAmount {
+		amount = fee.MaximumAmount
+	}
+	return amount, nil
+}
+
+// FeeContext describes the event a fee is charged on
+type FeeContext struct {
+	MemberID   uint
+	SourceType string
+	SourceID   uint
+	BaseAmount Money  // what percentage fees are worked out on, zero for events without an amount
+	Currency   string // currency of BaseAmount
+	Period     string // set for fees charged once per period of the source, e.g. a statement
+	PostedBy   uint
+}
+
+// FeeCharge is one fee charged to a member. A waiver never deletes the charge, it records who waived it,
+// when and why, and reverses the savings entry if the fee had been posted.
+type FeeCharge struct {
+	gorm.Model
+	FeeDefinitionID     uint          `gorm:"not null;index;uniqueIndex:idx_fee_charges_period"`
+	FeeDefinition       FeeDefinition `gorm:"foreignKey:FeeDefinitionID"`
+	MemberID            uint          `gorm:"not null;index"`
+	Trigger             string        `gorm:"size:30;not null"`
+	SourceType          string        `gorm:"size:20;not null;uniqueIndex:idx_fee_charges_period"`
+	SourceID            uint          `gorm:"not null;uniqueIndex:idx_fee_charges_period"`
+	Period              string        `gorm:"size:21;not null;default:'';uniqueIndex:idx_fee_charges_period,where:period <> ''"` // e.g. "2025-02-01/2025-02-28", empty for one-off fees
+	Amount              Money         `gorm:"not null"`
+	Currency            string        `gorm:"size:3;not null;default:NGN"`
+	Status              string        `gorm:"size:20;not null;index"` // e.g., "posted", "outstanding", "waived"
+	SavingTransactionID *uint         `gorm:"uniqueIndex"`
+	PostedBy            uint          `gorm:"not null"`
+	PaidAt              *time.Time
+	WaivedBy            *uint
+	WaivedAt            *time.Time
+	WaiverReason        string
+	WaiverTransactionID *uint
+}
+
+type FeeDefinitionResponse struct {
+	ID              uint      `json:"id"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	Code            string    `json:"code"`
+	Name            string    `json:"name"`
+	Trigger         string    `json:"trigger"`
+	CalculationType string    `json:"calculation_type"`
+	Amount          Money     `json:"amount"`
+	Rate            float64   `json:"rate"`
+	MinimumAmount   Money     `json:"minimum_amount"`
+	MaximumAmount   Money     `json:"maximum_amount"`
+	Currency        string    `json:"currency"`
+	Active          bool      `json:"active"`
+}
+
+func NewFeeDefinitionResponse(fee *FeeDefinition) FeeDefinitionResponse {
+	return FeeDefinitionResponse{
+		ID:              fee.ID,
+		CreatedAt:       fee.CreatedAt,
+		UpdatedAt:       fee.UpdatedAt,
+		Code:            fee.Code,
+		Name:            fee.Name,
+		Trigger:         fee.Trigger,
+		CalculationType: fee.CalculationType,
+		Amount:          fee.Amount,
+		Rate:            fee.Rate,
+		MinimumAmount:   fee.MinimumAmount,
+		MaximumAmount:   fee.MaximumAmount,
+		Currency:        fee.Currency,
+		Active:          fee.Active,
+	}
+}
+
+type FeeChargeResponse struct {
+	ID                  uint       `json:"id"`
+	CreatedAt           time.Time  `json:"created_at"`
+	FeeDefinitionID     uint       `json:"fee_definition_id"`
+	FeeCode             string     `json:"fee_code,omitempty"`
+	FeeName             string     `json:"fee_name,omitempty"`
+	MemberID            uint       `json:"member_id"`
+	Trigger             string     `json:"trigger"`
+	SourceType          string     `json:"source_type"`
+	SourceID            uint       `json:"source_id"`
+	Period              string     `json:"period,omitempty"`
+	Amount              Money      `json:"amount"`
+	Currency            string     `json:"currency"`
+	Status              string     `json:"status"`
+	SavingTransactionID *uint      `json:"saving_transaction_id,omitempty"`
+	PaidAt              *time.Time `json:"paid_at,omitempty"`
+	WaivedBy            *uint      `json:"waived_by,omitempty"`
+	WaivedAt            *time.Time `json:"waived_at,omitempty"`
+	WaiverReason        string     `json:"waiver_reason,omitempty"`
+	WaiverTransactionID *uint      `json:"waiver_transaction_id,omitempty"`
+}
+
+func NewFeeChargeResponse(charge *FeeCharge) FeeChargeResponse {
+	return FeeChargeResponse{
+		ID:                  charge.ID,
+		CreatedAt:           charge.CreatedAt,
+		FeeDefinitionID:     charge.FeeDefinitionID,
+		FeeCode:             charge.FeeDefinition.Code,
+		FeeName:             charge.FeeDefinition.Name,
+		MemberID:            charge.MemberID,
+		Trigger:             charge.Trigger,
+		SourceType:          charge.SourceType,
+		SourceID:            charge.SourceID,
+		Period:              charge.Period,
+		Amount:              charge.Amount,
+		Currency:            charge.Currency,
+		Status:              charge.Status,
+		SavingTransactionID: charge.SavingTransactionID,
+		PaidAt:              charge.PaidAt,
+		WaivedBy:            charge.WaivedBy,
+		WaivedAt:            charge.WaivedAt,
+		WaiverReason:        charge.WaiverReason,
+		WaiverTransactionID: charge.WaiverTransactionID,
+	}
+}
+
+func NewFeeChargeResponses(charges []FeeCharge) []FeeChargeResponse {
+	responses := make([]FeeChargeResponse, len(charges))
+	for i := range charges {
+		responses[i] = NewFeeChargeResponse(&charges[i])
+	}
+	return responses
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type FixedDeposit struct {
+	gorm.Model
+	MemberID            uint      `gorm:"not null"`
+	Principal           Money     `gorm:"not null"`
+	Currency            string    `gorm:"size:3;not null;default:NGN"`
+	InterestRate        float64   `gorm:"not null"`
+	TermMonths          uint      `gorm:"not null"` // e.g., 6 or 12
+	StartDate           time.Time `gorm:"not null"`
+	MaturityDate        time.Time `gorm:"not null"`
+	MaturityInstruction string    `gorm:"not null"` // e.g., "rollover", "move_to_savings", "payout"
+	Status              string    `gorm:"not null"` // e.g., "active", "matured", "broken"
+
+	InterestEarned Money
+	PenaltyAmount  Money
+	PayoutAmount   Money
+	ClosedAt       *time.Time
+	RolledOverFrom *uint
+	RolledOverTo   *uint
+	Member         Member `gorm:"foreignKey:MemberID"`
+}
+
+type FixedDepositResponse struct {
+	ID                  uint       `json:"id"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+	MemberID            uint       `json:"member_id"`
+	Principal           Money      `json:"principal"`
+	Currency            string     `json:"currency"`
+	InterestRate        float64    `json:"interest_rate"`
+	TermMonths          uint       `json:"term_months"`
+	StartDate           time.Time  `json:"start_date"`
+	MaturityDate        time.Time  `json:"maturity_date"`
+	MaturityInstruction string     `json:"maturity_instruction"`
+	Status              string     `json:"status"`
+	ExpectedInterest    Money      `json:"expected_interest"`
+	InterestEarned      Money      `json:"interest_earned"`
+	PenaltyAmount       Money      `json:"penalty_amount"`
+	PayoutAmount        Money      `json:"payout_amount"`
+	ClosedAt            *time.Time `json:"closed_at,omitempty"`
+	RolledOverFrom      *uint      `json:"rolled_over_from,omitempty"`
+	RolledOverTo        *uint      `json:"rolled_over_to,omitempty"`
+}
+
+func NewFixedDepositResponse(deposit *FixedDeposit) FixedDepositResponse {
+	// the interest is checked to fit when the deposit is opened
+	expectedInterest, _ := CalculateFixedDepositInterest(deposit.Principal, deposit.InterestRate, deposit.StartDate, deposit.MaturityDate)
+	return FixedDepositResponse{
+		ID:                  deposit.ID,
+		CreatedAt:           deposit.CreatedAt,
+		UpdatedAt:           deposit.UpdatedAt,
+		MemberID:            deposit.MemberID,
+		Principal:           deposit.Principal,
+		Currency:            deposit.Currency,
+		InterestRate:        deposit.InterestRate,
+		TermMonths:          deposit.TermMonths,
+		StartDate:           deposit.StartDate,
+		MaturityDate:        deposit.MaturityDate,
+		MaturityInstruction: deposit.MaturityInstruction,
+		Status:              deposit.Status,
+		ExpectedInterest:    expectedInterest,
+		InterestEarned:      deposit.InterestEarned,
+		PenaltyAmount:       deposit.PenaltyAmount,
+		PayoutAmount:        deposit.PayoutAmount,
+		ClosedAt:            deposit.ClosedAt,
+		RolledOverFrom:      deposit.RolledOverFrom,
+		RolledOverTo:        deposit.RolledOverTo,
+	}
+}
+
+const (
+	FixedDepositStatusActive     = "active"
+	FixedDepositStatusMatured    = "matured"
+	FixedDepositStatusRolledOver = "rolled_over"
+	FixedDepositStatusBroken     = "broken"
+)
+
+const (
+	MaturityInstructionRollover      = "rollover"
+	MaturityInstructionMoveToSavings = "move_to_savings"
+	MaturityInstructionPayout        = "payout"
+)
+
+var AllowedMaturityInstructions = map[string]bool{
+	MaturityInstructionRollover:      true,
+	MaturityInstructionMoveToSavings: true,
+	MaturityInstructionPayout:        true,
+}
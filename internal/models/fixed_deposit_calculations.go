@@ -0,0 +1,71 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	FixedDepositEarlyBreakPenaltyRate = 0.01 // 1% of the principal is charged when a deposit is broken before maturity
+	daysPerYear                       = 365
+)
+
+var (
+	ErrFixedDepositNotActive = errors.New("only active fixed deposits can be broken")
+	ErrFixedDepositMatured   = errors.New("fixed deposit has already matured")
+)
+
+// FixedDepositRates maps the supported terms (in months) to their annual interest rate
+var FixedDepositRates = map[uint]float64{
+	6:  0.08, // 8% per annum for 6 months
+	12: 0.10, // 10% per annum for 12 months
+}
+
+func GetFixedDepositRate(termMonths uint) (float64, error) {
+	rate, ok := FixedDepositRates[termMonths]
+	if !ok {
+		return 0, errors.New("unsupported fixed deposit term")
+	}
+	return rate, nil
+}
+
+func CalculateMaturityDate(startDate time.Time, termMonths uint) time.Time {
+	return startDate.AddDate(0, int(termMonths), 0)
+}
+
+// CalculateFixedDepositInterest returns the simple interest earned on principal for the whole days between from and to
+func CalculateFixedDepositInterest(principal Money, annualInterestRate float64, from time.Time, to time.Time) (Money, error) {
+	if !to.After(from) {
+		return 0, nil
+	}
+	days := int64(to.Sub(from).Hours() / 24)
+	return principal.Interest(annualInterestRate, days, daysPerYear)
+}
+
+// CalculateEarlyBreak works out what a member gets back when a deposit is broken before maturity.
+// Interest accrues up to breakDate and the penalty is taken from the total, never below zero.
+func CalculateEarlyBreak(deposit *FixedDeposit, breakDate time.Time) (interest Money, penalty Money, payout Money, err error) {
+	if deposit == nil {
+		return 0, 0, 0, errors.New("fixed deposit is nil")
+	}
+	if deposit.Status != FixedDepositStatusActive {
+		return 0, 0, 0, ErrFixedDepositNotActive
+	}
+	if !breakDate.Before(deposit.MaturityDate) {
+		return 0, 0, 0, ErrFixedDepositMatured
+	}
+
+	interest, err = CalculateFixedDepositInterest(deposit.Principal, deposit.InterestRate, deposit.StartDate, breakDate)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	penalty, err = deposit.Principal.MulRate(FixedDepositEarlyBreakPenaltyRate)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	payout = deposit.Principal + interest - penalty
+	if payout < 0 {
+		payout = 0
+	}
+	return interest, penalty, payout, nil
+}
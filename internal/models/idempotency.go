@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// IdempotencyKeyTTL is how long a key is remembered, a retry after that runs the request again
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// IdempotencyKey remembers the outcome of a money-moving request sent with an Idempotency-Key header so a
+// retry of the same request gets the original response instead of being processed twice. Keys are scoped to
+// the user who sent them.
+type IdempotencyKey struct {
+	gorm.Model
+	UserID      uint   `gorm:"not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	Key         string `gorm:"size:255;not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	Method      string `gorm:"not null"`
+	Path        string `gorm:"not null"`
+	RequestHash string `gorm:"size:64;not null"` // sha256 of the method, path and body
+	Completed   bool   `gorm:"not null;default:false"`
+	StatusCode  int
+	ContentType string
+	Response    []byte
+	ExpiresAt   time.Time `gorm:"not null;index"`
+}
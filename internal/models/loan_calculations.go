@@ -2,6 +2,7 @@ package models
 
 import (
 	"errors"
+	"fmt"
 )
 
 const (
@@ -33,23 +34,34 @@ func GetInterestRate(loanType string, loanTermMonths uint) float64 {
 	return calculatedInterestRate
 }
 
-func CalculateTotalRepayableAmount(principal float64, annualInterestRate float64, loanTermMonths uint) (float64, error) {
+// CalculateTotalRepayableAmount applies simple interest for the loan term, rounded once to the minor unit
+func CalculateTotalRepayableAmount(principal Money, annualInterestRate float64, loanTermMonths uint) (Money, error) {
 	if loanTermMonths == 0 {
 		return 0, errors.New("loan term months cannot be zero")
 	}
 
-	monthlyInterestRate := annualInterestRate / 12
-	totalRepayableAmount := principal * (1 + monthlyInterestRate*float64(loanTermMonths))
-	return totalRepayableAmount, nil
+	interest, err := principal.Interest(annualInterestRate, int64(loanTermMonths), 12)
+	if err != nil {
+		return 0, err
+	}
+	return principal + interest, nil
 
 }
 
-func CalculateInstallmentAmount(totalRepayableAmount float64, loanTermMonths uint) (float64, error) {
+// CalculateInstallmentSchedule splits the total into equal monthly installments, the last one absorbs any rounding remainder
+func CalculateInstallmentSchedule(totalRepayableAmount Money, loanTermMonths uint) ([]Money, error) {
 	if loanTermMonths == 0 {
-		return 0, errors.New("loan term months cannot be zero")
+		return nil, errors.New("loan term months cannot be zero")
+	}
+	return totalRepayableAmount.Split(int(loanTermMonths))
+}
+
+func CalculateInstallmentAmount(totalRepayableAmount Money, loanTermMonths uint) (Money, error) {
+	schedule, err := CalculateInstallmentSchedule(totalRepayableAmount, loanTermMonths)
+	if err != nil {
+		return 0, err
 	}
-	installmentAmount := totalRepayableAmount / float64(loanTermMonths)
-	return installmentAmount, nil
+	return schedule[0], nil
 }
 
 func CheckLoanStatus(loan *Loan) (canProcess bool, message string, err error) {
@@ -75,15 +87,24 @@ func CheckLoanStatus(loan *Loan) (canProcess bool, message string, err error) {
 	}
 }
 
-func CheckLoanEligibility(requestedLoan *Loan, member *Member, savings *Savings, existingLoans []Loan) (bool, []string, error) {
+func CheckLoanEligibility(requestedLoan *Loan, member *Member, savings *Savings, existingLoans []Loan, contributions *ContributionStatus) (bool, []string, error) {
 
 	var reasons []string
 
+	if !member.IsActive() {
+		reasons = append(reasons, fmt.Sprintf("member is %s, only approved members can borrow", member.Status))
+	}
+
 	if savings == nil {
-		reasons = append(reasons, "savings record not found")
+		reasons = append(reasons, fmt.Sprintf("member has no %s savings to secure the loan", requestedLoan.Currency))
+	} else if err := CheckSameCurrency(requestedLoan.Currency, savings.Currency); err != nil {
+		reasons = append(reasons, fmt.Sprintf("loan currency %s does not match savings currency %s", requestedLoan.Currency, savings.Currency))
 	} else {
-		loanLimit := savings.Balance * MaxLoanToSavingsRatio
-		if requestedLoan.Amount > float64(loanLimit) {
+		loanLimit, err := savings.Balance.MulRate(MaxLoanToSavingsRatio)
+		if err != nil {
+			return false, nil, err
+		}
+		if requestedLoan.Amount > loanLimit {
 			reasons = append(reasons, "requested loan exceeds twice the savings balance")
 		}
 	}
@@ -114,12 +135,17 @@ func CheckLoanEligibility(requestedLoan *Loan, member *Member, savings *Savings,
 		reasons = append(reasons, "member has reached the maximum number of active loans")
 	}
 
+	if contributions != nil && contributions.InArrears {
+		reasons = append(reasons, fmt.Sprintf("member is %d contribution(s) in arrears", contributions.PeriodsInArrears))
+	}
+
 	if requestedLoan.Amount <= 0 {
 		reasons = append(reasons, "requested loan amount must be greater than zero")
 	}
 
+	// an ineligible loan is rejected with the reasons, it is not an error
 	if len(reasons) > 0 {
-		return false, reasons, errors.New("loan eligibility check failed")
+		return false, reasons, nil
 	}
 
 	return true, nil, nil
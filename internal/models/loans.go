@@ -8,10 +8,12 @@ import (
 
 type Loan struct {
 	gorm.Model
-	MemberID       uint `gorm:"not null"`
+	MemberID       uint    `gorm:"not null"`
+	AccountNumber  *string `gorm:"size:30;uniqueIndex"` // issued on approval, see NumberingSettings
 	Description    string
 	Type           string  `gorm:"not null"` // e.g., "personal", "business", "education"
-	Amount         float64 `gorm:"not null"`
+	Amount         Money   `gorm:"not null"`
+	Currency       string  `gorm:"size:3;not null;default:NGN"`
 	InterestRate   float64 `gorm:"not null"`
 	LoanTermMonths uint    `gorm:"not null"` // e.g., 12 for 1 year
 	Status         string  `gorm:"not null"` // e.g., "pending", "approved", "rejected"
@@ -20,9 +22,9 @@ type Loan struct {
 	ApprovedBy        *uint
 	ApprovalDate      *time.Time
 	RejectionReason   string
-	InstallmentAmount float64
+	InstallmentAmount Money
 
-	TotalRepayableAmount float64
+	TotalRepayableAmount Money
 	Member               Member    `gorm:"foreignKey:MemberID"`
 	SubmittedAt          time.Time `gorm:"autoCreateTime"`
 	ReviewedAt           *time.Time
@@ -31,6 +33,11 @@ type Loan struct {
 	LoanHistory          []LoanHistory `gorm:"foreignKey:LoanID"`
 	DisbursedAt          *time.Time
 	IsActive             bool
+
+	// Loans carried over from the legacy system point at their migration batch
+	MigrationBatchID *uint `gorm:"index"`
+	LegacyReference  string
+	Installments     []LoanInstallment `gorm:"foreignKey:LoanID"`
 }
 
 type LoanHistory struct {
@@ -43,14 +50,16 @@ type LoanHistory struct {
 }
 
 type LoanResponse struct {
-	ID          uint      `json:"id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	MemberID    uint      `json:"member_id"`
-	Description string    `json:"description"`
-	Type        string    `json:"type"`
-
-	Amount               float64    `json:"amount"`
+	ID            uint      `json:"id"`
+	AccountNumber *string   `json:"account_number,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	MemberID      uint      `json:"member_id"`
+	Description   string    `json:"description"`
+	Type          string    `json:"type"`
+
+	Amount               Money      `json:"amount"`
+	Currency             string     `json:"currency"`
 	InterestRate         float64    `json:"interest_rate"`
 	LoanTermMonths       uint       `json:"loan_term_months"`
 	Status               string     `json:"status"`
@@ -58,13 +67,18 @@ type LoanResponse struct {
 	ApprovedBy           *uint      `json:"approved_by,omitempty"`
 	ApprovalDate         *time.Time `json:"approval_date,omitempty"`
 	RejectionReason      string     `json:"rejection_reason,omitempty"`
-	InstallmentAmount    float64    `json:"installment_amount"`
-	TotalRepayableAmount float64    `json:"total_repayable_amount"`
+	InstallmentAmount    Money      `json:"installment_amount"`
+	TotalRepayableAmount Money      `json:"total_repayable_amount"`
 	SubmittedAt          time.Time  `json:"submitted_at"`
 	ReviewedAt           *time.Time `json:"reviewed_at,omitempty"`
 	ApprovedAt           *time.Time `json:"approved_at,omitempty"`
 	RejectedAt           *time.Time `json:"rejected_at,omitempty"`
 	DisbursedAt          *time.Time `json:"disbursed_at,omitempty"`
+	Migrated             bool       `json:"migrated,omitempty"`
+	MigrationBatchID     *uint      `json:"migration_batch_id,omitempty"`
+	LegacyReference      string     `json:"legacy_reference,omitempty"`
+	// RemainingSchedule is only kept for migrated loans
+	RemainingSchedule []LoanInstallmentResponse `json:"remaining_schedule,omitempty"`
 	// LoanHistory          []LoanHistoryResponse `json:"loan_history"`
 }
 
@@ -91,12 +105,14 @@ func NewLoanResponse(loan *Loan) LoanResponse {
 	// }
 	return LoanResponse{
 		ID:                   loan.ID,
+		AccountNumber:        loan.AccountNumber,
 		CreatedAt:            loan.CreatedAt,
 		UpdatedAt:            loan.UpdatedAt,
 		MemberID:             loan.MemberID,
 		Description:          loan.Description,
 		Type:                 loan.Type,
 		Amount:               loan.Amount,
+		Currency:             loan.Currency,
 		InterestRate:         loan.InterestRate,
 		LoanTermMonths:       loan.LoanTermMonths,
 		Status:               loan.Status,
@@ -111,6 +127,10 @@ func NewLoanResponse(loan *Loan) LoanResponse {
 		ApprovedAt:           loan.ApprovedAt,
 		RejectedAt:           loan.RejectedAt,
 		DisbursedAt:          loan.DisbursedAt,
+		Migrated:             loan.MigrationBatchID != nil,
+		MigrationBatchID:     loan.MigrationBatchID,
+		LegacyReference:      loan.LegacyReference,
+		RemainingSchedule:    NewLoanInstallmentResponses(loan.Installments),
 		// LoanHistory:          histories,
 	}
 }
@@ -146,3 +166,58 @@ func NewLoanHistoryResponse(loanHistory *LoanHistory) LoanHistoryResponse {
 		Remarks:   loanHistory.Remarks,
 	}
 }
+
+// LoanRepayment is a payment received against an approved loan
+type LoanRepayment struct {
+	gorm.Model
+	LoanID   uint      `gorm:"not null;index"`
+	MemberID uint      `gorm:"not null;index"`
+	Amount   Money     `gorm:"not null"`
+	Currency string    `gorm:"size:3;not null;default:NGN"`
+	PaidAt   time.Time `gorm:"not null"`
+	PostedBy uint      `gorm:"not null"`
+	Note     string
+
+	Channel           string `gorm:"size:20"`
+	ExternalReference string `gorm:"index"`
+	MigrationBatchID  *uint  `gorm:"index"` // set on the paid to date of a migrated loan
+}
+
+type LoanRepaymentResponse struct {
+	ID                uint      `json:"id"`
+	CreatedAt         time.Time `json:"created_at"`
+	LoanID            uint      `json:"loan_id"`
+	MemberID          uint      `json:"member_id"`
+	Amount            Money     `json:"amount"`
+	Currency          string    `json:"currency"`
+	PaidAt            time.Time `json:"paid_at"`
+	PostedBy          uint      `json:"posted_by"`
+	Note              string    `json:"note,omitempty"`
+	Channel           string    `json:"channel,omitempty"`
+	ExternalReference string    `json:"external_reference,omitempty"`
+}
+
+func NewLoanRepaymentResponse(repayment *LoanRepayment) LoanRepaymentResponse {
+	return LoanRepaymentResponse{
+		ID:                repayment.ID,
+		CreatedAt:         repayment.CreatedAt,
+		LoanID:            repayment.LoanID,
+		MemberID:          repayment.MemberID,
+		Amount:            repayment.Amount,
+		Currency:          repayment.Currency,
+		PaidAt:            repayment.PaidAt,
+		PostedBy:          repayment.PostedBy,
+		Note:              repayment.Note,
+		Channel:           repayment.Channel,
+		ExternalReference: repayment.ExternalReference,
+	}
+}
+
+// IsRepayable reports whether a loan is running and can take repayments
+func (loan *Loan) IsRepayable() bool {
+	switch loan.Status {
+	case LoanStatusApproved, LoanStatusActive, LoanStatusDisbursed:
+		return true
+	}
+	return false
+}
@@ -1,39 +1,226 @@
 package models
 
 import (
+	"errors"
+	"regexp"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// A new member applies, is reviewed and is then approved, which makes them active, or rejected.
+// Members created before onboarding existed are already active. An active member can be suspended and
+// reactivated, and leaves through the exit process, which settles their accounts first.
+const (
+	MemberStatusApplied     = "applied"
+	MemberStatusUnderReview = "under_review"
+	MemberStatusActive      = "active"
+	MemberStatusRejected    = "rejected"
+	MemberStatusSuspended   = "suspended"
+	MemberStatusExited      = "exited"
+)
+
+var AllowedMemberStatuses = map[string]bool{
+	MemberStatusApplied:     true,
+	MemberStatusUnderReview: true,
+	MemberStatusActive:      true,
+	MemberStatusRejected:    true,
+	MemberStatusSuspended:   true,
+	MemberStatusExited:      true,
+}
+
+// memberStatusTransitions lists where each status can move to. Exiting is not listed, it only happens
+// through the exit settlement.
+var memberStatusTransitions = map[string][]string{
+	MemberStatusApplied:     {MemberStatusUnderReview, MemberStatusActive, MemberStatusRejected},
+	MemberStatusUnderReview: {MemberStatusActive, MemberStatusRejected},
+	MemberStatusActive:      {MemberStatusSuspended},
+	MemberStatusSuspended:   {MemberStatusActive},
+}
+
+const (
+	IDTypeNationalID     = "national_id"
+	IDTypePassport       = "passport"
+	IDTypeDriversLicense = "drivers_license"
+	IDTypeVotersCard     = "voters_card"
+)
+
+var AllowedIDTypes = map[string]bool{
+	IDTypeNationalID:     true,
+	IDTypePassport:       true,
+	IDTypeDriversLicense: true,
+	IDTypeVotersCard:     true,
+}
+
+var (
+	ErrMemberNotActive         = errors.New("only active members can save or borrow")
+	ErrInvalidMemberTransition = errors.New("member cannot move to that status")
+	ErrMemberHasAccounts       = errors.New("member must exit and be settled before being deleted")
+)
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9]{7,15}$`)
+
 type Member struct {
 	gorm.Model
-	UserID      uint   `gorm:"unique"`
-	Name        string `gorm:"not null"`
-	ContactInfo string `gorm:"not null"`
-	User        User   `gorm:"foreignKey:UserID"`
-	Status      string `gorm:"default:'active'"`
+	UserID          uint    `gorm:"unique"`
+	MemberNumber    *string `gorm:"size:30;uniqueIndex"` // issued on approval, see NumberingSettings
+	Name            string  `gorm:"not null"`
+	ContactInfo     string  `gorm:"not null"`
+	User            User    `gorm:"foreignKey:UserID"`
+	Status          string  `gorm:"size:20;default:'applied';index"`
+	Phone           string  `gorm:"size:20"`
+	Address         string
+	DateOfBirth     *time.Time `gorm:"type:date"`
+	IDType          string     `gorm:"size:20"`
+	IDNumber        string     `gorm:"size:50;index"`
+	Occupation      string
+	Employer        string
+	ReviewedBy      *uint
+	ReviewedAt      *time.Time
+	ApprovedAt      *time.Time
+	RejectionReason string
+	SuspendedAt     *time.Time
+	ExitedAt        *time.Time
+	// Members carried over from the legacy system point at their migration batch
+	MigrationBatchID *uint           `gorm:"index"`
+	LegacyReference  string          `gorm:"index"`
+	BranchID         *uint           `gorm:"index"`
+	Branch           *Branch         `gorm:"foreignKey:BranchID"`
+	GroupID          *uint           `gorm:"index"`
+	Group            *MemberGroup    `gorm:"foreignKey:GroupID"`
+	History          []MemberHistory `gorm:"foreignKey:MemberID"`
+}
+
+// MemberHistory records every change of a member's status, like LoanHistory does for loans
+type MemberHistory struct {
+	gorm.Model
+	MemberID  uint `gorm:"index"`
+	Status    string
+	ChangedAt time.Time `gorm:"autoCreateTime"`
+	ChangedBy uint
+	Remarks   string
+}
+
+// IsActive reports whether the member has been approved and can save or borrow
+func (member *Member) IsActive() bool {
+	return member.Status == MemberStatusActive
+}
+
+// CanExit reports whether the member can go through the exit process
+func (member *Member) CanExit() bool {
+	return member.Status == MemberStatusActive || member.Status == MemberStatusSuspended
+}
+
+// CanBeDeleted reports whether the member can be removed. Members who could still hold money must exit first.
+func (member *Member) CanBeDeleted() bool {
+	return member.Status != MemberStatusActive && member.Status != MemberStatusSuspended
+}
+
+// CanMoveTo reports whether the member's status can change to the given one
+func (member *Member) CanMoveTo(status string) bool {
+	for _, next := range memberStatusTransitions[member.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateKYC checks an application has every KYC field it needs in the right format
+func (member *Member) ValidateKYC() error {
+	switch {
+	case member.Phone == "":
+		return errors.New("phone is required")
+	case member.Address == "":
+		return errors.New("address is required")
+	case member.DateOfBirth == nil:
+		return errors.New("date of birth is required")
+	case member.IDType == "":
+		return errors.New("id type is required")
+	case member.IDNumber == "":
+		return errors.New("id number is required")
+	}
+	return member.ValidateKYCFormat()
+}
+
+// ValidateKYCFormat checks the KYC fields that are filled in, without requiring any of them
+func (member *Member) ValidateKYCFormat() error {
+	if member.Phone != "" && !phonePattern.MatchString(member.Phone) {
+		return errors.New("phone must be 7 to 15 digits, optionally starting with +")
+	}
+	if member.DateOfBirth != nil && !member.DateOfBirth.Before(time.Now()) {
+		return errors.New("date of birth must be in the past")
+	}
+	if member.IDType != "" && !AllowedIDTypes[member.IDType] {
+		return errors.New("id type must be national_id, passport, drivers_license or voters_card")
+	}
+	return nil
+}
+
+// NormalizePhone strips the spaces, dashes and brackets people type into phone numbers
+func NormalizePhone(phone string) string {
+	return strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(strings.TrimSpace(phone))
 }
 
 type MemberResponse struct {
-	ID        uint      `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           uint      `json:"id"`
+	MemberNumber *string   `json:"member_number,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 	// DeletedAt   string `json:"deleted_at"`
-	Name        string `json:"name"`
-	ContactInfo string `json:"contact_info"`
-	UserID      uint   `json:"user_id"`
-	Status      string `json:"status"`
+	Name             string     `json:"name"`
+	ContactInfo      string     `json:"contact_info"`
+	UserID           uint       `json:"user_id"`
+	Status           string     `json:"status"`
+	Phone            string     `json:"phone,omitempty"`
+	Address          string     `json:"address,omitempty"`
+	DateOfBirth      string     `json:"date_of_birth,omitempty"`
+	IDType           string     `json:"id_type,omitempty"`
+	IDNumber         string     `json:"id_number,omitempty"`
+	Occupation       string     `json:"occupation,omitempty"`
+	Employer         string     `json:"employer,omitempty"`
+	ReviewedBy       *uint      `json:"reviewed_by,omitempty"`
+	ReviewedAt       *time.Time `json:"reviewed_at,omitempty"`
+	ApprovedAt       *time.Time `json:"approved_at,omitempty"`
+	RejectionReason  string     `json:"rejection_reason,omitempty"`
+	SuspendedAt      *time.Time `json:"suspended_at,omitempty"`
+	ExitedAt         *time.Time `json:"exited_at,omitempty"`
+	MigrationBatchID *uint      `json:"migration_batch_id,omitempty"`
+	LegacyReference  string     `json:"legacy_reference,omitempty"`
+	BranchID         *uint      `json:"branch_id,omitempty"`
+	GroupID          *uint      `json:"group_id,omitempty"`
 }
 
 func NewMemberResponse(member *Member) MemberResponse {
-	return MemberResponse{
-		ID:          member.ID,
-		CreatedAt:   member.CreatedAt,
-		UpdatedAt:   member.UpdatedAt,
-		Name:        member.Name,
-		ContactInfo: member.ContactInfo,
-		UserID:      member.UserID,
-		Status:      member.Status,
+	response := MemberResponse{
+		ID:               member.ID,
+		MemberNumber:     member.MemberNumber,
+		CreatedAt:        member.CreatedAt,
+		UpdatedAt:        member.UpdatedAt,
+		Name:             member.Name,
+		ContactInfo:      member.ContactInfo,
+		UserID:           member.UserID,
+		Status:           member.Status,
+		Phone:            member.Phone,
+		Address:          member.Address,
+		IDType:           member.IDType,
+		IDNumber:         member.IDNumber,
+		Occupation:       member.Occupation,
+		Employer:         member.Employer,
+		ReviewedBy:       member.ReviewedBy,
+		ReviewedAt:       member.ReviewedAt,
+		ApprovedAt:       member.ApprovedAt,
+		RejectionReason:  member.RejectionReason,
+		SuspendedAt:      member.SuspendedAt,
+		ExitedAt:         member.ExitedAt,
+		MigrationBatchID: member.MigrationBatchID,
+		LegacyReference:  member.LegacyReference,
+		BranchID:         member.BranchID,
+		GroupID:          member.GroupID,
+	}
+	if member.DateOfBirth != nil {
+		response.DateOfBirth = member.DateOfBirth.Format(time.DateOnly)
 	}
+	return response
 }
@@ -0,0 +1,44 @@
+package models
+
+// What happened to each line of a member import. A dry run reports would_create instead of created.
+const (
+	MemberImportCreated     = "created"
+	MemberImportWouldCreate = "would_create"
+	MemberImportSkipped     = "skipped"
+	MemberImportInvalid     = "invalid"
+)
+
+// MemberImportRow is a validated line of an import file. PasswordHash is only used when the email has
+// no user yet, the member and savings are created for the user either way. BranchCode and GroupName are
+// what the line named, looked up when the batch is imported. Without a branch the member stays in the
+// branch already set on Member.
+type MemberImportRow struct {
+	Line         int
+	Email        string
+	PasswordHash string
+	BranchCode   string
+	GroupName    string
+	Member       Member
+}
+
+type MemberImportResult struct {
+	Line     int    `json:"line"`
+	Email    string `json:"email"`
+	Status   string `json:"status"`
+	MemberID uint   `json:"member_id,omitempty"`
+	// UserCreated is set when the email had no user, so the user needs the temporary password
+	UserCreated bool `json:"user_created,omitempty"`
+	// TemporaryPassword is only set for users the import created, to be handed to the member. It is never
+	// sent over the API, only the command line import prints it.
+	TemporaryPassword string   `json:"-"`
+	Errors            []string `json:"errors,omitempty"`
+}
+
+type MemberImportReport struct {
+	DryRun  bool                 `json:"dry_run"`
+	Total   int                  `json:"total"`
+	Created int                  `json:"created"`
+	Skipped int                  `json:"skipped"`
+	Invalid int                  `json:"invalid"`
+	Rows    []MemberImportResult `json:"rows"`
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Sort fields for the member directory. Joined sorts by the date the member was created.
+const (
+	MemberSortJoined = "joined"
+	MemberSortName   = "name"
+	MemberSortID     = "id"
+)
+
+var AllowedMemberSorts = map[string]bool{
+	MemberSortJoined: true,
+	MemberSortName:   true,
+	MemberSortID:     true,
+}
+
+const (
+	DefaultMemberPageSize = 50
+	MaxMemberPageSize     = 200
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// MemberQuery selects a page of the member directory. Search matches the name, email, contact details,
+// phone or member number. JoinedFrom is inclusive and JoinedTo exclusive. BranchID and GroupID limit the
+// directory to one branch or member group.
+type MemberQuery struct {
+	Search     string
+	Statuses   []string
+	JoinedFrom *time.Time
+	JoinedTo   *time.Time
+	BranchID   *uint
+	GroupID    *uint
+	Sort       string
+	Descending bool
+	Limit      int
+	Cursor     *MemberCursor
+}
+
+// MemberCursor marks where a page starts: just after (or, going back, just before) the member it was
+// taken from, in the order the directory is sorted by
+type MemberCursor struct {
+	Sort       string    `json:"s"`
+	Descending bool      `json:"d,omitempty"`
+	Backward   bool      `json:"b,omitempty"`
+	ID         uint      `json:"id"`
+	Name       string    `json:"n,omitempty"`
+	CreatedAt  time.Time `json:"c,omitempty"`
+}
+
+// NewMemberCursor points at a member in the query's order
+func NewMemberCursor(query *MemberQuery, member *Member, backward bool) *MemberCursor {
+	return &MemberCursor{
+		Sort:       query.Sort,
+		Descending: query.Descending,
+		Backward:   backward,
+		ID:         member.ID,
+		Name:       member.Name,
+		CreatedAt:  member.CreatedAt,
+	}
+}
+
+// Encode turns the cursor into the opaque string handed to clients
+func (cursor *MemberCursor) Encode() string {
+	if cursor == nil {
+		return ""
+	}
+	encoded, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// DecodeMemberCursor reads a cursor a client sent back
+func DecodeMemberCursor(value string) (*MemberCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor MemberCursor
+	if err := json.Unmarshal(decoded, &cursor); err != nil || !AllowedMemberSorts[cursor.Sort] || cursor.ID == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// MemberPage is one page of the member directory with the total matching the filters and the cursors
+// to the neighbouring pages, nil at either end
+type MemberPage struct {
+	Members        []Member
+	Total          int64
+	NextCursor     *MemberCursor
+	PreviousCursor *MemberCursor
+}
@@ -0,0 +1,379 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MigrationBatch is one load of members, savings and loans from the legacy system. Every record it creates
+// points back at it through MigrationBatchID, which is how migrated records are told apart.
+type MigrationBatch struct {
+	gorm.Model
+	Reference  string           `gorm:"not null;uniqueIndex"` // the legacy export the batch came from, imported only once
+	Source     string           // name of the legacy system
+	AsOf       time.Time        `gorm:"type:date;not null"` // date the legacy balances were taken
+	ImportedBy uint             `gorm:"not null"`
+	Members    int              `gorm:"not null;default:0"`
+	Totals     []MigrationTotal `gorm:"foreignKey:BatchID"`
+}
+
+// MigrationTotal is what the batch declared for one currency: the control totals from the legacy system's
+// own books, when they were sent, and the sums of the records in the file
+type MigrationTotal struct {
+	gorm.Model
+	BatchID                  uint   `gorm:"not null;index"`
+	Currency                 string `gorm:"size:3;not null"`
+	ControlSavings           *Money
+	ControlLoansOutstanding  *Money
+	ImportedSavings          Money `gorm:"not null;default:0"`
+	ImportedLoansOutstanding Money `gorm:"not null;default:0"`
+}
+
+// LoanInstallment is a payment still due on a migrated loan. Loans made in this system work out their
+// schedule from the term, migrated ones keep the schedule they had in the legacy system.
+type LoanInstallment struct {
+	gorm.Model
+	LoanID  uint      `gorm:"not null;index"`
+	Number  int       `gorm:"not null"`
+	DueDate time.Time `gorm:"type:date;not null"`
+	Amount  Money     `gorm:"not null"`
+}
+
+// MigrationMember is one member of a batch with everything carried over for them. It is not stored itself.
+type MigrationMember struct {
+	LegacyReference string
+	Email           string
+	PasswordHash    string
+	UserCreated     bool // set once the batch is loaded, when the email had no user yet
+	Member          Member
+	Savings         []MigrationSavings
+	Loans           []MigrationLoan
+}
+
+// MigrationMemberErrors lists what is wrong with one member of a batch, by their position in it
+type MigrationMemberErrors struct {
+	Index           int      `json:"index"`
+	LegacyReference string   `json:"legacy_reference,omitempty"`
+	Errors          []string `json:"errors"`
+}
+
+// MigrationSavings is a savings account's opening balance and the legacy transactions after it. The account
+// ends on ClosingBalance, when the legacy system gave one.
+type MigrationSavings struct {
+	Currency       string
+	OpeningBalance Money
+	OpeningDate    time.Time
+	Transactions   []SavingTransaction // signed amounts, dated through CreatedAt
+	ClosingBalance *Money
+}
+
+// Balance is what the account holds once the opening balance and every transaction are posted
+func (savings *MigrationSavings) Balance() Money {
+	balance := savings.OpeningBalance
+	for _, transaction := range savings.Transactions {
+		balance += transaction.Amount
+	}
+	return balance
+}
+
+// MigrationLoan is a loan that is still running in the legacy system, with what was repaid on it so far
+type MigrationLoan struct {
+	Loan          Loan
+	PaidToDate    Money
+	LastPaymentAt time.Time
+	Schedule      []LoanInstallment
+}
+
+// Outstanding is what the member still owes on the loan
+func (loan *MigrationLoan) Outstanding() Money {
+	return loan.Loan.TotalRepayableAmount - loan.PaidToDate
+}
+
+// RemainingSchedule lays the loan's equal monthly installments out from the month after it was disbursed and
+// takes off the ones the amount paid already covers. An installment that is partly paid keeps what is left.
+func RemainingSchedule(totalRepayableAmount Money, loanTermMonths uint, disbursedAt time.Time, paid Money) ([]LoanInstallment, error) {
+	installments, err := CalculateInstallmentSchedule(totalRepayableAmount, loanTermMonths)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := make([]LoanInstallment, len(installments))
+	for i, amount := range installments {
+		schedule[i] = LoanInstallment{
+			Number:  i + 1,
+			DueDate: disbursedAt.AddDate(0, i+1, 0),
+			Amount:  amount,
+		}
+	}
+	return payOffInstallments(schedule, paid), nil
+}
+
+// payOffInstallments takes an amount paid off the installments in order and returns the ones still due
+func payOffInstallments(installments []LoanInstallment, paid Money) []LoanInstallment {
+	var due []LoanInstallment
+	for _, installment := range installments {
+		covered := min(paid, installment.Amount)
+		paid -= covered
+		if installment.Amount == covered {
+			continue
+		}
+		installment.Amount -= covered
+		due = append(due, installment)
+	}
+	return due
+}
+
+// Validate checks a member of a batch can be loaded as of the batch date, listing every problem found
+func (migration *MigrationMember) Validate(asOf time.Time) []string {
+	var problems []string
+	if migration.LegacyReference == "" {
+		problems = append(problems, "legacy reference is required")
+	}
+	if migration.Email == "" {
+		problems = append(problems, "email is required")
+	}
+	if migration.Member.Name == "" {
+		problems = append(problems, "name is required")
+	}
+	if migration.Member.ContactInfo == "" {
+		problems = append(problems, "contact info is required")
+	}
+	// legacy members were never onboarded, so only the KYC they have is checked
+	if err := migration.Member.ValidateKYCFormat(); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	currencies := map[string]bool{}
+	for i := range migration.Savings {
+		savings := &migration.Savings[i]
+		prefix := fmt.Sprintf("savings %s: ", savings.Currency)
+		if currencies[savings.Currency] {
+			problems = append(problems, prefix+"only one savings account per currency")
+		}
+		currencies[savings.Currency] = true
+
+		if savings.OpeningDate.After(asOf) {
+			problems = append(problems, prefix+"opening date is after the batch date")
+		}
+		balance := savings.OpeningBalance
+		for _, transaction := range savings.Transactions {
+			if transaction.CreatedAt.Before(savings.OpeningDate) || transaction.CreatedAt.After(asOf) {
+				problems = append(problems, prefix+"transactions must be between the opening date and the batch date")
+				break
+			}
+			balance += transaction.Amount
+		}
+		if balance < 0 {
+			problems = append(problems, prefix+"balance cannot end below zero")
+		}
+		if savings.ClosingBalance != nil && *savings.ClosingBalance != balance {
+			problems = append(problems, fmt.Sprintf("%sclosing balance %s does not match the opening balance and transactions, which come to %s", prefix, *savings.ClosingBalance, balance))
+		}
+	}
+
+	for i := range migration.Loans {
+		migrated := &migration.Loans[i]
+		loan := &migrated.Loan
+		prefix := fmt.Sprintf("loan %s: ", loan.LegacyReference)
+		switch {
+		case !AllowedLoanTypes[loan.Type]:
+			problems = append(problems, prefix+"type must be personal, business or education")
+		case loan.Amount <= 0:
+			problems = append(problems, prefix+"amount must be greater than zero")
+		case loan.LoanTermMonths == 0:
+			problems = append(problems, prefix+"term must be at least one month")
+		case loan.TotalRepayableAmount < loan.Amount:
+			problems = append(problems, prefix+"total repayable cannot be less than the amount")
+		case migrated.PaidToDate < 0:
+			problems = append(problems, prefix+"paid to date cannot be negative")
+		case migrated.Outstanding() <= 0:
+			problems = append(problems, prefix+"only loans with something outstanding are migrated")
+		case loan.DisbursedAt == nil || loan.DisbursedAt.After(asOf):
+			problems = append(problems, prefix+"disbursement date must be on or before the batch date")
+		case migrated.PaidToDate > 0 && (migrated.LastPaymentAt.Before(*loan.DisbursedAt) || migrated.LastPaymentAt.After(asOf)):
+			problems = append(problems, prefix+"last payment date must be between the disbursement date and the batch date")
+		}
+
+		var scheduled Money
+		for _, installment := range migrated.Schedule {
+			if installment.Amount <= 0 {
+				problems = append(problems, prefix+"installments must be greater than zero")
+				break
+			}
+			scheduled += installment.Amount
+		}
+		if len(migrated.Schedule) > 0 && scheduled != migrated.Outstanding() {
+			problems = append(problems, fmt.Sprintf("%sremaining schedule adds up to %s, but %s is outstanding", prefix, scheduled, migrated.Outstanding()))
+		}
+	}
+	return problems
+}
+
+// CalculateMigrationTotals sums the savings and outstanding loans of a batch by currency, base currency first.
+// Control totals sent for a currency with no records still get a line, so the difference shows up.
+func CalculateMigrationTotals(members []MigrationMember, controls []MigrationTotal) []MigrationTotal {
+	totals := map[string]*MigrationTotal{}
+	total := func(currency string) *MigrationTotal {
+		if totals[currency] == nil {
+			totals[currency] = &MigrationTotal{Currency: currency}
+		}
+		return totals[currency]
+	}
+
+	for _, control := range controls {
+		line := total(control.Currency)
+		line.ControlSavings = control.ControlSavings
+		line.ControlLoansOutstanding = control.ControlLoansOutstanding
+	}
+	for i := range members {
+		for j := range members[i].Savings {
+			savings := &members[i].Savings[j]
+			total(savings.Currency).ImportedSavings += savings.Balance()
+		}
+		for j := range members[i].Loans {
+			loan := &members[i].Loans[j]
+			total(loan.Loan.Currency).ImportedLoansOutstanding += loan.Outstanding()
+		}
+	}
+
+	result := make([]MigrationTotal, 0, len(totals))
+	for _, line := range totals {
+		result = append(result, *line)
+	}
+	sortByCurrency(result, func(line MigrationTotal) string { return line.Currency })
+	return result
+}
+
+func sortByCurrency[T any](lines []T, currency func(T) string) {
+	sort.Slice(lines, func(i, j int) bool {
+		a, b := currency(lines[i]), currency(lines[j])
+		if (a == BaseCurrency) != (b == BaseCurrency) {
+			return a == BaseCurrency
+		}
+		return a < b
+	})
+}
+
+// MigrationReconciliationLine compares, for one currency, the legacy system's control totals, what the batch
+// file held and what is posted in this system for the batch
+type MigrationReconciliationLine struct {
+	Currency                 string `json:"currency"`
+	ControlSavings           *Money `json:"control_savings,omitempty"`
+	ImportedSavings          Money  `json:"imported_savings"`
+	PostedSavings            Money  `json:"posted_savings"`
+	SavingsDifference        Money  `json:"savings_difference"`
+	ControlLoansOutstanding  *Money `json:"control_loans_outstanding,omitempty"`
+	ImportedLoansOutstanding Money  `json:"imported_loans_outstanding"`
+	PostedLoansOutstanding   Money  `json:"posted_loans_outstanding"`
+	LoansDifference          Money  `json:"loans_difference"`
+	Balanced                 bool   `json:"balanced"`
+}
+
+type MigrationReconciliation struct {
+	BatchID   uint                          `json:"batch_id,omitempty"`
+	Reference string                        `json:"reference"`
+	AsOf      string                        `json:"as_of"`
+	Members   int                           `json:"members"`
+	Lines     []MigrationReconciliationLine `json:"lines"`
+	Balanced  bool                          `json:"balanced"`
+}
+
+// Reconcile compares the batch's declared totals with the savings and loan balances posted for it. The
+// differences are taken against the control totals when the legacy system gave them, otherwise against the file.
+func (batch *MigrationBatch) Reconcile(postedSavings map[string]Money, postedLoansOutstanding map[string]Money) MigrationReconciliation {
+	reconciliation := MigrationReconciliation{
+		BatchID:   batch.ID,
+		Reference: batch.Reference,
+		AsOf:      batch.AsOf.Format(time.DateOnly),
+		Members:   batch.Members,
+		Balanced:  true,
+	}
+
+	seen := map[string]bool{}
+	var lines []MigrationReconciliationLine
+	for _, total := range batch.Totals {
+		seen[total.Currency] = true
+		line := MigrationReconciliationLine{
+			Currency:                 total.Currency,
+			ControlSavings:           total.ControlSavings,
+			ImportedSavings:          total.ImportedSavings,
+			PostedSavings:            postedSavings[total.Currency],
+			ControlLoansOutstanding:  total.ControlLoansOutstanding,
+			ImportedLoansOutstanding: total.ImportedLoansOutstanding,
+			PostedLoansOutstanding:   postedLoansOutstanding[total.Currency],
+		}
+		expectedSavings, expectedLoans := line.ImportedSavings, line.ImportedLoansOutstanding
+		if line.ControlSavings != nil {
+			expectedSavings = *line.ControlSavings
+		}
+		if line.ControlLoansOutstanding != nil {
+			expectedLoans = *line.ControlLoansOutstanding
+		}
+		line.SavingsDifference = line.PostedSavings - expectedSavings
+		line.LoansDifference = line.PostedLoansOutstanding - expectedLoans
+		lines = append(lines, line)
+	}
+	// anything posted in a currency the batch never declared is a difference too
+	for currency, amount := range postedSavings {
+		if !seen[currency] && amount != 0 {
+			seen[currency] = true
+			lines = append(lines, MigrationReconciliationLine{Currency: currency, PostedSavings: amount, SavingsDifference: amount, PostedLoansOutstanding: postedLoansOutstanding[currency], LoansDifference: postedLoansOutstanding[currency]})
+		}
+	}
+	for currency, amount := range postedLoansOutstanding {
+		if !seen[currency] && amount != 0 {
+			lines = append(lines, MigrationReconciliationLine{Currency: currency, PostedLoansOutstanding: amount, LoansDifference: amount})
+		}
+	}
+
+	for i := range lines {
+		lines[i].Balanced = lines[i].SavingsDifference == 0 && lines[i].LoansDifference == 0
+		reconciliation.Balanced = reconciliation.Balanced && lines[i].Balanced
+	}
+	sortByCurrency(lines, func(line MigrationReconciliationLine) string { return line.Currency })
+	reconciliation.Lines = lines
+	return reconciliation
+}
+
+type MigrationBatchResponse struct {
+	ID         uint      `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	Reference  string    `json:"reference"`
+	Source     string    `json:"source,omitempty"`
+	AsOf       string    `json:"as_of"`
+	ImportedBy uint      `json:"imported_by"`
+	Members    int       `json:"members"`
+}
+
+func NewMigrationBatchResponse(batch *MigrationBatch) MigrationBatchResponse {
+	return MigrationBatchResponse{
+		ID:         batch.ID,
+		CreatedAt:  batch.CreatedAt,
+		Reference:  batch.Reference,
+		Source:     batch.Source,
+		AsOf:       batch.AsOf.Format(time.DateOnly),
+		ImportedBy: batch.ImportedBy,
+		Members:    batch.Members,
+	}
+}
+
+type LoanInstallmentResponse struct {
+	Number  int    `json:"number"`
+	DueDate string `json:"due_date"`
+	Amount  Money  `json:"amount"`
+}
+
+func NewLoanInstallmentResponses(installments []LoanInstallment) []LoanInstallmentResponse {
+	responses := make([]LoanInstallmentResponse, len(installments))
+	for i, installment := range installments {
+		responses[i] = LoanInstallmentResponse{
+			Number:  installment.Number,
+			DueDate: installment.DueDate.Format(time.DateOnly),
+			Amount:  installment.Amount,
+		}
+	}
+	return responses
+}
@@ -0,0 +1,211 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact amount in minor units (kobo, cents), stored as a bigint and sent
+// over JSON as a decimal number with two places, e.g. 1250.50.
+//
+// Rounding rules:
+//   - amounts coming in (JSON, CSV) must have at most two decimal places, anything
+//     finer is rejected rather than rounded
+//   - results of rate calculations are rounded once, half away from zero, to the
+//     nearest minor unit
+//   - a calculated amount too large to hold is refused with ErrMoneyOverflow rather
+//     than wrapping around
+//   - when an amount is split into parts the remainder goes to the last part, so
+//     the parts always add back up to the original amount
+type Money int64
+
+const MinorUnitsPerMajor = 100
+
+var ErrInvalidMoney = errors.New("invalid money amount")
+
+// ErrMoneyOverflow is returned when a calculated amount does not fit in a Money
+var ErrMoneyOverflow = fmt.Errorf("%w: amount is too large", ErrInvalidMoney)
+
+// NewMoneyFromMajor converts a whole number of major units (naira, dollars) to Money
+func NewMoneyFromMajor(major int64) Money {
+	return Money(major * MinorUnitsPerMajor)
+}
+
+// ParseMoney parses a decimal string such as "1250", "1250.5" or "-3.25"
+func ParseMoney(value string) (Money, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, ErrInvalidMoney
+	}
+
+	negative := false
+	switch value[0] {
+	case '-':
+		negative = true
+		value = value[1:]
+	case '+':
+		value = value[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" && fraction == "" {
+		return 0, ErrInvalidMoney
+	}
+	if len(fraction) > 2 {
+		return 0, fmt.Errorf("%w: more than two decimal places", ErrInvalidMoney)
+	}
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	for _, r := range whole + fraction {
+		if r < '0' || r > '9' {
+			return 0, ErrInvalidMoney
+		}
+	}
+
+	minor, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidMoney, err)
+	}
+	if negative {
+		minor = -minor
+	}
+	return Money(minor), nil
+}
+
+// String formats the amount in major units with two decimal places
+func (m Money) String() string {
+	sign := ""
+	minor := int64(m)
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, minor/MinorUnitsPerMajor, minor%MinorUnitsPerMajor)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a quoted decimal string. The raw text is parsed
+// directly so the amount never goes through a float.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	value := string(data)
+	if value == "null" {
+		return nil
+	}
+	value = strings.Trim(value, `"`)
+	if strings.ContainsAny(value, "eE") {
+		return fmt.Errorf("%w: exponent notation is not supported", ErrInvalidMoney)
+	}
+
+	parsed, err := ParseMoney(value)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return int64(m), nil
+}
+
+func (m *Money) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case int64:
+		*m = Money(v)
+	case []byte:
+		parsed, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidMoney, err)
+		}
+		*m = Money(parsed)
+	case string:
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidMoney, err)
+		}
+		*m = Money(parsed)
+	case nil:
+		*m = 0
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidMoney, value)
+	}
+	return nil
+}
+
+// Interest returns amount × annualRate × periods / periodsPerYear, e.g. 12 months out of 12
+// or 90 days out of 365, rounded once to the nearest minor unit
+func (m Money) Interest(annualRate float64, periods int64, periodsPerYear int64) (Money, error) {
+	if periodsPerYear == 0 {
+		return 0, nil
+	}
+	result := new(big.Rat).SetInt64(int64(m))
+	result.Mul(result, rateToRat(annualRate))
+	result.Mul(result, new(big.Rat).SetInt64(periods))
+	result.Quo(result, new(big.Rat).SetInt64(periodsPerYear))
+	return roundRat(result)
+}
+
+// MulRate returns amount × rate rounded to the nearest minor unit
+func (m Money) MulRate(rate float64) (Money, error) {
+	return m.Interest(rate, 1, 1)
+}
+
+// Portion returns amount × part / whole rounded to the nearest minor unit, e.g. the interest share of a repayment
+func (m Money) Portion(part Money, whole Money) (Money, error) {
+	return m.Interest(1, int64(part), int64(whole))
+}
+
+// Split divides the amount into n parts that add back up to it exactly, the last part takes the remainder
+func (m Money) Split(n int) ([]Money, error) {
+	if n <= 0 {
+		return nil, errors.New("cannot split an amount into zero parts")
+	}
+	parts := make([]Money, n)
+	share := m / Money(n)
+	for i := range parts {
+		parts[i] = share
+	}
+	parts[n-1] += m - share*Money(n)
+	return parts, nil
+}
+
+func rateToRat(rate float64) *big.Rat {
+	// go through the shortest decimal form so 0.035 is exactly 35/1000 rather than its binary approximation
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(rate, 'f', -1, 64))
+	if !ok {
+		return new(big.Rat)
+	}
+	return r
+}
+
+// roundRat rounds half away from zero
+func roundRat(r *big.Rat) (Money, error) {
+	num := new(big.Int).Set(r.Num())
+	den := r.Denom()
+	negative := num.Sign() < 0
+	num.Abs(num)
+
+	quotient, remainder := new(big.Int).QuoRem(num, den, new(big.Int))
+	if new(big.Int).Mul(remainder, big.NewInt(2)).Cmp(den) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	if negative {
+		quotient.Neg(quotient)
+	}
+	if !quotient.IsInt64() {
+		return 0, ErrMoneyOverflow
+	}
+	return Money(quotient.Int64()), nil
+}
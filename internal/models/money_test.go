@@ -0,0 +1,164 @@
+// Unit tests for Money parsing, rounding and splitting
+package models_test
+
+import (
+	"math"
+	"testing"
+
+	"cooperative-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected models.Money
+		wantErr  bool
+	}{
+		{name: "whole amount", input: "1250", expected: 125000},
+		{name: "one decimal place", input: "1250.5", expected: 125050},
+		{name: "two decimal places", input: "1250.55", expected: 125055},
+		{name: "negative", input: "-3.25", expected: -325},
+		{name: "negative below one", input: "-0.01", expected: -1},
+		{name: "explicit plus", input: "+1", expected: 100},
+		{name: "no whole part", input: ".5", expected: 50},
+		{name: "surrounding spaces", input: " 10.00 ", expected: 1000},
+		{name: "three decimal places", input: "1.234", wantErr: true},
+		{name: "negative with three decimal places", input: "-0.001", wantErr: true},
+		{name: "exponent", input: "1e3", wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+		{name: "only a sign", input: "-", wantErr: true},
+		{name: "not a number", input: "abc", wantErr: true},
+		{name: "too large", input: "92233720368547758.08", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			amount, err := models.ParseMoney(tc.input)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, models.ErrInvalidMoney)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, amount)
+		})
+	}
+}
+
+func TestMoney_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected models.Money
+		wantErr  bool
+	}{
+		{name: "number", input: `1250.50`, expected: 125050},
+		{name: "quoted string", input: `"1250.50"`, expected: 125050},
+		{name: "negative", input: `-3.25`, expected: -325},
+		{name: "quoted negative", input: `"-3.25"`, expected: -325},
+		{name: "null leaves the amount alone", input: `null`, expected: 0},
+		{name: "more than two decimal places", input: `1.234`, wantErr: true},
+		{name: "quoted with more than two decimal places", input: `"0.001"`, wantErr: true},
+		{name: "lower case exponent", input: `1e3`, wantErr: true},
+		{name: "upper case exponent", input: `1.5E2`, wantErr: true},
+		{name: "negative exponent", input: `-25e-1`, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var amount models.Money
+			err := amount.UnmarshalJSON([]byte(tc.input))
+			if tc.wantErr {
+				assert.ErrorIs(t, err, models.ErrInvalidMoney)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, amount)
+		})
+	}
+}
+
+func TestMoney_Rounding(t *testing.T) {
+	tests := []struct {
+		name      string
+		calculate func() (models.Money, error)
+		expected  models.Money
+	}{
+		{name: "MulRate half rounds up", calculate: func() (models.Money, error) { return models.Money(5).MulRate(0.1) }, expected: 1},
+		{name: "MulRate negative half rounds down", calculate: func() (models.Money, error) { return models.Money(-5).MulRate(0.1) }, expected: -1},
+		{name: "MulRate one and a half", calculate: func() (models.Money, error) { return models.Money(3).MulRate(0.5) }, expected: 2},
+		{name: "MulRate negative one and a half", calculate: func() (models.Money, error) { return models.Money(-3).MulRate(0.5) }, expected: -2},
+		{name: "MulRate below half", calculate: func() (models.Money, error) { return models.Money(4).MulRate(0.1) }, expected: 0},
+		{name: "MulRate decimal rate is exact", calculate: func() (models.Money, error) { return models.Money(100000).MulRate(0.035) }, expected: 3500},
+		{name: "Interest half rounds up", calculate: func() (models.Money, error) { return models.Money(100).Interest(0.01, 6, 12) }, expected: 1},
+		{name: "Interest negative half rounds down", calculate: func() (models.Money, error) { return models.Money(-100).Interest(0.01, 6, 12) }, expected: -1},
+		{name: "Interest over days", calculate: func() (models.Money, error) { return models.Money(1000000).Interest(0.1, 90, 365) }, expected: 24658},
+		{name: "Interest with no periods per year", calculate: func() (models.Money, error) { return models.Money(1000000).Interest(0.1, 1, 0) }, expected: 0},
+		{name: "Portion half rounds up", calculate: func() (models.Money, error) { return models.Money(1).Portion(1, 2) }, expected: 1},
+		{name: "Portion negative one and a half", calculate: func() (models.Money, error) { return models.Money(-3).Portion(1, 2) }, expected: -2},
+		{name: "Portion below half", calculate: func() (models.Money, error) { return models.Money(10).Portion(1, 3) }, expected: 3},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			amount, err := tc.calculate()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, amount)
+		})
+	}
+}
+
+func TestMoney_Overflow(t *testing.T) {
+	tests := []struct {
+		name      string
+		calculate func() (models.Money, error)
+	}{
+		{name: "MulRate", calculate: func() (models.Money, error) { return models.Money(math.MaxInt64).MulRate(2) }},
+		{name: "MulRate negative", calculate: func() (models.Money, error) { return models.Money(math.MinInt64).MulRate(1.5) }},
+		{name: "Interest", calculate: func() (models.Money, error) { return models.Money(math.MaxInt64).Interest(0.1, 24, 1) }},
+		{name: "Portion", calculate: func() (models.Money, error) { return models.Money(math.MaxInt64).Portion(3, 2) }},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.calculate()
+			assert.ErrorIs(t, err, models.ErrMoneyOverflow)
+			assert.ErrorIs(t, err, models.ErrInvalidMoney)
+		})
+	}
+}
+
+func TestMoney_Split(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   models.Money
+		parts    int
+		expected []models.Money
+	}{
+		{name: "even", amount: 900, parts: 3, expected: []models.Money{300, 300, 300}},
+		{name: "remainder to the last part", amount: 100, parts: 3, expected: []models.Money{33, 33, 34}},
+		{name: "less than a minor unit each", amount: 1, parts: 4, expected: []models.Money{0, 0, 0, 1}},
+		{name: "negative", amount: -100, parts: 3, expected: []models.Money{-33, -33, -34}},
+		{name: "one part", amount: 1035000, parts: 1, expected: []models.Money{1035000}},
+		{name: "loan installments", amount: 1035001, parts: 12, expected: []models.Money{86250, 86250, 86250, 86250, 86250, 86250, 86250, 86250, 86250, 86250, 86250, 86251}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			parts, err := tc.amount.Split(tc.parts)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, parts)
+
+			var sum models.Money
+			for _, part := range parts {
+				sum += part
+			}
+			assert.Equal(t, tc.amount, sum)
+		})
+	}
+
+	_, err := models.Money(100).Split(0)
+	assert.Error(t, err)
+}
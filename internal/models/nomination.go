@@ -0,0 +1,108 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// A member nominates next of kin to be contacted and beneficiaries to receive their savings and shares
+// if they die. Within each type the allocations are percentages that must total 100.
+const (
+	NominationTypeNextOfKin   = "next_of_kin"
+	NominationTypeBeneficiary = "beneficiary"
+)
+
+var ErrAllocationTotal = errors.New("allocations must total 100%")
+
+type Nomination struct {
+	gorm.Model
+	MemberID     uint   `gorm:"not null;index"`
+	Type         string `gorm:"size:20;not null"`
+	Name         string `gorm:"not null"`
+	Relationship string `gorm:"size:50;not null"`
+	Phone        string `gorm:"size:20"`
+	Email        string
+	Address      string
+	Allocation   float64 `gorm:"not null"` // percentage, e.g. 50 for 50%
+	UpdatedBy    uint
+}
+
+// Validate checks a single nomination has a name, relationship, a way to reach them and a sensible allocation
+func (nomination *Nomination) Validate() error {
+	switch {
+	case nomination.Name == "":
+		return errors.New("name is required")
+	case nomination.Relationship == "":
+		return errors.New("relationship is required")
+	case nomination.Phone == "" && nomination.Email == "":
+		return errors.New("a phone number or email is required")
+	case nomination.Phone != "" && !phonePattern.MatchString(nomination.Phone):
+		return errors.New("phone must be 7 to 15 digits, optionally starting with +")
+	case nomination.Allocation <= 0 || nomination.Allocation > 100:
+		return errors.New("allocation must be more than 0 and at most 100")
+	case math.Abs(nomination.Allocation*100-math.Round(nomination.Allocation*100)) > 1e-6:
+		return errors.New("allocation can have at most two decimal places")
+	}
+	return nil
+}
+
+// ValidateAllocations checks the allocations of each type of nomination add up to 100%
+func ValidateAllocations(nominations []Nomination) error {
+	totals := map[string]float64{}
+	for _, nomination := range nominations {
+		totals[nomination.Type] += nomination.Allocation
+	}
+	for _, nominationType := range []string{NominationTypeNextOfKin, NominationTypeBeneficiary} {
+		total, ok := totals[nominationType]
+		if ok && math.Round(total*100) != 10000 {
+			return fmt.Errorf("%w, %s allocations total %.2f%%", ErrAllocationTotal, nominationType, total)
+		}
+	}
+	return nil
+}
+
+type NominationResponse struct {
+	ID           uint      `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	MemberID     uint      `json:"member_id"`
+	Type         string    `json:"type"`
+	Name         string    `json:"name"`
+	Relationship string    `json:"relationship"`
+	Phone        string    `json:"phone,omitempty"`
+	Email        string    `json:"email,omitempty"`
+	Address      string    `json:"address,omitempty"`
+	Allocation   float64   `json:"allocation"`
+}
+
+func NewNominationResponse(nomination *Nomination) NominationResponse {
+	return NominationResponse{
+		ID:           nomination.ID,
+		CreatedAt:    nomination.CreatedAt,
+		MemberID:     nomination.MemberID,
+		Type:         nomination.Type,
+		Name:         nomination.Name,
+		Relationship: nomination.Relationship,
+		Phone:        nomination.Phone,
+		Email:        nomination.Email,
+		Address:      nomination.Address,
+		Allocation:   nomination.Allocation,
+	}
+}
+
+// NewNominationResponses splits a member's nominations into next of kin and beneficiaries
+func NewNominationResponses(nominations []Nomination) (nextOfKin []NominationResponse, beneficiaries []NominationResponse) {
+	nextOfKin = []NominationResponse{}
+	beneficiaries = []NominationResponse{}
+	for i := range nominations {
+		if nominations[i].Type == NominationTypeNextOfKin {
+			nextOfKin = append(nextOfKin, NewNominationResponse(&nominations[i]))
+		} else {
+			beneficiaries = append(beneficiaries, NewNominationResponse(&nominations[i]))
+		}
+	}
+	return nextOfKin, beneficiaries
+}
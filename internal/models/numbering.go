@@ -0,0 +1,248 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Members, savings accounts and loans each get a number of their own kind
+const (
+	NumberKindMember  = "member"
+	NumberKindSavings = "savings"
+	NumberKindLoan    = "loan"
+)
+
+// defaultSequenceDigits pads {seq} when the pattern does not say how wide it is
+const defaultSequenceDigits = 5
+
+var ErrInvalidNumberFormat = errors.New("number format must contain {seq} and a letter or {branch}, and only letters, digits, dashes and the fields {branch}, {year}, {yy}, {seq}, {seq:N} and {check}")
+
+// NumberFormat is how the numbers of one kind are written, as a pattern of literal text and fields:
+// {branch} the branch code, {year} or {yy} the year the number is issued, {seq} or {seq:N} the sequence
+// zero padded to N digits, and {check} a Luhn check digit over every digit before it. Sequences run
+// separately for each branch and, when the pattern has the year, for each year.
+type NumberFormat struct {
+	Pattern string
+	parts   []numberPart
+}
+
+type numberPart struct {
+	field   string // empty for literal text
+	literal string
+	digits  int
+}
+
+// NumberingSettings are the formats in use and the branch code numbers are issued under
+type NumberingSettings struct {
+	Branch  string
+	Member  NumberFormat
+	Savings NumberFormat
+	Loan    NumberFormat
+}
+
+// Format returns the format for a kind of number
+func (settings *NumberingSettings) Format(kind string) NumberFormat {
+	switch kind {
+	case NumberKindSavings:
+		return settings.Savings
+	case NumberKindLoan:
+		return settings.Loan
+	default:
+		return settings.Member
+	}
+}
+
+// DefaultNumbering numbers members like HQ26000174, savings accounts like SVHQ0000125 and loans like LNHQ26000034
+func DefaultNumbering() NumberingSettings {
+	return NumberingSettings{
+		Branch:  "HQ",
+		Member:  MustParseNumberFormat("{branch}{yy}{seq:5}{check}"),
+		Savings: MustParseNumberFormat("SV{branch}{seq:6}{check}"),
+		Loan:    MustParseNumberFormat("LN{branch}{yy}{seq:5}{check}"),
+	}
+}
+
+// ParseNumberFormat reads a number pattern, refusing ones that could not be used in a URL, would not be unique
+// or could be taken for an internal ID, which is always a plain number
+func ParseNumberFormat(pattern string) (NumberFormat, error) {
+	format := NumberFormat{Pattern: pattern}
+	hasSequence, hasLetters := false, false
+	rest := pattern
+	for rest != "" {
+		start := strings.IndexByte(rest, '{')
+		if start != 0 {
+			literal := rest
+			if start > 0 {
+				literal = rest[:start]
+			}
+			for _, char := range literal {
+				isLetter := char >= 'A' && char <= 'Z' || char >= 'a' && char <= 'z'
+				if !(isLetter || char >= '0' && char <= '9' || char == '-') {
+					return NumberFormat{}, ErrInvalidNumberFormat
+				}
+				hasLetters = hasLetters || isLetter
+			}
+			format.parts = append(format.parts, numberPart{literal: strings.ToUpper(literal)})
+			rest = rest[len(literal):]
+			continue
+		}
+
+		end := strings.IndexByte(rest, '}')
+		if end < 0 {
+			return NumberFormat{}, ErrInvalidNumberFormat
+		}
+		field, option, _ := strings.Cut(rest[1:end], ":")
+		part := numberPart{field: field}
+		switch field {
+		case "branch", "year", "yy", "check":
+			if option != "" {
+				return NumberFormat{}, ErrInvalidNumberFormat
+			}
+			hasLetters = hasLetters || field == "branch"
+		case "seq":
+			if hasSequence {
+				return NumberFormat{}, ErrInvalidNumberFormat
+			}
+			hasSequence = true
+			part.digits = defaultSequenceDigits
+			if option != "" {
+				digits, err := strconv.Atoi(option)
+				if err != nil || digits < 1 || digits > 12 {
+					return NumberFormat{}, ErrInvalidNumberFormat
+				}
+				part.digits = digits
+			}
+		default:
+			return NumberFormat{}, ErrInvalidNumberFormat
+		}
+		format.parts = append(format.parts, part)
+		rest = rest[end+1:]
+	}
+	if !hasSequence || !hasLetters {
+		return NumberFormat{}, ErrInvalidNumberFormat
+	}
+	return format, nil
+}
+
+// MustParseNumberFormat is ParseNumberFormat for patterns known to be valid
+func MustParseNumberFormat(pattern string) NumberFormat {
+	format, err := ParseNumberFormat(pattern)
+	if err != nil {
+		panic(fmt.Sprintf("invalid number format %q: %v", pattern, err))
+	}
+	return format
+}
+
+// Scope is the sequence a number issued on the given date falls in: the pattern with everything but the
+// sequence and check digit filled in
+func (format NumberFormat) Scope(branch string, issuedAt time.Time) string {
+	return format.render(branch, issuedAt, -1)
+}
+
+// Number writes the number with the given place in its sequence
+func (format NumberFormat) Number(branch string, issuedAt time.Time, sequence int64) string {
+	return format.render(branch, issuedAt, sequence)
+}
+
+func (format NumberFormat) render(branch string, issuedAt time.Time, sequence int64) string {
+	var number strings.Builder
+	for _, part := range format.parts {
+		switch part.field {
+		case "":
+			number.WriteString(part.literal)
+		case "branch":
+			number.WriteString(strings.ToUpper(branch))
+		case "year":
+			number.WriteString(issuedAt.Format("2006"))
+		case "yy":
+			number.WriteString(issuedAt.Format("06"))
+		case "seq":
+			if sequence < 0 {
+				number.WriteString("{seq}")
+			} else {
+				fmt.Fprintf(&number, "%0*d", part.digits, sequence)
+			}
+		case "check":
+			if sequence >= 0 {
+				number.WriteByte(luhnCheckDigit(number.String()))
+			}
+		}
+	}
+	return number.String()
+}
+
+// luhnCheckDigit works out the digit that makes the digits of value pass the Luhn check, so a mistyped
+// or swapped digit on a paper form is caught
+func luhnCheckDigit(value string) byte {
+	sum := 0
+	double := true
+	for i := len(value) - 1; i >= 0; i-- {
+		if value[i] < '0' || value[i] > '9' {
+			continue
+		}
+		digit := int(value[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+var branchCodePattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{0,9}$`)
+
+// ValidateBranchCode checks a branch code starts with a letter, so numbers that use it are never a plain number
+func ValidateBranchCode(code string) error {
+	if !branchCodePattern.MatchString(code) {
+		return errors.New("branch code must be a letter followed by up to 9 letters or digits")
+	}
+	return nil
+}
+
+// NormalizeNumber tidies a member, savings or loan number typed in by hand
+func NormalizeNumber(value string) string {
+	return strings.ToUpper(strings.TrimSpace(value))
+}
+
+// MemberReference names a member in a request body by ID or member number, looked up the same way as the
+// member ID in a URL
+type MemberReference string
+
+// UnmarshalJSON accepts a member ID as a JSON number, or a member ID or member number as a string
+func (ref *MemberReference) UnmarshalJSON(data []byte) error {
+	value := strings.TrimSpace(string(data))
+	if value == "null" {
+		return nil
+	}
+	if strings.HasPrefix(value, `"`) {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return fmt.Errorf("member must be a member ID or member number: %w", err)
+		}
+		*ref = MemberReference(strings.TrimSpace(unquoted))
+		return nil
+	}
+	if _, err := strconv.ParseUint(value, 10, 32); err != nil {
+		return errors.New("member must be a member ID or member number")
+	}
+	*ref = MemberReference(value)
+	return nil
+}
+
+// NumberSequence is the last number issued in one scope, locked while the next one is taken
+type NumberSequence struct {
+	gorm.Model
+	Kind  string `gorm:"size:20;not null;uniqueIndex:idx_number_sequences_kind_scope"`
+	Scope string `gorm:"size:50;not null;uniqueIndex:idx_number_sequences_kind_scope"`
+	Last  int64  `gorm:"not null"`
+}
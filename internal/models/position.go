@@ -0,0 +1,125 @@
+package models
+
+import "time"
+
+// MemberPosition is everything a member holds, owes and is waiting on, for the member's own home screen
+type MemberPosition struct {
+	Member       Member
+	Savings      []Savings
+	ShareAccount ShareAccount
+	ActiveLoans  []LoanPosition
+	PendingLoans []Loan
+}
+
+// LoanPosition is a running loan with what has been repaid on it
+type LoanPosition struct {
+	Loan   Loan
+	Repaid Money
+}
+
+// Outstanding is what is still to be repaid on the loan
+func (position *LoanPosition) Outstanding() Money {
+	return position.Loan.TotalRepayableAmount - position.Repaid
+}
+
+// RemainingInstallments lists what is still due on the loan once the repayments are taken off. Migrated loans
+// start from the schedule they came over with, other loans from equal monthly installments after disbursement.
+func (position *LoanPosition) RemainingInstallments() ([]LoanInstallment, error) {
+	loan := &position.Loan
+	if len(loan.Installments) == 0 {
+		start := loan.DisbursedAt
+		if start == nil {
+			start = loan.ApprovedAt
+		}
+		if start == nil {
+			return nil, nil
+		}
+		return RemainingSchedule(loan.TotalRepayableAmount, loan.LoanTermMonths, *start, position.Repaid)
+	}
+
+	// the migrated schedule covers what was outstanding at migration, anything above today's outstanding
+	// has been repaid since
+	var scheduled Money
+	for _, installment := range loan.Installments {
+		scheduled += installment.Amount
+	}
+	return payOffInstallments(loan.Installments, scheduled-position.Outstanding()), nil
+}
+
+type NextInstallmentResponse struct {
+	Number  int    `json:"number"`
+	DueDate string `json:"due_date"`
+	Amount  Money  `json:"amount"`
+	Overdue bool   `json:"overdue"`
+}
+
+type LoanPositionResponse struct {
+	LoanResponse
+	Repaid          Money                    `json:"repaid"`
+	Outstanding     Money                    `json:"outstanding"`
+	NextInstallment *NextInstallmentResponse `json:"next_installment"`
+}
+
+type MemberPositionResponse struct {
+	Member              MemberResponse         `json:"member"`
+	Savings             []SavingsResponse      `json:"savings"`
+	Shares              ShareAccountResponse   `json:"shares"`
+	ActiveLoans         []LoanPositionResponse `json:"active_loans"`
+	PendingApplications PendingApplications    `json:"pending_applications"`
+}
+
+// PendingApplications are the member's requests still waiting for a decision. Membership is only set while
+// the member's own application is open.
+type PendingApplications struct {
+	Membership string         `json:"membership,omitempty"`
+	Loans      []LoanResponse `json:"loans"`
+}
+
+// NewMemberPositionResponse values the share holding at the configured price and works out the next installment
+// due on each loan as of the given date
+func NewMemberPositionResponse(position *MemberPosition, settings ShareSettings, asOf time.Time) (MemberPositionResponse, error) {
+	response := MemberPositionResponse{
+		Member:      NewMemberResponse(&position.Member),
+		Savings:     make([]SavingsResponse, len(position.Savings)),
+		Shares:      NewShareAccountResponse(&position.ShareAccount, settings),
+		ActiveLoans: make([]LoanPositionResponse, len(position.ActiveLoans)),
+		PendingApplications: PendingApplications{
+			Loans: make([]LoanResponse, len(position.PendingLoans)),
+		},
+	}
+	for i := range position.Savings {
+		response.Savings[i] = NewSavingsResponse(&position.Savings[i])
+	}
+
+	today := asOf.Truncate(24 * time.Hour)
+	for i := range position.ActiveLoans {
+		loanPosition := &position.ActiveLoans[i]
+		loanResponse := LoanPositionResponse{
+			LoanResponse: NewLoanResponse(&loanPosition.Loan),
+			Repaid:       loanPosition.Repaid,
+			Outstanding:  loanPosition.Outstanding(),
+		}
+		installments, err := loanPosition.RemainingInstallments()
+		if err != nil {
+			return MemberPositionResponse{}, err
+		}
+		if len(installments) > 0 {
+			next := installments[0]
+			loanResponse.NextInstallment = &NextInstallmentResponse{
+				Number:  next.Number,
+				DueDate: next.DueDate.Format(time.DateOnly),
+				Amount:  next.Amount,
+				Overdue: next.DueDate.Before(today),
+			}
+		}
+		response.ActiveLoans[i] = loanResponse
+	}
+
+	for i := range position.PendingLoans {
+		response.PendingApplications.Loans[i] = NewLoanResponse(&position.PendingLoans[i])
+	}
+	if status := position.Member.Status; status == MemberStatusApplied || status == MemberStatusUnderReview {
+		response.PendingApplications.Membership = status
+	}
+	return response, nil
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"errors"
+	"sort"
+)
+
+// Every user has one role. Members only ever act on their own records, every other role is staff and
+// gets the permissions listed for it in rolePermissions.
+const (
+	RoleAdmin       = "admin"
+	RoleTreasurer   = "treasurer"
+	RoleLoanOfficer = "loan_officer"
+	RoleAuditor     = "auditor"
+	RoleTeller      = "teller"
+	RoleMember      = "member"
+)
+
+// Permissions name what staff can do. The read permissions never change anything.
+const (
+	PermMembersRead         = "members:read"          // see any member's records and statements
+	PermMembersManage       = "members:manage"        // decide applications, change members' records and statuses
+	PermMembersImport       = "members:import"        // bulk import and legacy migration
+	PermLoansApprove        = "loans:approve"         // approve or reject loan applications
+	PermLoansDisburse       = "loans:disburse"        // pay out approved loans
+	PermRepaymentsRecord    = "repayments:record"     // take loan repayments
+	PermTransactionsReverse = "transactions:reverse"  // reverse savings transactions and transfers
+	PermReportsRead         = "reports:read"          // registers, arrears, consolidated report, distributions
+	PermExchangeRatesManage = "exchange_rates:manage" // set exchange rates
+	PermSharesManage        = "shares:manage"         // redeem shares
+	PermDistributionsManage = "distributions:manage"  // compute, approve and cancel distributions
+	PermFeesManage          = "fees:manage"           // set up fees, collect and waive charges
+	PermDepositsProcess     = "deposits:process"      // run fixed deposit maturities
+	PermExitsManage         = "exits:manage"          // settle member exits
+	PermDocumentsVerify     = "documents:verify"      // verify KYC and loan documents
+	PermBranchesManage      = "branches:manage"       // branches, member groups and member assignment
+	PermUsersManage         = "users:manage"          // roles, staff branches and sessions
+)
+
+var ErrUnknownRole = errors.New("role must be one of admin, treasurer, loan_officer, auditor, teller or member")
+
+// readPermissions are everything an auditor may look at
+var readPermissions = []string{PermMembersRead, PermReportsRead}
+
+var rolePermissions = map[string][]string{
+	RoleAdmin: {
+		PermMembersRead, PermMembersManage, PermMembersImport, PermLoansApprove, PermLoansDisburse,
+		PermRepaymentsRecord, PermTransactionsReverse, PermReportsRead, PermExchangeRatesManage,
+		PermSharesManage, PermDistributionsManage, PermFeesManage, PermDepositsProcess, PermExitsManage,
+		PermDocumentsVerify, PermBranchesManage, PermUsersManage,
+	},
+	RoleTreasurer: {
+		PermMembersRead, PermLoansDisburse, PermRepaymentsRecord, PermTransactionsReverse, PermReportsRead,
+		PermExchangeRatesManage, PermSharesManage, PermDistributionsManage, PermFeesManage,
+		PermDepositsProcess, PermExitsManage,
+	},
+	RoleLoanOfficer: {PermMembersRead, PermLoansApprove, PermDocumentsVerify},
+	RoleAuditor:     readPermissions,
+	RoleTeller:      {PermMembersRead, PermRepaymentsRecord},
+	RoleMember:      {},
+}
+
+// ValidateRole checks a role is one the system knows
+func ValidateRole(role string) error {
+	if _, ok := rolePermissions[role]; !ok {
+		return ErrUnknownRole
+	}
+	return nil
+}
+
+// RolePermissions lists the permissions a role grants
+func RolePermissions(role string) []string {
+	return append([]string(nil), rolePermissions[role]...)
+}
+
+// Roles lists every role, staff roles first in alphabetical order and members last
+func Roles() []string {
+	roles := make([]string, 0, len(rolePermissions))
+	for role := range rolePermissions {
+		if role != RoleMember {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+	return append(roles, RoleMember)
+}
+
+// Can reports whether the user's role grants the permission
+func (user *User) Can(permission string) bool {
+	for _, granted := range rolePermissions[user.Role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// IsStaff reports whether the user works for the cooperative rather than being one of its members
+func (user *User) IsStaff() bool {
+	return len(rolePermissions[user.Role]) > 0
+}
+
+type RoleResponse struct {
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+// NewRoleResponses describes every role and what it can do
+func NewRoleResponses() []RoleResponse {
+	roles := Roles()
+	responses := make([]RoleResponse, len(roles))
+	for i, role := range roles {
+		responses[i] = RoleResponse{Role: role, Permissions: RolePermissions(role)}
+	}
+	return responses
+}
@@ -8,64 +8,103 @@ import (
 
 type Savings struct {
 	gorm.Model
-	UserID       uint   `gorm:"not null"`
-	MemberID     uint   `gorm:"not null"`
-	Balance      int    `gorm:"not null"`
-	AmountToSave int    `gorm:"not null"`
-	Member       Member `gorm:"foreignKey:MemberID"`
-	Description  string
+	UserID        uint    `gorm:"not null"`
+	AccountNumber *string `gorm:"size:30;uniqueIndex"` // issued once the member has a member number
+	MemberID      uint    `gorm:"not null;uniqueIndex:idx_savings_member_currency"`
+	Currency      string  `gorm:"size:3;not null;default:NGN;uniqueIndex:idx_savings_member_currency"` // a member holds one savings account per currency
+	Balance       Money   `gorm:"not null"`
+	AmountToSave  Money   `gorm:"not null"`
+	Member        Member  `gorm:"foreignKey:MemberID"`
+	Description   string
+	ClosedAt      *time.Time // set when the member exits and the balance is paid out
 }
 
 type SavingTransaction struct {
 	gorm.Model
-	SavingsID   uint `gorm:"not null"`
-	MemberID    uint `gorm:"not null"`
-	Amount      int  `gorm:"not null"`
+	SavingsID   uint   `gorm:"not null"`
+	MemberID    uint   `gorm:"not null"`
+	Amount      Money  `gorm:"not null"`
+	Currency    string `gorm:"size:3;not null;default:NGN"`
 	Description string
 	// TransactionDate time.Time
-	Savings Savings `gorm:"foreignKey:SavingID"`
+	Savings Savings `gorm:"foreignKey:SavingsID"`
+
+	// Reversals are compensating entries, the original row is never changed or deleted
+	ReversalOfID   *uint `gorm:"uniqueIndex"` // set on the reversal entry, points at the transaction it reverses
+	ReversalReason string
+	PostedBy       *uint // user who posted the entry, when it was not the member
+
+	TransferID     *uint `gorm:"index"` // set on both legs of a member-to-member transfer
+	FixedDepositID *uint `gorm:"index"` // set on the entries that fund a fixed deposit and pay it back
+
+	Type              string `gorm:"size:20;not null;default:deposit;index"` // one of the TransactionType constants
+	Channel           string `gorm:"size:20"`                                // how the money came in, empty on entries older than channels
+	ExternalReference string `gorm:"index"`                                  // bank or payment provider reference, if any
+	MigrationBatchID  *uint  `gorm:"index"`                                  // set on balances and history carried over from the legacy system
 }
 
 type SavingsResponse struct {
-	ID           uint      `json:"id"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	Balance      int       `json:"balance"`
-	AmountToSave int       `json:"amount_to_save"`
-	Description  string    `json:"description"`
-	MemberID     uint      `json:"member_id"`
+	ID            uint       `json:"id"`
+	AccountNumber *string    `json:"account_number,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	Currency      string     `json:"currency"`
+	Balance       Money      `json:"balance"`
+	AmountToSave  Money      `json:"amount_to_save"`
+	Description   string     `json:"description"`
+	MemberID      uint       `json:"member_id"`
+	ClosedAt      *time.Time `json:"closed_at,omitempty"`
 }
 
 func NewSavingsResponse(savings *Savings) SavingsResponse {
 	return SavingsResponse{
-		ID:           savings.ID,
-		CreatedAt:    savings.CreatedAt,
-		UpdatedAt:    savings.UpdatedAt,
-		Balance:      savings.Balance,
-		AmountToSave: savings.AmountToSave,
-		Description:  savings.Description,
-		MemberID:     savings.MemberID,
+		ID:            savings.ID,
+		AccountNumber: savings.AccountNumber,
+		CreatedAt:     savings.CreatedAt,
+		UpdatedAt:     savings.UpdatedAt,
+		Currency:      savings.Currency,
+		Balance:       savings.Balance,
+		AmountToSave:  savings.AmountToSave,
+		Description:   savings.Description,
+		MemberID:      savings.MemberID,
+		ClosedAt:      savings.ClosedAt,
 	}
 }
 
 type SavingTransactionResponse struct {
-	ID          uint      `json:"id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Amount      int       `json:"amount"`
-	Description string    `json:"description"`
-	MemberID    uint      `json:"member_id"`
-	SavingsID   uint      `json:"savings_id"`
+	ID                uint      `json:"id"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+	Amount            Money     `json:"amount"`
+	Currency          string    `json:"currency"`
+	Description       string    `json:"description"`
+	MemberID          uint      `json:"member_id"`
+	SavingsID         uint      `json:"savings_id"`
+	ReversalOfID      *uint     `json:"reversal_of_id,omitempty"`
+	ReversalReason    string    `json:"reversal_reason,omitempty"`
+	PostedBy          *uint     `json:"posted_by,omitempty"`
+	TransferID        *uint     `json:"transfer_id,omitempty"`
+	Type              string    `json:"type"`
+	Channel           string    `json:"channel,omitempty"`
+	ExternalReference string    `json:"external_reference,omitempty"`
 }
 
 func NewSavingTransactionResponse(transaction *SavingTransaction) SavingTransactionResponse {
 	return SavingTransactionResponse{
-		ID:          transaction.ID,
-		CreatedAt:   transaction.CreatedAt,
-		UpdatedAt:   transaction.UpdatedAt,
-		Amount:      transaction.Amount,
-		Description: transaction.Description,
-		MemberID:    transaction.MemberID,
-		SavingsID:   transaction.SavingsID,
+		ID:                transaction.ID,
+		CreatedAt:         transaction.CreatedAt,
+		UpdatedAt:         transaction.UpdatedAt,
+		Amount:            transaction.Amount,
+		Currency:          transaction.Currency,
+		Description:       transaction.Description,
+		MemberID:          transaction.MemberID,
+		SavingsID:         transaction.SavingsID,
+		ReversalOfID:      transaction.ReversalOfID,
+		ReversalReason:    transaction.ReversalReason,
+		PostedBy:          transaction.PostedBy,
+		TransferID:        transaction.TransferID,
+		Type:              transaction.Type,
+		Channel:           transaction.Channel,
+		ExternalReference: transaction.ExternalReference,
 	}
 }
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Reasons a refresh token stops working before it expires
+const (
+	RevokedLogout    = "logout"
+	RevokedLogoutAll = "logout_all"
+	RevokedReuse     = "refresh_token_reused"
+	RevokedByAdmin   = "revoked_by_admin"
+)
+
+// RefreshTokenCookie is the cookie Login leaves the refresh token in for browsers
+const RefreshTokenCookie = "RefreshToken"
+
+// TokenSettings are how long access tokens and refresh tokens last
+type TokenSettings struct {
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// DefaultTokenSettings keeps access tokens short, so a stolen one is soon useless, and lets a session be
+// refreshed for 30 days
+func DefaultTokenSettings() TokenSettings {
+	return TokenSettings{
+		AccessTTL:  15 * time.Minute,
+		RefreshTTL: 30 * 24 * time.Hour,
+	}
+}
+
+// RefreshToken is one refresh token of a login session, kept as a hash. Each refresh swaps it for a new one
+// in the same family. A token used a second time means it was stolen, and the whole family is revoked.
+type RefreshToken struct {
+	gorm.Model
+	UserID        uint      `gorm:"not null;index"`
+	User          User      `gorm:"foreignKey:UserID"`
+	FamilyID      string    `gorm:"size:64;not null;index"` // every token of one login
+	TokenHash     string    `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt     time.Time `gorm:"not null"`
+	RotatedAt     *time.Time
+	RevokedAt     *time.Time
+	RevokedReason string
+	UserAgent     string
+	IPAddress     string
+}
+
+// Usable reports whether the token can still be swapped for a new one
+func (token *RefreshToken) Usable(now time.Time) bool {
+	return token.RotatedAt == nil && token.RevokedAt == nil && now.Before(token.ExpiresAt)
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MemberSettlement is the final account of a member who exits: what they held in each currency, what was
+// offset against it and what was paid out. It is computed as a preview first and saved when the exit is posted.
+type MemberSettlement struct {
+	gorm.Model
+	MemberID          uint   `gorm:"not null;uniqueIndex"`
+	SharesRedeemed    int64  `gorm:"not null;default:0"`
+	SharePrice        Money  `gorm:"not null;default:0"`
+	Channel           string `gorm:"size:20"` // how the payout left the cooperative
+	ExternalReference string
+	Reason            string
+	SettledBy         uint
+	SettledAt         *time.Time
+	Lines             []MemberSettlementLine `gorm:"foreignKey:SettlementID"`
+}
+
+// MemberSettlementLine settles one currency. Share capital is valued in the base currency.
+type MemberSettlementLine struct {
+	gorm.Model
+	SettlementID     uint   `gorm:"not null;index"`
+	Currency         string `gorm:"size:3;not null"`
+	Savings          Money  `gorm:"not null;default:0"`
+	ShareValue       Money  `gorm:"not null;default:0"`
+	LoansOutstanding Money  `gorm:"not null;default:0"`
+	FeesOutstanding  Money  `gorm:"not null;default:0"`
+	Payout           Money  `gorm:"not null;default:0"` // negative when the member still owes the cooperative
+}
+
+// Shortfall reports what the member still owes in the line's currency after everything they hold is offset
+func (line *MemberSettlementLine) Shortfall() Money {
+	if line.Payout < 0 {
+		return -line.Payout
+	}
+	return 0
+}
+
+// CalculateSettlement nets a member's savings and share capital against their outstanding loans and fees,
+// currency by currency, base currency first
+func CalculateSettlement(savings []Savings, shares int64, settings ShareSettings, loansOutstanding map[string]Money, feesOutstanding map[string]Money) []MemberSettlementLine {
+	lines := map[string]*MemberSettlementLine{}
+	line := func(currency string) *MemberSettlementLine {
+		if lines[currency] == nil {
+			lines[currency] = &MemberSettlementLine{Currency: currency}
+		}
+		return lines[currency]
+	}
+
+	for _, account := range savings {
+		line(account.Currency).Savings += account.Balance
+	}
+	if shares > 0 {
+		line(BaseCurrency).ShareValue = settings.Value(shares)
+	}
+	for currency, amount := range loansOutstanding {
+		line(currency).LoansOutstanding += amount
+	}
+	for currency, amount := range feesOutstanding {
+		line(currency).FeesOutstanding += amount
+	}
+
+	result := make([]MemberSettlementLine, 0, len(lines))
+	for _, settled := range lines {
+		settled.Payout = settled.Savings + settled.ShareValue - settled.LoansOutstanding - settled.FeesOutstanding
+		result = append(result, *settled)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if (result[i].Currency == BaseCurrency) != (result[j].Currency == BaseCurrency) {
+			return result[i].Currency == BaseCurrency
+		}
+		return result[i].Currency < result[j].Currency
+	})
+	return result
+}
+
+type MemberSettlementLineResponse struct {
+	Currency         string `json:"currency"`
+	Savings          Money  `json:"savings"`
+	ShareValue       Money  `json:"share_value"`
+	LoansOutstanding Money  `json:"loans_outstanding"`
+	FeesOutstanding  Money  `json:"fees_outstanding"`
+	Payout           Money  `json:"payout"`
+	Shortfall        Money  `json:"shortfall"`
+}
+
+type MemberSettlementResponse struct {
+	ID                uint                           `json:"id,omitempty"`
+	MemberID          uint                           `json:"member_id"`
+	SharesRedeemed    int64                          `json:"shares_redeemed"`
+	SharePrice        Money                          `json:"share_price"`
+	Channel           string                         `json:"channel,omitempty"`
+	ExternalReference string                         `json:"external_reference,omitempty"`
+	Reason            string                         `json:"reason,omitempty"`
+	SettledBy         uint                           `json:"settled_by,omitempty"`
+	SettledAt         *time.Time                     `json:"settled_at,omitempty"`
+	Lines             []MemberSettlementLineResponse `json:"lines"`
+}
+
+func NewMemberSettlementResponse(settlement *MemberSettlement) MemberSettlementResponse {
+	lines := make([]MemberSettlementLineResponse, len(settlement.Lines))
+	for i := range settlement.Lines {
+		line := &settlement.Lines[i]
+		lines[i] = MemberSettlementLineResponse{
+			Currency:         line.Currency,
+			Savings:          line.Savings,
+			ShareValue:       line.ShareValue,
+			LoansOutstanding: line.LoansOutstanding,
+			FeesOutstanding:  line.FeesOutstanding,
+			Payout:           line.Payout,
+			Shortfall:        line.Shortfall(),
+		}
+	}
+	return MemberSettlementResponse{
+		ID:                settlement.ID,
+		MemberID:          settlement.MemberID,
+		SharesRedeemed:    settlement.SharesRedeemed,
+		SharePrice:        settlement.SharePrice,
+		Channel:           settlement.Channel,
+		ExternalReference: settlement.ExternalReference,
+		Reason:            settlement.Reason,
+		SettledBy:         settlement.SettledBy,
+		SettledAt:         settlement.SettledAt,
+		Lines:             lines,
+	}
+}
@@ -0,0 +1,229 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ShareCertificateStatusActive    = "active"
+	ShareCertificateStatusCancelled = "cancelled"
+)
+
+const (
+	ShareTransactionPurchase    = "purchase"
+	ShareTransactionTransferIn  = "transfer_in"
+	ShareTransactionTransferOut = "transfer_out"
+	ShareTransactionRedemption  = "redemption"
+)
+
+var (
+	ErrBelowMinimumHolding = errors.New("holding would fall below the minimum shareholding")
+	ErrInsufficientShares  = errors.New("not enough shares")
+)
+
+// ShareSettings are the bylaw values that govern share capital. Shares are always priced in the base currency.
+type ShareSettings struct {
+	Price          Money `json:"price"`
+	MinimumHolding int64 `json:"minimum_holding"`
+}
+
+// Value is what a number of shares is worth at the configured price
+func (s ShareSettings) Value(shares int64) Money {
+	return s.Price * Money(shares)
+}
+
+// CheckHolding checks the number of shares a member would hold after a change. A holding of zero is only
+// accepted when allowZero is set, which is how a member leaves share capital entirely.
+func (s ShareSettings) CheckHolding(shares int64, allowZero bool) error {
+	if shares < 0 {
+		return ErrInsufficientShares
+	}
+	if shares == 0 && allowZero {
+		return nil
+	}
+	if shares < s.MinimumHolding {
+		return fmt.Errorf("%w of %d shares", ErrBelowMinimumHolding, s.MinimumHolding)
+	}
+	return nil
+}
+
+// ShareAccount holds a member's share capital, separate from savings. Shares is kept equal to the total of
+// the member's active certificates.
+type ShareAccount struct {
+	gorm.Model
+	MemberID     uint               `gorm:"not null;uniqueIndex"`
+	Shares       int64              `gorm:"not null;default:0"`
+	Member       Member             `gorm:"foreignKey:MemberID"`
+	Certificates []ShareCertificate `gorm:"foreignKey:ShareAccountID"`
+	ClosedAt     *time.Time         // set when the member exits and every share is redeemed
+}
+
+// ShareCertificate evidences a number of shares. Certificates are never edited: when a holding shrinks the
+// member's active certificates are cancelled and one certificate is issued for what is left.
+type ShareCertificate struct {
+	gorm.Model
+	ShareAccountID uint      `gorm:"not null;index"`
+	MemberID       uint      `gorm:"not null;index"`
+	Shares         int64     `gorm:"not null"`
+	Status         string    `gorm:"not null;index"` // e.g., "active", "cancelled"
+	IssuedAt       time.Time `gorm:"not null"`
+	CancelledAt    *time.Time
+	ReplacedByID   *uint
+}
+
+// Number is the certificate number printed for members, derived from the certificate's id
+func (certificate *ShareCertificate) Number() string {
+	return fmt.Sprintf("SC-%06d", certificate.ID)
+}
+
+// ShareTransaction records one change to a member's holding
+type ShareTransaction struct {
+	gorm.Model
+	ShareAccountID       uint   `gorm:"not null;index"`
+	MemberID             uint   `gorm:"not null;index"`
+	Type                 string `gorm:"size:20;not null"` // one of the ShareTransaction constants
+	Shares               int64  `gorm:"not null"`         // positive when the holding grows
+	PricePerShare        Money  `gorm:"not null"`
+	Amount               Money  `gorm:"not null"`
+	CounterpartyMemberID *uint
+	CertificateID        *uint             // certificate issued by this change, if any
+	Certificate          *ShareCertificate `gorm:"foreignKey:CertificateID"`
+	SavingTransactionID  *uint             // savings entry that paid for or received the shares
+	PostedBy             uint              `gorm:"not null"`
+	Note                 string
+}
+
+type ShareCertificateResponse struct {
+	ID          uint       `json:"id"`
+	Number      string     `json:"number"`
+	MemberID    uint       `json:"member_id"`
+	Shares      int64      `json:"shares"`
+	Status      string     `json:"status"`
+	IssuedAt    time.Time  `json:"issued_at"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+}
+
+func NewShareCertificateResponse(certificate *ShareCertificate) ShareCertificateResponse {
+	return ShareCertificateResponse{
+		ID:          certificate.ID,
+		Number:      certificate.Number(),
+		MemberID:    certificate.MemberID,
+		Shares:      certificate.Shares,
+		Status:      certificate.Status,
+		IssuedAt:    certificate.IssuedAt,
+		CancelledAt: certificate.CancelledAt,
+	}
+}
+
+type ShareAccountResponse struct {
+	ID           uint                       `json:"id"`
+	MemberID     uint                       `json:"member_id"`
+	Shares       int64                      `json:"shares"`
+	Value        Money                      `json:"value"`
+	Currency     string                     `json:"currency"`
+	MeetsMinimum bool                       `json:"meets_minimum"`
+	Certificates []ShareCertificateResponse `json:"certificates"`
+}
+
+// NewShareAccountResponse values the account at the configured price and lists the certificates loaded with it
+func NewShareAccountResponse(account *ShareAccount, settings ShareSettings) ShareAccountResponse {
+	certificates := make([]ShareCertificateResponse, len(account.Certificates))
+	for i := range account.Certificates {
+		certificates[i] = NewShareCertificateResponse(&account.Certificates[i])
+	}
+	return ShareAccountResponse{
+		ID:           account.ID,
+		MemberID:     account.MemberID,
+		Shares:       account.Shares,
+		Value:        settings.Value(account.Shares),
+		Currency:     BaseCurrency,
+		MeetsMinimum: account.Shares >= settings.MinimumHolding,
+		Certificates: certificates,
+	}
+}
+
+type ShareTransactionResponse struct {
+	ID                   uint                      `json:"id"`
+	CreatedAt            time.Time                 `json:"created_at"`
+	MemberID             uint                      `json:"member_id"`
+	Type                 string                    `json:"type"`
+	Shares               int64                     `json:"shares"`
+	PricePerShare        Money                     `json:"price_per_share"`
+	Amount               Money                     `json:"amount"`
+	CounterpartyMemberID *uint                     `json:"counterparty_member_id,omitempty"`
+	Certificate          *ShareCertificateResponse `json:"certificate,omitempty"`
+	SavingTransactionID  *uint                     `json:"saving_transaction_id,omitempty"`
+	PostedBy             uint                      `json:"posted_by"`
+	Note                 string                    `json:"note,omitempty"`
+}
+
+func NewShareTransactionResponse(transaction *ShareTransaction) ShareTransactionResponse {
+	response := ShareTransactionResponse{
+		ID:                   transaction.ID,
+		CreatedAt:            transaction.CreatedAt,
+		MemberID:             transaction.MemberID,
+		Type:                 transaction.Type,
+		Shares:               transaction.Shares,
+		PricePerShare:        transaction.PricePerShare,
+		Amount:               transaction.Amount,
+		CounterpartyMemberID: transaction.CounterpartyMemberID,
+		SavingTransactionID:  transaction.SavingTransactionID,
+		PostedBy:             transaction.PostedBy,
+		Note:                 transaction.Note,
+	}
+	if transaction.Certificate != nil {
+		certificate := NewShareCertificateResponse(transaction.Certificate)
+		response.Certificate = &certificate
+	}
+	return response
+}
+
+// ShareRegisterEntry is one member's line in the share register
+type ShareRegisterEntry struct {
+	MemberID     uint     `json:"member_id"`
+	MemberName   string   `json:"member_name"`
+	Shares       int64    `json:"shares"`
+	Value        Money    `json:"value"`
+	MeetsMinimum bool     `json:"meets_minimum"`
+	Certificates []string `json:"certificates"`
+}
+
+type ShareRegister struct {
+	Settings    ShareSettings        `json:"settings"`
+	Currency    string               `json:"currency"`
+	Entries     []ShareRegisterEntry `json:"entries"`
+	TotalShares int64                `json:"total_shares"`
+	TotalValue  Money                `json:"total_value"`
+}
+
+// BuildShareRegister lists every share account with its active certificates and totals the share capital
+func BuildShareRegister(accounts []ShareAccount, settings ShareSettings) ShareRegister {
+	register := ShareRegister{
+		Settings: settings,
+		Currency: BaseCurrency,
+		Entries:  make([]ShareRegisterEntry, 0, len(accounts)),
+	}
+	for _, account := range accounts {
+		numbers := []string{}
+		for i := range account.Certificates {
+			if account.Certificates[i].Status == ShareCertificateStatusActive {
+				numbers = append(numbers, account.Certificates[i].Number())
+			}
+		}
+		register.Entries = append(register.Entries, ShareRegisterEntry{
+			MemberID:     account.MemberID,
+			MemberName:   account.Member.Name,
+			Shares:       account.Shares,
+			Value:        settings.Value(account.Shares),
+			MeetsMinimum: account.Shares >= settings.MinimumHolding,
+			Certificates: numbers,
+		})
+		register.TotalShares += account.Shares
+	}
+	register.TotalValue = settings.Value(register.TotalShares)
+	return register
+}
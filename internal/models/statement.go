@@ -0,0 +1,157 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	StatementAccountSavings = "savings"
+	StatementAccountLoan    = "loan"
+)
+
+// StatementEntry is one line of an account statement. Balance is the running balance after the entry:
+// what the member holds for savings, what the member still owes for a loan.
+type StatementEntry struct {
+	Date        time.Time `json:"date"`
+	Reference   string    `json:"reference"`
+	Description string    `json:"description"`
+	Debit       Money     `json:"debit"`
+	Credit      Money     `json:"credit"`
+	Balance     Money     `json:"balance"`
+}
+
+type Statement struct {
+	MemberID       uint             `json:"member_id"`
+	MemberName     string           `json:"member_name"`
+	Account        string           `json:"account"` // "savings" or "loan"
+	AccountID      uint             `json:"account_id"`
+	Currency       string           `json:"currency"`
+	From           time.Time        `json:"from"`
+	To             time.Time        `json:"to"`
+	OpeningBalance Money            `json:"opening_balance"`
+	Entries        []StatementEntry `json:"entries"`
+	TotalDebits    Money            `json:"total_debits"`
+	TotalCredits   Money            `json:"total_credits"`
+	ClosingBalance Money            `json:"closing_balance"`
+	GeneratedAt    time.Time        `json:"generated_at"`
+}
+
+// balanceChange is how an entry moves the balance, credits grow savings while debits grow what is owed on a loan
+func (s *Statement) balanceChange(entry StatementEntry) Money {
+	if s.Account == StatementAccountLoan {
+		return entry.Debit - entry.Credit
+	}
+	return entry.Credit - entry.Debit
+}
+
+// fill sorts the entries, folds everything before From into the opening balance and keeps the entries
+// dated From up to and including the To date, each with its running balance
+func (s *Statement) fill(entries []StatementEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date.Before(entries[j].Date)
+	})
+
+	end := s.To.AddDate(0, 0, 1)
+	s.Entries = []StatementEntry{}
+	balance := Money(0)
+	for _, entry := range entries {
+		if !entry.Date.Before(end) {
+			break
+		}
+		balance += s.balanceChange(entry)
+		if entry.Date.Before(s.From) {
+			s.OpeningBalance = balance
+			continue
+		}
+		entry.Balance = balance
+		s.TotalDebits += entry.Debit
+		s.TotalCredits += entry.Credit
+		s.Entries = append(s.Entries, entry)
+	}
+	s.ClosingBalance = balance
+}
+
+// BuildSavingsStatement builds the statement of a savings account from its transactions. The transactions
+// must cover the whole life of the account up to the To date so the opening balance is right.
+func BuildSavingsStatement(member *Member, savings *Savings, transactions []SavingTransaction, from time.Time, to time.Time) Statement {
+	statement := Statement{
+		MemberID:    member.ID,
+		MemberName:  member.Name,
+		Account:     StatementAccountSavings,
+		AccountID:   savings.ID,
+		Currency:    savings.Currency,
+		From:        from,
+		To:          to,
+		GeneratedAt: time.Now(),
+	}
+
+	entries := make([]StatementEntry, 0, len(transactions))
+	for _, transaction := range transactions {
+		entry := StatementEntry{
+			Date:        transaction.CreatedAt,
+			Reference:   fmt.Sprintf("TXN-%d", transaction.ID),
+			Description: transaction.Description,
+		}
+		if transaction.Amount >= 0 {
+			entry.Credit = transaction.Amount
+		} else {
+			entry.Debit = -transaction.Amount
+		}
+		entries = append(entries, entry)
+	}
+
+	statement.fill(entries)
+	return statement
+}
+
+// BuildLoanStatement builds the statement of a loan. The principal and the interest for the whole term are
+// debited when the loan is disbursed and every repayment is credited against them.
+func BuildLoanStatement(member *Member, loan *Loan, repayments []LoanRepayment, from time.Time, to time.Time) Statement {
+	statement := Statement{
+		MemberID:    member.ID,
+		MemberName:  member.Name,
+		Account:     StatementAccountLoan,
+		AccountID:   loan.ID,
+		Currency:    loan.Currency,
+		From:        from,
+		To:          to,
+		GeneratedAt: time.Now(),
+	}
+
+	var entries []StatementEntry
+	if loan.DisbursedAt != nil {
+		reference := fmt.Sprintf("LOAN-%d", loan.ID)
+		entries = append(entries, StatementEntry{
+			Date:        *loan.DisbursedAt,
+			Reference:   reference,
+			Description: fmt.Sprintf("%s loan principal", loan.Type),
+			Debit:       loan.Amount,
+		})
+		if interest := loan.TotalRepayableAmount - loan.Amount; interest > 0 {
+			entries = append(entries, StatementEntry{
+				Date:        *loan.DisbursedAt,
+				Reference:   reference,
+				Description: fmt.Sprintf("Interest for %d months", loan.LoanTermMonths),
+				Debit:       interest,
+			})
+		}
+	}
+
+	for _, repayment := range repayments {
+		description := "Repayment"
+		if repayment.Note != "" {
+			description = "Repayment - " + repayment.Note
+		}
+		entries = append(entries, StatementEntry{
+			Date:        repayment.PaidAt,
+			Reference:   fmt.Sprintf("RPY-%d", repayment.ID),
+			Description: description,
+			Credit:      repayment.Amount,
+		})
+	}
+
+	statement.fill(entries)
+	return statement
+}
@@ -0,0 +1,202 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Transaction types say why money moved. The first group is posted to savings accounts,
+// the loan types only appear in a member's journal.
+const (
+	TransactionTypeDeposit     = "deposit"
+	TransactionTypeWithdrawal  = "withdrawal"
+	TransactionTypeInterest    = "interest"
+	TransactionTypeFee         = "fee"
+	TransactionTypeTransferIn  = "transfer_in"
+	TransactionTypeTransferOut = "transfer_out"
+	TransactionTypeLoanOffset  = "loan_offset"
+	TransactionTypeReversal    = "reversal"
+	TransactionTypeDividend    = "dividend"
+	TransactionTypePatronage   = "patronage_refund"
+	// TransactionTypeFixedDeposit moves money between savings and a fixed deposit, out when the deposit
+	// is opened and back when it is broken or matures into savings
+	TransactionTypeFixedDeposit = "fixed_deposit"
+	// TransactionTypeShares moves money between savings and share capital, out when shares are bought and
+	// back when they are redeemed
+	TransactionTypeShares = "shares"
+	// TransactionTypeOpeningBalance is the balance a savings account carried over from the legacy system
+	TransactionTypeOpeningBalance = "opening_balance"
+
+	TransactionTypeLoanDisbursement = "loan_disbursement"
+	TransactionTypeLoanRepayment    = "loan_repayment"
+)
+
+var AllowedTransactionTypes = map[string]bool{
+	TransactionTypeDeposit:          true,
+	TransactionTypeWithdrawal:       true,
+	TransactionTypeInterest:         true,
+	TransactionTypeFee:              true,
+	TransactionTypeTransferIn:       true,
+	TransactionTypeTransferOut:      true,
+	TransactionTypeLoanOffset:       true,
+	TransactionTypeReversal:         true,
+	TransactionTypeDividend:         true,
+	TransactionTypePatronage:        true,
+	TransactionTypeFixedDeposit:     true,
+	TransactionTypeShares:           true,
+	TransactionTypeOpeningBalance:   true,
+	TransactionTypeLoanDisbursement: true,
+	TransactionTypeLoanRepayment:    true,
+}
+
+// Channels say how money reached the cooperative. Internal is used for entries the system posts itself,
+// such as transfers, interest and reversals.
+const (
+	TransactionChannelCash         = "cash"
+	TransactionChannelBankTransfer = "bank_transfer"
+	TransactionChannelCard         = "card"
+	TransactionChannelOnline       = "online"
+	TransactionChannelInternal     = "internal"
+)
+
+var AllowedTransactionChannels = map[string]bool{
+	TransactionChannelCash:         true,
+	TransactionChannelBankTransfer: true,
+	TransactionChannelCard:         true,
+	TransactionChannelOnline:       true,
+	TransactionChannelInternal:     true,
+}
+
+var ErrInvalidChannel = errors.New("channel must be one of cash, bank_transfer, card or online")
+
+// NormalizeChannel checks the channel given with a request. An empty channel means online, and the
+// internal channel is kept for entries the system posts itself.
+func NormalizeChannel(channel string) (string, error) {
+	channel = strings.ToLower(strings.TrimSpace(channel))
+	if channel == "" {
+		return TransactionChannelOnline, nil
+	}
+	if !AllowedTransactionChannels[channel] || channel == TransactionChannelInternal {
+		return "", ErrInvalidChannel
+	}
+	return channel, nil
+}
+
+const (
+	JournalSourceSavings = "savings"
+	JournalSourceLoan    = "loan"
+)
+
+// JournalEntry is one movement in a member's journal. Amount is signed from the member's side:
+// positive when money reaches the member (savings credits, loan disbursements), negative when the
+// member pays it out (savings debits, loan repayments).
+type JournalEntry struct {
+	Date              time.Time `json:"date"`
+	Source            string    `json:"source"` // "savings" or "loan"
+	AccountID         uint      `json:"account_id"`
+	Reference         string    `json:"reference"`
+	Type              string    `json:"type"`
+	Channel           string    `json:"channel,omitempty"`
+	ExternalReference string    `json:"external_reference,omitempty"`
+	Description       string    `json:"description"`
+	Amount            Money     `json:"amount"`
+	Currency          string    `json:"currency"`
+}
+
+// JournalFilter narrows a journal to some transaction types and to a date range, both dates inclusive.
+// Empty fields do not filter.
+type JournalFilter struct {
+	Types []string
+	From  *time.Time
+	To    *time.Time
+}
+
+// Includes reports whether an entry of the given type and date passes the filter
+func (f JournalFilter) Includes(entryType string, date time.Time) bool {
+	if f.From != nil && date.Before(*f.From) {
+		return false
+	}
+	if f.To != nil && !date.Before(f.To.AddDate(0, 0, 1)) {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if t == entryType {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildJournal merges a member's savings transactions, loan disbursements and loan repayments into one
+// list, oldest first, keeping only the entries the filter lets through. Loans appear on the day they were disbursed.
+func BuildJournal(transactions []SavingTransaction, loans []Loan, repayments []LoanRepayment, filter JournalFilter) []JournalEntry {
+	entries := []JournalEntry{}
+
+	for _, transaction := range transactions {
+		if !filter.Includes(transaction.Type, transaction.CreatedAt) {
+			continue
+		}
+		entries = append(entries, JournalEntry{
+			Date:              transaction.CreatedAt,
+			Source:            JournalSourceSavings,
+			AccountID:         transaction.SavingsID,
+			Reference:         fmt.Sprintf("TXN-%d", transaction.ID),
+			Type:              transaction.Type,
+			Channel:           transaction.Channel,
+			ExternalReference: transaction.ExternalReference,
+			Description:       transaction.Description,
+			Amount:            transaction.Amount,
+			Currency:          transaction.Currency,
+		})
+	}
+
+	for _, loan := range loans {
+		if loan.DisbursedAt == nil || !filter.Includes(TransactionTypeLoanDisbursement, *loan.DisbursedAt) {
+			continue
+		}
+		entries = append(entries, JournalEntry{
+			Date:        *loan.DisbursedAt,
+			Source:      JournalSourceLoan,
+			AccountID:   loan.ID,
+			Reference:   fmt.Sprintf("LOAN-%d", loan.ID),
+			Type:        TransactionTypeLoanDisbursement,
+			Channel:     TransactionChannelInternal,
+			Description: fmt.Sprintf("%s loan disbursed", loan.Type),
+			Amount:      loan.Amount,
+			Currency:    loan.Currency,
+		})
+	}
+
+	for _, repayment := range repayments {
+		if !filter.Includes(TransactionTypeLoanRepayment, repayment.PaidAt) {
+			continue
+		}
+		description := fmt.Sprintf("Repayment of loan #%d", repayment.LoanID)
+		if repayment.Note != "" {
+			description += " - " + repayment.Note
+		}
+		entries = append(entries, JournalEntry{
+			Date:              repayment.PaidAt,
+			Source:            JournalSourceLoan,
+			AccountID:         repayment.LoanID,
+			Reference:         fmt.Sprintf("RPY-%d", repayment.ID),
+			Type:              TransactionTypeLoanRepayment,
+			Channel:           repayment.Channel,
+			ExternalReference: repayment.ExternalReference,
+			Description:       description,
+			Amount:            -repayment.Amount,
+			Currency:          repayment.Currency,
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date.Before(entries[j].Date)
+	})
+	return entries
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	TransferStatusCompleted = "completed"
+	TransferStatusReversed  = "reversed"
+)
+
+// SavingsTransfer moves money from one member's savings account to another's in the same currency.
+// It is posted as a debit on the sender and a credit on the recipient, both pointing back at the transfer.
+type SavingsTransfer struct {
+	gorm.Model
+	FromMemberID        uint   `gorm:"not null;index"`
+	ToMemberID          uint   `gorm:"not null;index"`
+	Amount              Money  `gorm:"not null"`
+	Currency            string `gorm:"size:3;not null;default:NGN"`
+	Note                string
+	Status              string `gorm:"not null"` // e.g., "completed", "reversed"
+	DebitTransactionID  uint
+	CreditTransactionID uint
+	ReversedAt          *time.Time
+	ReversedBy          *uint
+	ReversalReason      string
+}
+
+type SavingsTransferResponse struct {
+	ID                  uint       `json:"id"`
+	CreatedAt           time.Time  `json:"created_at"`
+	FromMemberID        uint       `json:"from_member_id"`
+	ToMemberID          uint       `json:"to_member_id"`
+	Amount              Money      `json:"amount"`
+	Currency            string     `json:"currency"`
+	Note                string     `json:"note,omitempty"`
+	Status              string     `json:"status"`
+	DebitTransactionID  uint       `json:"debit_transaction_id"`
+	CreditTransactionID uint       `json:"credit_transaction_id"`
+	ReversedAt          *time.Time `json:"reversed_at,omitempty"`
+	ReversedBy          *uint      `json:"reversed_by,omitempty"`
+	ReversalReason      string     `json:"reversal_reason,omitempty"`
+}
+
+func NewSavingsTransferResponse(transfer *SavingsTransfer) SavingsTransferResponse {
+	return SavingsTransferResponse{
+		ID:                  transfer.ID,
+		CreatedAt:           transfer.CreatedAt,
+		FromMemberID:        transfer.FromMemberID,
+		ToMemberID:          transfer.ToMemberID,
+		Amount:              transfer.Amount,
+		Currency:            transfer.Currency,
+		Note:                transfer.Note,
+		Status:              transfer.Status,
+		DebitTransactionID:  transfer.DebitTransactionID,
+		CreditTransactionID: transfer.CreditTransactionID,
+		ReversedAt:          transfer.ReversedAt,
+		ReversedBy:          transfer.ReversedBy,
+		ReversalReason:      transfer.ReversalReason,
+	}
+}
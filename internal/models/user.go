@@ -11,6 +11,11 @@ type User struct {
 	Email    string `gorm:"unique"`
 	Password string `gorm:"not null"`
 	Role     string `gorm:"not null"`
+	// Admins assigned to a branch only see its members, see BranchScope
+	BranchID *uint   `gorm:"index"`
+	Branch   *Branch `gorm:"foreignKey:BranchID"`
+	// Access tokens issued before this are refused, set when every session of the user is logged out
+	TokensRevokedAt *time.Time
 }
 
 type UserResponse struct {
@@ -19,6 +24,7 @@ type UserResponse struct {
 	UpdatedAt time.Time `json:"updated_at"`
 	Email     string    `json:"email"`
 	Role      string    `json:"role"`
+	BranchID  *uint     `json:"branch_id,omitempty"`
 }
 
 func NewUserResponse(user *User) UserResponse {
@@ -28,6 +34,7 @@ func NewUserResponse(user *User) UserResponse {
 		UpdatedAt: user.UpdatedAt,
 		Email:     user.Email,
 		Role:      user.Role,
+		BranchID:  user.BranchID,
 	}
 
 }
@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"cooperative-system/internal/models"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBranchCodeTaken  = errors.New("a branch with this code already exists")
+	ErrUnknownBranch    = errors.New("branch does not exist")
+	ErrUnknownGroup     = errors.New("member group does not exist")
+	ErrGroupNotInBranch = errors.New("member group belongs to another branch")
+)
+
+type gormBranchRepository struct {
+	db *gorm.DB
+}
+
+// NewGormBranchRepository creates a new branch repository instance
+func NewGormBranchRepository(db *gorm.DB) *gormBranchRepository {
+	return &gormBranchRepository{db: db}
+}
+
+// inBranch limits a query on rows that belong to a member to the members of one branch, or leaves it alone
+// when branchID is nil
+func inBranch(query *gorm.DB, table string, branchID *uint) *gorm.DB {
+	if branchID == nil {
+		return query
+	}
+	return query.Where(table+".member_id IN (SELECT id FROM members WHERE branch_id = ? AND deleted_at IS NULL)", *branchID)
+}
+
+// checkAssignmentTx checks a member can be put in the given branch and group: both must exist and the group
+// must belong to the branch or take members from every branch
+func checkAssignmentTx(tx *gorm.DB, branchID *uint, groupID *uint) (string, error) {
+	if branchID != nil {
+		var count int64
+		if err := tx.Model(&models.Branch{}).Where("id = ?", *branchID).Count(&count).Error; err != nil {
+			return "failed to fetch branch", err
+		}
+		if count == 0 {
+			return "branch not found", ErrUnknownBranch
+		}
+	}
+	if groupID != nil {
+		var group models.MemberGroup
+		if err := tx.Where("id = ?", *groupID).First(&group).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return "member group not found", ErrUnknownGroup
+			}
+			return "failed to fetch member group", err
+		}
+		if !group.AcceptsBranch(branchID) {
+			return "member group belongs to another branch", ErrGroupNotInBranch
+		}
+	}
+	return "assignment is valid", nil
+}
+
+// CreateBranch adds a branch, refusing a code another branch already uses
+func (r *gormBranchRepository) CreateBranch(branch *models.Branch) (*models.Branch, string, error) {
+	var count int64
+	if err := r.db.Model(&models.Branch{}).Where("code = ?", branch.Code).Count(&count).Error; err != nil {
+		return nil, "failed to check branch code", err
+	}
+	if count > 0 {
+		return nil, "branch code already in use", ErrBranchCodeTaken
+	}
+	if err := r.db.Create(branch).Error; err != nil {
+		return nil, "failed to create branch", err
+	}
+	return branch, "branch created successfully", nil
+}
+
+// GetBranches lists every branch by code
+func (r *gormBranchRepository) GetBranches() ([]models.Branch, string, error) {
+	var branches []models.Branch
+	if err := r.db.Order("code ASC").Find(&branches).Error; err != nil {
+		return nil, "failed to fetch branches", err
+	}
+	return branches, "branches fetched successfully", nil
+}
+
+// GetBranchByID finds a branch by its ID
+func (r *gormBranchRepository) GetBranchByID(branchID string) (*models.Branch, string, error) {
+	var branch models.Branch
+	if err := r.db.Where("id = ?", branchID).First(&branch).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "branch not found", err
+		}
+		return nil, "failed to fetch branch", err
+	}
+	return &branch, "branch fetched successfully", nil
+}
+
+// UpdateBranch saves a branch's details. A new code only changes numbers issued from now on.
+func (r *gormBranchRepository) UpdateBranch(branch *models.Branch) (*models.Branch, string, error) {
+	var count int64
+	if err := r.db.Model(&models.Branch{}).Where("code = ? AND id <> ?", branch.Code, branch.ID).Count(&count).Error; err != nil {
+		return nil, "failed to check branch code", err
+	}
+	if count > 0 {
+		return nil, "branch code already in use", ErrBranchCodeTaken
+	}
+	if err := r.db.Save(branch).Error; err != nil {
+		return nil, "failed to update branch", err
+	}
+	return branch, "branch updated successfully", nil
+}
+
+// CreateGroup adds a member group to a branch, or to the whole cooperative when it has no branch
+func (r *gormBranchRepository) CreateGroup(group *models.MemberGroup) (*models.MemberGroup, string, error) {
+	if msg, err := checkAssignmentTx(r.db, group.BranchID, nil); err != nil {
+		return nil, msg, err
+	}
+	if err := r.db.Create(group).Error; err != nil {
+		return nil, "failed to create member group", err
+	}
+	return group, "member group created successfully", nil
+}
+
+// GetGroups lists the member groups by name. With a branch, only its groups and those open to every branch.
+func (r *gormBranchRepository) GetGroups(branchID *uint) ([]models.MemberGroup, string, error) {
+	var groups []models.MemberGroup
+	query := r.db.Order("name ASC, id ASC")
+	if branchID != nil {
+		query = query.Where("branch_id = ? OR branch_id IS NULL", *branchID)
+	}
+	if err := query.Find(&groups).Error; err != nil {
+		return nil, "failed to fetch member groups", err
+	}
+	return groups, "member groups fetched successfully", nil
+}
+
+// GetGroupByID finds a member group by its ID
+func (r *gormBranchRepository) GetGroupByID(groupID string) (*models.MemberGroup, string, error) {
+	var group models.MemberGroup
+	if err := r.db.Where("id = ?", groupID).First(&group).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "member group not found", err
+		}
+		return nil, "failed to fetch member group", err
+	}
+	return &group, "member group fetched successfully", nil
+}
+
+// UpdateGroup saves a member group's name and employer
+func (r *gormBranchRepository) UpdateGroup(group *models.MemberGroup) (*models.MemberGroup, string, error) {
+	if err := r.db.Model(group).Select("name", "employer").Updates(group).Error; err != nil {
+		return nil, "failed to update member group", err
+	}
+	return group, "member group updated successfully", nil
+}
+
+// AssignMember moves a member to a branch and group and records the move in their history. A member keeps
+// the numbers already issued to them when they move branch.
+func (r *gormBranchRepository) AssignMember(memberID uint, branchID *uint, groupID *uint, changedBy uint) (*models.Member, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, "failed to start transaction", err
+	}
+
+	var member models.Member
+	if err := tx.Where("id = ?", memberID).First(&member).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "member not found", err
+		}
+		return nil, "failed to fetch member", err
+	}
+	if msg, err := checkAssignmentTx(tx, branchID, groupID); err != nil {
+		tx.Rollback()
+		return nil, msg, err
+	}
+
+	if err := tx.Model(&member).Updates(map[string]interface{}{"branch_id": branchID, "group_id": groupID}).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to assign member", err
+	}
+	member.BranchID, member.GroupID = branchID, groupID
+
+	history := models.MemberHistory{
+		MemberID:  member.ID,
+		Status:    member.Status,
+		ChangedBy: changedBy,
+		Remarks:   assignmentRemarks(branchID, groupID),
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to create member history", err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, "failed to commit transaction", err
+	}
+	return &member, "member assigned successfully", nil
+}
+
+func assignmentRemarks(branchID *uint, groupID *uint) string {
+	parts := []string{"no branch", "no group"}
+	if branchID != nil {
+		parts[0] = fmt.Sprintf("branch %d", *branchID)
+	}
+	if groupID != nil {
+		parts[1] = fmt.Sprintf("group %d", *groupID)
+	}
+	return "Assigned to " + strings.Join(parts, " and ")
+}
+
+// AssignUserBranch limits a staff user to one branch, or lifts the limit when branchID is nil
+func (r *gormBranchRepository) AssignUserBranch(userID uint, branchID *uint) (*models.User, string, error) {
+	if msg, err := checkAssignmentTx(r.db, branchID, nil); err != nil {
+		return nil, msg, err
+	}
+	var user models.User
+	if err := r.db.Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "user not found", err
+		}
+		return nil, "failed to fetch user", err
+	}
+	if err := r.db.Model(&user).Update("branch_id", branchID).Error; err != nil {
+		return nil, "failed to assign user to branch", err
+	}
+	user.BranchID = branchID
+	return &user, "user assigned to branch successfully", nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"cooperative-system/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type gormContributionRepository struct {
+	db *gorm.DB
+}
+
+func NewGormContributionRepository(db *gorm.DB) *gormContributionRepository {
+	return &gormContributionRepository{db: db}
+}
+
+// CreateMandate ends the member's current mandate, creates the new one and keeps Savings.AmountToSave in step
+func (r *gormContributionRepository) CreateMandate(mandate *models.ContributionMandate) (*models.ContributionMandate, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, "failed to start transaction", err
+	}
+
+	err := tx.Model(&models.ContributionMandate{}).
+		Where("member_id = ? AND status = ?", mandate.MemberID, models.MandateStatusActive).
+		Updates(map[string]interface{}{
+			"status":   models.MandateStatusEnded,
+			"end_date": mandate.StartDate,
+		}).Error
+	if err != nil {
+		tx.Rollback()
+		return nil, "failed to end current mandate", err
+	}
+
+	if err := tx.Create(mandate).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to create mandate", err
+	}
+
+	err = tx.Model(&models.Savings{}).Where("member_id = ? AND currency = ?", mandate.MemberID, mandate.Currency).Update("amount_to_save", mandate.Amount).Error
+	if err != nil {
+		tx.Rollback()
+		return nil, "failed to update amount to save", err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, "failed to commit transaction", err
+	}
+
+	return mandate, "mandate created successfully", nil
+}
+
+// GetActiveMandateByMemberID fetches the mandate currently in force for a member
+func (r *gormContributionRepository) GetActiveMandateByMemberID(memberID uint) (*models.ContributionMandate, string, error) {
+	var mandate models.ContributionMandate
+	err := r.db.Where("member_id = ? AND status = ?", memberID, models.MandateStatusActive).First(&mandate).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "no active mandate found for the given member ID", err
+		}
+		return nil, "failed to fetch mandate", err
+	}
+	return &mandate, "mandate fetched successfully", nil
+}
+
+// GetMandatesByMemberID fetches every mandate a member has had, oldest first
+func (r *gormContributionRepository) GetMandatesByMemberID(memberID uint) ([]models.ContributionMandate, string, error) {
+	var mandates []models.ContributionMandate
+	if err := r.db.Where("member_id = ?", memberID).Order("start_date ASC").Find(&mandates).Error; err != nil {
+		return nil, "failed to fetch mandates", err
+	}
+	return mandates, "mandates fetched successfully", nil
+}
+
+// GetMandatesForActiveMembers fetches the mandates of every member who currently has an active one, limited
+// to the members of one branch when branchID is set
+func (r *gormContributionRepository) GetMandatesForActiveMembers(branchID *uint) ([]models.ContributionMandate, string, error) {
+	var mandates []models.ContributionMandate
+	activeMembers := r.db.Model(&models.ContributionMandate{}).Select("member_id").Where("status = ?", models.MandateStatusActive)
+	query := inBranch(r.db.Preload("Member").Where("member_id IN (?)", activeMembers), "contribution_mandates", branchID)
+	err := query.Order("member_id ASC, start_date ASC").Find(&mandates).Error
+	if err != nil {
+		return nil, "failed to fetch mandates", err
+	}
+	return mandates, "mandates fetched successfully", nil
+}
+
+// GetContributionsByMemberIDs fetches the savings transactions made since a date, grouped by member
+func (r *gormContributionRepository) GetContributionsByMemberIDs(memberIDs []uint, since time.Time) (map[uint][]models.SavingTransaction, string, error) {
+	contributions := make(map[uint][]models.SavingTransaction)
+	if len(memberIDs) == 0 {
+		return contributions, "no members given", nil
+	}
+
+	var transactions []models.SavingTransaction
+	err := r.db.Where("member_id IN ? AND created_at >= ?", memberIDs, since).Order("created_at ASC").Find(&transactions).Error
+	if err != nil {
+		return nil, "failed to fetch contributions", err
+	}
+
+	for _, transaction := range transactions {
+		contributions[transaction.MemberID] = append(contributions[transaction.MemberID], transaction)
+	}
+	return contributions, "contributions fetched successfully", nil
+}
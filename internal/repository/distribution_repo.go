@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"cooperative-system/internal/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrDistributionNotDraft        = errors.New("only draft distributions can be approved or cancelled")
+	ErrDistributionAlreadyApproved = errors.New("a distribution has already been approved for this fiscal year")
+)
+
+// distributionYearLock ("DIST") is the first key of the advisory lock taken on a fiscal year while one of its
+// distributions is approved, the year is the second
+const distributionYearLock = 0x44495354
+
+type gormDistributionRepository struct {
+	db *gorm.DB
+}
+
+func NewGormDistributionRepository(db *gorm.DB) *gormDistributionRepository {
+	return &gormDistributionRepository{db: db}
+}
+
+// CreateDistribution computes every member's entitlement for the distribution's period from the share
+// transactions and loan repayments on record and saves the result as a draft
+func (r *gormDistributionRepository) CreateDistribution(distribution *models.Distribution) (*models.Distribution, string, error) {
+	periodEnd := distribution.PeriodEnd.AddDate(0, 0, 1)
+
+	var shareTransactions []models.ShareTransaction
+	if err := r.db.Where("created_at < ?", periodEnd).Order("created_at ASC, id ASC").Find(&shareTransactions).Error; err != nil {
+		return nil, "failed to fetch share transactions", err
+	}
+	sharesByMember := make(map[uint][]models.ShareTransaction)
+	for _, transaction := range shareTransactions {
+		sharesByMember[transaction.MemberID] = append(sharesByMember[transaction.MemberID], transaction)
+	}
+
+	var repayments []models.LoanRepayment
+	err := r.db.Where("paid_at >= ? AND paid_at < ?", distribution.PeriodStart, periodEnd).Order("paid_at ASC, id ASC").Find(&repayments).Error
+	if err != nil {
+		return nil, "failed to fetch loan repayments", err
+	}
+	repaymentsByMember := make(map[uint][]models.LoanRepayment)
+	loanIDs := []uint{}
+	for _, repayment := range repayments {
+		repaymentsByMember[repayment.MemberID] = append(repaymentsByMember[repayment.MemberID], repayment)
+		loanIDs = append(loanIDs, repayment.LoanID)
+	}
+
+	loans := make(map[uint]models.Loan)
+	if len(loanIDs) > 0 {
+		var rows []models.Loan
+		if err := r.db.Where("id IN ?", loanIDs).Find(&rows).Error; err != nil {
+			return nil, "failed to fetch loans", err
+		}
+		for _, loan := range rows {
+			loans[loan.ID] = loan
+		}
+	}
+
+	if err := models.CalculateDistribution(distribution, sharesByMember, repaymentsByMember, loans); err != nil {
+		return nil, "failed to calculate distribution", err
+	}
+	distribution.Status = models.DistributionStatusDraft
+
+	if err := r.db.Create(distribution).Error; err != nil {
+		return nil, "failed to save distribution", err
+	}
+	return distribution, "distribution draft created successfully", nil
+}
+
+// GetDistributions fetches every distribution without its lines, newest first
+func (r *gormDistributionRepository) GetDistributions() ([]models.Distribution, string, error) {
+	var distributions []models.Distribution
+	if err := r.db.Order("fiscal_year DESC, id DESC").Find(&distributions).Error; err != nil {
+		return nil, "failed to fetch distributions", err
+	}
+	return distributions, "distributions fetched successfully", nil
+}
+
+// GetDistributionByID fetches a distribution with every member's line
+func (r *gormDistributionRepository) GetDistributionByID(distributionID string) (*models.Distribution, string, error) {
+	var distribution models.Distribution
+	err := r.db.Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("member_id ASC") }).Preload("Lines.Member").
+		Where("id = ?", distributionID).First(&distribution).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "distribution not found", err
+		}
+		return nil, "failed to fetch distribution", err
+	}
+	return &distribution, "distribution fetched successfully", nil
+}
+
+// ApproveDistribution approves a draft and, when it pays into savings, credits every member's dividend and
+// patronage refund to their base currency savings in the same transaction
+func (r *gormDistributionRepository) ApproveDistribution(distributionID uint, approvedBy uint) (*models.Distribution, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, "failed to start transaction", err
+	}
+
+	var distribution models.Distribution
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lines").Where("id = ?", distributionID).First(&distribution).Error
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "distribution not found", err
+		}
+		return nil, "failed to fetch distribution for update", err
+	}
+	if distribution.Status != models.DistributionStatusDraft {
+		tx.Rollback()
+		return nil, ErrDistributionNotDraft.Error(), ErrDistributionNotDraft
+	}
+
+	// two drafts of the same year approved at once would both find no approved distribution, so approvals
+	// of a year wait for each other
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?, ?)", distributionYearLock, distribution.FiscalYear).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to lock the fiscal year", err
+	}
+
+	var approved int64
+	err = tx.Model(&models.Distribution{}).Where("fiscal_year = ? AND status = ?", distribution.FiscalYear, models.DistributionStatusApproved).Count(&approved).Error
+	if err != nil {
+		tx.Rollback()
+		return nil, "failed to check for an approved distribution", err
+	}
+	if approved > 0 {
+		tx.Rollback()
+		return nil, ErrDistributionAlreadyApproved.Error(), ErrDistributionAlreadyApproved
+	}
+
+	if distribution.PayoutMethod == models.DistributionPayoutSavings {
+		for _, line := range distribution.Lines {
+			msg, err := creditSavingsTx(tx, line.MemberID, models.BaseCurrency,
+				models.SavingTransaction{Type: models.TransactionTypeDividend, Amount: line.Dividend, Description: fmt.Sprintf("Dividend for %d, distribution #%d", distribution.FiscalYear, distribution.ID)},
+				models.SavingTransaction{Type: models.TransactionTypePatronage, Amount: line.Patronage, Description: fmt.Sprintf("Patronage refund for %d, distribution #%d", distribution.FiscalYear, distribution.ID)},
+			)
+			if err != nil {
+				tx.Rollback()
+				return nil, msg, err
+			}
+		}
+	}
+
+	now := time.Now()
+	err = tx.Model(&distribution).Updates(map[string]interface{}{
+		"status":      models.DistributionStatusApproved,
+		"approved_by": approvedBy,
+		"approved_at": now,
+	}).Error
+	if err != nil {
+		tx.Rollback()
+		return nil, "failed to approve distribution", err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, "failed to commit transaction", err
+	}
+
+	return r.GetDistributionByID(fmt.Sprint(distributionID))
+}
+
+// CancelDistribution discards a draft so a new one can be computed
+func (r *gormDistributionRepository) CancelDistribution(distributionID uint) (*models.Distribution, string, error) {
+	result := r.db.Model(&models.Distribution{}).
+		Where("id = ? AND status = ?", distributionID, models.DistributionStatusDraft).
+		Update("status", models.DistributionStatusCancelled)
+	if result.Error != nil {
+		return nil, "failed to cancel distribution", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrDistributionNotDraft.Error(), ErrDistributionNotDraft
+	}
+	return r.GetDistributionByID(fmt.Sprint(distributionID))
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"cooperative-system/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type gormDocumentRepository struct {
+	db *gorm.DB
+}
+
+// NewGormDocumentRepository creates a new document repository instance
+func NewGormDocumentRepository(db *gorm.DB) *gormDocumentRepository {
+	return &gormDocumentRepository{db: db}
+}
+
+func (r *gormDocumentRepository) CreateDocument(document *models.Document) (*models.Document, string, error) {
+	if err := r.db.Create(document).Error; err != nil {
+		return nil, "failed to save document", err
+	}
+	return document, "document uploaded successfully", nil
+}
+
+// GetDocumentsByMemberID lists a member's documents, newest first, only those for one loan when loanID is set
+func (r *gormDocumentRepository) GetDocumentsByMemberID(memberID uint, loanID *uint) ([]models.Document, string, error) {
+	var documents []models.Document
+	query := r.db.Where("member_id = ?", memberID)
+	if loanID != nil {
+		query = query.Where("loan_id = ?", *loanID)
+	}
+	if err := query.Order("created_at DESC, id DESC").Find(&documents).Error; err != nil {
+		return nil, "failed to fetch documents", err
+	}
+	return documents, "documents fetched successfully", nil
+}
+
+func (r *gormDocumentRepository) GetDocumentByID(documentID string) (*models.Document, string, error) {
+	var document models.Document
+	if err := r.db.Where("id = ?", documentID).First(&document).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "document not found", err
+		}
+		return nil, "failed to fetch document", err
+	}
+	return &document, "document fetched successfully", nil
+}
+
+func (r *gormDocumentRepository) UpdateDocument(document *models.Document) (*models.Document, string, error) {
+	if err := r.db.Save(document).Error; err != nil {
+		return nil, "failed to update document", err
+	}
+	return document, "document updated successfully", nil
+}
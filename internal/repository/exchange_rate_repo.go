@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"cooperative-system/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormExchangeRateRepository struct {
+	db *gorm.DB
+}
+
+func NewGormExchangeRateRepository(db *gorm.DB) *gormExchangeRateRepository {
+	return &gormExchangeRateRepository{db: db}
+}
+
+// SetExchangeRate records the rate for a currency on a date, replacing any rate already set for that day
+func (r *gormExchangeRateRepository) SetExchangeRate(rate *models.ExchangeRate) (*models.ExchangeRate, string, error) {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "currency"}, {Name: "effective_date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "set_by", "updated_at"}),
+	}).Create(rate).Error
+	if err != nil {
+		return nil, "failed to set exchange rate", err
+	}
+	return rate, "exchange rate set successfully", nil
+}
+
+// GetExchangeRates fetches the rate history, newest first, optionally for a single currency
+func (r *gormExchangeRateRepository) GetExchangeRates(currency string) ([]models.ExchangeRate, string, error) {
+	var rates []models.ExchangeRate
+	query := r.db.Order("effective_date DESC, currency ASC")
+	if currency != "" {
+		query = query.Where("currency = ?", currency)
+	}
+	if err := query.Find(&rates).Error; err != nil {
+		return nil, "failed to fetch exchange rates", err
+	}
+	return rates, "exchange rates fetched successfully", nil
+}
+
+// GetExchangeRatesOn fetches the rate in force on a date for every currency, keyed by currency
+func (r *gormExchangeRateRepository) GetExchangeRatesOn(date time.Time) (map[string]models.ExchangeRate, string, error) {
+	var rates []models.ExchangeRate
+	if err := r.db.Where("effective_date <= ?", date).Order("effective_date ASC").Find(&rates).Error; err != nil {
+		return nil, "failed to fetch exchange rates", err
+	}
+
+	// later rates overwrite earlier ones so each currency ends up with its latest rate
+	inForce := make(map[string]models.ExchangeRate)
+	for _, rate := range rates {
+		inForce[rate.Currency] = rate
+	}
+	return inForce, "exchange rates fetched successfully", nil
+}
@@ -0,0 +1,307 @@
+package repository
+
+import (
+	"cooperative-system/internal/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrFeeAlreadyWaived  = errors.New("fee has already been waived")
+	ErrFeeNotOutstanding = errors.New("fee is not outstanding")
+	ErrFeeTransaction    = errors.New("transaction is a fee, waive the fee instead")
+)
+
+type gormFeeRepository struct {
+	db *gorm.DB
+}
+
+// NewGormFeeRepository creates a new fee repository instance
+func NewGormFeeRepository(db *gorm.DB) *gormFeeRepository {
+	return &gormFeeRepository{db: db}
+}
+
+func (r *gormFeeRepository) CreateFeeDefinition(fee *models.FeeDefinition) (*models.FeeDefinition, string, error) {
+	if err := r.db.Create(fee).Error; err != nil {
+		return nil, "failed to create fee", err
+	}
+	return fee, "fee created successfully", nil
+}
+
+func (r *gormFeeRepository) GetFeeDefinitions() ([]models.FeeDefinition, string, error) {
+	var fees []models.FeeDefinition
+	if err := r.db.Order("trigger ASC, id ASC").Find(&fees).Error; err != nil {
+		return nil, "failed to fetch fees", err
+	}
+	return fees, "fees fetched successfully", nil
+}
+
+func (r *gormFeeRepository) GetFeeDefinitionByID(feeID string) (*models.FeeDefinition, string, error) {
+	var fee models.FeeDefinition
+	if err := r.db.Where("id = ?", feeID).First(&fee).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "fee not found", err
+		}
+		return nil, "failed to fetch fee", err
+	}
+	return &fee, "fee fetched successfully", nil
+}
+
+// UpdateFeeDefinition saves changes to a fee, charges already made keep the amount they were charged at
+func (r *gormFeeRepository) UpdateFeeDefinition(fee *models.FeeDefinition) (*models.FeeDefinition, string, error) {
+	if err := r.db.Save(fee).Error; err != nil {
+		return nil, "failed to update fee", err
+	}
+	return fee, "fee updated successfully", nil
+}
+
+// ApplyFees charges every active fee for the trigger in a transaction of its own
+func (r *gormFeeRepository) ApplyFees(trigger string, feeContext models.FeeContext) ([]models.FeeCharge, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, "failed to start transaction", err
+	}
+
+	charges, msg, err := applyFeesTx(tx, trigger, feeContext)
+	if err != nil {
+		tx.Rollback()
+		return nil, msg, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, "failed to commit transaction", err
+	}
+	return charges, msg, nil
+}
+
+// ApplyFeesTx charges every active fee for the trigger inside a transaction the caller owns
+func (r *gormFeeRepository) ApplyFeesTx(tx *gorm.DB, trigger string, feeContext models.FeeContext) ([]models.FeeCharge, string, error) {
+	return applyFeesTx(tx, trigger, feeContext)
+}
+
+func (r *gormFeeRepository) GetFeeChargesByMemberID(memberID uint) ([]models.FeeCharge, string, error) {
+	var charges []models.FeeCharge
+	if err := r.db.Preload("FeeDefinition").Where("member_id = ?", memberID).Order("created_at DESC, id DESC").Find(&charges).Error; err != nil {
+		return nil, "failed to fetch fee charges", err
+	}
+	return charges, "fee charges fetched successfully", nil
+}
+
+func (r *gormFeeRepository) GetFeeChargeByID(chargeID string) (*models.FeeCharge, string, error) {
+	var charge models.FeeCharge
+	if err := r.db.Preload("FeeDefinition").Where("id = ?", chargeID).First(&charge).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "fee charge not found", err
+		}
+		return nil, "failed to fetch fee charge", err
+	}
+	return &charge, "fee charge fetched successfully", nil
+}
+
+// PayFeeCharge debits an outstanding fee from the member's savings now that the balance covers it
+func (r *gormFeeRepository) PayFeeCharge(chargeID uint, paidBy uint) (*models.FeeCharge, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, "failed to start transaction", err
+	}
+
+	charge, msg, err := lockFeeCharge(tx, chargeID)
+	if err != nil {
+		tx.Rollback()
+		return nil, msg, err
+	}
+	if charge.Status != models.FeeChargeStatusOutstanding {
+		tx.Rollback()
+		return nil, ErrFeeNotOutstanding.Error(), ErrFeeNotOutstanding
+	}
+
+	posted, msg, err := debitFeeTx(tx, charge, paidBy)
+	if err != nil {
+		tx.Rollback()
+		return nil, msg, err
+	}
+	if !posted {
+		tx.Rollback()
+		return nil, "savings balance does not cover the fee", ErrInsufficientBalance
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, "failed to commit transaction", err
+	}
+	return charge, "fee paid successfully", nil
+}
+
+// WaiveFeeCharge waives a fee, reversing the savings debit if it had been posted. The charge is kept with
+// the admin, time and reason of the waiver.
+func (r *gormFeeRepository) WaiveFeeCharge(chargeID uint, reason string, waivedBy uint) (*models.FeeCharge, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, "failed to start transaction", err
+	}
+
+	charge, msg, err := lockFeeCharge(tx, chargeID)
+	if err != nil {
+		tx.Rollback()
+		return nil, msg, err
+	}
+	if charge.Status == models.FeeChargeStatusWaived {
+		tx.Rollback()
+		return nil, ErrFeeAlreadyWaived.Error(), ErrFeeAlreadyWaived
+	}
+
+	if charge.Status == models.FeeChargeStatusPosted && charge.SavingTransactionID != nil {
+		reversal, msg, err := reverseTransactionTx(tx, *charge.SavingTransactionID, "Fee waived: "+reason, waivedBy)
+		if err != nil {
+			tx.Rollback()
+			return nil, msg, err
+		}
+		charge.WaiverTransactionID = &reversal.ID
+	}
+
+	now := time.Now()
+	charge.Status = models.FeeChargeStatusWaived
+	charge.WaivedBy = &waivedBy
+	charge.WaivedAt = &now
+	charge.WaiverReason = reason
+	if err := tx.Omit("FeeDefinition").Save(charge).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to update fee charge", err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, "failed to commit transaction", err
+	}
+	return charge, "fee waived successfully", nil
+}
+
+func lockFeeCharge(tx *gorm.DB, chargeID uint) (*models.FeeCharge, string, error) {
+	var charge models.FeeCharge
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", chargeID).First(&charge).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "fee charge not found", err
+		}
+		return nil, "failed to fetch fee charge for update", err
+	}
+	if err := tx.Where("id = ?", charge.FeeDefinitionID).First(&charge.FeeDefinition).Error; err != nil {
+		return nil, "failed to fetch fee", err
+	}
+	return &charge, "fee charge fetched successfully for update", nil
+}
+
+// applyFeesTx records a charge for every active fee on the trigger and debits it from the member's savings
+// in the fee's currency. A charge the savings cannot cover is left outstanding rather than failing the event
+// that triggered it. When the context has a period a fee already charged on the source for that period is
+// not charged again.
+func applyFeesTx(tx *gorm.DB, trigger string, feeContext models.FeeContext) ([]models.FeeCharge, string, error) {
+	var fees []models.FeeDefinition
+	if err := tx.Where("trigger = ? AND active = ?", trigger, true).Order("id ASC").Find(&fees).Error; err != nil {
+		return nil, "failed to fetch fees", err
+	}
+
+	charges := make([]models.FeeCharge, 0, len(fees))
+	for _, fee := range fees {
+		amount, err := fee.Calculate(feeContext.BaseAmount)
+		if err != nil {
+			return nil, "failed to calculate fee", err
+		}
+		if amount <= 0 {
+			continue
+		}
+		currency := fee.Currency
+		if fee.CalculationType == models.FeeCalculationPercentage {
+			currency = feeContext.Currency
+		}
+
+		charge := models.FeeCharge{
+			FeeDefinitionID: fee.ID,
+			FeeDefinition:   fee,
+			MemberID:        feeContext.MemberID,
+			Trigger:         trigger,
+			SourceType:      feeContext.SourceType,
+			SourceID:        feeContext.SourceID,
+			Period:          feeContext.Period,
+			Amount:          amount,
+			Currency:        currency,
+			Status:          models.FeeChargeStatusOutstanding,
+			PostedBy:        feeContext.PostedBy,
+		}
+		create := tx.Omit("FeeDefinition")
+		if charge.Period != "" {
+			create = create.Clauses(clause.OnConflict{DoNothing: true})
+		}
+		result := create.Create(&charge)
+		if result.Error != nil {
+			return nil, "failed to record fee charge", result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue // already charged for this period
+		}
+
+		if _, msg, err := debitFeeTx(tx, &charge, feeContext.PostedBy); err != nil {
+			return nil, msg, err
+		}
+		charges = append(charges, charge)
+	}
+	return charges, "fees applied successfully", nil
+}
+
+// debitFeeTx posts an outstanding charge to the member's savings as a fee transaction. It reports false,
+// leaving the charge outstanding, when the member has no savings in the currency or not enough in them.
+func debitFeeTx(tx *gorm.DB, charge *models.FeeCharge, postedBy uint) (bool, string, error) {
+	var savings models.Savings
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("member_id = ? AND currency = ?", charge.MemberID, charge.Currency).First(&savings).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, "member has no savings account in the fee currency", nil
+	}
+	if err != nil {
+		return false, "failed to fetch savings for update", err
+	}
+	if savings.Balance < charge.Amount {
+		return false, ErrInsufficientBalance.Error(), nil
+	}
+
+	debit := models.SavingTransaction{
+		SavingsID:   savings.ID,
+		MemberID:    charge.MemberID,
+		Amount:      -charge.Amount,
+		Currency:    charge.Currency,
+		Description: fmt.Sprintf("%s (fee charge #%d)", charge.FeeDefinition.Name, charge.ID),
+		PostedBy:    &postedBy,
+		Type:        models.TransactionTypeFee,
+		Channel:     models.TransactionChannelInternal,
+	}
+	if err := tx.Create(&debit).Error; err != nil {
+		return false, "failed to create fee transaction", err
+	}
+	if err := tx.Model(&savings).Update("balance", savings.Balance-charge.Amount).Error; err != nil {
+		return false, "failed to update savings balance", err
+	}
+
+	now := time.Now()
+	charge.Status = models.FeeChargeStatusPosted
+	charge.SavingTransactionID = &debit.ID
+	charge.PaidAt = &now
+	if err := tx.Omit("FeeDefinition").Save(charge).Error; err != nil {
+		return false, "failed to update fee charge", err
+	}
+	return true, "fee posted successfully", nil
+}
@@ -0,0 +1,328 @@
+package repository
+
+import (
+	"cooperative-system/internal/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrFixedDepositTransaction = errors.New("transaction funds or pays back a fixed deposit and cannot be reversed on its own")
+
+type gormFixedDepositRepository struct {
+	db *gorm.DB
+}
+
+func NewGormFixedDepositRepository(db *gorm.DB) *gormFixedDepositRepository {
+	return &gormFixedDepositRepository{db: db}
+}
+
+// CreateFixedDeposit opens a fixed deposit and takes its principal out of the member's savings in the same
+// currency, refusing with ErrInsufficientBalance when the savings do not cover it
+func (r *gormFixedDepositRepository) CreateFixedDeposit(deposit *models.FixedDeposit) (*models.FixedDeposit, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, "failed to start transaction", err
+	}
+
+	var savings models.Savings
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("member_id = ? AND currency = ?", deposit.MemberID, deposit.Currency).First(&savings).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		tx.Rollback()
+		return nil, "fixed deposits are paid from " + deposit.Currency + " savings and you have none", ErrInsufficientBalance
+	} else if err != nil {
+		tx.Rollback()
+		return nil, "failed to fetch savings for update", err
+	}
+	if savings.Balance < deposit.Principal {
+		tx.Rollback()
+		return nil, fmt.Sprintf("a fixed deposit of %s %s is more than your savings balance", deposit.Principal, deposit.Currency), ErrInsufficientBalance
+	}
+
+	if err := tx.Create(deposit).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to create fixed deposit", err
+	}
+
+	funding := models.SavingTransaction{
+		SavingsID:      savings.ID,
+		MemberID:       deposit.MemberID,
+		Amount:         -deposit.Principal,
+		Currency:       deposit.Currency,
+		Description:    fmt.Sprintf("Moved to fixed deposit #%d", deposit.ID),
+		Type:           models.TransactionTypeFixedDeposit,
+		Channel:        models.TransactionChannelInternal,
+		FixedDepositID: &deposit.ID,
+	}
+	if err := tx.Create(&funding).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to debit savings", err
+	}
+	if err := tx.Model(&savings).Update("balance", savings.Balance-deposit.Principal).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to update savings balance", err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, "failed to commit transaction", err
+	}
+	return deposit, "fixed deposit created successfully", nil
+}
+
+// GetFixedDepositByID fetches a fixed deposit by its ID with its member
+func (r *gormFixedDepositRepository) GetFixedDepositByID(depositID string) (*models.FixedDeposit, string, error) {
+	var deposit models.FixedDeposit
+	if err := r.db.Preload("Member").Where("id = ?", depositID).First(&deposit).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "fixed deposit not found", err
+		}
+		return nil, "failed to fetch fixed deposit", err
+	}
+	return &deposit, "fixed deposit fetched successfully", nil
+}
+
+// GetFixedDepositsByMemberID fetches all fixed deposits belonging to a member
+func (r *gormFixedDepositRepository) GetFixedDepositsByMemberID(memberID uint) ([]models.FixedDeposit, string, error) {
+	var deposits []models.FixedDeposit
+	if err := r.db.Where("member_id = ?", memberID).Order("start_date DESC").Find(&deposits).Error; err != nil {
+		return nil, "failed to fetch fixed deposits", err
+	}
+	return deposits, "fixed deposits fetched successfully", nil
+}
+
+// GetMaturedFixedDeposits fetches active deposits whose maturity date is on or before asOf
+func (r *gormFixedDepositRepository) GetMaturedFixedDeposits(asOf time.Time) ([]models.FixedDeposit, string, error) {
+	var deposits []models.FixedDeposit
+	err := r.db.Where("status = ? AND maturity_date <= ?", models.FixedDepositStatusActive, asOf).
+		Order("maturity_date ASC").Find(&deposits).Error
+	if err != nil {
+		return nil, "failed to fetch matured fixed deposits", err
+	}
+	return deposits, "matured fixed deposits fetched successfully", nil
+}
+
+// MatureFixedDeposit settles a matured deposit according to its maturity instruction within a transaction
+func (r *gormFixedDepositRepository) MatureFixedDeposit(depositID uint, asOf time.Time) (*models.FixedDeposit, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, "failed to start transaction", err
+	}
+
+	deposit, msg, err := lockFixedDeposit(tx, depositID)
+	if err != nil {
+		tx.Rollback()
+		return nil, msg, err
+	}
+
+	if deposit.Status != models.FixedDepositStatusActive {
+		tx.Rollback()
+		return nil, "fixed deposit is not active", errors.New("fixed deposit is not active")
+	}
+	if deposit.MaturityDate.After(asOf) {
+		tx.Rollback()
+		return nil, "fixed deposit has not matured yet", errors.New("fixed deposit has not matured yet")
+	}
+
+	interest, err := models.CalculateFixedDepositInterest(deposit.Principal, deposit.InterestRate, deposit.StartDate, deposit.MaturityDate)
+	if err != nil {
+		tx.Rollback()
+		return nil, "failed to calculate interest", err
+	}
+	total := deposit.Principal + interest
+
+	deposit.InterestEarned = interest
+	deposit.PayoutAmount = total
+	deposit.ClosedAt = &asOf
+	deposit.Status = models.FixedDepositStatusMatured
+
+	switch deposit.MaturityInstruction {
+	case models.MaturityInstructionRollover:
+		rate, err := models.GetFixedDepositRate(deposit.TermMonths)
+		if err != nil {
+			rate = deposit.InterestRate // keep the old rate if the term is no longer offered
+		}
+		renewed := models.FixedDeposit{
+			MemberID:            deposit.MemberID,
+			Principal:           total,
+			Currency:            deposit.Currency,
+			InterestRate:        rate,
+			TermMonths:          deposit.TermMonths,
+			StartDate:           deposit.MaturityDate,
+			MaturityDate:        models.CalculateMaturityDate(deposit.MaturityDate, deposit.TermMonths),
+			MaturityInstruction: deposit.MaturityInstruction,
+			Status:              models.FixedDepositStatusActive,
+			RolledOverFrom:      &deposit.ID,
+		}
+		if err := tx.Create(&renewed).Error; err != nil {
+			tx.Rollback()
+			return nil, "failed to roll over fixed deposit", err
+		}
+		deposit.Status = models.FixedDepositStatusRolledOver
+		deposit.RolledOverTo = &renewed.ID
+
+	case models.MaturityInstructionMoveToSavings:
+		msg, err := creditSavingsTx(tx, deposit.MemberID, deposit.Currency,
+			models.SavingTransaction{Type: models.TransactionTypeFixedDeposit, Amount: deposit.Principal, Description: fmt.Sprintf("Fixed deposit #%d matured", deposit.ID), FixedDepositID: &deposit.ID},
+			models.SavingTransaction{Type: models.TransactionTypeInterest, Amount: interest, Description: fmt.Sprintf("Interest on fixed deposit #%d", deposit.ID), FixedDepositID: &deposit.ID},
+		)
+		if err != nil {
+			tx.Rollback()
+			return nil, msg, err
+		}
+
+	case models.MaturityInstructionPayout:
+		// the payout itself happens outside the system, the deposit is only closed here
+
+	default:
+		tx.Rollback()
+		return nil, "unknown maturity instruction", errors.New("unknown maturity instruction: " + deposit.MaturityInstruction)
+	}
+
+	if err := tx.Save(deposit).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to update fixed deposit", err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, "failed to commit transaction", err
+	}
+
+	return deposit, "fixed deposit matured successfully", nil
+}
+
+// BreakFixedDeposit closes a deposit before maturity and credits the payout, less the penalty, to savings
+func (r *gormFixedDepositRepository) BreakFixedDeposit(depositID uint, breakDate time.Time) (*models.FixedDeposit, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, "failed to start transaction", err
+	}
+
+	deposit, msg, err := lockFixedDeposit(tx, depositID)
+	if err != nil {
+		tx.Rollback()
+		return nil, msg, err
+	}
+
+	interest, penalty, payout, err := models.CalculateEarlyBreak(deposit, breakDate)
+	if err != nil {
+		tx.Rollback()
+		return nil, err.Error(), err
+	}
+
+	// the penalty actually taken can be less than the full penalty when the payout bottoms out at zero
+	msg, err = creditSavingsTx(tx, deposit.MemberID, deposit.Currency,
+		models.SavingTransaction{Type: models.TransactionTypeFixedDeposit, Amount: deposit.Principal, Description: fmt.Sprintf("Fixed deposit #%d broken before maturity", deposit.ID), FixedDepositID: &deposit.ID},
+		models.SavingTransaction{Type: models.TransactionTypeInterest, Amount: interest, Description: fmt.Sprintf("Interest on fixed deposit #%d", deposit.ID), FixedDepositID: &deposit.ID},
+		models.SavingTransaction{Type: models.TransactionTypeFee, Amount: payout - deposit.Principal - interest, Description: fmt.Sprintf("Early break penalty on fixed deposit #%d", deposit.ID), FixedDepositID: &deposit.ID},
+	)
+	if err != nil {
+		tx.Rollback()
+		return nil, msg, err
+	}
+
+	deposit.InterestEarned = interest
+	deposit.PenaltyAmount = penalty
+	deposit.PayoutAmount = payout
+	deposit.ClosedAt = &breakDate
+	deposit.Status = models.FixedDepositStatusBroken
+
+	if err := tx.Save(deposit).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to update fixed deposit", err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, "failed to commit transaction", err
+	}
+
+	return deposit, "fixed deposit broken successfully", nil
+}
+
+func lockFixedDeposit(tx *gorm.DB, depositID uint) (*models.FixedDeposit, string, error) {
+	var deposit models.FixedDeposit
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", depositID).First(&deposit).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "fixed deposit not found", err
+		}
+		return nil, "failed to fetch fixed deposit for update", err
+	}
+	return &deposit, "fixed deposit fetched successfully for update", nil
+}
+
+// lockOrOpenSavingsTx locks the member's savings account in the given currency for update, opening it first
+// if the member does not hold that currency yet
+func lockOrOpenSavingsTx(tx *gorm.DB, memberID uint, currency string, description string) (*models.Savings, string, error) {
+	var savings models.Savings
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("member_id = ? AND currency = ?", memberID, currency).First(&savings).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		var member models.Member
+		if err := tx.Where("id = ?", memberID).First(&member).Error; err != nil {
+			return nil, "failed to fetch member for savings", err
+		}
+		if description == "" {
+			description = currency + " savings"
+		}
+		savings = models.Savings{
+			UserID:      member.UserID,
+			MemberID:    memberID,
+			Currency:    currency,
+			Description: description,
+		}
+		if err := tx.Create(&savings).Error; err != nil {
+			return nil, "failed to create savings account", err
+		}
+		if msg, err := assignSavingsNumberTx(tx, &savings, &member); err != nil {
+			return nil, msg, err
+		}
+	} else if err != nil {
+		return nil, "failed to fetch savings for update", err
+	}
+	return &savings, "savings locked for update", nil
+}
+
+// creditSavingsTx posts entries to the member's savings account in the given currency and moves the balance
+// by their total, opening the account first if the member does not hold that currency yet. Zero amounts are skipped.
+func creditSavingsTx(tx *gorm.DB, memberID uint, currency string, entries ...models.SavingTransaction) (string, error) {
+	savings, msg, err := lockOrOpenSavingsTx(tx, memberID, currency, "")
+	if err != nil {
+		return msg, err
+	}
+
+	var total models.Money
+	for _, entry := range entries {
+		if entry.Amount == 0 {
+			continue
+		}
+		entry.SavingsID = savings.ID
+		entry.MemberID = memberID
+		entry.Currency = currency
+		entry.Channel = models.TransactionChannelInternal
+		if err := tx.Create(&entry).Error; err != nil {
+			return "failed to create transaction", err
+		}
+		total += entry.Amount
+	}
+
+	if err := tx.Model(savings).Update("balance", savings.Balance+total).Error; err != nil {
+		return "failed to update savings balance", err
+	}
+	return "savings credited successfully", nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"cooperative-system/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormIdempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewGormIdempotencyRepository(db *gorm.DB) *gormIdempotencyRepository {
+	return &gormIdempotencyRepository{db: db}
+}
+
+// ReserveIdempotencyKey claims a key for a request. When the key is already held the existing record is
+// returned with reserved set to false. An expired key is dropped and claimed again.
+func (r *gormIdempotencyRepository) ReserveIdempotencyKey(record *models.IdempotencyKey) (*models.IdempotencyKey, bool, string, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil {
+			return nil, false, "failed to reserve idempotency key", result.Error
+		}
+		if result.RowsAffected == 1 {
+			return record, true, "idempotency key reserved", nil
+		}
+
+		var existing models.IdempotencyKey
+		if err := r.db.Unscoped().Where("user_id = ? AND key = ?", record.UserID, record.Key).First(&existing).Error; err != nil {
+			return nil, false, "failed to fetch idempotency key", err
+		}
+		if existing.ExpiresAt.After(time.Now()) {
+			return &existing, false, "idempotency key already used", nil
+		}
+		if err := r.db.Unscoped().Delete(&existing).Error; err != nil {
+			return nil, false, "failed to drop expired idempotency key", err
+		}
+		record.ID = 0
+	}
+	return nil, false, "failed to reserve idempotency key", gorm.ErrDuplicatedKey
+}
+
+// CompleteIdempotencyKey stores the response sent for a reserved key
+func (r *gormIdempotencyRepository) CompleteIdempotencyKey(id uint, statusCode int, contentType string, response []byte) error {
+	return r.db.Model(&models.IdempotencyKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"completed":    true,
+		"status_code":  statusCode,
+		"content_type": contentType,
+		"response":     response,
+	}).Error
+}
+
+// ReleaseIdempotencyKey forgets a reserved key so the request can be retried, used when it failed on our side
+func (r *gormIdempotencyRepository) ReleaseIdempotencyKey(id uint) error {
+	return r.db.Unscoped().Where("id = ?", id).Delete(&models.IdempotencyKey{}).Error
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"cooperative-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type gormJournalRepository struct {
+	db *gorm.DB
+}
+
+func NewGormJournalRepository(db *gorm.DB) *gormJournalRepository {
+	return &gormJournalRepository{db: db}
+}
+
+// journalPeriod narrows a query to the filter's dates on the given column, the To date is inclusive
+func journalPeriod(query *gorm.DB, column string, filter models.JournalFilter) *gorm.DB {
+	if filter.From != nil {
+		query = query.Where(column+" >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where(column+" < ?", filter.To.AddDate(0, 0, 1))
+	}
+	return query
+}
+
+// GetJournal fetches a member's savings transactions, loan disbursements and loan repayments that pass the
+// filter and merges them into one journal, oldest first
+func (r *gormJournalRepository) GetJournal(memberID uint, filter models.JournalFilter) ([]models.JournalEntry, string, error) {
+	var transactions []models.SavingTransaction
+	query := journalPeriod(r.db.Where("member_id = ?", memberID), "created_at", filter)
+	if len(filter.Types) > 0 {
+		query = query.Where("type IN ?", filter.Types)
+	}
+	if err := query.Order("created_at ASC, id ASC").Find(&transactions).Error; err != nil {
+		return nil, "failed to fetch savings transactions", err
+	}
+
+	var loans []models.Loan
+	query = journalPeriod(r.db.Where("member_id = ? AND disbursed_at IS NOT NULL", memberID), "disbursed_at", filter)
+	if err := query.Order("disbursed_at ASC, id ASC").Find(&loans).Error; err != nil {
+		return nil, "failed to fetch loans", err
+	}
+
+	var repayments []models.LoanRepayment
+	query = journalPeriod(r.db.Where("member_id = ?", memberID), "paid_at", filter)
+	if err := query.Order("paid_at ASC, id ASC").Find(&repayments).Error; err != nil {
+		return nil, "failed to fetch repayments", err
+	}
+
+	return models.BuildJournal(transactions, loans, repayments, filter), "journal fetched successfully", nil
+}
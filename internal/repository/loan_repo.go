@@ -3,11 +3,19 @@ package repository
 import (
 	"cooperative-system/internal/models"
 	"errors"
+	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+var (
+	ErrLoanNotRepayable        = errors.New("loan is not running and cannot take repayments")
+	ErrRepaymentExceedsBalance = errors.New("repayment is more than the outstanding balance")
+	ErrLoanNotDisbursable      = errors.New("only approved loans can be disbursed")
+)
+
 type gormLoanRepository struct {
 	db *gorm.DB
 }
@@ -41,12 +49,8 @@ func (r *gormLoanRepository) CreateLoanWithInitialHistory(loan *models.Loan, loa
 	}
 	loanHistory.LoanID = loan.ID
 
-	// Commit the transaction
-	if err := tx.Commit().Error; err != nil {
-		return nil, nil, "failed to commit transaction", err
-	}
 	// Create the loan history record
-	if err := tx.Create(&loanHistory).Error; err != nil {
+	if err := tx.Create(loanHistory).Error; err != nil {
 		tx.Rollback()
 		return nil, nil, "failed to create loan history", err
 	}
@@ -58,9 +62,11 @@ func (r *gormLoanRepository) CreateLoanWithInitialHistory(loan *models.Loan, loa
 	return loan, loanHistory, "loan created successfully", nil
 }
 
+// GetLoanByID finds a loan by its ID or its account number
 func (r *gormLoanRepository) GetLoanByID(loanID string) (*models.Loan, string, error) {
 	var loan models.Loan
-	if err := r.db.Where("id = ?", loanID).First(&loan).Error; err != nil {
+	column, value := lookupColumn(loanID, "account_number")
+	if err := r.db.Preload("Installments", func(db *gorm.DB) *gorm.DB { return db.Order("number ASC") }).Where(column+" = ?", value).First(&loan).Error; err != nil {
 		return nil, "loan not found", err
 	}
 	return &loan, "success", nil
@@ -96,7 +102,8 @@ func (r *gormLoanRepository) BeginTransaction() *gorm.DB {
 func (h *gormLoanRepository) GetLoanByIDForUpdate(tx *gorm.DB, loanID string) (*models.Loan, string, error) {
 	var loan models.Loan
 	// Add Clauses(clause.Locking{Strength: "UPDATE"}) for row-level lock
-	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", loanID).First(&loan).Error; err != nil {
+	column, value := lookupColumn(loanID, "account_number")
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(column+" = ?", value).First(&loan).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) { // More specific error check
 			return nil, "loan not found", err
 		}
@@ -113,10 +120,16 @@ func (h *gormLoanRepository) GetAllLoansByMemberID(tx *gorm.DB, memberID uint) (
 	return loans, "loans fetched successfully", nil
 }
 
+// UpdateLoan saves a loan, giving it an account number the first time it is approved
 func (h *gormLoanRepository) UpdateLoan(tx *gorm.DB, loan *models.Loan) (*models.Loan, string, error) {
 	if err := tx.Save(loan).Error; err != nil {
 		return nil, "failed to update loan", err
 	}
+	if loan.Status == models.LoanStatusApproved {
+		if msg, err := assignLoanNumberTx(tx, loan); err != nil {
+			return nil, msg, err
+		}
+	}
 	return loan, "loan updated successfully", nil
 }
 
@@ -127,3 +140,144 @@ func (h *gormLoanRepository) CreateLoanHistory(tx *gorm.DB, loanHistory *models.
 	}
 	return nil
 }
+
+// GetRepaymentsByLoanID fetches every repayment made against a loan, oldest first
+func (h *gormLoanRepository) GetRepaymentsByLoanID(loanID uint) ([]models.LoanRepayment, string, error) {
+	var repayments []models.LoanRepayment
+	if err := h.db.Where("loan_id = ?", loanID).Order("paid_at ASC, id ASC").Find(&repayments).Error; err != nil {
+		return nil, "failed to fetch repayments", err
+	}
+	return repayments, "repayments fetched successfully", nil
+}
+
+// RecordRepayment posts a repayment against a running loan and closes the loan once nothing is outstanding
+func (h *gormLoanRepository) RecordRepayment(repayment *models.LoanRepayment) (*models.LoanRepayment, *models.Loan, string, error) {
+	tx := h.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, nil, "failed to start transaction", err
+	}
+
+	loan, msg, err := h.GetLoanByIDForUpdate(tx, fmt.Sprint(repayment.LoanID))
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, msg, err
+	}
+
+	if !loan.IsRepayable() {
+		tx.Rollback()
+		return nil, nil, ErrLoanNotRepayable.Error(), ErrLoanNotRepayable
+	}
+	if err := models.CheckSameCurrency(loan.Currency, repayment.Currency); err != nil {
+		tx.Rollback()
+		return nil, nil, "repayment currency does not match the loan", err
+	}
+
+	var repaid models.Money
+	if err := tx.Model(&models.LoanRepayment{}).Where("loan_id = ?", loan.ID).Select("COALESCE(SUM(amount), 0)").Scan(&repaid).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to total repayments", err
+	}
+
+	outstanding := loan.TotalRepayableAmount - repaid
+	if repayment.Amount > outstanding {
+		tx.Rollback()
+		return nil, nil, fmt.Sprintf("repayment is more than the outstanding balance of %s", outstanding), ErrRepaymentExceedsBalance
+	}
+
+	repayment.MemberID = loan.MemberID
+	if err := tx.Create(repayment).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to record repayment", err
+	}
+
+	if repayment.Amount == outstanding {
+		loan.Status = models.LoanStatusPaid
+		loan.IsActive = false
+		if err := tx.Save(loan).Error; err != nil {
+			tx.Rollback()
+			return nil, nil, "failed to update loan", err
+		}
+		history := models.LoanHistory{
+			LoanID:    loan.ID,
+			Status:    models.LoanStatusPaid,
+			ChangedBy: repayment.PostedBy,
+			Remarks:   "Loan fully repaid",
+		}
+		if err := tx.Create(&history).Error; err != nil {
+			tx.Rollback()
+			return nil, nil, "failed to create loan history", err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, nil, "failed to commit transaction", err
+	}
+
+	return repayment, loan, "repayment recorded successfully", nil
+}
+
+// DisburseLoan marks an approved loan as paid out to the member and charges the disbursement fees
+func (h *gormLoanRepository) DisburseLoan(loanID uint, disbursedBy uint) (*models.Loan, []models.FeeCharge, string, error) {
+	tx := h.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, nil, "failed to start transaction", err
+	}
+
+	loan, msg, err := h.GetLoanByIDForUpdate(tx, fmt.Sprint(loanID))
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, msg, err
+	}
+	if loan.Status != models.LoanStatusApproved {
+		tx.Rollback()
+		return nil, nil, ErrLoanNotDisbursable.Error(), ErrLoanNotDisbursable
+	}
+
+	now := time.Now()
+	loan.Status = models.LoanStatusDisbursed
+	loan.DisbursedAt = &now
+	if err := tx.Save(loan).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to update loan", err
+	}
+	history := models.LoanHistory{
+		LoanID:    loan.ID,
+		Status:    models.LoanStatusDisbursed,
+		ChangedBy: disbursedBy,
+		Remarks:   "Loan disbursed",
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to create loan history", err
+	}
+
+	feeContext := models.FeeContext{
+		MemberID:   loan.MemberID,
+		SourceType: models.FeeSourceLoan,
+		SourceID:   loan.ID,
+		BaseAmount: loan.Amount,
+		Currency:   loan.Currency,
+		PostedBy:   disbursedBy,
+	}
+	charges, msg, err := applyFeesTx(tx, models.FeeTriggerLoanDisbursed, feeContext)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, msg, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, nil, "failed to commit transaction", err
+	}
+
+	return loan, charges, "loan disbursed successfully", nil
+}
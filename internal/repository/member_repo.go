@@ -4,8 +4,11 @@ import (
 	"cooperative-system/internal/models"
 	"errors"
 	"strconv"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // MemberRepository handles data access and transactions
@@ -31,48 +34,305 @@ func (r *gormMemberRepository) CreateMemberWithSavings(member *models.Member, sa
 		return nil, nil, "failed to start transaction", err
 	}
 
-	// Create the member in the database
-	if err := tx.Create(member).Error; err != nil {
+	if msg, err := createMemberTx(tx, member, savings, member.UserID, "Membership application submitted"); err != nil {
 		tx.Rollback()
-		return nil, nil, "failed to create member", err
+		return nil, nil, msg, err
 	}
 
-	// Set the member ID in the savings record
-	savings.MemberID = member.ID
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		return nil, nil, "failed to commit transaction", err
+	}
+
+	return member, savings, "member created successfully", nil
+}
 
-	// Create the savings record
+// createMemberTx creates a member with their base currency savings account, records their first status
+// and charges the membership entry fees, which stay outstanding until the new savings account can cover them
+func createMemberTx(tx *gorm.DB, member *models.Member, savings *models.Savings, createdBy uint, remarks string) (string, error) {
+	if msg, err := checkAssignmentTx(tx, member.BranchID, member.GroupID); err != nil {
+		return msg, err
+	}
+	if err := tx.Create(member).Error; err != nil {
+		return "failed to create member", err
+	}
+
+	savings.MemberID = member.ID
 	if err := tx.Create(savings).Error; err != nil {
+		return "failed to create initial savings", err
+	}
+
+	// members imported as approved are numbered straight away
+	if member.Status == models.MemberStatusActive {
+		if msg, err := assignMemberNumberTx(tx, member); err != nil {
+			return msg, err
+		}
+	}
+
+	application := models.MemberHistory{
+		MemberID:  member.ID,
+		Status:    member.Status,
+		ChangedBy: createdBy,
+		Remarks:   remarks,
+	}
+	if err := tx.Create(&application).Error; err != nil {
+		return "failed to create member history", err
+	}
+
+	feeContext := models.FeeContext{
+		MemberID:   member.ID,
+		SourceType: models.FeeSourceMember,
+		SourceID:   member.ID,
+		Currency:   models.BaseCurrency,
+		PostedBy:   createdBy,
+	}
+	if _, msg, err := applyFeesTx(tx, models.FeeTriggerMemberCreated, feeContext); err != nil {
+		return msg, err
+	}
+	return "member created successfully", nil
+}
+
+// ImportMembers creates the users, members and savings for a batch of import rows in one transaction.
+// Emails that already have a member are skipped, so importing the same file twice creates nothing new.
+// Lines naming a branch or group that does not exist, or a branch outside branchScope, are reported as
+// invalid. A dry run does all the work and rolls it back, so it reports exactly what a real run would do.
+func (r *gormMemberRepository) ImportMembers(rows []models.MemberImportRow, branchScope *uint, importedBy uint, dryRun bool) ([]models.MemberImportResult, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, "failed to start transaction", err
+	}
+
+	results := make([]models.MemberImportResult, len(rows))
+	for i := range rows {
+		row := &rows[i]
+		results[i] = models.MemberImportResult{Line: row.Line, Email: row.Email}
+
+		var user models.User
+		userCreated := false
+		err := tx.Where("LOWER(email) = ?", strings.ToLower(row.Email)).First(&user).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			tx.Rollback()
+			return nil, "failed to fetch user for line " + strconv.Itoa(row.Line), err
+		}
+		if err == nil {
+			var existing models.Member
+			err := tx.Where("user_id = ?", user.ID).First(&existing).Error
+			if err == nil {
+				results[i].Status = models.MemberImportSkipped
+				results[i].MemberID = existing.ID
+				results[i].Errors = []string{"a member already exists for this email"}
+				continue
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				tx.Rollback()
+				return nil, "failed to fetch member for line " + strconv.Itoa(row.Line), err
+			}
+		}
+
+		problems, msg, err := importAssignmentTx(tx, row, branchScope)
+		if err != nil {
+			tx.Rollback()
+			return nil, msg + " for line " + strconv.Itoa(row.Line), err
+		}
+		if len(problems) > 0 {
+			results[i].Status = models.MemberImportInvalid
+			results[i].Errors = problems
+			continue
+		}
+
+		if user.ID == 0 {
+			user = models.User{Email: row.Email, Password: row.PasswordHash, Role: "member"}
+			if err := tx.Create(&user).Error; err != nil {
+				tx.Rollback()
+				return nil, "failed to create user for line " + strconv.Itoa(row.Line), err
+			}
+			userCreated = true
+		}
+
+		member := row.Member
+		member.UserID = user.ID
+		savings := models.Savings{
+			UserID:      user.ID,
+			Currency:    models.BaseCurrency,
+			Description: "Initial savings record",
+		}
+		remarks := "Imported from CSV"
+		if member.Status == models.MemberStatusActive {
+			remarks = "Imported from CSV and approved"
+		}
+		if msg, err := createMemberTx(tx, &member, &savings, importedBy, remarks); err != nil {
+			tx.Rollback()
+			return nil, msg + " for line " + strconv.Itoa(row.Line), err
+		}
+
+		results[i].Status = models.MemberImportCreated
+		if dryRun {
+			results[i].Status = models.MemberImportWouldCreate
+		}
+		results[i].MemberID = member.ID
+		results[i].UserCreated = userCreated
+	}
+
+	if dryRun {
 		tx.Rollback()
-		return nil, nil, "failed to create initial savings", err
+		for i := range results {
+			if results[i].Status == models.MemberImportWouldCreate {
+				results[i].MemberID = 0
+			}
+		}
+		return results, "import checked successfully", nil
 	}
 
-	// Commit the transaction
 	if err := tx.Commit().Error; err != nil {
-		return nil, nil, "failed to commit transaction", err
+		return nil, "failed to commit transaction", err
 	}
+	return results, "members imported successfully", nil
+}
 
-	return member, savings, "member created successfully", nil
+// importAssignmentTx puts the member of an import line in the branch and group the line names and lists
+// what is wrong with them. A branch is looked up by its code and a group by its name among the groups the
+// branch can join.
+func importAssignmentTx(tx *gorm.DB, row *models.MemberImportRow, branchScope *uint) ([]string, string, error) {
+	if row.BranchCode != "" {
+		var branch models.Branch
+		err := tx.Where("code = ?", row.BranchCode).First(&branch).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return []string{"branch " + row.BranchCode + " does not exist"}, "branch not found", nil
+		}
+		if err != nil {
+			return nil, "failed to fetch branch", err
+		}
+		row.Member.BranchID = &branch.ID
+	}
+	if branchScope != nil && (row.Member.BranchID == nil || *row.Member.BranchID != *branchScope) {
+		return []string{"members can only be imported into your own branch"}, "branch is outside the admin's branch", nil
+	}
+
+	if row.GroupName != "" {
+		var groups []models.MemberGroup
+		if err := tx.Where("LOWER(name) = ?", strings.ToLower(row.GroupName)).Order("id ASC").Find(&groups).Error; err != nil {
+			return nil, "failed to fetch member group", err
+		}
+		for _, group := range groups {
+			if group.AcceptsBranch(row.Member.BranchID) {
+				row.Member.GroupID = &group.ID
+				break
+			}
+		}
+		if row.Member.GroupID == nil {
+			return []string{"group " + row.GroupName + " does not exist in the member's branch"}, "member group not found", nil
+		}
+	}
+	return nil, "assignment is valid", nil
 }
 
-func (r *gormMemberRepository) FetchAll(members []models.Member) ([]models.Member, string, error) {
+// memberSortColumns maps each directory sort to its column. The member id breaks ties so every row has a
+// fixed place in the order and cursors never skip or repeat members.
+var memberSortColumns = map[string]string{
+	models.MemberSortJoined: "members.created_at",
+	models.MemberSortName:   "members.name",
+	models.MemberSortID:     "members.id",
+}
 
-	if err := r.db.Preload("User").Find(&members).Error; err != nil {
+// FetchPage returns one page of the member directory with their linked users, filtered, searched and
+// sorted as the query asks, paging by keyset cursor so deep pages stay as fast as the first
+func (r *gormMemberRepository) FetchPage(query models.MemberQuery) (*models.MemberPage, string, error) {
+	filtered := r.db.Model(&models.Member{}).Joins("User")
+	if search := strings.TrimSpace(query.Search); search != "" {
+		pattern := "%" + search + "%"
+		condition := r.db.Where("members.name ILIKE ?", pattern).
+			Or("members.contact_info ILIKE ?", pattern).
+			Or("members.phone ILIKE ?", pattern).
+			Or(`"User"."email" ILIKE ?`, pattern)
+		condition = condition.Or("members.member_number ILIKE ?", pattern)
+		if id, err := strconv.ParseUint(search, 10, 64); err == nil {
+			condition = condition.Or("members.id = ?", id)
+		}
+		filtered = filtered.Where(condition)
+	}
+	if len(query.Statuses) > 0 {
+		filtered = filtered.Where("members.status IN ?", query.Statuses)
+	}
+	if query.JoinedFrom != nil {
+		filtered = filtered.Where("members.created_at >= ?", *query.JoinedFrom)
+	}
+	if query.JoinedTo != nil {
+		filtered = filtered.Where("members.created_at < ?", *query.JoinedTo)
+	}
+	if query.BranchID != nil {
+		filtered = filtered.Where("members.branch_id = ?", *query.BranchID)
+	}
+	if query.GroupID != nil {
+		filtered = filtered.Where("members.group_id = ?", *query.GroupID)
+	}
+
+	var total int64
+	if err := filtered.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, "failed to count members", err
+	}
+
+	// Going back from a cursor reads the previous page in reverse and flips it afterwards
+	backward := query.Cursor != nil && query.Cursor.Backward
+	descending := query.Descending != backward
+	column := memberSortColumns[query.Sort]
+	direction, comparison := "ASC", ">"
+	if descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	page := filtered.Session(&gorm.Session{})
+	if cursor := query.Cursor; cursor != nil {
+		switch cursor.Sort {
+		case models.MemberSortID:
+			page = page.Where("members.id "+comparison+" ?", cursor.ID)
+		case models.MemberSortName:
+			page = page.Where("(members.name "+comparison+" ?) OR (members.name = ? AND members.id "+comparison+" ?)", cursor.Name, cursor.Name, cursor.ID)
+		case models.MemberSortJoined:
+			page = page.Where("(members.created_at "+comparison+" ?) OR (members.created_at = ? AND members.id "+comparison+" ?)", cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+		}
+	}
+	if column != "members.id" {
+		page = page.Order(column + " " + direction)
+	}
+
+	var members []models.Member
+	if err := page.Order("members.id " + direction).Limit(query.Limit + 1).Find(&members).Error; err != nil {
 		return nil, "failed to fetch members", err
 	}
 
-	return members, "members fetched successfully", nil
+	more := len(members) > query.Limit
+	if more {
+		members = members[:query.Limit]
+	}
+	if backward {
+		for i, j := 0, len(members)-1; i < j; i, j = i+1, j-1 {
+			members[i], members[j] = members[j], members[i]
+		}
+	}
 
+	result := models.MemberPage{Members: members, Total: total}
+	if len(members) > 0 {
+		// Coming back from a later page means there is always a next one, and the other way round
+		if (!backward && more) || backward {
+			result.NextCursor = models.NewMemberCursor(&query, &members[len(members)-1], false)
+		}
+		if (backward && more) || (!backward && query.Cursor != nil) {
+			result.PreviousCursor = models.NewMemberCursor(&query, &members[0], true)
+		}
+	}
+	return &result, "members fetched successfully", nil
 }
 
-// In your GORM implementation for MemberRepositoryInterface
+// FetchByID finds a member by their ID or their member number
 func (r *gormMemberRepository) FetchByID(memberID string) (*models.Member, string, error) {
 	var member models.Member
-	// Convert memberID to uint if your primary key is uint
-	id, err := strconv.ParseUint(memberID, 10, 32)
-	if err != nil {
-		return nil, "invalid member ID format", err
-	}
-	if err := r.db.First(&member, id).Error; err != nil {
+	column, value := lookupColumn(memberID, "member_number")
+	if err := r.db.Where(column+" = ?", value).First(&member).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, "member not found", nil
 		}
@@ -112,12 +372,8 @@ func (r *gormMemberRepository) FetchMemberByUserID(userID uint) (*models.Member,
 
 func (r *gormMemberRepository) FetchMemberByID(tx *gorm.DB, memberID string) (*models.Member, string, error) {
 	var member models.Member
-	// Convert memberID to uint if your primary key is uint
-	id, err := strconv.ParseUint(memberID, 10, 32)
-	if err != nil {
-		return nil, "invalid member ID format", err
-	}
-	if err := tx.First(&member, id).Error; err != nil {
+	column, value := lookupColumn(memberID, "member_number")
+	if err := tx.Where(column+" = ?", value).First(&member).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, "member not found", nil
 		}
@@ -125,3 +381,87 @@ func (r *gormMemberRepository) FetchMemberByID(tx *gorm.DB, memberID string) (*m
 	}
 	return &member, "member fetched successfully", nil
 }
+
+// FetchByStatus lists the members in any of the given statuses, oldest application first, limited to one
+// branch when branchID is set
+func (r *gormMemberRepository) FetchByStatus(statuses []string, branchID *uint) ([]models.Member, string, error) {
+	var members []models.Member
+	query := r.db.Preload("User").Where("status IN ?", statuses)
+	if branchID != nil {
+		query = query.Where("branch_id = ?", *branchID)
+	}
+	if err := query.Order("created_at ASC, id ASC").Find(&members).Error; err != nil {
+		return nil, "failed to fetch members", err
+	}
+	return members, "members fetched successfully", nil
+}
+
+// ChangeStatus moves a member to a new status when the current one allows it and records the change
+func (r *gormMemberRepository) ChangeStatus(memberID uint, status string, changedBy uint, remarks string) (*models.Member, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, "failed to start transaction", err
+	}
+
+	var member models.Member
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", memberID).First(&member).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "member not found", err
+		}
+		return nil, "failed to fetch member for update", err
+	}
+
+	if !member.CanMoveTo(status) {
+		tx.Rollback()
+		return nil, "a member who is " + member.Status + " cannot become " + status, models.ErrInvalidMemberTransition
+	}
+
+	now := time.Now()
+	previous := member.Status
+	member.Status = status
+	switch {
+	case status == models.MemberStatusSuspended:
+		member.SuspendedAt = &now
+	case previous == models.MemberStatusSuspended:
+		member.SuspendedAt = nil
+	default:
+		member.ReviewedBy = &changedBy
+		member.ReviewedAt = &now
+		if status == models.MemberStatusActive {
+			member.ApprovedAt = &now
+			if msg, err := assignMemberNumberTx(tx, &member); err != nil {
+				tx.Rollback()
+				return nil, msg, err
+			}
+		} else if status == models.MemberStatusRejected {
+			member.RejectionReason = remarks
+		}
+	}
+	if err := tx.Save(&member).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to update member", err
+	}
+
+	history := models.MemberHistory{
+		MemberID:  member.ID,
+		Status:    status,
+		ChangedBy: changedBy,
+		Remarks:   remarks,
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to create member history", err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, "failed to commit transaction", err
+	}
+
+	return &member, "member status updated successfully", nil
+}
@@ -0,0 +1,313 @@
+package repository
+
+import (
+	"cooperative-system/internal/models"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMigrationBatchExists = errors.New("a migration batch with this reference has already been imported")
+	ErrMigrationConflicts   = errors.New("some members of the batch clash with records already in the system")
+)
+
+type gormMigrationRepository struct {
+	db *gorm.DB
+}
+
+// NewGormMigrationRepository creates a new legacy migration repository instance
+func NewGormMigrationRepository(db *gorm.DB) *gormMigrationRepository {
+	return &gormMigrationRepository{db: db}
+}
+
+// ImportMigration loads a batch from the legacy system in one transaction: the members, their opening savings
+// balances and history, and their running loans with what was paid to date and the schedule left. Members
+// whose email or legacy reference is already taken are listed and nothing is loaded. A dry run rolls
+// everything back, so its reconciliation shows exactly what a real run would post.
+func (r *gormMigrationRepository) ImportMigration(batch *models.MigrationBatch, members []models.MigrationMember, dryRun bool) (*models.MigrationReconciliation, []models.MigrationMemberErrors, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, nil, "failed to start transaction", err
+	}
+
+	var existing int64
+	if err := tx.Model(&models.MigrationBatch{}).Where("reference = ?", batch.Reference).Count(&existing).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to check migration batch", err
+	}
+	if existing > 0 {
+		tx.Rollback()
+		return nil, nil, ErrMigrationBatchExists.Error(), ErrMigrationBatchExists
+	}
+
+	users, conflicts, msg, err := migrationUsersTx(tx, members)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, msg, err
+	}
+	if len(conflicts) > 0 {
+		tx.Rollback()
+		return nil, conflicts, ErrMigrationConflicts.Error(), ErrMigrationConflicts
+	}
+
+	batch.Members = len(members)
+	if err := tx.Create(batch).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to create migration batch", err
+	}
+
+	for i := range members {
+		if msg, err := migrateMemberTx(tx, batch, &members[i], users[i]); err != nil {
+			tx.Rollback()
+			return nil, nil, fmt.Sprintf("%s for legacy member %s", msg, members[i].LegacyReference), err
+		}
+	}
+
+	reconciliation, msg, err := reconcileMigrationTx(tx, batch)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, msg, err
+	}
+
+	if dryRun {
+		tx.Rollback()
+		reconciliation.BatchID = 0
+		return reconciliation, nil, "migration checked successfully", nil
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, nil, "failed to commit transaction", err
+	}
+	return reconciliation, nil, "migration imported successfully", nil
+}
+
+// migrationUsersTx finds or creates the user for each member of a batch and lists the members that clash
+// with what is already in the system
+func migrationUsersTx(tx *gorm.DB, members []models.MigrationMember) ([]models.User, []models.MigrationMemberErrors, string, error) {
+	users := make([]models.User, len(members))
+	var conflicts []models.MigrationMemberErrors
+	for i := range members {
+		migration := &members[i]
+		var problems []string
+
+		var migrated models.Member
+		err := tx.Where("legacy_reference = ?", migration.LegacyReference).First(&migrated).Error
+		if err == nil {
+			problems = append(problems, fmt.Sprintf("legacy reference was already migrated as member %d", migrated.ID))
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, "failed to check legacy reference", err
+		}
+
+		err = tx.Where("LOWER(email) = ?", strings.ToLower(migration.Email)).First(&users[i]).Error
+		if err == nil {
+			var member models.Member
+			err := tx.Where("user_id = ?", users[i].ID).First(&member).Error
+			if err == nil {
+				problems = append(problems, fmt.Sprintf("email already belongs to member %d", member.ID))
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil, "failed to check member", err
+			}
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			users[i] = models.User{Email: migration.Email, Password: migration.PasswordHash, Role: "member"}
+		} else {
+			return nil, nil, "failed to check email", err
+		}
+
+		if len(problems) > 0 {
+			conflicts = append(conflicts, models.MigrationMemberErrors{Index: i, LegacyReference: migration.LegacyReference, Errors: problems})
+		}
+	}
+	return users, conflicts, "users checked successfully", nil
+}
+
+// migrateMemberTx creates one member of a batch as an active member with their savings and loans. Legacy
+// members are not charged the entry fees, they joined long ago.
+func migrateMemberTx(tx *gorm.DB, batch *models.MigrationBatch, migration *models.MigrationMember, user models.User) (string, error) {
+	if user.ID == 0 {
+		if err := tx.Create(&user).Error; err != nil {
+			return "failed to create user", err
+		}
+		migration.UserCreated = true
+	}
+
+	member := migration.Member
+	member.UserID = user.ID
+	member.Status = models.MemberStatusActive
+	member.MigrationBatchID = &batch.ID
+	member.LegacyReference = migration.LegacyReference
+	member.ReviewedBy = &batch.ImportedBy
+	if err := tx.Create(&member).Error; err != nil {
+		return "failed to create member", err
+	}
+	if msg, err := assignMemberNumberTx(tx, &member); err != nil {
+		return msg, err
+	}
+	migration.Member = member
+
+	history := models.MemberHistory{
+		MemberID:  member.ID,
+		Status:    member.Status,
+		ChangedBy: batch.ImportedBy,
+		Remarks:   "Migrated from the legacy system in batch " + batch.Reference,
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return "failed to create member history", err
+	}
+
+	// every member has a base currency account, even when the legacy system had nothing in it
+	hasBaseSavings := false
+	for i := range migration.Savings {
+		savings := &migration.Savings[i]
+		hasBaseSavings = hasBaseSavings || savings.Currency == models.BaseCurrency
+
+		entries := []models.SavingTransaction{{
+			Amount:           savings.OpeningBalance,
+			Description:      "Opening balance from the legacy system",
+			Type:             models.TransactionTypeOpeningBalance,
+			PostedBy:         &batch.ImportedBy,
+			MigrationBatchID: &batch.ID,
+		}}
+		entries[0].CreatedAt = savings.OpeningDate
+		for _, transaction := range savings.Transactions {
+			transaction.PostedBy = &batch.ImportedBy
+			transaction.MigrationBatchID = &batch.ID
+			entries = append(entries, transaction)
+		}
+		if msg, err := creditSavingsTx(tx, member.ID, savings.Currency, entries...); err != nil {
+			return msg, err
+		}
+	}
+	if !hasBaseSavings {
+		if msg, err := creditSavingsTx(tx, member.ID, models.BaseCurrency); err != nil {
+			return msg, err
+		}
+	}
+
+	for i := range migration.Loans {
+		if msg, err := migrateLoanTx(tx, batch, &member, &migration.Loans[i]); err != nil {
+			return msg, err
+		}
+	}
+	return "member migrated successfully", nil
+}
+
+// migrateLoanTx creates a running loan as it stood in the legacy system. What was paid to date is posted as one
+// repayment on the last payment date, so the statement and the outstanding balance carry on from there.
+func migrateLoanTx(tx *gorm.DB, batch *models.MigrationBatch, member *models.Member, migrated *models.MigrationLoan) (string, error) {
+	loan := migrated.Loan
+	loan.MemberID = member.ID
+	loan.MigrationBatchID = &batch.ID
+	loan.ApprovedBy = &batch.ImportedBy
+	if err := tx.Omit("Installments").Create(&loan).Error; err != nil {
+		return "failed to create loan", err
+	}
+	if msg, err := assignLoanNumberTx(tx, &loan); err != nil {
+		return msg, err
+	}
+	migrated.Loan = loan
+
+	history := models.LoanHistory{
+		LoanID:    loan.ID,
+		Status:    loan.Status,
+		ChangedBy: batch.ImportedBy,
+		Remarks:   fmt.Sprintf("Migrated from the legacy system with %s paid to date", migrated.PaidToDate),
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return "failed to create loan history", err
+	}
+
+	if migrated.PaidToDate > 0 {
+		repayment := models.LoanRepayment{
+			LoanID:            loan.ID,
+			MemberID:          member.ID,
+			Amount:            migrated.PaidToDate,
+			Currency:          loan.Currency,
+			PaidAt:            migrated.LastPaymentAt,
+			PostedBy:          batch.ImportedBy,
+			Note:              "Paid to date in the legacy system",
+			Channel:           models.TransactionChannelInternal,
+			ExternalReference: loan.LegacyReference,
+			MigrationBatchID:  &batch.ID,
+		}
+		if err := tx.Create(&repayment).Error; err != nil {
+			return "failed to record paid to date", err
+		}
+	}
+
+	for _, installment := range migrated.Schedule {
+		installment.LoanID = loan.ID
+		if err := tx.Create(&installment).Error; err != nil {
+			return "failed to create loan schedule", err
+		}
+	}
+	return "loan migrated successfully", nil
+}
+
+// reconcileMigrationTx totals what is posted for a batch: its savings entries and what is left to repay
+// on its loans once the paid to date is taken off
+func reconcileMigrationTx(tx *gorm.DB, batch *models.MigrationBatch) (*models.MigrationReconciliation, string, error) {
+	type currencyTotal struct {
+		Currency string
+		Total    models.Money
+	}
+
+	var savings []currencyTotal
+	if err := tx.Model(&models.SavingTransaction{}).Select("currency, COALESCE(SUM(amount), 0) AS total").
+		Where("migration_batch_id = ?", batch.ID).Group("currency").Scan(&savings).Error; err != nil {
+		return nil, "failed to total migrated savings", err
+	}
+	var loans []currencyTotal
+	if err := tx.Model(&models.Loan{}).Select("currency, COALESCE(SUM(total_repayable_amount), 0) AS total").
+		Where("migration_batch_id = ?", batch.ID).Group("currency").Scan(&loans).Error; err != nil {
+		return nil, "failed to total migrated loans", err
+	}
+	var paid []currencyTotal
+	if err := tx.Model(&models.LoanRepayment{}).Select("currency, COALESCE(SUM(amount), 0) AS total").
+		Where("migration_batch_id = ?", batch.ID).Group("currency").Scan(&paid).Error; err != nil {
+		return nil, "failed to total migrated repayments", err
+	}
+
+	postedSavings := map[string]models.Money{}
+	for _, total := range savings {
+		postedSavings[total.Currency] += total.Total
+	}
+	postedLoans := map[string]models.Money{}
+	for _, total := range loans {
+		postedLoans[total.Currency] += total.Total
+	}
+	for _, total := range paid {
+		postedLoans[total.Currency] -= total.Total
+	}
+
+	reconciliation := batch.Reconcile(postedSavings, postedLoans)
+	return &reconciliation, "migration reconciled successfully", nil
+}
+
+// GetMigrationBatches lists every batch loaded from the legacy system, newest first
+func (r *gormMigrationRepository) GetMigrationBatches() ([]models.MigrationBatch, string, error) {
+	var batches []models.MigrationBatch
+	if err := r.db.Order("id DESC").Find(&batches).Error; err != nil {
+		return nil, "failed to fetch migration batches", err
+	}
+	return batches, "migration batches fetched successfully", nil
+}
+
+// ReconcileMigration compares what a batch declared with what is posted for it now
+func (r *gormMigrationRepository) ReconcileMigration(batchID uint) (*models.MigrationReconciliation, string, error) {
+	var batch models.MigrationBatch
+	if err := r.db.Preload("Totals").Where("id = ?", batchID).First(&batch).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "migration batch not found", err
+		}
+		return nil, "failed to fetch migration batch", err
+	}
+	return reconcileMigrationTx(r.db, &batch)
+}
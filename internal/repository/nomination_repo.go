@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"cooperative-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type gormNominationRepository struct {
+	db *gorm.DB
+}
+
+// NewGormNominationRepository creates a new nomination repository instance
+func NewGormNominationRepository(db *gorm.DB) *gormNominationRepository {
+	return &gormNominationRepository{db: db}
+}
+
+// GetNominationsByMemberID fetches a member's current next of kin and beneficiaries
+func (r *gormNominationRepository) GetNominationsByMemberID(memberID uint) ([]models.Nomination, string, error) {
+	var nominations []models.Nomination
+	if err := r.db.Where("member_id = ?", memberID).Order("type ASC, id ASC").Find(&nominations).Error; err != nil {
+		return nil, "failed to fetch nominations", err
+	}
+	return nominations, "nominations fetched successfully", nil
+}
+
+// ReplaceNominations swaps a member's nominations for a new set in one transaction, so the allocations are
+// never seen half changed. The old nominations are soft deleted and kept for the record.
+func (r *gormNominationRepository) ReplaceNominations(memberID uint, nominations []models.Nomination) ([]models.Nomination, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, "failed to start transaction", err
+	}
+
+	if err := tx.Where("member_id = ?", memberID).Delete(&models.Nomination{}).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to remove previous nominations", err
+	}
+
+	for i := range nominations {
+		nominations[i].MemberID = memberID
+		if err := tx.Create(&nominations[i]).Error; err != nil {
+			tx.Rollback()
+			return nil, "failed to save nomination", err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, "failed to commit transaction", err
+	}
+
+	return nominations, "nominations updated successfully", nil
+}
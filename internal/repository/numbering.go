@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"cooperative-system/internal/models"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// numbering is how member, savings and loan numbers are written. It is set once at start-up from the
+// configuration, before any repository is used.
+var numbering = models.DefaultNumbering()
+
+// SetNumbering changes the formats new member, savings and loan numbers are issued in. Numbers already
+// issued keep their old format.
+func SetNumbering(settings models.NumberingSettings) {
+	numbering = settings
+}
+
+// branchCodeTx is the code numbers are issued under for a member of the given branch: the branch's own code,
+// or the configured one for members not assigned to a branch
+func branchCodeTx(tx *gorm.DB, branchID *uint) (string, error) {
+	if branchID == nil {
+		return numbering.Branch, nil
+	}
+	var branch models.Branch
+	if err := tx.Select("id", "code").Where("id = ?", *branchID).First(&branch).Error; err != nil {
+		return "", err
+	}
+	return branch.Code, nil
+}
+
+// nextNumberTx takes the next number of a kind for a member of the given branch, locking its sequence so two
+// approvals at the same time never get the same number
+func nextNumberTx(tx *gorm.DB, kind string, branchID *uint, issuedAt time.Time) (string, error) {
+	branch, err := branchCodeTx(tx, branchID)
+	if err != nil {
+		return "", err
+	}
+	format := numbering.Format(kind)
+	sequence := models.NumberSequence{Kind: kind, Scope: format.Scope(branch, issuedAt)}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&sequence).Error; err != nil {
+		return "", err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("kind = ? AND scope = ?", sequence.Kind, sequence.Scope).First(&sequence).Error; err != nil {
+		return "", err
+	}
+	sequence.Last++
+	if err := tx.Model(&sequence).Update("last", sequence.Last).Error; err != nil {
+		return "", err
+	}
+	return format.Number(branch, issuedAt, sequence.Last), nil
+}
+
+// assignMemberNumberTx gives an approved member their member number, and numbers the savings accounts they
+// opened while their application was pending. Members keep their number when suspended and reactivated.
+func assignMemberNumberTx(tx *gorm.DB, member *models.Member) (string, error) {
+	if member.MemberNumber != nil {
+		return "member already numbered", nil
+	}
+	number, err := nextNumberTx(tx, models.NumberKindMember, member.BranchID, time.Now())
+	if err != nil {
+		return "failed to issue member number", err
+	}
+	if err := tx.Model(member).Update("member_number", number).Error; err != nil {
+		return "failed to save member number", err
+	}
+	member.MemberNumber = &number
+
+	var accounts []models.Savings
+	if err := tx.Where("member_id = ? AND account_number IS NULL", member.ID).Order("id ASC").Find(&accounts).Error; err != nil {
+		return "failed to fetch savings to number", err
+	}
+	for i := range accounts {
+		if msg, err := assignSavingsNumberTx(tx, &accounts[i], member); err != nil {
+			return msg, err
+		}
+	}
+	return "member numbered successfully", nil
+}
+
+// assignSavingsNumberTx gives a savings account its account number once its member has been numbered.
+// The member is looked up when it is not passed in.
+func assignSavingsNumberTx(tx *gorm.DB, savings *models.Savings, member *models.Member) (string, error) {
+	if savings.AccountNumber != nil {
+		return "savings already numbered", nil
+	}
+	if member == nil {
+		member = &models.Member{}
+		if err := tx.Select("id", "member_number", "branch_id").Where("id = ?", savings.MemberID).First(member).Error; err != nil {
+			return "failed to fetch member for savings number", err
+		}
+	}
+	if member.MemberNumber == nil {
+		// accounts of applicants are numbered when the member is approved
+		return "savings left unnumbered", nil
+	}
+
+	number, err := nextNumberTx(tx, models.NumberKindSavings, member.BranchID, time.Now())
+	if err != nil {
+		return "failed to issue savings account number", err
+	}
+	if err := tx.Model(savings).Update("account_number", number).Error; err != nil {
+		return "failed to save savings account number", err
+	}
+	savings.AccountNumber = &number
+	return "savings numbered successfully", nil
+}
+
+// assignLoanNumberTx gives an approved loan its account number
+func assignLoanNumberTx(tx *gorm.DB, loan *models.Loan) (string, error) {
+	if loan.AccountNumber != nil {
+		return "loan already numbered", nil
+	}
+	var member models.Member
+	if err := tx.Select("id", "branch_id").Where("id = ?", loan.MemberID).First(&member).Error; err != nil {
+		return "failed to fetch member for loan number", err
+	}
+	number, err := nextNumberTx(tx, models.NumberKindLoan, member.BranchID, time.Now())
+	if err != nil {
+		return "failed to issue loan account number", err
+	}
+	if err := tx.Model(loan).Update("account_number", number).Error; err != nil {
+		return "failed to save loan account number", err
+	}
+	loan.AccountNumber = &number
+	return "loan numbered successfully", nil
+}
+
+// numberedMemberStatuses and numberedLoanStatuses are the statuses that carry a number
+var (
+	numberedMemberStatuses = []string{models.MemberStatusActive, models.MemberStatusSuspended, models.MemberStatusExited}
+	numberedLoanStatuses   = []string{models.LoanStatusApproved, models.LoanStatusActive, models.LoanStatusDisbursed, models.LoanStatusPaid}
+)
+
+// NumberExistingAccounts issues numbers to the members and loans approved before numbering existed, and to
+// their savings accounts, oldest first. Only rows still without a number are touched, so it is safe to call
+// on every start.
+func NumberExistingAccounts(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var members []models.Member
+		if err := tx.Where("member_number IS NULL AND status IN ?", numberedMemberStatuses).Order("id ASC").Find(&members).Error; err != nil {
+			return err
+		}
+		for i := range members {
+			if msg, err := assignMemberNumberTx(tx, &members[i]); err != nil {
+				return fmt.Errorf("%s for member %d: %w", msg, members[i].ID, err)
+			}
+		}
+
+		var loans []models.Loan
+		if err := tx.Where("account_number IS NULL AND status IN ?", numberedLoanStatuses).Order("id ASC").Find(&loans).Error; err != nil {
+			return err
+		}
+		for i := range loans {
+			if msg, err := assignLoanNumberTx(tx, &loans[i]); err != nil {
+				return fmt.Errorf("%s for loan %d: %w", msg, loans[i].ID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// lookupColumn says how to find a row from an identifier in a URL: a plain number is the internal ID,
+// anything else is a member, savings or loan number
+func lookupColumn(identifier string, numberColumn string) (string, interface{}) {
+	if id, err := strconv.ParseUint(identifier, 10, 32); err == nil {
+		return "id", id
+	}
+	return numberColumn, models.NormalizeNumber(identifier)
+}
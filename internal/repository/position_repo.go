@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"cooperative-system/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type gormPositionRepository struct {
+	db *gorm.DB
+}
+
+// NewGormPositionRepository creates a new member position repository instance
+func NewGormPositionRepository(db *gorm.DB) *gormPositionRepository {
+	return &gormPositionRepository{db: db}
+}
+
+// GetMemberPosition fetches the member of a user with their savings, share holding, running loans with what
+// was repaid on each, and loan applications still waiting for a decision
+func (r *gormPositionRepository) GetMemberPosition(userID uint) (*models.MemberPosition, string, error) {
+	var position models.MemberPosition
+	if err := r.db.Where("user_id = ?", userID).First(&position.Member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "no membership found for this user", err
+		}
+		return nil, "failed to fetch member", err
+	}
+	memberID := position.Member.ID
+
+	if err := r.db.Where("member_id = ? AND closed_at IS NULL", memberID).Order("id ASC").Find(&position.Savings).Error; err != nil {
+		return nil, "failed to fetch savings", err
+	}
+
+	err := r.db.Preload("Certificates", activeCertificates).Where("member_id = ?", memberID).First(&position.ShareAccount).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// no shares bought yet, show an empty holding
+		position.ShareAccount = models.ShareAccount{MemberID: memberID}
+	} else if err != nil {
+		return nil, "failed to fetch share account", err
+	}
+
+	var loans []models.Loan
+	repayable := []string{models.LoanStatusApproved, models.LoanStatusActive, models.LoanStatusDisbursed}
+	if err := r.db.Preload("Installments", func(db *gorm.DB) *gorm.DB { return db.Order("number ASC") }).
+		Where("member_id = ? AND status IN ?", memberID, repayable).Order("id ASC").Find(&loans).Error; err != nil {
+		return nil, "failed to fetch loans", err
+	}
+	for _, loan := range loans {
+		var repaid models.Money
+		if err := r.db.Model(&models.LoanRepayment{}).Where("loan_id = ?", loan.ID).Select("COALESCE(SUM(amount), 0)").Scan(&repaid).Error; err != nil {
+			return nil, "failed to total repayments", err
+		}
+		position.ActiveLoans = append(position.ActiveLoans, models.LoanPosition{Loan: loan, Repaid: repaid})
+	}
+
+	if err := r.db.Where("member_id = ? AND status = ?", memberID, models.LoanStatusPending).Order("id ASC").Find(&position.PendingLoans).Error; err != nil {
+		return nil, "failed to fetch loan applications", err
+	}
+	return &position, "member position fetched successfully", nil
+}
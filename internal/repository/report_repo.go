@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"cooperative-system/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type gormReportRepository struct {
+	db *gorm.DB
+}
+
+func NewGormReportRepository(db *gorm.DB) *gormReportRepository {
+	return &gormReportRepository{db: db}
+}
+
+type currencyTotalRow struct {
+	Currency string
+	Total    models.Money
+}
+
+func totalsByCurrency(rows []currencyTotalRow) map[string]models.Money {
+	totals := make(map[string]models.Money, len(rows))
+	for _, row := range rows {
+		totals[row.Currency] = row.Total
+	}
+	return totals
+}
+
+// GetSavingsTotalsByCurrency sums every savings transaction posted before a cut-off, per currency, over the
+// members of one branch when branchID is set
+func (r *gormReportRepository) GetSavingsTotalsByCurrency(before time.Time, branchID *uint) (map[string]models.Money, string, error) {
+	var rows []currencyTotalRow
+	err := inBranch(r.db.Model(&models.SavingTransaction{}), "saving_transactions", branchID).
+		Select("currency, COALESCE(SUM(amount), 0) AS total").
+		Where("created_at < ?", before).
+		Group("currency").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, "failed to total savings", err
+	}
+	return totalsByCurrency(rows), "savings totals fetched successfully", nil
+}
+
+// GetLoanTotalsByCurrency sums what was still owed at a cut-off on the principal of every loan disbursed
+// before it, per currency, over the members of one branch when branchID is set. The repayments made before
+// the cut-off come off the principal, never below zero, whatever the loan's status is now.
+func (r *gormReportRepository) GetLoanTotalsByCurrency(before time.Time, branchID *uint) (map[string]models.Money, string, error) {
+	repaid := r.db.Model(&models.LoanRepayment{}).
+		Select("loan_id, SUM(amount) AS amount").
+		Where("paid_at < ?", before).
+		Group("loan_id")
+
+	var rows []currencyTotalRow
+	err := inBranch(r.db.Model(&models.Loan{}), "loans", branchID).
+		Select("loans.currency, COALESCE(SUM(GREATEST(loans.amount - COALESCE(repaid.amount, 0), 0)), 0) AS total").
+		Joins("LEFT JOIN (?) AS repaid ON repaid.loan_id = loans.id", repaid).
+		Where("loans.disbursed_at < ?", before).
+		Group("loans.currency").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, "failed to total loans", err
+	}
+	return totalsByCurrency(rows), "loan totals fetched successfully", nil
+}
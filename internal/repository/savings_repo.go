@@ -3,8 +3,17 @@ package repository
 import (
 	"cooperative-system/internal/models"
 	"errors" // Added for gorm.ErrRecordNotFound check
+	"fmt"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTransactionAlreadyReversed = errors.New("transaction has already been reversed")
+	ErrCannotReverseReversal      = errors.New("a reversal cannot itself be reversed")
+	ErrInsufficientBalance        = errors.New("insufficient savings balance")
 )
 
 type gormSavingsRepository struct {
@@ -34,6 +43,10 @@ func (r *gormSavingsRepository) CreateSavingsEntry(savings *models.Savings) (*mo
 		tx.Rollback()
 		return nil, "failed to create savings entry", err
 	}
+	if msg, err := assignSavingsNumberTx(tx, savings, nil); err != nil {
+		tx.Rollback()
+		return nil, msg, err
+	}
 
 	// Commit the transaction
 	if err := tx.Commit().Error; err != nil {
@@ -56,29 +69,46 @@ func (r *gormSavingsRepository) FetchMemberByUserID(userID uint) (*models.Member
 	return &member, "member fetched successfully", nil
 }
 
-// GetOrCreateSavings fetches an existing savings record or creates a new one for a member
-func (r *gormSavingsRepository) GetOrCreateSavings(member *models.Member, userID uint, amount int, description string) (*models.Savings, bool, string, error) {
-	var savings models.Savings
-	err := r.db.Preload("Member").Where("member_id = ?", member.ID).First(&savings).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			// No existing savings found, create a new savings record
-			savings = models.Savings{
-				UserID:       userID,
-				MemberID:     member.ID,
-				Balance:      amount,
-				AmountToSave: amount,
-				Description:  description,
-			}
-			createdSavings, msg, err := r.CreateSavingsEntry(&savings)
-			if err != nil {
-				return nil, true, msg, err
-			}
-			return createdSavings, true, "savings created", err
+// Deposit pays money into the member's savings account in the deposit's currency, opening the account on
+// the first deposit. The account is locked while the balance moves, and the balance and the transaction are
+// written together so one is never posted without the other.
+func (r *gormSavingsRepository) Deposit(memberID uint, deposit *models.SavingTransaction, description string) (*models.Savings, *models.SavingTransaction, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
 		}
-		return nil, false, "failed to find savings record", err
+	}()
+	if err := tx.Error; err != nil {
+		return nil, nil, "failed to start transaction", err
+	}
+
+	savings, msg, err := lockOrOpenSavingsTx(tx, memberID, deposit.Currency, description)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, msg, err
+	}
+
+	deposit.SavingsID = savings.ID
+	deposit.MemberID = memberID
+	if err := tx.Create(deposit).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to create transaction", err
+	}
+
+	savings.Balance += deposit.Amount
+	if description != "" {
+		savings.Description = description
+	}
+	if err := tx.Model(savings).Select("balance", "description").Updates(savings).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to update savings balance", err
 	}
-	return &savings, false, "savings found", err
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, nil, "failed to commit transaction", err
+	}
+	return savings, deposit, "deposit posted successfully", nil
 }
 
 // UpdateSavings updates an existing savings record
@@ -91,23 +121,28 @@ func (r *gormSavingsRepository) UpdateSavings(savings *models.Savings, updatedDa
 
 }
 
-// CreateTransaction creates a new saving transaction record
-func (r *gormSavingsRepository) CreateTransaction(transaction *models.SavingTransaction) (*models.SavingTransaction, string, error) {
-	if err := r.db.Create(transaction).Error; err != nil {
-		return nil, "failed to create transaction", err
+// GetSavingsByMemberID fetches a member's savings account in the given currency
+func (r *gormSavingsRepository) GetSavingsByMemberID(memberID uint, currency string) (*models.Savings, string, error) {
+	var savings models.Savings
+	err := r.db.Where("member_id = ? AND currency = ?", memberID, currency).First(&savings).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "savings not found for the given member ID", err
+		}
+		return nil, "failed to fetch savings by member ID", err
 	}
-	return transaction, "transaction created successfully", nil
+	return &savings, "savings fetched successfully", nil
 }
 
-// GetSavingsByMemberID fetches a savings record by member ID
-func (r *gormSavingsRepository) GetSavingsByMemberID(memberID uint) (*models.Savings, string, error) {
+// GetSavingsByAccountNumber fetches a savings account with its member by the account number
+func (r *gormSavingsRepository) GetSavingsByAccountNumber(accountNumber string) (*models.Savings, string, error) {
 	var savings models.Savings
-	err := r.db.Where("member_id = ?", memberID).First(&savings).Error
+	err := r.db.Preload("Member").Where("account_number = ?", models.NormalizeNumber(accountNumber)).First(&savings).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, "savings not found for the given member ID", err
+			return nil, "savings not found for the given account number", err
 		}
-		return nil, "failed to fetch savings by member ID", err
+		return nil, "failed to fetch savings by account number", err
 	}
 	return &savings, "savings fetched successfully", nil
 }
@@ -134,10 +169,20 @@ func (r *gormSavingsRepository) GetTransactionsByMemberID(memberID uint) ([]mode
 
 }
 
-// GetSavingsByMemberIDTx fetches a savings record by member ID within a transaction
-func (r *gormSavingsRepository) GetSavingsByMemberIDTx(tx *gorm.DB, memberID uint) (*models.Savings, string, error) {
+// GetTransactionsBySavingsID fetches the transactions of a savings account posted before a cut-off, oldest first
+func (r *gormSavingsRepository) GetTransactionsBySavingsID(savingsID uint, before time.Time) ([]models.SavingTransaction, string, error) {
+	var transactions []models.SavingTransaction
+	err := r.db.Where("savings_id = ? AND created_at < ?", savingsID, before).Order("created_at ASC, id ASC").Find(&transactions).Error
+	if err != nil {
+		return nil, "failed to fetch transactions", err
+	}
+	return transactions, "transactions fetched successfully", nil
+}
+
+// GetSavingsByMemberIDTx fetches a member's savings account in the given currency within a transaction
+func (r *gormSavingsRepository) GetSavingsByMemberIDTx(tx *gorm.DB, memberID uint, currency string) (*models.Savings, string, error) {
 	var savings models.Savings
-	err := tx.Where("member_id = ?", memberID).First(&savings).Error
+	err := tx.Where("member_id = ? AND currency = ?", memberID, currency).First(&savings).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, "savings not found for the given member ID", err
@@ -146,3 +191,138 @@ func (r *gormSavingsRepository) GetSavingsByMemberIDTx(tx *gorm.DB, memberID uin
 	}
 	return &savings, "savings fetched successfully", nil
 }
+
+// GetTransactionByID fetches a single saving transaction
+func (r *gormSavingsRepository) GetTransactionByID(transactionID string) (*models.SavingTransaction, string, error) {
+	var transaction models.SavingTransaction
+	if err := r.db.Where("id = ?", transactionID).First(&transaction).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "transaction not found", err
+		}
+		return nil, "failed to fetch transaction", err
+	}
+	return &transaction, "transaction fetched successfully", nil
+}
+
+// ReverseTransaction posts a compensating entry for a transaction and adjusts the savings balance in one transaction
+func (r *gormSavingsRepository) ReverseTransaction(transactionID uint, reason string, postedBy uint) (*models.SavingTransaction, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, "failed to start transaction", err
+	}
+
+	var transferLegs int64
+	if err := tx.Model(&models.SavingTransaction{}).Where("id = ? AND transfer_id IS NOT NULL", transactionID).Count(&transferLegs).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to fetch transaction", err
+	}
+	if transferLegs > 0 {
+		tx.Rollback()
+		return nil, ErrTransferLeg.Error(), ErrTransferLeg
+	}
+
+	var fixedDepositEntries int64
+	if err := tx.Model(&models.SavingTransaction{}).Where("id = ? AND fixed_deposit_id IS NOT NULL", transactionID).Count(&fixedDepositEntries).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to fetch transaction", err
+	}
+	if fixedDepositEntries > 0 {
+		tx.Rollback()
+		return nil, ErrFixedDepositTransaction.Error(), ErrFixedDepositTransaction
+	}
+
+	var shareTransactions int64
+	if err := tx.Model(&models.ShareTransaction{}).Where("saving_transaction_id = ?", transactionID).Count(&shareTransactions).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to fetch share transaction", err
+	}
+	if shareTransactions > 0 {
+		tx.Rollback()
+		return nil, ErrShareTransaction.Error(), ErrShareTransaction
+	}
+
+	var feeCharges int64
+	if err := tx.Model(&models.FeeCharge{}).Where("saving_transaction_id = ?", transactionID).Count(&feeCharges).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to fetch fee charge", err
+	}
+	if feeCharges > 0 {
+		tx.Rollback()
+		return nil, ErrFeeTransaction.Error(), ErrFeeTransaction
+	}
+
+	reversal, msg, err := reverseTransactionTx(tx, transactionID, reason, postedBy)
+	if err != nil {
+		tx.Rollback()
+		return nil, msg, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, "failed to commit transaction", err
+	}
+
+	return reversal, "transaction reversed successfully", nil
+}
+
+func reverseTransactionTx(tx *gorm.DB, transactionID uint, reason string, postedBy uint) (*models.SavingTransaction, string, error) {
+	var original models.SavingTransaction
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", transactionID).First(&original).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "transaction not found", err
+		}
+		return nil, "failed to fetch transaction for update", err
+	}
+
+	if original.ReversalOfID != nil {
+		return nil, ErrCannotReverseReversal.Error(), ErrCannotReverseReversal
+	}
+
+	var existing int64
+	if err := tx.Model(&models.SavingTransaction{}).Where("reversal_of_id = ?", original.ID).Count(&existing).Error; err != nil {
+		return nil, "failed to check for an existing reversal", err
+	}
+	if existing > 0 {
+		return nil, ErrTransactionAlreadyReversed.Error(), ErrTransactionAlreadyReversed
+	}
+
+	var savings models.Savings
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", original.SavingsID).First(&savings).Error; err != nil {
+		return nil, "failed to fetch savings for update", err
+	}
+	if err := models.CheckSameCurrency(savings.Currency, original.Currency); err != nil {
+		return nil, "transaction currency does not match the savings account", err
+	}
+
+	newBalance := savings.Balance - original.Amount
+	if newBalance < 0 {
+		return nil, "reversal would leave the savings balance negative", ErrInsufficientBalance
+	}
+
+	reversal := models.SavingTransaction{
+		SavingsID:      original.SavingsID,
+		MemberID:       original.MemberID,
+		Amount:         -original.Amount,
+		Currency:       original.Currency,
+		Description:    fmt.Sprintf("Reversal of transaction #%d", original.ID),
+		ReversalOfID:   &original.ID,
+		ReversalReason: reason,
+		PostedBy:       &postedBy,
+		TransferID:     original.TransferID,
+		Type:           models.TransactionTypeReversal,
+		Channel:        models.TransactionChannelInternal,
+	}
+	if err := tx.Create(&reversal).Error; err != nil {
+		return nil, "failed to create reversal", err
+	}
+
+	if err := tx.Model(&savings).Update("balance", newBalance).Error; err != nil {
+		return nil, "failed to update savings balance", err
+	}
+
+	return &reversal, "transaction reversed successfully", nil
+}
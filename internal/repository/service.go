@@ -2,18 +2,22 @@ package repository
 
 import (
 	"cooperative-system/internal/models"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type MemberRepository interface {
 	CreateMemberWithSavings(member *models.Member, savings *models.Savings) (*models.Member, *models.Savings, string, error)
-	FetchAll(members []models.Member) ([]models.Member, string, error)
+	FetchPage(query models.MemberQuery) (*models.MemberPage, string, error)
 	FetchByID(memberID string) (*models.Member, string, error)
 	Update(member *models.Member, updateFields interface{}) (*models.Member, string, error)
 	Delete(member *models.Member) (*models.Member, string, error)
 	FetchMemberByUserID(userID uint) (*models.Member, string, error)
 	FetchMemberByID(tx *gorm.DB, memberID string) (*models.Member, string, error)
+	FetchByStatus(statuses []string, branchID *uint) ([]models.Member, string, error)
+	ChangeStatus(memberID uint, status string, changedBy uint, remarks string) (*models.Member, string, error)
+	ImportMembers(rows []models.MemberImportRow, branchScope *uint, importedBy uint, dryRun bool) ([]models.MemberImportResult, string, error)
 }
 
 type LoanRepository interface {
@@ -27,22 +31,149 @@ type LoanRepository interface {
 	GetAllLoansByMemberID(tx *gorm.DB, memberID uint) ([]models.Loan, string, error)
 	UpdateLoan(tx *gorm.DB, loan *models.Loan) (*models.Loan, string, error)
 	CreateLoanHistory(tx *gorm.DB, loanHistory *models.LoanHistory) error
+	GetRepaymentsByLoanID(loanID uint) ([]models.LoanRepayment, string, error)
+	RecordRepayment(repayment *models.LoanRepayment) (*models.LoanRepayment, *models.Loan, string, error)
+	DisburseLoan(loanID uint, disbursedBy uint) (*models.Loan, []models.FeeCharge, string, error)
 }
 
 type UserRepository interface {
 	CreateUser(user *models.User) (*models.User, string, error)
 	FindUserByEmail(email string) (*models.User, string, error)
 	UpdateUser(user *models.User, role string) (*models.User, string, error)
+	FindUserByID(userID uint) (*models.User, string, error)
 }
 
 type SavingsRepository interface {
 	CreateSavingsEntry(savings *models.Savings) (*models.Savings, string, error)
 	FetchMemberByUserID(userID uint) (*models.Member, string, error)
-	GetOrCreateSavings(member *models.Member, userID uint, amount int, description string) (*models.Savings, bool, string, error)
+	Deposit(memberID uint, deposit *models.SavingTransaction, description string) (*models.Savings, *models.SavingTransaction, string, error)
 	UpdateSavings(savings *models.Savings, updateFields interface{}) (*models.Savings, string, error)
-	CreateTransaction(transaction *models.SavingTransaction) (*models.SavingTransaction, string, error)
-	GetSavingsByMemberID(memberID uint) (*models.Savings, string, error)
+	GetSavingsByMemberID(memberID uint, currency string) (*models.Savings, string, error)
+	GetSavingsByAccountNumber(accountNumber string) (*models.Savings, string, error)
 	DeleteSavings(savings *models.Savings) (*models.Savings, string, error)
 	GetTransactionsByMemberID(memberID uint) ([]models.SavingTransaction, string, error)
-	GetSavingsByMemberIDTx(tx *gorm.DB, memberID uint) (*models.Savings, string, error)
+	GetSavingsByMemberIDTx(tx *gorm.DB, memberID uint, currency string) (*models.Savings, string, error)
+	GetTransactionsBySavingsID(savingsID uint, before time.Time) ([]models.SavingTransaction, string, error)
+	GetTransactionByID(transactionID string) (*models.SavingTransaction, string, error)
+	ReverseTransaction(transactionID uint, reason string, postedBy uint) (*models.SavingTransaction, string, error)
+}
+
+type FixedDepositRepository interface {
+	CreateFixedDeposit(deposit *models.FixedDeposit) (*models.FixedDeposit, string, error)
+	GetFixedDepositByID(depositID string) (*models.FixedDeposit, string, error)
+	GetFixedDepositsByMemberID(memberID uint) ([]models.FixedDeposit, string, error)
+	GetMaturedFixedDeposits(asOf time.Time) ([]models.FixedDeposit, string, error)
+	MatureFixedDeposit(depositID uint, asOf time.Time) (*models.FixedDeposit, string, error)
+	BreakFixedDeposit(depositID uint, breakDate time.Time) (*models.FixedDeposit, string, error)
+}
+
+type ContributionRepository interface {
+	CreateMandate(mandate *models.ContributionMandate) (*models.ContributionMandate, string, error)
+	GetActiveMandateByMemberID(memberID uint) (*models.ContributionMandate, string, error)
+	GetMandatesByMemberID(memberID uint) ([]models.ContributionMandate, string, error)
+	GetMandatesForActiveMembers(branchID *uint) ([]models.ContributionMandate, string, error)
+	GetContributionsByMemberIDs(memberIDs []uint, since time.Time) (map[uint][]models.SavingTransaction, string, error)
+}
+
+type ExchangeRateRepository interface {
+	SetExchangeRate(rate *models.ExchangeRate) (*models.ExchangeRate, string, error)
+	GetExchangeRates(currency string) ([]models.ExchangeRate, string, error)
+	GetExchangeRatesOn(date time.Time) (map[string]models.ExchangeRate, string, error)
+}
+
+type ReportRepository interface {
+	GetSavingsTotalsByCurrency(before time.Time, branchID *uint) (map[string]models.Money, string, error)
+	GetLoanTotalsByCurrency(before time.Time, branchID *uint) (map[string]models.Money, string, error)
+}
+
+type TransferRepository interface {
+	CreateTransfer(transfer *models.SavingsTransfer, dailyLimit models.Money) (*models.SavingsTransfer, string, error)
+	GetTransferByID(transferID string) (*models.SavingsTransfer, string, error)
+	ReverseTransfer(transferID uint, reason string, postedBy uint) (*models.SavingsTransfer, string, error)
+}
+
+type IdempotencyRepository interface {
+	ReserveIdempotencyKey(record *models.IdempotencyKey) (*models.IdempotencyKey, bool, string, error)
+	CompleteIdempotencyKey(id uint, statusCode int, contentType string, response []byte) error
+	ReleaseIdempotencyKey(id uint) error
+}
+
+type JournalRepository interface {
+	GetJournal(memberID uint, filter models.JournalFilter) ([]models.JournalEntry, string, error)
+}
+
+type ShareRepository interface {
+	GetShareAccountByMemberID(memberID uint) (*models.ShareAccount, string, error)
+	GetShareAccounts(branchID *uint) ([]models.ShareAccount, string, error)
+	PurchaseShares(memberID uint, shares int64, settings models.ShareSettings, postedBy uint) (*models.ShareAccount, *models.ShareTransaction, string, error)
+	TransferShares(fromMemberID uint, toMemberID uint, shares int64, settings models.ShareSettings, postedBy uint, note string) (*models.ShareAccount, *models.ShareTransaction, string, error)
+	RedeemShares(memberID uint, shares int64, settings models.ShareSettings, postedBy uint, note string) (*models.ShareAccount, *models.ShareTransaction, string, error)
+}
+
+type DistributionRepository interface {
+	CreateDistribution(distribution *models.Distribution) (*models.Distribution, string, error)
+	GetDistributions() ([]models.Distribution, string, error)
+	GetDistributionByID(distributionID string) (*models.Distribution, string, error)
+	ApproveDistribution(distributionID uint, approvedBy uint) (*models.Distribution, string, error)
+	CancelDistribution(distributionID uint) (*models.Distribution, string, error)
+}
+
+type FeeRepository interface {
+	CreateFeeDefinition(fee *models.FeeDefinition) (*models.FeeDefinition, string, error)
+	GetFeeDefinitions() ([]models.FeeDefinition, string, error)
+	GetFeeDefinitionByID(feeID string) (*models.FeeDefinition, string, error)
+	UpdateFeeDefinition(fee *models.FeeDefinition) (*models.FeeDefinition, string, error)
+	ApplyFees(trigger string, feeContext models.FeeContext) ([]models.FeeCharge, string, error)
+	ApplyFeesTx(tx *gorm.DB, trigger string, feeContext models.FeeContext) ([]models.FeeCharge, string, error)
+	GetFeeChargesByMemberID(memberID uint) ([]models.FeeCharge, string, error)
+	GetFeeChargeByID(chargeID string) (*models.FeeCharge, string, error)
+	PayFeeCharge(chargeID uint, paidBy uint) (*models.FeeCharge, string, error)
+	WaiveFeeCharge(chargeID uint, reason string, waivedBy uint) (*models.FeeCharge, string, error)
+}
+
+type SettlementRepository interface {
+	PreviewSettlement(memberID uint, settings models.ShareSettings) (*models.MemberSettlement, string, error)
+	ExitMember(memberID uint, settings models.ShareSettings, exitedBy uint, reason string, channel string, externalReference string) (*models.MemberSettlement, *models.Member, string, error)
+}
+
+type NominationRepository interface {
+	GetNominationsByMemberID(memberID uint) ([]models.Nomination, string, error)
+	ReplaceNominations(memberID uint, nominations []models.Nomination) ([]models.Nomination, string, error)
+}
+
+type DocumentRepository interface {
+	CreateDocument(document *models.Document) (*models.Document, string, error)
+	GetDocumentsByMemberID(memberID uint, loanID *uint) ([]models.Document, string, error)
+	GetDocumentByID(documentID string) (*models.Document, string, error)
+	UpdateDocument(document *models.Document) (*models.Document, string, error)
+}
+
+type MigrationRepository interface {
+	ImportMigration(batch *models.MigrationBatch, members []models.MigrationMember, dryRun bool) (*models.MigrationReconciliation, []models.MigrationMemberErrors, string, error)
+	GetMigrationBatches() ([]models.MigrationBatch, string, error)
+	ReconcileMigration(batchID uint) (*models.MigrationReconciliation, string, error)
+}
+
+type PositionRepository interface {
+	GetMemberPosition(userID uint) (*models.MemberPosition, string, error)
+}
+
+type BranchRepository interface {
+	CreateBranch(branch *models.Branch) (*models.Branch, string, error)
+	GetBranches() ([]models.Branch, string, error)
+	GetBranchByID(branchID string) (*models.Branch, string, error)
+	UpdateBranch(branch *models.Branch) (*models.Branch, string, error)
+	CreateGroup(group *models.MemberGroup) (*models.MemberGroup, string, error)
+	GetGroups(branchID *uint) ([]models.MemberGroup, string, error)
+	GetGroupByID(groupID string) (*models.MemberGroup, string, error)
+	UpdateGroup(group *models.MemberGroup) (*models.MemberGroup, string, error)
+	AssignMember(memberID uint, branchID *uint, groupID *uint, changedBy uint) (*models.Member, string, error)
+	AssignUserBranch(userID uint, branchID *uint) (*models.User, string, error)
+}
+
+type SessionRepository interface {
+	CreateRefreshToken(token *models.RefreshToken) (*models.RefreshToken, string, error)
+	RotateRefreshToken(tokenHash string, next *models.RefreshToken) (*models.RefreshToken, string, error)
+	RevokeRefreshToken(tokenHash string) (string, error)
+	RevokeUserSessions(userID uint, reason string) (int64, string, error)
 }
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"cooperative-system/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid, expired or revoked")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used, every session of this login has been logged out")
+)
+
+type gormSessionRepository struct {
+	db *gorm.DB
+}
+
+// NewGormSessionRepository creates a new session repository instance
+func NewGormSessionRepository(db *gorm.DB) *gormSessionRepository {
+	return &gormSessionRepository{db: db}
+}
+
+// CreateRefreshToken stores the first refresh token of a new login
+func (r *gormSessionRepository) CreateRefreshToken(token *models.RefreshToken) (*models.RefreshToken, string, error) {
+	if err := r.db.Create(token).Error; err != nil {
+		return nil, "failed to create refresh token", err
+	}
+	return token, "refresh token created successfully", nil
+}
+
+// RotateRefreshToken swaps the refresh token with the given hash for next, in the same family and for the
+// same user, which is loaded into next. A token already swapped once is being replayed, so its whole family
+// is revoked and ErrRefreshTokenReused returned.
+func (r *gormSessionRepository) RotateRefreshToken(tokenHash string, next *models.RefreshToken) (*models.RefreshToken, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, "failed to start transaction", err
+	}
+
+	var current models.RefreshToken
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("token_hash = ?", tokenHash).First(&current).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "refresh token not found", ErrRefreshTokenInvalid
+		}
+		return nil, "failed to fetch refresh token", err
+	}
+
+	now := time.Now()
+	if current.RotatedAt != nil && current.RevokedAt == nil {
+		if err := revokeFamilyTx(tx, current.FamilyID, models.RevokedReuse, now); err != nil {
+			tx.Rollback()
+			return nil, "failed to revoke reused refresh token", err
+		}
+		if err := tx.Commit().Error; err != nil {
+			return nil, "failed to commit transaction", err
+		}
+		return nil, "refresh token reused", ErrRefreshTokenReused
+	}
+	if !current.Usable(now) {
+		tx.Rollback()
+		return nil, "refresh token expired or revoked", ErrRefreshTokenInvalid
+	}
+
+	if err := tx.Model(&current).Update("rotated_at", now).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to rotate refresh token", err
+	}
+	next.UserID = current.UserID
+	next.FamilyID = current.FamilyID
+	if err := tx.Create(next).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to create refresh token", err
+	}
+	if err := tx.Where("id = ?", next.UserID).First(&next.User).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to fetch user", err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, "failed to commit transaction", err
+	}
+	return next, "refresh token rotated successfully", nil
+}
+
+// revokeFamilyTx revokes every token of one login that is not already revoked
+func revokeFamilyTx(tx *gorm.DB, familyID string, reason string, now time.Time) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Updates(map[string]interface{}{"revoked_at": now, "revoked_reason": reason}).Error
+}
+
+// RevokeRefreshToken logs out the session the refresh token with the given hash belongs to. An unknown
+// token has nothing to log out, which is not an error.
+func (r *gormSessionRepository) RevokeRefreshToken(tokenHash string) (string, error) {
+	var token models.RefreshToken
+	if err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "no session to log out", nil
+		}
+		return "failed to fetch refresh token", err
+	}
+	if err := revokeFamilyTx(r.db, token.FamilyID, models.RevokedLogout, time.Now()); err != nil {
+		return "failed to revoke refresh token", err
+	}
+	return "logged out successfully", nil
+}
+
+// RevokeUserSessions logs a user out everywhere: every refresh token is revoked and every access token
+// already issued is refused from now on. It returns how many sessions were still open.
+func (r *gormSessionRepository) RevokeUserSessions(userID uint, reason string) (int64, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return 0, "failed to start transaction", err
+	}
+
+	now := time.Now()
+	user := tx.Model(&models.User{}).Where("id = ?", userID).Update("tokens_revoked_at", now)
+	if user.Error != nil {
+		tx.Rollback()
+		return 0, "failed to revoke access tokens", user.Error
+	}
+	if user.RowsAffected == 0 {
+		tx.Rollback()
+		return 0, "user not found", gorm.ErrRecordNotFound
+	}
+
+	var sessions int64
+	if err := tx.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND rotated_at IS NULL AND expires_at > ?", userID, now).
+		Count(&sessions).Error; err != nil {
+		tx.Rollback()
+		return 0, "failed to count sessions", err
+	}
+	if err := tx.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{"revoked_at": now, "revoked_reason": reason}).Error; err != nil {
+		tx.Rollback()
+		return 0, "failed to revoke refresh tokens", err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return 0, "failed to commit transaction", err
+	}
+	return sessions, "sessions revoked successfully", nil
+}
@@ -0,0 +1,360 @@
+package repository
+
+import (
+	"cooperative-system/internal/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrMemberCannotExit    = errors.New("only active or suspended members can exit")
+	ErrActiveFixedDeposits = errors.New("member has running fixed deposits, break them or let them mature first")
+	ErrSettlementShortfall = errors.New("member owes more than they hold and must pay the difference before exiting")
+)
+
+type gormSettlementRepository struct {
+	db *gorm.DB
+}
+
+// NewGormSettlementRepository creates a new exit settlement repository instance
+func NewGormSettlementRepository(db *gorm.DB) *gormSettlementRepository {
+	return &gormSettlementRepository{db: db}
+}
+
+// exitPosition is everything a member holds and owes when they exit, locked for update
+type exitPosition struct {
+	member      models.Member
+	account     *models.ShareAccount
+	savings     []models.Savings
+	loans       []models.Loan
+	outstanding map[uint]models.Money // what is left to repay on each loan
+	charges     []models.FeeCharge
+	lines       []models.MemberSettlementLine
+}
+
+// PreviewSettlement works out what a member would be paid if they exited now. Once they have exited it
+// returns the settlement that was posted.
+func (r *gormSettlementRepository) PreviewSettlement(memberID uint, settings models.ShareSettings) (*models.MemberSettlement, string, error) {
+	var posted models.MemberSettlement
+	err := r.db.Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).Where("member_id = ?", memberID).First(&posted).Error
+	if err == nil {
+		return &posted, "member has already exited", nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "failed to fetch settlement", err
+	}
+
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, "failed to start transaction", err
+	}
+	// Nothing is written, the transaction only gives a consistent view of the member's accounts
+	defer tx.Rollback()
+
+	position, msg, err := lockExitPositionTx(tx, memberID, settings)
+	if err != nil {
+		return nil, msg, err
+	}
+
+	return &models.MemberSettlement{
+		MemberID:       memberID,
+		SharesRedeemed: position.account.Shares,
+		SharePrice:     settings.Price,
+		Lines:          position.lines,
+	}, "settlement calculated successfully", nil
+}
+
+// ExitMember settles a member's accounts and marks them exited in one transaction. Shares are redeemed into
+// savings, outstanding fees and loans are offset against savings, what is left is paid out through the given
+// channel and every account is closed. A member who would still owe the cooperative in any currency cannot exit.
+func (r *gormSettlementRepository) ExitMember(memberID uint, settings models.ShareSettings, exitedBy uint, reason string, channel string, externalReference string) (*models.MemberSettlement, *models.Member, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, nil, "failed to start transaction", err
+	}
+
+	position, msg, err := lockExitPositionTx(tx, memberID, settings)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, msg, err
+	}
+	for _, line := range position.lines {
+		if line.Payout < 0 {
+			tx.Rollback()
+			return nil, nil, fmt.Sprintf("member is short by %s %s", line.Shortfall(), line.Currency), ErrSettlementShortfall
+		}
+	}
+
+	now := time.Now()
+	sharesRedeemed := position.account.Shares
+	if sharesRedeemed > 0 {
+		if _, msg, err := redeemSharesTx(tx, position.account, sharesRedeemed, settings, exitedBy, "Redeemed on exit"); err != nil {
+			tx.Rollback()
+			return nil, nil, msg, err
+		}
+	}
+	if err := tx.Model(position.account).Update("closed_at", now).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to close share account", err
+	}
+
+	for i := range position.charges {
+		posted, msg, err := debitFeeTx(tx, &position.charges[i], exitedBy)
+		if err != nil {
+			tx.Rollback()
+			return nil, nil, msg, err
+		}
+		if !posted {
+			tx.Rollback()
+			return nil, nil, msg, ErrSettlementShortfall
+		}
+	}
+
+	for i := range position.loans {
+		if msg, err := offsetLoanTx(tx, &position.loans[i], position.outstanding[position.loans[i].ID], exitedBy); err != nil {
+			tx.Rollback()
+			return nil, nil, msg, err
+		}
+	}
+
+	if msg, err := rejectPendingLoansTx(tx, memberID, exitedBy); err != nil {
+		tx.Rollback()
+		return nil, nil, msg, err
+	}
+
+	if msg, err := payOutSavingsTx(tx, memberID, exitedBy, channel, externalReference); err != nil {
+		tx.Rollback()
+		return nil, nil, msg, err
+	}
+
+	err = tx.Model(&models.ContributionMandate{}).
+		Where("member_id = ? AND status = ?", memberID, models.MandateStatusActive).
+		Updates(map[string]interface{}{
+			"status":   models.MandateStatusEnded,
+			"end_date": now,
+		}).Error
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to end contribution mandate", err
+	}
+
+	member := position.member
+	member.Status = models.MemberStatusExited
+	member.ExitedAt = &now
+	if err := tx.Save(&member).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to update member", err
+	}
+	history := models.MemberHistory{
+		MemberID:  member.ID,
+		Status:    models.MemberStatusExited,
+		ChangedBy: exitedBy,
+		Remarks:   reason,
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to create member history", err
+	}
+
+	settlement := models.MemberSettlement{
+		MemberID:          memberID,
+		SharesRedeemed:    sharesRedeemed,
+		SharePrice:        settings.Price,
+		Channel:           channel,
+		ExternalReference: externalReference,
+		Reason:            reason,
+		SettledBy:         exitedBy,
+		SettledAt:         &now,
+		Lines:             position.lines,
+	}
+	if err := tx.Create(&settlement).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to record settlement", err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, nil, "failed to commit transaction", err
+	}
+
+	return &settlement, &member, "member exited successfully", nil
+}
+
+// lockExitPositionTx locks the member and everything they hold or owe, and nets it into settlement lines
+func lockExitPositionTx(tx *gorm.DB, memberID uint, settings models.ShareSettings) (*exitPosition, string, error) {
+	position := exitPosition{outstanding: map[uint]models.Money{}}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", memberID).First(&position.member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "member not found", err
+		}
+		return nil, "failed to fetch member for update", err
+	}
+	if !position.member.CanExit() {
+		return nil, "a member who is " + position.member.Status + " cannot exit", ErrMemberCannotExit
+	}
+
+	var fixedDeposits int64
+	if err := tx.Model(&models.FixedDeposit{}).Where("member_id = ? AND status = ?", memberID, models.FixedDepositStatusActive).Count(&fixedDeposits).Error; err != nil {
+		return nil, "failed to fetch fixed deposits", err
+	}
+	if fixedDeposits > 0 {
+		return nil, ErrActiveFixedDeposits.Error(), ErrActiveFixedDeposits
+	}
+
+	accounts, err := lockShareAccountsTx(tx, memberID)
+	if err != nil {
+		return nil, "failed to fetch share account for update", err
+	}
+	position.account = accounts[memberID]
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("member_id = ?", memberID).Order("id ASC").Find(&position.savings).Error; err != nil {
+		return nil, "failed to fetch savings for update", err
+	}
+
+	repayable := []string{models.LoanStatusApproved, models.LoanStatusActive, models.LoanStatusDisbursed}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("member_id = ? AND status IN ?", memberID, repayable).Order("id ASC").Find(&position.loans).Error; err != nil {
+		return nil, "failed to fetch loans for update", err
+	}
+	loansOutstanding := map[string]models.Money{}
+	for _, loan := range position.loans {
+		var repaid models.Money
+		if err := tx.Model(&models.LoanRepayment{}).Where("loan_id = ?", loan.ID).Select("COALESCE(SUM(amount), 0)").Scan(&repaid).Error; err != nil {
+			return nil, "failed to total repayments", err
+		}
+		position.outstanding[loan.ID] = loan.TotalRepayableAmount - repaid
+		loansOutstanding[loan.Currency] += loan.TotalRepayableAmount - repaid
+	}
+
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("FeeDefinition").
+		Where("member_id = ? AND status = ?", memberID, models.FeeChargeStatusOutstanding).Order("id ASC").Find(&position.charges).Error
+	if err != nil {
+		return nil, "failed to fetch fee charges for update", err
+	}
+	feesOutstanding := map[string]models.Money{}
+	for _, charge := range position.charges {
+		feesOutstanding[charge.Currency] += charge.Amount
+	}
+
+	position.lines = models.CalculateSettlement(position.savings, position.account.Shares, settings, loansOutstanding, feesOutstanding)
+	return &position, "exit position calculated successfully", nil
+}
+
+// offsetLoanTx repays what is left on a loan from the member's savings in the loan currency and closes it
+func offsetLoanTx(tx *gorm.DB, loan *models.Loan, outstanding models.Money, postedBy uint) (string, error) {
+	if outstanding > 0 {
+		msg, err := creditSavingsTx(tx, loan.MemberID, loan.Currency, models.SavingTransaction{
+			Type:        models.TransactionTypeLoanOffset,
+			Amount:      -outstanding,
+			Description: fmt.Sprintf("Offset against loan #%d on exit", loan.ID),
+			PostedBy:    &postedBy,
+		})
+		if err != nil {
+			return msg, err
+		}
+
+		repayment := models.LoanRepayment{
+			LoanID:   loan.ID,
+			MemberID: loan.MemberID,
+			Amount:   outstanding,
+			Currency: loan.Currency,
+			PaidAt:   time.Now(),
+			PostedBy: postedBy,
+			Note:     "Offset from savings on exit",
+			Channel:  models.TransactionChannelInternal,
+		}
+		if err := tx.Create(&repayment).Error; err != nil {
+			return "failed to record repayment", err
+		}
+	}
+
+	loan.Status = models.LoanStatusPaid
+	loan.IsActive = false
+	if err := tx.Omit("Member").Save(loan).Error; err != nil {
+		return "failed to update loan", err
+	}
+	history := models.LoanHistory{
+		LoanID:    loan.ID,
+		Status:    models.LoanStatusPaid,
+		ChangedBy: postedBy,
+		Remarks:   "Loan settled from savings on exit",
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return "failed to create loan history", err
+	}
+	return "loan offset successfully", nil
+}
+
+// rejectPendingLoansTx rejects the loan applications an exiting member still has waiting
+func rejectPendingLoansTx(tx *gorm.DB, memberID uint, rejectedBy uint) (string, error) {
+	var pending []models.Loan
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("member_id = ? AND status = ?", memberID, models.LoanStatusPending).Find(&pending).Error; err != nil {
+		return "failed to fetch pending loans", err
+	}
+
+	now := time.Now()
+	for i := range pending {
+		loan := &pending[i]
+		loan.Status = models.LoanStatusRejected
+		loan.RejectedAt = &now
+		loan.RejectionReason = "Member exited"
+		if err := tx.Omit("Member").Save(loan).Error; err != nil {
+			return "failed to update loan", err
+		}
+		history := models.LoanHistory{
+			LoanID:    loan.ID,
+			Status:    models.LoanStatusRejected,
+			ChangedBy: rejectedBy,
+			Remarks:   "Member exited",
+		}
+		if err := tx.Create(&history).Error; err != nil {
+			return "failed to create loan history", err
+		}
+	}
+	return "pending loans rejected successfully", nil
+}
+
+// payOutSavingsTx withdraws what is left in each of the member's savings accounts and closes them
+func payOutSavingsTx(tx *gorm.DB, memberID uint, postedBy uint, channel string, externalReference string) (string, error) {
+	var accounts []models.Savings
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("member_id = ?", memberID).Order("id ASC").Find(&accounts).Error; err != nil {
+		return "failed to fetch savings for update", err
+	}
+
+	now := time.Now()
+	for i := range accounts {
+		savings := &accounts[i]
+		if savings.Balance > 0 {
+			payout := models.SavingTransaction{
+				SavingsID:         savings.ID,
+				MemberID:          memberID,
+				Amount:            -savings.Balance,
+				Currency:          savings.Currency,
+				Description:       "Exit settlement payout",
+				PostedBy:          &postedBy,
+				Type:              models.TransactionTypeWithdrawal,
+				Channel:           channel,
+				ExternalReference: externalReference,
+			}
+			if err := tx.Create(&payout).Error; err != nil {
+				return "failed to create payout transaction", err
+			}
+		}
+		if err := tx.Model(savings).Updates(map[string]interface{}{"balance": 0, "closed_at": now}).Error; err != nil {
+			return "failed to close savings account", err
+		}
+	}
+	return "savings paid out successfully", nil
+}
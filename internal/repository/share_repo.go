@@ -0,0 +1,418 @@
+package repository
+
+import (
+	"cooperative-system/internal/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrShareTransaction = errors.New("transaction paid for or redeemed shares and cannot be reversed on its own")
+
+type gormShareRepository struct {
+	db *gorm.DB
+}
+
+func NewGormShareRepository(db *gorm.DB) *gormShareRepository {
+	return &gormShareRepository{db: db}
+}
+
+func activeCertificates(db *gorm.DB) *gorm.DB {
+	return db.Where("status = ?", models.ShareCertificateStatusActive).Order("id ASC")
+}
+
+// GetShareAccountByMemberID fetches a member's share account with its active certificates
+func (r *gormShareRepository) GetShareAccountByMemberID(memberID uint) (*models.ShareAccount, string, error) {
+	var account models.ShareAccount
+	err := r.db.Preload("Certificates", activeCertificates).Where("member_id = ?", memberID).First(&account).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "share account not found for the given member ID", err
+		}
+		return nil, "failed to fetch share account", err
+	}
+	return &account, "share account fetched successfully", nil
+}
+
+// GetShareAccounts fetches every share account with its member and active certificates, for the share register,
+// limited to the members of one branch when branchID is set
+func (r *gormShareRepository) GetShareAccounts(branchID *uint) ([]models.ShareAccount, string, error) {
+	var accounts []models.ShareAccount
+	query := inBranch(r.db.Preload("Member").Preload("Certificates", activeCertificates), "share_accounts", branchID)
+	err := query.Order("member_id ASC").Find(&accounts).Error
+	if err != nil {
+		return nil, "failed to fetch share accounts", err
+	}
+	return accounts, "share accounts fetched successfully", nil
+}
+
+// PurchaseShares buys shares at the configured price, paid from the member's base currency savings.
+// A certificate is issued for the shares bought.
+func (r *gormShareRepository) PurchaseShares(memberID uint, shares int64, settings models.ShareSettings, postedBy uint) (*models.ShareAccount, *models.ShareTransaction, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, nil, "failed to start transaction", err
+	}
+
+	accounts, err := lockShareAccountsTx(tx, memberID)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to fetch share account for update", err
+	}
+	account := accounts[memberID]
+
+	if err := settings.CheckHolding(account.Shares+shares, false); err != nil {
+		tx.Rollback()
+		return nil, nil, fmt.Sprintf("a holding of %d shares is below the minimum of %d", account.Shares+shares, settings.MinimumHolding), err
+	}
+
+	var savings models.Savings
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("member_id = ? AND currency = ?", memberID, models.BaseCurrency).First(&savings).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		tx.Rollback()
+		return nil, nil, "shares are paid from " + models.BaseCurrency + " savings and you have none", ErrInsufficientBalance
+	} else if err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to fetch savings for update", err
+	}
+
+	amount := settings.Value(shares)
+	if savings.Balance < amount {
+		tx.Rollback()
+		return nil, nil, fmt.Sprintf("%d shares cost %s %s, more than your savings balance", shares, amount, models.BaseCurrency), ErrInsufficientBalance
+	}
+
+	certificate, err := issueCertificateTx(tx, account, shares)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to issue share certificate", err
+	}
+
+	transaction := models.ShareTransaction{
+		ShareAccountID: account.ID,
+		MemberID:       memberID,
+		Type:           models.ShareTransactionPurchase,
+		Shares:         shares,
+		PricePerShare:  settings.Price,
+		Amount:         amount,
+		CertificateID:  &certificate.ID,
+		Certificate:    certificate,
+		PostedBy:       postedBy,
+	}
+	if err := tx.Omit("Certificate").Create(&transaction).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to record share purchase", err
+	}
+
+	payment := models.SavingTransaction{
+		SavingsID:   savings.ID,
+		MemberID:    memberID,
+		Amount:      -amount,
+		Currency:    models.BaseCurrency,
+		Description: fmt.Sprintf("Purchase of %d shares, share transaction #%d", shares, transaction.ID),
+		Type:        models.TransactionTypeShares,
+		Channel:     models.TransactionChannelInternal,
+	}
+	if err := tx.Create(&payment).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to debit savings", err
+	}
+	if err := tx.Model(&savings).Update("balance", savings.Balance-amount).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to update savings balance", err
+	}
+	transaction.SavingTransactionID = &payment.ID
+	if err := tx.Model(&transaction).Update("saving_transaction_id", payment.ID).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to link share purchase to its payment", err
+	}
+
+	if err := tx.Model(account).Update("shares", account.Shares+shares).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to update share account", err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, nil, "failed to commit transaction", err
+	}
+
+	updated, msg, err := r.GetShareAccountByMemberID(memberID)
+	if err != nil {
+		return nil, nil, msg, err
+	}
+	return updated, &transaction, "shares purchased successfully", nil
+}
+
+// TransferShares moves shares between two members. The sender must keep at least the minimum holding,
+// the sender's certificates are replaced by one for what is left and the recipient gets a new certificate.
+func (r *gormShareRepository) TransferShares(fromMemberID uint, toMemberID uint, shares int64, settings models.ShareSettings, postedBy uint, note string) (*models.ShareAccount, *models.ShareTransaction, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, nil, "failed to start transaction", err
+	}
+
+	accounts, err := lockShareAccountsTx(tx, fromMemberID, toMemberID)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to fetch share accounts for update", err
+	}
+	sender, recipient := accounts[fromMemberID], accounts[toMemberID]
+
+	if sender.Shares < shares {
+		tx.Rollback()
+		return nil, nil, fmt.Sprintf("you hold %d shares", sender.Shares), models.ErrInsufficientShares
+	}
+	if err := settings.CheckHolding(sender.Shares-shares, false); err != nil {
+		tx.Rollback()
+		return nil, nil, fmt.Sprintf("you must keep at least %d shares", settings.MinimumHolding), err
+	}
+
+	senderCertificate, err := reissueCertificatesTx(tx, sender, sender.Shares-shares)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to reissue share certificates", err
+	}
+	recipientCertificate, err := issueCertificateTx(tx, recipient, shares)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to issue share certificate", err
+	}
+
+	amount := settings.Value(shares)
+	out := models.ShareTransaction{
+		ShareAccountID:       sender.ID,
+		MemberID:             fromMemberID,
+		Type:                 models.ShareTransactionTransferOut,
+		Shares:               -shares,
+		PricePerShare:        settings.Price,
+		Amount:               amount,
+		CounterpartyMemberID: &toMemberID,
+		Certificate:          senderCertificate,
+		PostedBy:             postedBy,
+		Note:                 note,
+	}
+	if senderCertificate != nil {
+		out.CertificateID = &senderCertificate.ID
+	}
+	in := models.ShareTransaction{
+		ShareAccountID:       recipient.ID,
+		MemberID:             toMemberID,
+		Type:                 models.ShareTransactionTransferIn,
+		Shares:               shares,
+		PricePerShare:        settings.Price,
+		Amount:               amount,
+		CounterpartyMemberID: &fromMemberID,
+		CertificateID:        &recipientCertificate.ID,
+		PostedBy:             postedBy,
+		Note:                 note,
+	}
+	for _, transaction := range []*models.ShareTransaction{&out, &in} {
+		if err := tx.Omit("Certificate").Create(transaction).Error; err != nil {
+			tx.Rollback()
+			return nil, nil, "failed to record share transfer", err
+		}
+	}
+
+	if err := tx.Model(sender).Update("shares", sender.Shares-shares).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to update sender share account", err
+	}
+	if err := tx.Model(recipient).Update("shares", recipient.Shares+shares).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to update recipient share account", err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, nil, "failed to commit transaction", err
+	}
+
+	updated, msg, err := r.GetShareAccountByMemberID(fromMemberID)
+	if err != nil {
+		return nil, nil, msg, err
+	}
+	return updated, &out, "shares transferred successfully", nil
+}
+
+// RedeemShares buys shares back from a member at the configured price and credits their base currency
+// savings. What is left must be zero or at least the minimum holding.
+func (r *gormShareRepository) RedeemShares(memberID uint, shares int64, settings models.ShareSettings, postedBy uint, note string) (*models.ShareAccount, *models.ShareTransaction, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, nil, "failed to start transaction", err
+	}
+
+	accounts, err := lockShareAccountsTx(tx, memberID)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, "failed to fetch share account for update", err
+	}
+	account := accounts[memberID]
+
+	if account.Shares < shares {
+		tx.Rollback()
+		return nil, nil, fmt.Sprintf("member holds %d shares", account.Shares), models.ErrInsufficientShares
+	}
+	if err := settings.CheckHolding(account.Shares-shares, true); err != nil {
+		tx.Rollback()
+		return nil, nil, fmt.Sprintf("redeem all %d shares or keep at least %d", account.Shares, settings.MinimumHolding), err
+	}
+
+	transaction, msg, err := redeemSharesTx(tx, account, shares, settings, postedBy, note)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, msg, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, nil, "failed to commit transaction", err
+	}
+
+	updated, msg, err := r.GetShareAccountByMemberID(memberID)
+	if err != nil {
+		return nil, nil, msg, err
+	}
+	return updated, transaction, "shares redeemed successfully", nil
+}
+
+// redeemSharesTx buys shares back from a locked account at the configured price, reissuing the certificates
+// for what is left and crediting the member's base currency savings
+func redeemSharesTx(tx *gorm.DB, account *models.ShareAccount, shares int64, settings models.ShareSettings, postedBy uint, note string) (*models.ShareTransaction, string, error) {
+	certificate, err := reissueCertificatesTx(tx, account, account.Shares-shares)
+	if err != nil {
+		return nil, "failed to reissue share certificates", err
+	}
+
+	amount := settings.Value(shares)
+	transaction := models.ShareTransaction{
+		ShareAccountID: account.ID,
+		MemberID:       account.MemberID,
+		Type:           models.ShareTransactionRedemption,
+		Shares:         -shares,
+		PricePerShare:  settings.Price,
+		Amount:         amount,
+		Certificate:    certificate,
+		PostedBy:       postedBy,
+		Note:           note,
+	}
+	if certificate != nil {
+		transaction.CertificateID = &certificate.ID
+	}
+	if err := tx.Omit("Certificate").Create(&transaction).Error; err != nil {
+		return nil, "failed to record share redemption", err
+	}
+
+	savings, msg, err := lockOrOpenSavingsTx(tx, account.MemberID, models.BaseCurrency, "")
+	if err != nil {
+		return nil, msg, err
+	}
+	proceeds := models.SavingTransaction{
+		SavingsID:   savings.ID,
+		MemberID:    account.MemberID,
+		Amount:      amount,
+		Currency:    models.BaseCurrency,
+		Description: fmt.Sprintf("Redemption of %d shares, share transaction #%d", shares, transaction.ID),
+		Type:        models.TransactionTypeShares,
+		Channel:     models.TransactionChannelInternal,
+	}
+	if err := tx.Create(&proceeds).Error; err != nil {
+		return nil, "failed to credit savings", err
+	}
+	if err := tx.Model(savings).Update("balance", savings.Balance+amount).Error; err != nil {
+		return nil, "failed to update savings balance", err
+	}
+	transaction.SavingTransactionID = &proceeds.ID
+	if err := tx.Model(&transaction).Update("saving_transaction_id", proceeds.ID).Error; err != nil {
+		return nil, "failed to link share redemption to its proceeds", err
+	}
+
+	account.Shares -= shares
+	if err := tx.Model(account).Update("shares", account.Shares).Error; err != nil {
+		return nil, "failed to update share account", err
+	}
+	return &transaction, "shares redeemed successfully", nil
+}
+
+// lockShareAccountsTx opens any missing share accounts for the members and locks them all in id order,
+// so two opposite transfers cannot deadlock
+func lockShareAccountsTx(tx *gorm.DB, memberIDs ...uint) (map[uint]*models.ShareAccount, error) {
+	for _, memberID := range memberIDs {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ShareAccount{MemberID: memberID}).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var rows []models.ShareAccount
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("member_id IN ?", memberIDs).Order("id ASC").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make(map[uint]*models.ShareAccount, len(rows))
+	for i := range rows {
+		accounts[rows[i].MemberID] = &rows[i]
+	}
+	for _, memberID := range memberIDs {
+		if accounts[memberID] == nil {
+			return nil, gorm.ErrRecordNotFound
+		}
+	}
+	return accounts, nil
+}
+
+func issueCertificateTx(tx *gorm.DB, account *models.ShareAccount, shares int64) (*models.ShareCertificate, error) {
+	certificate := models.ShareCertificate{
+		ShareAccountID: account.ID,
+		MemberID:       account.MemberID,
+		Shares:         shares,
+		Status:         models.ShareCertificateStatusActive,
+		IssuedAt:       time.Now(),
+	}
+	if err := tx.Create(&certificate).Error; err != nil {
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+// reissueCertificatesTx cancels the account's active certificates and issues one for the remaining shares,
+// returning nil when nothing remains
+func reissueCertificatesTx(tx *gorm.DB, account *models.ShareAccount, remaining int64) (*models.ShareCertificate, error) {
+	var replacement *models.ShareCertificate
+	if remaining > 0 {
+		var err error
+		if replacement, err = issueCertificateTx(tx, account, remaining); err != nil {
+			return nil, err
+		}
+	}
+
+	updates := map[string]interface{}{
+		"status":       models.ShareCertificateStatusCancelled,
+		"cancelled_at": time.Now(),
+	}
+	query := tx.Model(&models.ShareCertificate{}).Where("share_account_id = ? AND status = ?", account.ID, models.ShareCertificateStatusActive)
+	if replacement != nil {
+		updates["replaced_by_id"] = replacement.ID
+		query = query.Where("id <> ?", replacement.ID)
+	}
+	if err := query.Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return replacement, nil
+}
@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"cooperative-system/internal/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTransferLimitExceeded    = errors.New("transfer would exceed the daily transfer limit")
+	ErrRecipientAccountNotFound = errors.New("recipient has no savings account in this currency")
+	ErrTransferAlreadyReversed  = errors.New("transfer has already been reversed")
+	ErrTransferLeg              = errors.New("transaction is part of a transfer, reverse the transfer instead")
+)
+
+type gormTransferRepository struct {
+	db *gorm.DB
+}
+
+func NewGormTransferRepository(db *gorm.DB) *gormTransferRepository {
+	return &gormTransferRepository{db: db}
+}
+
+// CreateTransfer debits the sender and credits the recipient in one transaction. dailyLimit caps what the
+// sender can send in the transfer's currency since midnight, zero means no limit.
+func (r *gormTransferRepository) CreateTransfer(transfer *models.SavingsTransfer, dailyLimit models.Money) (*models.SavingsTransfer, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, "failed to start transaction", err
+	}
+
+	// lock both accounts in id order so two opposite transfers cannot deadlock
+	var accounts []models.Savings
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("member_id IN ? AND currency = ?", []uint{transfer.FromMemberID, transfer.ToMemberID}, transfer.Currency).
+		Order("id ASC").Find(&accounts).Error
+	if err != nil {
+		tx.Rollback()
+		return nil, "failed to fetch savings for update", err
+	}
+
+	var sender, recipient *models.Savings
+	for i := range accounts {
+		switch accounts[i].MemberID {
+		case transfer.FromMemberID:
+			sender = &accounts[i]
+		case transfer.ToMemberID:
+			recipient = &accounts[i]
+		}
+	}
+	if sender == nil {
+		tx.Rollback()
+		return nil, "you have no savings account in this currency", gorm.ErrRecordNotFound
+	}
+	if recipient == nil {
+		tx.Rollback()
+		return nil, ErrRecipientAccountNotFound.Error(), ErrRecipientAccountNotFound
+	}
+
+	if sender.Balance < transfer.Amount {
+		tx.Rollback()
+		return nil, ErrInsufficientBalance.Error(), ErrInsufficientBalance
+	}
+
+	if dailyLimit > 0 {
+		now := time.Now()
+		startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		var sentToday models.Money
+		err := tx.Model(&models.SavingsTransfer{}).
+			Where("from_member_id = ? AND currency = ? AND status = ? AND created_at >= ?", transfer.FromMemberID, transfer.Currency, models.TransferStatusCompleted, startOfDay).
+			Select("COALESCE(SUM(amount), 0)").Scan(&sentToday).Error
+		if err != nil {
+			tx.Rollback()
+			return nil, "failed to total today's transfers", err
+		}
+		if sentToday+transfer.Amount > dailyLimit {
+			tx.Rollback()
+			return nil, fmt.Sprintf("transfer would exceed the daily limit of %s %s, %s already sent today", dailyLimit, transfer.Currency, sentToday), ErrTransferLimitExceeded
+		}
+	}
+
+	transfer.Status = models.TransferStatusCompleted
+	if err := tx.Create(transfer).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to create transfer", err
+	}
+
+	debit := models.SavingTransaction{
+		SavingsID:   sender.ID,
+		MemberID:    sender.MemberID,
+		Amount:      -transfer.Amount,
+		Currency:    transfer.Currency,
+		Description: fmt.Sprintf("Transfer #%d to member #%d", transfer.ID, transfer.ToMemberID),
+		TransferID:  &transfer.ID,
+		Type:        models.TransactionTypeTransferOut,
+		Channel:     models.TransactionChannelInternal,
+	}
+	credit := models.SavingTransaction{
+		SavingsID:   recipient.ID,
+		MemberID:    recipient.MemberID,
+		Amount:      transfer.Amount,
+		Currency:    transfer.Currency,
+		Description: fmt.Sprintf("Transfer #%d from member #%d", transfer.ID, transfer.FromMemberID),
+		TransferID:  &transfer.ID,
+		Type:        models.TransactionTypeTransferIn,
+		Channel:     models.TransactionChannelInternal,
+	}
+	if err := tx.Create(&debit).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to create transfer debit", err
+	}
+	if err := tx.Create(&credit).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to create transfer credit", err
+	}
+
+	if err := tx.Model(sender).Update("balance", sender.Balance-transfer.Amount).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to update sender balance", err
+	}
+	if err := tx.Model(recipient).Update("balance", recipient.Balance+transfer.Amount).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to update recipient balance", err
+	}
+
+	transfer.DebitTransactionID = debit.ID
+	transfer.CreditTransactionID = credit.ID
+	if err := tx.Save(transfer).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to link transfer transactions", err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, "failed to commit transaction", err
+	}
+
+	return transfer, "transfer completed successfully", nil
+}
+
+// GetTransferByID fetches a transfer by its ID
+func (r *gormTransferRepository) GetTransferByID(transferID string) (*models.SavingsTransfer, string, error) {
+	var transfer models.SavingsTransfer
+	if err := r.db.Where("id = ?", transferID).First(&transfer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "transfer not found", err
+		}
+		return nil, "failed to fetch transfer", err
+	}
+	return &transfer, "transfer fetched successfully", nil
+}
+
+// ReverseTransfer posts reversals of both legs of a transfer and marks it reversed. The recipient must
+// still hold the amount.
+func (r *gormTransferRepository) ReverseTransfer(transferID uint, reason string, postedBy uint) (*models.SavingsTransfer, string, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return nil, "failed to start transaction", err
+	}
+
+	var transfer models.SavingsTransfer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", transferID).First(&transfer).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "transfer not found", err
+		}
+		return nil, "failed to fetch transfer for update", err
+	}
+	if transfer.Status == models.TransferStatusReversed {
+		tx.Rollback()
+		return nil, ErrTransferAlreadyReversed.Error(), ErrTransferAlreadyReversed
+	}
+
+	// take the money back from the recipient first so a spent transfer fails before anything is posted
+	for _, transactionID := range []uint{transfer.CreditTransactionID, transfer.DebitTransactionID} {
+		if _, msg, err := reverseTransactionTx(tx, transactionID, reason, postedBy); err != nil {
+			tx.Rollback()
+			return nil, msg, err
+		}
+	}
+
+	now := time.Now()
+	transfer.Status = models.TransferStatusReversed
+	transfer.ReversedAt = &now
+	transfer.ReversedBy = &postedBy
+	transfer.ReversalReason = reason
+	if err := tx.Save(&transfer).Error; err != nil {
+		tx.Rollback()
+		return nil, "failed to update transfer", err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, "failed to commit transaction", err
+	}
+
+	return &transfer, "transfer reversed successfully", nil
+}
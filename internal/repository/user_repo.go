@@ -38,6 +38,18 @@ func (r *gormUserRepository) FindUserByEmail(email string) (*models.User, string
 	return &user, "success", nil
 }
 
+// FindUserByID fetches a user with the branch they are limited to
+func (r *gormUserRepository) FindUserByID(userID uint) (*models.User, string, error) {
+	var user models.User
+	if err := r.db.Preload("Branch").Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "user not found", err
+		}
+		return nil, "failed to find user", err
+	}
+	return &user, "success", nil
+}
+
 func (r *gormUserRepository) UpdateUser(user *models.User, role string) (*models.User, string, error) {
 
 	if err := r.db.Model(&user).Update("role", role).Error; err != nil {
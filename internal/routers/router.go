@@ -4,6 +4,7 @@ import (
 	"cooperative-system/internal/config"
 	"cooperative-system/internal/handlers"
 	"cooperative-system/internal/middleware"
+	"cooperative-system/internal/models"
 	"cooperative-system/internal/repository"
 
 	"github.com/gin-gonic/gin"
@@ -11,11 +12,27 @@ import (
 )
 
 type Handlers struct {
-	UserService    handlers.UserService
-	MemberService  handlers.MemberService
-	SavingsService handlers.SavingsService
-	LoanService    handlers.LoanService
-	AdminService   handlers.AdminService
+	UserService         handlers.UserService
+	MemberService       handlers.MemberService
+	SavingsService      handlers.SavingsService
+	LoanService         handlers.LoanService
+	AdminService        handlers.AdminService
+	FixedDepositService handlers.FixedDepositService
+	ContributionService handlers.ContributionService
+	ExchangeRateService handlers.ExchangeRateService
+	StatementService    handlers.StatementService
+	RepaymentService    handlers.RepaymentService
+	TransferService     handlers.TransferService
+	JournalService      handlers.JournalService
+	ShareService        handlers.ShareService
+	DistributionService handlers.DistributionService
+	FeeService          handlers.FeeService
+	SettlementService   handlers.SettlementService
+	NominationService   handlers.NominationService
+	DocumentService     handlers.DocumentService
+	MigrationService    handlers.MigrationService
+	MeService           handlers.MeService
+	BranchService       handlers.BranchService
 }
 
 // NewHandlers creates new handler instances
@@ -24,18 +41,50 @@ type Handlers struct {
 func NewHandlers(db *gorm.DB) *Handlers {
 
 	userRepo := repository.NewUserRepository(db)
+	sessionRepo := repository.NewGormSessionRepository(db)
 	memberRepo := repository.NewMGormemberRepository(db)
 	savingsRepo := repository.NewgormSavingsRepository(db)
 	loanRepo := repository.NewGormLoanRepository(db)
-
-	adminHandler := handlers.NewAdminHandler(userRepo, memberRepo, savingsRepo, loanRepo)
+	fixedDepositRepo := repository.NewGormFixedDepositRepository(db)
+	contributionRepo := repository.NewGormContributionRepository(db)
+	exchangeRateRepo := repository.NewGormExchangeRateRepository(db)
+	reportRepo := repository.NewGormReportRepository(db)
+	transferRepo := repository.NewGormTransferRepository(db)
+	journalRepo := repository.NewGormJournalRepository(db)
+	shareRepo := repository.NewGormShareRepository(db)
+	distributionRepo := repository.NewGormDistributionRepository(db)
+	feeRepo := repository.NewGormFeeRepository(db)
+	settlementRepo := repository.NewGormSettlementRepository(db)
+	nominationRepo := repository.NewGormNominationRepository(db)
+	documentRepo := repository.NewGormDocumentRepository(db)
+	migrationRepo := repository.NewGormMigrationRepository(db)
+	positionRepo := repository.NewGormPositionRepository(db)
+	branchRepo := repository.NewGormBranchRepository(db)
+
+	adminHandler := handlers.NewAdminHandler(userRepo, memberRepo, savingsRepo, loanRepo, contributionRepo, feeRepo)
 
 	return &Handlers{
-		UserService:    handlers.NewUserHandler(userRepo),
-		MemberService:  handlers.NewMemberHandler(memberRepo),
-		SavingsService: handlers.NewSavingsHandler(savingsRepo, memberRepo),
-		LoanService:    handlers.NewLoanHandler(loanRepo, memberRepo),
-		AdminService:   adminHandler,
+		UserService:         handlers.NewUserHandler(userRepo, sessionRepo, config.TokenSettings),
+		MemberService:       handlers.NewMemberHandler(memberRepo),
+		SavingsService:      handlers.NewSavingsHandler(savingsRepo, memberRepo),
+		LoanService:         handlers.NewLoanHandler(loanRepo, memberRepo),
+		AdminService:        adminHandler,
+		FixedDepositService: handlers.NewFixedDepositHandler(fixedDepositRepo, memberRepo),
+		ContributionService: handlers.NewContributionHandler(contributionRepo, memberRepo),
+		ExchangeRateService: handlers.NewExchangeRateHandler(exchangeRateRepo, reportRepo),
+		StatementService:    handlers.NewStatementHandler(savingsRepo, loanRepo, memberRepo, feeRepo),
+		RepaymentService:    handlers.NewRepaymentHandler(loanRepo),
+		TransferService:     handlers.NewTransferHandler(transferRepo, memberRepo, config.TransferDailyLimit),
+		JournalService:      handlers.NewJournalHandler(journalRepo, memberRepo),
+		ShareService:        handlers.NewShareHandler(shareRepo, memberRepo, config.ShareSettings),
+		DistributionService: handlers.NewDistributionHandler(distributionRepo, config.ShareSettings),
+		FeeService:          handlers.NewFeeHandler(feeRepo, memberRepo),
+		SettlementService:   handlers.NewSettlementHandler(settlementRepo, memberRepo, config.ShareSettings),
+		NominationService:   handlers.NewNominationHandler(nominationRepo, memberRepo),
+		DocumentService:     handlers.NewDocumentHandler(documentRepo, memberRepo, loanRepo, config.DocumentStorage(), config.DocumentMaxSize),
+		MigrationService:    handlers.NewMigrationHandler(migrationRepo),
+		MeService:           handlers.NewMeHandler(positionRepo, config.ShareSettings),
+		BranchService:       handlers.NewBranchHandler(branchRepo, memberRepo),
 	}
 
 }
@@ -43,9 +92,20 @@ func NewHandlers(db *gorm.DB) *Handlers {
 func SetUpRoute(router *gin.Engine) {
 	db := config.DB
 	handler := NewHandlers(db)
+	idempotent := middleware.Idempotency(repository.NewGormIdempotencyRepository(db))
+	can := middleware.RequirePermission
 
 	router.POST("/signup", handler.UserService.Signup)
 	router.POST("/login", handler.UserService.Login)
+	router.POST("/refresh", handler.UserService.Refresh)
+	router.POST("/logout", handler.UserService.Logout)
+	router.POST("/logout/all", middleware.RequireAuth, handler.UserService.LogoutAllSessions)
+
+	meGroup := router.Group("/api/v1/me")
+	meGroup.Use(middleware.RequireAuth)
+	{
+		meGroup.GET("", handler.MeService.GetMe)
+	}
 
 	memberGroup := router.Group("/api/v1/members")
 	memberGroup.Use(middleware.RequireAuth)
@@ -55,33 +115,120 @@ func SetUpRoute(router *gin.Engine) {
 		memberGroup.GET("/:id", handler.MemberService.GetMemberByID)
 		memberGroup.PATCH("/:id", handler.MemberService.UpdateAMember)
 		memberGroup.DELETE("/:id", handler.MemberService.DeleteAMember)
+		memberGroup.PUT("/:id/mandate", handler.ContributionService.SetMandate)
+		memberGroup.GET("/:id/contributions", handler.ContributionService.GetContributionStatus)
+		memberGroup.GET("/:id/statements/savings", handler.StatementService.GetSavingsStatement)
+		memberGroup.GET("/:id/statements/loans/:loan_id", handler.StatementService.GetLoanStatement)
+		memberGroup.GET("/:id/journal", handler.JournalService.GetMemberJournal)
+		memberGroup.GET("/:id/fees", handler.FeeService.GetMemberFeeCharges)
+		memberGroup.GET("/:id/nominations", handler.NominationService.GetNominations)
+		memberGroup.PUT("/:id/nominations", handler.NominationService.SetNominations)
+		memberGroup.POST("/:id/documents", handler.DocumentService.UploadMemberDocument)
+		memberGroup.GET("/:id/documents", handler.DocumentService.GetMemberDocuments)
 
 	}
 
 	savingsGroup := router.Group("/api/v1/savings")
 	savingsGroup.Use(middleware.RequireAuth)
 	{
-		savingsGroup.POST("", handler.SavingsService.CreateSavings)
+		savingsGroup.POST("", idempotent, handler.SavingsService.CreateSavings)
+		savingsGroup.POST("/transfers", idempotent, handler.TransferService.CreateTransfer)
 		savingsGroup.GET("/:id", handler.SavingsService.GetSavingByID)
 		savingsGroup.PUT("/:id", handler.SavingsService.UpdateSavings)
 		savingsGroup.DELETE("/:id", handler.SavingsService.DeleteSavings)
 	}
 
+	// staff routes, each open to the roles with its permission
 	adminGroup := router.Group("/api/v1/admins")
-	adminGroup.Use(middleware.RequireAuth, middleware.RequireAdmin)
+	adminGroup.Use(middleware.RequireAuth)
 	{
-		adminGroup.POST("", handler.AdminService.CreateAdmin)
-		adminGroup.DELETE("", handler.AdminService.DeleteMember)
-		adminGroup.PUT("/loans/:loan_id/approve", handler.AdminService.ApproveLoan)
-		adminGroup.GET("/members", handler.MemberService.GetAllMembers)
-		adminGroup.GET("/savings/:id", handler.SavingsService.GetTransactionsForMember)
+		adminGroup.POST("", can(models.PermUsersManage), handler.AdminService.CreateAdmin)
+		adminGroup.DELETE("", can(models.PermMembersManage), handler.AdminService.DeleteMember)
+		adminGroup.PUT("/loans/:loan_id/approve", can(models.PermLoansApprove), handler.AdminService.ApproveLoan)
+		adminGroup.PUT("/loans/:loan_id/disburse", can(models.PermLoansDisburse), handler.AdminService.DisburseLoan)
+		adminGroup.POST("/loans/:loan_id/repayments", can(models.PermRepaymentsRecord), idempotent, handler.RepaymentService.RecordRepayment)
+		adminGroup.GET("/members", can(models.PermMembersRead), handler.MemberService.GetAllMembers)
+		adminGroup.GET("/members/applications", can(models.PermMembersRead), handler.MemberService.GetMemberApplications)
+		adminGroup.POST("/members/import", can(models.PermMembersImport), handler.MemberService.ImportMembers)
+		adminGroup.POST("/members/:id/review", can(models.PermMembersManage), handler.MemberService.ReviewMemberApplication)
+		adminGroup.POST("/members/:id/approve", can(models.PermMembersManage), handler.MemberService.ApproveMember)
+		adminGroup.POST("/members/:id/reject", can(models.PermMembersManage), handler.MemberService.RejectMember)
+		adminGroup.POST("/members/:id/suspend", can(models.PermMembersManage), handler.MemberService.SuspendMember)
+		adminGroup.POST("/members/:id/reactivate", can(models.PermMembersManage), handler.MemberService.ReactivateMember)
+		adminGroup.GET("/members/:id/exit-settlement", can(models.PermExitsManage), handler.SettlementService.GetExitSettlement)
+		adminGroup.POST("/members/:id/exit", can(models.PermExitsManage), idempotent, handler.SettlementService.ExitMember)
+		adminGroup.GET("/savings/:id", can(models.PermMembersRead), handler.SavingsService.GetTransactionsForMember)
+		adminGroup.POST("/savings/transactions/:transaction_id/reverse", can(models.PermTransactionsReverse), handler.SavingsService.ReverseTransaction)
+		adminGroup.POST("/savings/transfers/:transfer_id/reverse", can(models.PermTransactionsReverse), handler.TransferService.ReverseTransfer)
+		adminGroup.POST("/fixed-deposits/process-maturities", can(models.PermDepositsProcess), handler.FixedDepositService.ProcessMaturedDeposits)
+		adminGroup.GET("/contributions/arrears", can(models.PermReportsRead), handler.ContributionService.GetMembersInArrears)
+		adminGroup.POST("/exchange-rates", can(models.PermExchangeRatesManage), handler.ExchangeRateService.SetExchangeRate)
+		adminGroup.GET("/exchange-rates", can(models.PermReportsRead), handler.ExchangeRateService.GetExchangeRates)
+		adminGroup.GET("/reports/consolidated", can(models.PermReportsRead), handler.ExchangeRateService.GetConsolidatedReport)
+		adminGroup.GET("/shares/register", can(models.PermReportsRead), handler.ShareService.GetShareRegister)
+		adminGroup.POST("/members/:id/shares/redeem", can(models.PermSharesManage), idempotent, handler.ShareService.RedeemShares)
+		adminGroup.POST("/distributions", can(models.PermDistributionsManage), handler.DistributionService.CreateDistribution)
+		adminGroup.GET("/distributions", can(models.PermReportsRead), handler.DistributionService.GetDistributions)
+		adminGroup.GET("/distributions/:distribution_id", can(models.PermReportsRead), handler.DistributionService.GetDistributionByID)
+		adminGroup.POST("/distributions/:distribution_id/approve", can(models.PermDistributionsManage), handler.DistributionService.ApproveDistribution)
+		adminGroup.POST("/distributions/:distribution_id/cancel", can(models.PermDistributionsManage), handler.DistributionService.CancelDistribution)
+		adminGroup.GET("/distributions/:distribution_id/payout-list", can(models.PermReportsRead), handler.DistributionService.GetPayoutList)
+		adminGroup.POST("/fees", can(models.PermFeesManage), handler.FeeService.CreateFeeDefinition)
+		adminGroup.GET("/fees", can(models.PermReportsRead), handler.FeeService.GetFeeDefinitions)
+		adminGroup.PATCH("/fees/:fee_id", can(models.PermFeesManage), handler.FeeService.UpdateFeeDefinition)
+		adminGroup.POST("/fees/charges/:charge_id/waive", can(models.PermFeesManage), handler.FeeService.WaiveFeeCharge)
+		adminGroup.PATCH("/documents/:document_id/verification", can(models.PermDocumentsVerify), handler.DocumentService.VerifyDocument)
+		adminGroup.POST("/migrations", can(models.PermMembersImport), handler.MigrationService.ImportMigration)
+		adminGroup.GET("/migrations", can(models.PermMembersImport), handler.MigrationService.GetMigrationBatches)
+		adminGroup.GET("/migrations/:batch_id/reconciliation", can(models.PermMembersImport), handler.MigrationService.GetMigrationReconciliation)
+		adminGroup.POST("/branches", can(models.PermBranchesManage), handler.BranchService.CreateBranch)
+		adminGroup.GET("/branches", can(models.PermMembersRead), handler.BranchService.GetBranches)
+		adminGroup.PATCH("/branches/:branch_id", can(models.PermBranchesManage), handler.BranchService.UpdateBranch)
+		adminGroup.POST("/groups", can(models.PermBranchesManage), handler.BranchService.CreateGroup)
+		adminGroup.GET("/groups", can(models.PermMembersRead), handler.BranchService.GetGroups)
+		adminGroup.PATCH("/groups/:group_id", can(models.PermBranchesManage), handler.BranchService.UpdateGroup)
+		adminGroup.PUT("/members/:id/assignment", can(models.PermBranchesManage), handler.BranchService.AssignMember)
+		adminGroup.PUT("/users/:user_id/branch", can(models.PermUsersManage), handler.BranchService.AssignUserBranch)
+		adminGroup.POST("/users/:user_id/sessions/revoke", can(models.PermUsersManage), handler.UserService.RevokeUserSessions)
+		adminGroup.GET("/roles", can(models.PermUsersManage), handler.AdminService.GetRoles)
+		adminGroup.PUT("/users/:user_id/role", can(models.PermUsersManage), handler.AdminService.AssignRole)
 	}
 
 	loanGroup := router.Group("/api/v1/loans")
 	loanGroup.Use(middleware.RequireAuth)
 	{
-		loanGroup.POST("", handler.LoanService.ApplyLoan)
+		loanGroup.POST("", idempotent, handler.LoanService.ApplyLoan)
 		loanGroup.GET("/:loan_id", handler.LoanService.TrackLoanApproval)
+		loanGroup.POST("/:loan_id/documents", handler.DocumentService.UploadLoanDocument)
+	}
+
+	shareGroup := router.Group("/api/v1/shares")
+	shareGroup.Use(middleware.RequireAuth)
+	{
+		shareGroup.GET("", handler.ShareService.GetMyShares)
+		shareGroup.POST("/purchases", idempotent, handler.ShareService.PurchaseShares)
+		shareGroup.POST("/transfers", idempotent, handler.ShareService.TransferShares)
+	}
+
+	feeGroup := router.Group("/api/v1/fees")
+	feeGroup.Use(middleware.RequireAuth)
+	{
+		feeGroup.POST("/charges/:charge_id/pay", idempotent, handler.FeeService.PayFeeCharge)
+	}
+
+	documentGroup := router.Group("/api/v1/documents")
+	documentGroup.Use(middleware.RequireAuth)
+	{
+		documentGroup.GET("/:document_id", handler.DocumentService.DownloadDocument)
+	}
+
+	fixedDepositGroup := router.Group("/api/v1/fixed-deposits")
+	fixedDepositGroup.Use(middleware.RequireAuth)
+	{
+		fixedDepositGroup.POST("", handler.FixedDepositService.CreateFixedDeposit)
+		fixedDepositGroup.GET("", handler.FixedDepositService.GetFixedDeposits)
+		fixedDepositGroup.GET("/:id", handler.FixedDepositService.GetFixedDepositByID)
+		fixedDepositGroup.POST("/:id/break", handler.FixedDepositService.BreakFixedDeposit)
 	}
 
 }
@@ -0,0 +1,50 @@
+package statements
+
+import (
+	"cooperative-system/internal/models"
+	"encoding/csv"
+	"io"
+	"time"
+)
+
+// WriteCSV writes a statement as CSV: a few header rows describing the account, then one row per entry
+// between the opening and closing balances
+func WriteCSV(w io.Writer, statement *models.Statement) error {
+	writer := csv.NewWriter(w)
+
+	rows := [][]string{
+		{"Member", statement.MemberName},
+		{"Account", accountLabel(statement)},
+		{"Currency", statement.Currency},
+		{"Period", statement.From.Format(time.DateOnly) + " to " + statement.To.Format(time.DateOnly)},
+		{},
+		{"Date", "Reference", "Description", "Debit", "Credit", "Balance"},
+		{statement.From.Format(time.DateOnly), "", "Opening balance", "", "", statement.OpeningBalance.String()},
+	}
+	for _, entry := range statement.Entries {
+		rows = append(rows, []string{
+			entry.Date.Format(time.DateOnly),
+			entry.Reference,
+			entry.Description,
+			amountCell(entry.Debit),
+			amountCell(entry.Credit),
+			entry.Balance.String(),
+		})
+	}
+	rows = append(rows, []string{
+		statement.To.Format(time.DateOnly), "", "Closing balance",
+		statement.TotalDebits.String(), statement.TotalCredits.String(), statement.ClosingBalance.String(),
+	})
+
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+func amountCell(amount models.Money) string {
+	if amount == 0 {
+		return ""
+	}
+	return amount.String()
+}
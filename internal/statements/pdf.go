@@ -0,0 +1,166 @@
+package statements
+
+import (
+	"bytes"
+	"cooperative-system/internal/models"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// The PDF is laid out on A4 in a monospaced font so the columns line up without measuring text
+const (
+	pageWidth     = 595
+	pageHeight    = 842
+	pageMargin    = 40
+	fontSize      = 9
+	lineHeight    = 12
+	linesPerPage  = (pageHeight - 2*pageMargin) / lineHeight
+	descriptionLn = 28
+)
+
+type pdfLine struct {
+	text string
+	bold bool
+}
+
+// WritePDF writes a statement as a printable PDF with the column headings repeated on every page
+func WritePDF(w io.Writer, statement *models.Statement) error {
+	header := []pdfLine{
+		{text: "ACCOUNT STATEMENT", bold: true},
+		{},
+		{text: "Member:   " + statement.MemberName},
+		{text: "Account:  " + accountLabel(statement)},
+		{text: "Currency: " + statement.Currency},
+		{text: "Period:   " + statement.From.Format(time.DateOnly) + " to " + statement.To.Format(time.DateOnly)},
+		{text: "Printed:  " + statement.GeneratedAt.Format("2006-01-02 15:04")},
+		{},
+	}
+	columns := pdfLine{text: statementRow("Date", "Reference", "Description", "Debit", "Credit", "Balance"), bold: true}
+
+	body := []pdfLine{{text: statementRow(statement.From.Format(time.DateOnly), "", "Opening balance", "", "", statement.OpeningBalance.String())}}
+	for _, entry := range statement.Entries {
+		body = append(body, pdfLine{text: statementRow(
+			entry.Date.Format(time.DateOnly),
+			entry.Reference,
+			entry.Description,
+			amountCell(entry.Debit),
+			amountCell(entry.Credit),
+			entry.Balance.String(),
+		)})
+	}
+	body = append(body, pdfLine{text: statementRow(statement.To.Format(time.DateOnly), "", "Closing balance",
+		statement.TotalDebits.String(), statement.TotalCredits.String(), statement.ClosingBalance.String()), bold: true})
+
+	// paginate, the header block only goes on the first page, the column headings and footer on all of them
+	var pages [][]pdfLine
+	current := append([]pdfLine{}, header...)
+	current = append(current, columns)
+	for _, line := range body {
+		if len(current) >= linesPerPage-2 {
+			pages = append(pages, current)
+			current = []pdfLine{columns}
+		}
+		current = append(current, line)
+	}
+	pages = append(pages, current)
+
+	return writePDFDocument(w, pages)
+}
+
+func statementRow(date, reference, description, debit, credit, balance string) string {
+	return fmt.Sprintf("%-10s %-10s %-*s %14s %14s %14s", date, truncate(reference, 10), descriptionLn, truncate(description, descriptionLn), debit, credit, balance)
+}
+
+func truncate(value string, length int) string {
+	runes := []rune(value)
+	if len(runes) <= length {
+		return value
+	}
+	return string(runes[:length-1]) + "~"
+}
+
+// escapePDFText escapes a string for a PDF literal, characters outside printable ASCII are replaced
+// because the standard fonts are used without a custom encoding
+func escapePDFText(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteRune('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func writePDFDocument(w io.Writer, pages [][]pdfLine) error {
+	var buf bytes.Buffer
+	var offsets []int
+
+	// objects are numbered from 1: catalog, page tree, two fonts, then a page and its content stream per page
+	startObject := func() int {
+		offsets = append(offsets, buf.Len())
+		number := len(offsets)
+		fmt.Fprintf(&buf, "%d 0 obj\n", number)
+		return number
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	startObject()
+	buf.WriteString("<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	startObject()
+	fmt.Fprintf(&buf, "<< /Type /Pages /Kids [%s] /Count %d >>\nendobj\n", strings.Join(kids, " "), len(pages))
+
+	startObject()
+	buf.WriteString("<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>\nendobj\n")
+	startObject()
+	buf.WriteString("<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold >>\nendobj\n")
+
+	for i, lines := range pages {
+		var content bytes.Buffer
+		content.WriteString("BT\n")
+		fmt.Fprintf(&content, "%d TL\n", lineHeight)
+		fmt.Fprintf(&content, "%d %d Td\n", pageMargin, pageHeight-pageMargin)
+		for _, line := range lines {
+			font := "F1"
+			if line.bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&content, "/%s %d Tf\n(%s) Tj\nT*\n", font, fontSize, escapePDFText(line.text))
+		}
+		content.WriteString("ET\n")
+		footer := fmt.Sprintf("Page %d of %d", i+1, len(pages))
+		fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d %d Td\n(%s) Tj\nET\n", fontSize, pageWidth-pageMargin-len(footer)*fontSize*6/10, pageMargin/2, footer)
+
+		pageObject := startObject()
+		fmt.Fprintf(&buf, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>\nendobj\n",
+			pageWidth, pageHeight, pageObject+1)
+
+		startObject()
+		fmt.Fprintf(&buf, "<< /Length %d >>\nstream\n", content.Len())
+		buf.Write(content.Bytes())
+		buf.WriteString("endstream\nendobj\n")
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
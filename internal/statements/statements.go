@@ -0,0 +1,21 @@
+// Package statements renders member account statements as CSV and PDF.
+package statements
+
+import (
+	"cooperative-system/internal/models"
+	"fmt"
+	"time"
+)
+
+func accountLabel(statement *models.Statement) string {
+	if statement.Account == models.StatementAccountLoan {
+		return fmt.Sprintf("Loan #%d", statement.AccountID)
+	}
+	return fmt.Sprintf("Savings #%d", statement.AccountID)
+}
+
+// Filename is the download name for a statement in the given format, e.g. statement-savings-3-2025-01-01-2025-01-31.csv
+func Filename(statement *models.Statement, extension string) string {
+	return fmt.Sprintf("statement-%s-%d-%s-%s.%s", statement.Account, statement.AccountID,
+		statement.From.Format(time.DateOnly), statement.To.Format(time.DateOnly), extension)
+}
@@ -1,6 +1,10 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"time"
@@ -10,10 +14,19 @@ import (
 
 var secretKey = []byte(os.Getenv("SECRETKEY"))
 
-func CreateToken(email string) (string, error) {
+// TokenClaims is what a verified access token says about who it was issued to and when
+type TokenClaims struct {
+	Email    string
+	IssuedAt time.Time
+}
+
+// CreateToken issues an access token for the user with the given email, valid for ttl
+func CreateToken(email string, ttl time.Duration) (string, error) {
+	now := time.Now()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"email": email,
-		"exp":   time.Now().Add(time.Hour * 24).Unix(),
+		"iat":   now.Unix(),
+		"exp":   now.Add(ttl).Unix(),
 	})
 
 	tokenString, err := token.SignedString(secretKey)
@@ -23,29 +36,53 @@ func CreateToken(email string) (string, error) {
 	return tokenString, nil
 }
 
-func VerifyToken(tokenString string) (string, error) {
+func VerifyToken(tokenString string) (*TokenClaims, error) {
 
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		return secretKey, nil
-	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
 
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if !token.Valid {
-		return "", fmt.Errorf("invalid token")
+		return nil, fmt.Errorf("invalid token")
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 
 	if !ok {
-		return "", fmt.Errorf("could not parse claims")
+		return nil, fmt.Errorf("could not parse claims")
 	}
 
 	email, ok := claims["email"].(string)
 	if !ok || email == "" {
-		return "", fmt.Errorf("invalid claim")
+		return nil, fmt.Errorf("invalid claim")
+	}
+
+	// tokens issued before iat was added count as issued at the start of time, so revoking a user's
+	// sessions also ends them
+	verified := TokenClaims{Email: email}
+	if issuedAt, err := claims.GetIssuedAt(); err == nil && issuedAt != nil {
+		verified.IssuedAt = issuedAt.Time
 	}
-	return email, nil
+	return &verified, nil
+}
+
+// NewRefreshToken makes a random refresh token for the client and the hash it is stored under. Only the hash
+// is kept, so the tokens cannot be read back out of the database.
+func NewRefreshToken() (string, string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(random)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken is the hash a refresh token is stored and looked up under
+func HashRefreshToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
 }